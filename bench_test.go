@@ -0,0 +1,72 @@
+package shedoc
+
+import (
+	"bytes"
+	"fmt"
+	"os"
+	"strings"
+	"testing"
+)
+
+// benchLibrary returns a library of about lines lines: public functions,
+// each documented with a description, options, an operand, and exit
+// statuses, followed by a body.
+func benchLibrary(lines int) []byte {
+	var sb strings.Builder
+	sb.WriteString("#!/usr/bin/env bash\n#?/name benchlib\n#?/version 1.0.0\n#?/description\n # A generated library.\n ##\n\n")
+	for i := 0; strings.Count(sb.String(), "\n") < lines; i++ {
+		fmt.Fprintf(&sb, `#@/public
+ # Does step %[1]d of the work, reading its input from the
+ # file given and writing a report.
+ # @flag -v | --verbose Print progress
+ # @option -o | --output [file=report.txt] Where to write the report
+ # @operand <input> File to read
+ # @env BENCH_HOME Base directory
+ # @exit 0 Success
+ # @exit 1 Failure
+ ##
+step_%[1]d() {
+	local input="$1"
+	echo "step %[1]d: $input"
+}
+
+`, i)
+	}
+	return []byte(sb.String())
+}
+
+func benchmarkParse(b *testing.B, inputs [][]byte) {
+	var size int64
+	for _, in := range inputs {
+		size += int64(len(in))
+	}
+	b.SetBytes(size)
+	b.ReportAllocs()
+	for b.Loop() {
+		for _, in := range inputs {
+			if _, err := ParseReader(bytes.NewReader(in)); err != nil {
+				b.Fatal(err)
+			}
+		}
+	}
+}
+
+func BenchmarkParseReader_Script(b *testing.B) {
+	data, err := os.ReadFile("testdata/comprehensive.sh")
+	if err != nil {
+		b.Fatal(err)
+	}
+	benchmarkParse(b, [][]byte{data})
+}
+
+func BenchmarkParseReader_Library10k(b *testing.B) {
+	benchmarkParse(b, [][]byte{benchLibrary(10000)})
+}
+
+func BenchmarkParseReader_Tree1000(b *testing.B) {
+	inputs := make([][]byte, 1000)
+	for i := range inputs {
+		inputs[i] = benchLibrary(100)
+	}
+	benchmarkParse(b, inputs)
+}
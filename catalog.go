@@ -0,0 +1,303 @@
+package shedoc
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"strconv"
+	"strings"
+)
+
+// Catalog maps translation keys to translated text. Keys are stable across
+// edits to the text they replace; see Translations for the key scheme.
+type Catalog map[string]string
+
+// Translation is a single translatable text in a document.
+type Translation struct {
+	Key  string
+	Text string
+}
+
+// Translations returns every translatable text in d, in source order. Keys
+// take the form:
+//
+//	description, examples                       file metadata
+//	requires.<command>                          #?/requires
+//	<block>.description, <block>.deprecated     block text
+//	<block>.<tag>.<name>                        flag, option, operand, env,
+//	                                            reads, requires, exit, sets,
+//	                                            and writes
+//	<block>.stdin, <block>.stdout, <block>.stderr
+//
+// where <block> is "command", "subcommand.<name>", "section.<title>", or
+// "function.<name>".
+// Blocks with no name or function are skipped.
+func Translations(d *Document) []Translation {
+	var ts []Translation
+	walkTranslations(d, func(key string, text *string) {
+		ts = append(ts, Translation{Key: key, Text: *text})
+	})
+	return ts
+}
+
+// Translate replaces each translatable text in d that has an entry in c. A
+// "lang" entry sets Meta.Lang.
+func Translate(d *Document, c Catalog) {
+	if lang, ok := c["lang"]; ok {
+		d.Meta.Lang = lang
+	}
+	walkTranslations(d, func(key string, text *string) {
+		if t, ok := c[key]; ok {
+			*text = t
+		}
+	})
+}
+
+func walkTranslations(d *Document, fn func(key string, text *string)) {
+	visit := func(key string, text *string) {
+		if *text != "" {
+			fn(key, text)
+		}
+	}
+
+	visit("description", &d.Meta.Description)
+	visit("examples", &d.Meta.Examples)
+	for i := range d.Meta.Requires {
+		visit("requires."+d.Meta.Requires[i].Command, &d.Meta.Requires[i].Description)
+	}
+
+	for i := range d.Blocks {
+		b := &d.Blocks[i]
+		prefix := blockKey(b)
+		if prefix == "" {
+			continue
+		}
+
+		visit(prefix+".description", &b.Description)
+		for j := range b.Flags {
+			f := &b.Flags[j]
+			visit(prefix+".flag."+firstNonEmpty(f.Long, f.Short), &f.Description)
+		}
+		for j := range b.Options {
+			o := &b.Options[j]
+			visit(prefix+".option."+firstNonEmpty(o.Long, o.Short), &o.Description)
+		}
+		for j := range b.Operands {
+			o := &b.Operands[j]
+			visit(prefix+".operand."+o.Value.Name, &o.Description)
+		}
+		for j := range b.Env {
+			visit(prefix+".env."+b.Env[j].Name, &b.Env[j].Description)
+		}
+		for j := range b.Reads {
+			visit(prefix+".reads."+b.Reads[j].Path, &b.Reads[j].Description)
+		}
+		for j := range b.Requires {
+			visit(prefix+".requires."+b.Requires[j].Command, &b.Requires[j].Description)
+		}
+		if b.Stdin != nil {
+			visit(prefix+".stdin", &b.Stdin.Description)
+		}
+		for j := range b.Exit {
+			visit(prefix+".exit."+b.Exit[j].Code, &b.Exit[j].Description)
+		}
+		for j := range b.Return {
+			visit(prefix+".return."+b.Return[j].Code, &b.Return[j].Description)
+		}
+		if b.Stdout != nil {
+			visit(prefix+".stdout", &b.Stdout.Description)
+		}
+		if b.Stderr != nil {
+			visit(prefix+".stderr", &b.Stderr.Description)
+		}
+		for j := range b.Sets {
+			visit(prefix+".sets."+b.Sets[j].Name, &b.Sets[j].Description)
+		}
+		for j := range b.Writes {
+			visit(prefix+".writes."+b.Writes[j].Path, &b.Writes[j].Description)
+		}
+		if b.Deprecated != nil {
+			visit(prefix+".deprecated", &b.Deprecated.Message)
+		}
+	}
+}
+
+// blockKey returns the translation key prefix for b, or "" if b has no
+// stable name.
+func blockKey(b *Block) string {
+	switch {
+	case b.Visibility == VisibilityCommand:
+		return "command"
+	case b.Visibility == VisibilitySubcommand && b.Name != "":
+		return "subcommand." + b.Name
+	case b.Visibility == VisibilitySection && b.Name != "":
+		return "section." + b.Name
+	case (b.Visibility == VisibilityVar || b.Readonly) && b.Name != "":
+		return "var." + b.Name
+	case b.FunctionName != "":
+		return "function." + b.FunctionName
+	default:
+		return ""
+	}
+}
+
+func firstNonEmpty(values ...string) string {
+	for _, v := range values {
+		if v != "" {
+			return v
+		}
+	}
+	return ""
+}
+
+// ReadCatalog reads a translation catalog written as a flat YAML mapping of
+// keys to strings. Values may be plain, single- or double-quoted, or literal
+// blocks introduced by "|" for multi-line text. Nested mappings, lists, and
+// other YAML features are not supported.
+func ReadCatalog(r io.Reader) (Catalog, error) {
+	c := make(Catalog)
+	scanner := bufio.NewScanner(r)
+
+	// A literal block collects indented lines until the indentation ends.
+	var literalKey, literalIndent string
+	var literalLines []string
+	endLiteral := func() {
+		if literalKey != "" {
+			c[literalKey] = strings.TrimRight(strings.Join(literalLines, "\n"), "\n")
+		}
+		literalKey, literalIndent, literalLines = "", "", nil
+	}
+
+	line := 0
+	for scanner.Scan() {
+		line++
+		text := scanner.Text()
+
+		if literalKey != "" {
+			if strings.TrimSpace(text) == "" {
+				literalLines = append(literalLines, "")
+				continue
+			}
+			if literalIndent == "" {
+				literalIndent = text[:len(text)-len(strings.TrimLeft(text, " "))]
+			}
+			if literalIndent != "" && strings.HasPrefix(text, literalIndent) {
+				literalLines = append(literalLines, strings.TrimPrefix(text, literalIndent))
+				continue
+			}
+			endLiteral()
+		}
+
+		trimmed := strings.TrimSpace(text)
+		if trimmed == "" || strings.HasPrefix(trimmed, "#") {
+			continue
+		}
+		if text != strings.TrimLeft(text, " \t") {
+			return nil, fmt.Errorf("line %d: nested values are not supported", line)
+		}
+
+		key, value, ok := splitCatalogEntry(text)
+		if !ok {
+			return nil, fmt.Errorf("line %d: expected \"key: value\"", line)
+		}
+		if value == "|" {
+			literalKey = key
+			continue
+		}
+		v, err := catalogScalar(value)
+		if err != nil {
+			return nil, fmt.Errorf("line %d: %w", line, err)
+		}
+		c[key] = v
+	}
+	endLiteral()
+
+	if err := scanner.Err(); err != nil {
+		return nil, err
+	}
+	return c, nil
+}
+
+// splitCatalogEntry splits "key: value" at the first ": ", allowing the key to
+// be quoted so that it may itself contain ": ".
+func splitCatalogEntry(text string) (key, value string, ok bool) {
+	if strings.HasPrefix(text, `"`) {
+		end := strings.Index(text[1:], `": `)
+		if end < 0 {
+			if !strings.HasSuffix(text, `":`) {
+				return "", "", false
+			}
+			end = len(text) - 3
+		}
+		k, err := strconv.Unquote(text[:end+2])
+		if err != nil {
+			return "", "", false
+		}
+		return k, strings.TrimSpace(text[end+3:]), true
+	}
+
+	if k, v, found := strings.Cut(text, ": "); found {
+		return strings.TrimSpace(k), strings.TrimSpace(v), true
+	}
+	if k, found := strings.CutSuffix(text, ":"); found {
+		return strings.TrimSpace(k), "", true
+	}
+	return "", "", false
+}
+
+// catalogScalar decodes a plain or quoted YAML scalar.
+func catalogScalar(s string) (string, error) {
+	switch {
+	case strings.HasPrefix(s, `"`):
+		return strconv.Unquote(s)
+	case strings.HasPrefix(s, "'"):
+		if len(s) < 2 || !strings.HasSuffix(s, "'") {
+			return "", fmt.Errorf("unterminated quoted value")
+		}
+		return strings.ReplaceAll(s[1:len(s)-1], "''", "'"), nil
+	default:
+		if i := strings.Index(s, " #"); i >= 0 {
+			s = strings.TrimSpace(s[:i])
+		}
+		return s, nil
+	}
+}
+
+// WriteCatalog writes ts as a catalog that ReadCatalog accepts, as a starting
+// point for a translation.
+func WriteCatalog(w io.Writer, ts []Translation) error {
+	bw := bufio.NewWriter(w)
+	for _, t := range ts {
+		key := t.Key
+		if strings.ContainsAny(key, ":#\"'") || strings.TrimSpace(key) != key {
+			key = strconv.Quote(key)
+		}
+		if isLiteralText(t.Text) {
+			fmt.Fprintf(bw, "%s: |\n", key)
+			for _, line := range strings.Split(t.Text, "\n") {
+				if line == "" {
+					bw.WriteString("\n")
+				} else {
+					bw.WriteString("  " + line + "\n")
+				}
+			}
+			continue
+		}
+		fmt.Fprintf(bw, "%s: %s\n", key, strconv.Quote(t.Text))
+	}
+	return bw.Flush()
+}
+
+// isLiteralText reports whether s is written as a literal block: it spans
+// lines, and no line's indentation would be mistaken for the block's.
+func isLiteralText(s string) bool {
+	if !strings.Contains(s, "\n") {
+		return false
+	}
+	for _, line := range strings.Split(s, "\n") {
+		if strings.HasPrefix(line, " ") || strings.HasPrefix(line, "\t") {
+			return false
+		}
+	}
+	return true
+}
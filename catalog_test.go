@@ -0,0 +1,171 @@
+package shedoc
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+)
+
+func catalogTestDoc() *Document {
+	return &Document{
+		Meta: Meta{Name: "deploy", Description: "Deploys things.\n\nSecond paragraph."},
+		Blocks: []Block{
+			{
+				Visibility:  VisibilityCommand,
+				Description: "Manages deployments.",
+				Flags:       []Flag{{Short: "-v", Long: "--verbose", Description: "Verbose output"}},
+				Operands:    []Operand{{Value: Value{Name: "command", Required: true}, Description: "Subcommand"}},
+				Exit:        []Exit{{Code: "0", Description: "Success"}},
+			},
+			{
+				Visibility: VisibilitySubcommand,
+				Name:       "push",
+				Options:    []Option{{Short: "-t", Value: Value{Name: "tag"}, Description: "Tag: the version"}},
+				Reads:      []Reads{{Path: "~/.deployrc", Description: "Configuration"}},
+				Deprecated: &Deprecated{Message: "Use ship."},
+			},
+			{Visibility: VisibilityPrivate, FunctionName: "_helper", Description: "Helps."},
+			{Visibility: VisibilityPublic, Description: "Anonymous."},
+		},
+	}
+}
+
+func TestTranslations(t *testing.T) {
+	var keys []string
+	for _, tr := range Translations(catalogTestDoc()) {
+		keys = append(keys, tr.Key)
+	}
+
+	want := []string{
+		"description",
+		"command.description",
+		"command.flag.--verbose",
+		"command.operand.command",
+		"command.exit.0",
+		"subcommand.push.option.-t",
+		"subcommand.push.reads.~/.deployrc",
+		"subcommand.push.deprecated",
+		"function._helper.description",
+	}
+	if strings.Join(keys, "\n") != strings.Join(want, "\n") {
+		t.Errorf("keys:\n%s\nwant:\n%s", strings.Join(keys, "\n"), strings.Join(want, "\n"))
+	}
+}
+
+func TestTranslate(t *testing.T) {
+	doc := catalogTestDoc()
+	Translate(doc, Catalog{
+		"lang":                        "de",
+		"command.flag.--verbose":      "Ausführliche Ausgabe",
+		"subcommand.push.deprecated":  "Stattdessen ship verwenden.",
+		"subcommand.pull.description": "Ignored",
+	})
+
+	if doc.Meta.Lang != "de" {
+		t.Errorf("Meta.Lang = %q, want de", doc.Meta.Lang)
+	}
+	if got := doc.Blocks[0].Flags[0].Description; got != "Ausführliche Ausgabe" {
+		t.Errorf("flag description = %q", got)
+	}
+	if got := doc.Blocks[1].Deprecated.Message; got != "Stattdessen ship verwenden." {
+		t.Errorf("deprecated message = %q", got)
+	}
+	if got := doc.Blocks[0].Description; got != "Manages deployments." {
+		t.Errorf("untranslated description changed to %q", got)
+	}
+}
+
+func TestCatalogRoundTrip(t *testing.T) {
+	ts := Translations(catalogTestDoc())
+
+	var buf bytes.Buffer
+	if err := WriteCatalog(&buf, ts); err != nil {
+		t.Fatal(err)
+	}
+	c, err := ReadCatalog(&buf)
+	if err != nil {
+		t.Fatalf("ReadCatalog: %v\n%s", err, buf.String())
+	}
+
+	if len(c) != len(ts) {
+		t.Errorf("got %d entries, want %d", len(c), len(ts))
+	}
+	for _, tr := range ts {
+		if c[tr.Key] != tr.Text {
+			t.Errorf("%s = %q, want %q", tr.Key, c[tr.Key], tr.Text)
+		}
+	}
+}
+
+func TestReadCatalog(t *testing.T) {
+	tests := []struct {
+		name    string
+		input   string
+		want    Catalog
+		wantErr string
+	}{
+		{
+			name: "scalars",
+			input: `# German
+lang: de
+command.description: Verwaltet Deployments  # trailing comment
+command.flag.--verbose: "Ausführliche \"Ausgabe\""
+command.exit.0: 'Erfolg, it''s done'
+"key: with colon": x
+`,
+			want: Catalog{
+				"lang":                   "de",
+				"command.description":    "Verwaltet Deployments",
+				"command.flag.--verbose": `Ausführliche "Ausgabe"`,
+				"command.exit.0":         "Erfolg, it's done",
+				"key: with colon":        "x",
+			},
+		},
+		{
+			name: "literal block",
+			input: `description: |
+  Erste Zeile.
+
+  Zweiter Absatz.
+examples: x
+`,
+			want: Catalog{
+				"description": "Erste Zeile.\n\nZweiter Absatz.",
+				"examples":    "x",
+			},
+		},
+		{
+			name:    "nested",
+			input:   "command:\n  description: x\n",
+			wantErr: "line 2: nested values are not supported",
+		},
+		{
+			name:    "not a mapping",
+			input:   "- item\n",
+			wantErr: `line 1: expected "key: value"`,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := ReadCatalog(strings.NewReader(tt.input))
+			if tt.wantErr != "" {
+				if err == nil || err.Error() != tt.wantErr {
+					t.Fatalf("error = %v, want %q", err, tt.wantErr)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if len(got) != len(tt.want) {
+				t.Errorf("got %v, want %v", got, tt.want)
+			}
+			for k, v := range tt.want {
+				if got[k] != v {
+					t.Errorf("%s = %q, want %q", k, got[k], v)
+				}
+			}
+		})
+	}
+}
@@ -18,6 +18,6 @@ func main() {
 	cmd := cli.NewRootCmd(versionStr)
 	if err := cmd.Execute(); err != nil {
 		fmt.Fprintln(os.Stderr, err)
-		os.Exit(1)
+		os.Exit(cli.ExitCode(err))
 	}
 }
@@ -0,0 +1,294 @@
+package format
+
+import (
+	"fmt"
+	"io"
+	"strings"
+
+	"github.com/nickawilliams/shedoc"
+)
+
+func init() {
+	shedoc.RegisterFormatter("asciidoc", &AsciiDocFormatter{})
+}
+
+// AsciiDocFormatter generates an AsciiDoc document for Asciidoctor and
+// Antora: file metadata as header attributes, one section per block with an
+// anchor, and flags, options, and the other tags as definition lists.
+type AsciiDocFormatter struct {
+	// SourceURL, if set, is a template for links from each block to its
+	// source; see HTMLFormatter.
+	SourceURL string
+}
+
+func (f *AsciiDocFormatter) Format(w io.Writer, doc *shedoc.Document) error {
+	// Read the license first so a missing file fails before any output.
+	var license string
+	if doc.Meta.LicenseFile != "" {
+		text, err := readLicenseFile(doc)
+		if err != nil {
+			return err
+		}
+		license = strings.TrimRight(text, "\n")
+	}
+	name := doc.Meta.Name
+	if name == "" {
+		name = "UNKNOWN"
+	}
+
+	fmt.Fprintf(w, "= %s\n", name)
+	if doc.Meta.Author != "" {
+		fmt.Fprintln(w, firstLine(doc.Meta.Author))
+	}
+	if doc.Meta.Version != "" {
+		fmt.Fprintf(w, ":revnumber: %s\n", doc.Meta.Version)
+	}
+	if doc.Meta.Lang != "" {
+		fmt.Fprintf(w, ":lang: %s\n", doc.Meta.Lang)
+	}
+	if doc.Meta.License != "" {
+		fmt.Fprintf(w, ":license: %s\n", doc.Meta.License)
+	}
+	fmt.Fprintln(w)
+
+	if doc.Meta.Description != "" {
+		writeAsciiDocText(w, doc.Meta.Description)
+	}
+
+	if synopsis := synopsisLines(doc); len(synopsis) > 0 {
+		fmt.Fprintln(w, "== Synopsis")
+		fmt.Fprintln(w)
+		writeAsciiDocListing(w, strings.Join(synopsis, "\n"))
+	}
+	if doc.Meta.Examples != "" {
+		fmt.Fprintln(w, "== Examples")
+		fmt.Fprintln(w)
+		writeAsciiDocListing(w, doc.Meta.Examples)
+	}
+	writeAsciiDocList(w, "==", "Dependencies", asciiDocRequires(doc.Meta.Requires))
+
+	depths := blockDepths(doc)
+	for i := range doc.Blocks {
+		b := &doc.Blocks[i]
+		writeAsciiDocBlock(w, b, depths[i], sourceURL(f.SourceURL, doc, b))
+	}
+
+	if license != "" {
+		fmt.Fprintln(w, "== License")
+		fmt.Fprintln(w)
+		writeAsciiDocLiteral(w, license)
+	}
+	if refs := seeAlso(doc); len(refs) > 0 {
+		fmt.Fprintln(w, "== See Also")
+		fmt.Fprintln(w)
+		for _, ref := range refs {
+			fmt.Fprintf(w, "* %s\n", asciiDocEscape(ref))
+		}
+		fmt.Fprintln(w)
+	}
+	return nil
+}
+
+// writeAsciiDocBlock writes a block as a section nested depth levels below
+// the top level, linking to source if it is not empty.
+func writeAsciiDocBlock(w io.Writer, b *shedoc.Block, depth int, source string) {
+	level := strings.Repeat("=", 2+depth)
+	fmt.Fprintf(w, "[#%s]\n", blockAnchor(b))
+	fmt.Fprintf(w, "%s %s\n", level, blockHeading(b))
+	fmt.Fprintln(w)
+
+	var notes []string
+	if len(b.Aliases) > 0 {
+		notes = append(notes, "Aliases: "+asciiDocCode(b.Aliases...))
+	}
+	if b.FunctionName != "" && b.Visibility != shedoc.VisibilityCommand {
+		notes = append(notes, "Function: "+asciiDocCode(b.FunctionName))
+	}
+	if b.Default != "" {
+		notes = append(notes, "Default: "+asciiDocCode(b.Default))
+	}
+	if b.Readonly {
+		notes = append(notes, "Read-only")
+	}
+	if b.Since != "" {
+		notes = append(notes, "Since "+asciiDocEscape(b.Since))
+	}
+	if len(b.See) > 0 && b.Visibility != shedoc.VisibilityCommand {
+		notes = append(notes, "See also: "+asciiDocCode(b.See...))
+	}
+	if source != "" {
+		notes = append(notes, "link:++"+source+"++[Source]")
+	}
+	if b.Hidden {
+		notes = append(notes, "Hidden")
+	}
+	if len(notes) > 0 {
+		fmt.Fprintf(w, "%s\n\n", strings.Join(notes, " +\n"))
+	}
+	if b.Deprecated != nil {
+		msg := b.Deprecated.Message
+		if msg == "" {
+			msg = "This command is deprecated."
+		}
+		fmt.Fprintf(w, "WARNING: Deprecated. %s\n\n", asciiDocEscape(msg))
+	}
+	if b.Description != "" {
+		writeAsciiDocText(w, b.Description)
+	}
+
+	var items []orgItem
+	for _, fl := range b.Flags {
+		items = append(items, orgItem{asciiDocCode(fl.Short, fl.Long), asciiDocHidden(fl.Description, fl.Hidden)})
+	}
+	for _, o := range b.Options {
+		term := asciiDocCode(o.Short, o.Long) + " " + asciiDocCode(o.Value.String())
+		items = append(items, orgItem{term, asciiDocHidden(optionDescription(o), o.Hidden)})
+	}
+	writeAsciiDocList(w, level+"=", "Options", items)
+
+	items = nil
+	for _, o := range b.Operands {
+		items = append(items, orgItem{asciiDocCode(o.Value.String()), asciiDocEscape(o.Description)})
+	}
+	writeAsciiDocList(w, level+"=", "Operands", items)
+
+	items = nil
+	for _, e := range b.Env {
+		items = append(items, orgItem{asciiDocCode(e.Name), asciiDocEscape(envDescription(e))})
+	}
+	writeAsciiDocList(w, level+"=", "Environment", items)
+	writeAsciiDocList(w, level+"=", "Dependencies", asciiDocRequires(b.Requires))
+
+	items = nil
+	for _, r := range b.Reads {
+		items = append(items, orgItem{asciiDocCode(r.Path) + " (read)", asciiDocEscape(r.Description)})
+	}
+	for _, wr := range b.Writes {
+		items = append(items, orgItem{asciiDocCode(wr.Path) + " (written)", asciiDocEscape(wr.Description)})
+	}
+	writeAsciiDocList(w, level+"=", "Files", items)
+
+	items = nil
+	if b.Stdin != nil {
+		items = append(items, orgItem{"standard input", asciiDocEscape(b.Stdin.Description)})
+	}
+	if b.Stdout != nil {
+		items = append(items, orgItem{"standard output", asciiDocEscape(b.Stdout.Description)})
+	}
+	if b.Stderr != nil {
+		items = append(items, orgItem{"standard error", asciiDocEscape(b.Stderr.Description)})
+	}
+	for _, s := range b.Sets {
+		items = append(items, orgItem{asciiDocCode(s.Name) + " (set)", asciiDocEscape(s.Description)})
+	}
+	writeAsciiDocList(w, level+"=", "Input and Output", items)
+
+	items = nil
+	for _, e := range b.Exit {
+		items = append(items, orgItem{asciiDocCode(e.Code), asciiDocEscape(e.Description)})
+	}
+	writeAsciiDocList(w, level+"=", "Exit Status", items)
+
+	items = nil
+	for _, r := range b.Return {
+		items = append(items, orgItem{asciiDocCode(r.Code), asciiDocEscape(r.Description)})
+	}
+	writeAsciiDocList(w, level+"=", "Returns", items)
+}
+
+// writeAsciiDocList writes a subsection, with the given title marker,
+// holding a definition list, if there are any items. Terms and descriptions
+// are already escaped.
+func writeAsciiDocList(w io.Writer, level, title string, items []orgItem) {
+	if len(items) == 0 {
+		return
+	}
+	fmt.Fprintf(w, "%s %s\n\n", level, title)
+	for _, it := range items {
+		if it.description == "" {
+			fmt.Fprintf(w, "%s::\n", it.term)
+			continue
+		}
+		// Join wrapped lines; a blank line would end the list item.
+		desc := strings.Join(strings.Fields(it.description), " ")
+		fmt.Fprintf(w, "%s:: %s\n", it.term, desc)
+	}
+	fmt.Fprintln(w)
+}
+
+// writeAsciiDocText writes paragraph text, followed by a blank line.
+func writeAsciiDocText(w io.Writer, text string) {
+	for _, line := range strings.Split(strings.TrimSpace(text), "\n") {
+		fmt.Fprintln(w, asciiDocEscapeLine(line))
+	}
+	fmt.Fprintln(w)
+}
+
+// writeAsciiDocListing writes text in a literal listing block, which
+// AsciiDoc does not interpret, followed by a blank line.
+func writeAsciiDocListing(w io.Writer, text string) {
+	fence := "----"
+	for strings.Contains(text, fence) {
+		fence += "-"
+	}
+	fmt.Fprintf(w, "[source,shell]\n%s\n%s\n%s\n\n", fence, text, fence)
+}
+
+// writeAsciiDocLiteral writes text in a literal block, shown verbatim in a
+// monospace font, followed by a blank line.
+func writeAsciiDocLiteral(w io.Writer, text string) {
+	fence := "...."
+	for strings.Contains(text, fence) {
+		fence += "."
+	}
+	fmt.Fprintf(w, "%s\n%s\n%s\n\n", fence, text, fence)
+}
+
+// asciiDocCode marks up each non-empty value as literal monospace and joins
+// them with commas.
+func asciiDocCode(values ...string) string {
+	var parts []string
+	for _, v := range values {
+		if v != "" {
+			parts = append(parts, "`+"+v+"+`")
+		}
+	}
+	return strings.Join(parts, ", ")
+}
+
+// asciiDocEscape protects text from being read as attribute references.
+func asciiDocEscape(s string) string {
+	return strings.ReplaceAll(s, "{", "\\{")
+}
+
+// asciiDocEscapeLine escapes a paragraph line, also guarding lines that
+// AsciiDoc would read as section titles, list items, or block delimiters.
+func asciiDocEscapeLine(line string) string {
+	line = asciiDocEscape(line)
+	trimmed := strings.TrimLeft(line, " ")
+	for _, prefix := range []string{"=", "*", "-", ".", "[", "//", "|", ":"} {
+		if strings.HasPrefix(trimmed, prefix) {
+			return "{empty}" + line
+		}
+	}
+	return line
+}
+
+// asciiDocHidden escapes the description of a flag or option, marking it
+// when hidden.
+func asciiDocHidden(desc string, hidden bool) string {
+	desc = asciiDocEscape(desc)
+	if !hidden {
+		return desc
+	}
+	return strings.TrimSpace("_(hidden)_ " + desc)
+}
+
+// asciiDocRequires returns the list items for requirements.
+func asciiDocRequires(reqs []shedoc.Requires) []orgItem {
+	var items []orgItem
+	for _, r := range reqs {
+		items = append(items, orgItem{asciiDocCode(r.Command), asciiDocEscape(r.Description)})
+	}
+	return items
+}
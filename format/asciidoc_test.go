@@ -0,0 +1,94 @@
+package format
+
+import (
+	"bytes"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/nickawilliams/shedoc"
+)
+
+func TestAsciiDocFormatter(t *testing.T) {
+	doc := &shedoc.Document{
+		Meta: shedoc.Meta{
+			Name:        "deploy",
+			Version:     "2.1.0",
+			Author:      "Jane Doe <jane@example.com>",
+			Description: "Deploys {things}.\n\n* not a list",
+			SeeAlso:     []string{"git(1)"},
+		},
+		Blocks: []shedoc.Block{
+			{
+				Visibility: shedoc.VisibilityCommand,
+				See:        []string{"deploy-push(1)"},
+				Flags:      []shedoc.Flag{{Short: "-v", Long: "--verbose", Description: "Verbose"}},
+				Options:    []shedoc.Option{{Long: "--format", Value: shedoc.Value{Name: "fmt", Default: "text"}, Description: "Output\nformat"}},
+			},
+			{
+				Visibility: shedoc.VisibilitySubcommand,
+				Name:       "migrate",
+				Deprecated: &shedoc.Deprecated{Message: "Use push."},
+				Operands:   []shedoc.Operand{{Value: shedoc.Value{Name: "env", Required: true}}},
+			},
+			{Visibility: shedoc.VisibilitySection, Name: "Helpers"},
+			{Visibility: shedoc.VisibilityPublic, FunctionName: "helper", See: []string{"setup"}, Env: []shedoc.Env{{Name: "HOME"}}},
+		},
+	}
+
+	var buf bytes.Buffer
+	if err := (&AsciiDocFormatter{}).Format(&buf, doc); err != nil {
+		t.Fatal(err)
+	}
+	got := buf.String()
+
+	for _, want := range []string{
+		"= deploy\nJane Doe <jane@example.com>\n:revnumber: 2.1.0\n\n",
+		"Deploys \\{things}.\n\n{empty}* not a list\n\n",
+		"== Synopsis\n\n[source,shell]\n----\ndeploy [-v] [--format [fmt]] <command> [args...]\n----\n",
+		"[#command]\n== Command\n",
+		"=== Options\n\n`+-v+`, `+--verbose+`:: Verbose\n`+--format+` `+[fmt=text]+`:: Output format (default: text)\n",
+		"[#sub-migrate]\n== migrate\n",
+		"WARNING: Deprecated. Use push.\n",
+		"=== Operands\n\n`+<env>+`::\n",
+		"[#section-Helpers]\n== Helpers\n",
+		"[#fn-helper]\n=== helper\n\nFunction: `+helper+` +\nSee also: `+setup+`\n\n",
+		"==== Environment\n\n`+HOME+`::\n",
+		"== See Also\n\n* git(1)\n* deploy-push(1)\n\n",
+	} {
+		if !strings.Contains(got, want) {
+			t.Errorf("output missing %q\n\nfull output:\n%s", want, got)
+		}
+	}
+}
+
+func TestAsciiDocFormatter_LicenseFile(t *testing.T) {
+	dir := t.TempDir()
+	if err := os.WriteFile(filepath.Join(dir, "LICENSE"), []byte("MIT License\n\n....\n"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	doc := &shedoc.Document{
+		Path: filepath.Join(dir, "tool.sh"),
+		Meta: shedoc.Meta{Name: "tool", LicenseFile: "LICENSE", SeeAlso: []string{"git(1)"}},
+	}
+
+	var buf bytes.Buffer
+	f := &AsciiDocFormatter{}
+	if err := f.Format(&buf, doc); err != nil {
+		t.Fatal(err)
+	}
+
+	want := "== License\n\n.....\nMIT License\n\n....\n.....\n\n== See Also\n"
+	if got := buf.String(); !strings.Contains(got, want) {
+		t.Errorf("output missing %q\n%s", want, got)
+	}
+
+	doc.Meta.LicenseFile = "MISSING"
+	buf.Reset()
+	if err := f.Format(&buf, doc); err == nil {
+		t.Error("expected error for missing license file")
+	} else if buf.Len() > 0 {
+		t.Errorf("wrote output before failing:\n%s", buf.String())
+	}
+}
@@ -0,0 +1,110 @@
+package format
+
+import (
+	"fmt"
+	"io"
+	"path/filepath"
+	"strconv"
+	"strings"
+
+	"github.com/nickawilliams/shedoc"
+)
+
+func init() {
+	shedoc.RegisterFormatter("bats", &BatsFormatter{})
+}
+
+// BatsFormatter generates a bats-core test skeleton with one test per
+// subcommand (or one for the command when it has no subcommands). Each test
+// invokes the script with placeholder values for the documented required
+// operands and asserts that the exit status is one of the documented codes.
+type BatsFormatter struct{}
+
+func (f *BatsFormatter) Format(w io.Writer, doc *shedoc.Document) error {
+	script := doc.Meta.Name
+	if doc.Path != "" {
+		script = filepath.Base(doc.Path)
+	}
+	if script == "" {
+		return fmt.Errorf("bats generation requires #?/name or a file path")
+	}
+	name := doc.Meta.Name
+	if name == "" {
+		name = script
+	}
+
+	fmt.Fprintln(w, "#!/usr/bin/env bats")
+	fmt.Fprintf(w, "# Test skeleton for %s, generated by shedoc from its documentation.\n", name)
+	fmt.Fprintln(w)
+	fmt.Fprintln(w, "setup() {")
+	fmt.Fprintf(w, "  SCRIPT=\"${SCRIPT:-$BATS_TEST_DIRNAME/%s}\"\n", script)
+	fmt.Fprintln(w, "}")
+
+	cmdBlock := doc.CommandBlock()
+	subcommands := doc.Subcommands()
+
+	if len(subcommands) == 0 {
+		if cmdBlock == nil {
+			return nil
+		}
+		fmt.Fprintln(w)
+		writeBatsTest(w, name, nil, cmdBlock.Operands, cmdBlock.Exit)
+		return nil
+	}
+
+	for _, sub := range subcommands {
+		exits := sub.Exit
+		if len(exits) == 0 && cmdBlock != nil {
+			exits = cmdBlock.Exit
+		}
+		fmt.Fprintln(w)
+		writeBatsTest(w, name+" "+sub.Name, []string{sub.Name}, sub.Operands, exits)
+	}
+	return nil
+}
+
+// writeBatsTest writes one @test that runs the script with args followed by a
+// placeholder for each required operand.
+func writeBatsTest(w io.Writer, label string, args []string, operands []shedoc.Operand, exits []shedoc.Exit) {
+	words := []string{`"$SCRIPT"`}
+	for _, a := range args {
+		words = append(words, shellQuote(a))
+	}
+	for _, op := range operands {
+		if op.Value.Required {
+			words = append(words, shellQuote(op.Value.Name))
+		}
+	}
+
+	var codes, documented []string
+	for _, e := range exits {
+		if _, err := strconv.Atoi(e.Code); err == nil {
+			codes = append(codes, e.Code)
+		}
+		entry := e.Code
+		if e.Description != "" {
+			entry += " (" + firstLine(e.Description) + ")"
+		}
+		documented = append(documented, entry)
+	}
+
+	fmt.Fprintf(w, "@test %s {\n", strconv.Quote(label+": exits with a documented status"))
+	fmt.Fprintf(w, "  run %s\n", strings.Join(words, " "))
+	if len(codes) == 0 {
+		fmt.Fprintln(w, "  # TODO: no numeric exit codes are documented; assert on $status.")
+	} else {
+		fmt.Fprintf(w, "  # Documented: %s\n", strings.Join(documented, ", "))
+		fmt.Fprintf(w, "  [[ \"$status\" =~ ^(%s)$ ]]\n", strings.Join(codes, "|"))
+	}
+	fmt.Fprintln(w, "  # TODO: assert on $output.")
+	fmt.Fprintln(w, "}")
+}
+
+// shellQuote quotes s for a shell command line when it contains anything
+// other than safe word characters.
+func shellQuote(s string) string {
+	if s != "" && strings.Trim(s, "abcdefghijklmnopqrstuvwxyzABCDEFGHIJKLMNOPQRSTUVWXYZ0123456789-_./:") == "" {
+		return s
+	}
+	return "'" + strings.ReplaceAll(s, "'", `'\''`) + "'"
+}
@@ -0,0 +1,87 @@
+package format
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+
+	"github.com/nickawilliams/shedoc"
+)
+
+func TestBatsFormatter_Subcommands(t *testing.T) {
+	doc := &shedoc.Document{
+		Path: "bin/deploy.sh",
+		Meta: shedoc.Meta{Name: "deploy"},
+		Blocks: []shedoc.Block{
+			{
+				Visibility: shedoc.VisibilityCommand,
+				Exit:       []shedoc.Exit{{Code: "0"}, {Code: "2", Description: "Usage error"}},
+			},
+			{
+				Visibility: shedoc.VisibilitySubcommand,
+				Name:       "push",
+				Operands: []shedoc.Operand{
+					{Value: shedoc.Value{Name: "environment", Required: true}},
+					{Value: shedoc.Value{Name: "services", Variadic: true}},
+				},
+				Exit: []shedoc.Exit{{Code: "0", Description: "Success"}, {Code: "1", Description: "Deploy failed"}},
+			},
+			{
+				Visibility: shedoc.VisibilitySubcommand,
+				Name:       "status",
+			},
+		},
+	}
+
+	var buf bytes.Buffer
+	f := &BatsFormatter{}
+	if err := f.Format(&buf, doc); err != nil {
+		t.Fatal(err)
+	}
+	got := buf.String()
+
+	for _, want := range []string{
+		"#!/usr/bin/env bats\n",
+		`SCRIPT="${SCRIPT:-$BATS_TEST_DIRNAME/deploy.sh}"`,
+		"@test \"deploy push: exits with a documented status\" {\n  run \"$SCRIPT\" push environment\n  # Documented: 0 (Success), 1 (Deploy failed)\n  [[ \"$status\" =~ ^(0|1)$ ]]\n",
+		// status documents no exit codes, so it inherits the command's.
+		"  run \"$SCRIPT\" status\n  # Documented: 0, 2 (Usage error)\n  [[ \"$status\" =~ ^(0|2)$ ]]\n",
+	} {
+		if !strings.Contains(got, want) {
+			t.Errorf("output missing %q\n\nfull output:\n%s", want, got)
+		}
+	}
+}
+
+func TestBatsFormatter_NoExitCodes(t *testing.T) {
+	doc := &shedoc.Document{
+		Meta: shedoc.Meta{Name: "greet"},
+		Blocks: []shedoc.Block{{
+			Visibility: shedoc.VisibilityCommand,
+			Operands:   []shedoc.Operand{{Value: shedoc.Value{Name: "first name", Required: true}}},
+		}},
+	}
+
+	var buf bytes.Buffer
+	f := &BatsFormatter{}
+	if err := f.Format(&buf, doc); err != nil {
+		t.Fatal(err)
+	}
+	got := buf.String()
+
+	for _, want := range []string{
+		"run \"$SCRIPT\" 'first name'\n",
+		"# TODO: no numeric exit codes are documented",
+	} {
+		if !strings.Contains(got, want) {
+			t.Errorf("output missing %q\n\nfull output:\n%s", want, got)
+		}
+	}
+}
+
+func TestBatsFormatter_RequiresName(t *testing.T) {
+	f := &BatsFormatter{}
+	if err := f.Format(&bytes.Buffer{}, &shedoc.Document{}); err == nil {
+		t.Error("expected error without a name or path")
+	}
+}
@@ -0,0 +1,89 @@
+package format
+
+import (
+	"fmt"
+	"path/filepath"
+	"strconv"
+	"strings"
+
+	"github.com/nickawilliams/shedoc"
+)
+
+// blockDepths returns the nesting depth of each block of doc under
+// #@/section blocks: 0 for sections and any blocks before the first section,
+// and 1 for the blocks that follow a section.
+func blockDepths(doc *shedoc.Document) []int {
+	depths := make([]int, len(doc.Blocks))
+	inSection := false
+	for i, b := range doc.Blocks {
+		if b.Visibility == shedoc.VisibilitySection {
+			inSection = true
+			continue
+		}
+		if inSection {
+			depths[i] = 1
+		}
+	}
+	return depths
+}
+
+// blockHeading returns the section heading for a block.
+func blockHeading(b *shedoc.Block) string {
+	switch {
+	case b.Visibility == shedoc.VisibilityCommand:
+		return "Command"
+	case b.Name != "":
+		return b.Name
+	case b.FunctionName != "":
+		return b.FunctionName
+	default:
+		return fmt.Sprintf("Block at line %d", b.Line)
+	}
+}
+
+// blockAnchor returns the fragment identifier for a block: "command",
+// "sub-<name>", "section-<title>", "var-<name>", "fn-<name>", or "line-<n>". Identifiers
+// depend only on names, so links to them survive edits elsewhere in the
+// script. Characters other than letters, digits, "_", and "." become "-".
+func blockAnchor(b *shedoc.Block) string {
+	var id string
+	switch {
+	case b.Visibility == shedoc.VisibilityCommand:
+		id = "command"
+	case b.Visibility == shedoc.VisibilitySubcommand && b.Name != "":
+		id = "sub-" + b.Name
+	case b.Visibility == shedoc.VisibilitySection && b.Name != "":
+		id = "section-" + b.Name
+	case (b.Visibility == shedoc.VisibilityVar || b.Readonly) && b.Name != "":
+		id = "var-" + b.Name
+	case b.FunctionName != "":
+		id = "fn-" + b.FunctionName
+	default:
+		id = fmt.Sprintf("line-%d", b.Line)
+	}
+
+	var sb strings.Builder
+	dash := false
+	for _, r := range id {
+		if r >= 'a' && r <= 'z' || r >= 'A' && r <= 'Z' || r >= '0' && r <= '9' || r == '_' || r == '.' {
+			sb.WriteRune(r)
+			dash = false
+		} else if !dash {
+			sb.WriteByte('-')
+			dash = true
+		}
+	}
+	return strings.Trim(sb.String(), "-")
+}
+
+// sourceURL expands a link template for the block b of doc: "{path}" becomes
+// the document's path, with forward slashes and no leading "./", "{line}"
+// the block's line, and "{ref}" HEAD. It returns "" when there is no
+// template.
+func sourceURL(template string, doc *shedoc.Document, b *shedoc.Block) string {
+	if template == "" {
+		return ""
+	}
+	path := strings.TrimPrefix(filepath.ToSlash(doc.Path), "./")
+	return strings.NewReplacer("{path}", path, "{line}", strconv.Itoa(b.Line), "{ref}", "HEAD").Replace(template)
+}
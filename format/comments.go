@@ -0,0 +1,279 @@
+package format
+
+import (
+	"fmt"
+	"io"
+	"strings"
+
+	"github.com/nickawilliams/shedoc"
+)
+
+func init() {
+	shedoc.RegisterFormatter("comments", &CommentsFormatter{})
+}
+
+// CommentsFormatter writes a Document back out as shedoc comments: the
+// shebang, #?/ metadata, and one #@/ sheblock per block. Function bodies and
+// other script code are not part of the Document and are not written.
+//
+// Tags are written in the same order whatever order the script gave them
+// in, so that rewritten blocks diff cleanly: the description, then @flag,
+// @option, @operand, @env, @reads, @requires, and @stdin; @exit, @return,
+// @stdout, @stderr, @sets, and @writes; and last the metadata tags, @alias
+// through @see.
+type CommentsFormatter struct{}
+
+func (f *CommentsFormatter) Format(w io.Writer, doc *shedoc.Document) error {
+	var sections []string
+
+	if doc.Shebang != "" {
+		sections = append(sections, "#!"+doc.Shebang+"\n")
+	}
+	if meta := commentMeta(doc.Meta); meta != "" {
+		sections = append(sections, meta)
+	}
+	for i := range doc.Blocks {
+		sections = append(sections, commentBlock(&doc.Blocks[i]))
+	}
+
+	_, err := io.WriteString(w, strings.Join(sections, "\n"))
+	return err
+}
+
+// commentMeta renders the #?/ tags. Single-line values use the inline form,
+// aligned in a column; anything else uses the block form.
+func commentMeta(m shedoc.Meta) string {
+	fields := []struct{ path, value string }{
+		{"name", m.Name},
+		{"version", m.Version},
+		{"synopsis", strings.Join(m.Synopsis, "\n")},
+		{"section", m.Section},
+		{"author", m.Author},
+		{"license", m.License},
+		{"license-file", m.LicenseFile},
+		{"lang", m.Lang},
+		{"see-also", strings.Join(m.SeeAlso, ", ")},
+		{"requires", commentRequires(m.Requires)},
+		{"description", m.Description},
+		{"examples", m.Examples},
+	}
+
+	width := 0
+	for _, f := range fields {
+		if f.value != "" && isInlineValue(f.value) {
+			width = max(width, len(f.path))
+		}
+	}
+
+	var inline, blocks strings.Builder
+	for _, f := range fields {
+		switch {
+		case f.value == "":
+		case isInlineValue(f.value):
+			fmt.Fprintf(&inline, "#?/%-*s %s\n", width, f.path, f.value)
+		default:
+			fmt.Fprintf(&blocks, "\n#?/%s\n", f.path)
+			for _, line := range strings.Split(f.value, "\n") {
+				blocks.WriteString(commentLine(line))
+			}
+			blocks.WriteString(" ##\n")
+		}
+	}
+
+	return strings.TrimPrefix(inline.String()+blocks.String(), "\n")
+}
+
+// commentRequires renders #?/requires, one requirement per line.
+func commentRequires(reqs []shedoc.Requires) string {
+	var lines []string
+	for _, r := range reqs {
+		lines = append(lines, strings.TrimSpace(r.Command+" "+r.Description))
+	}
+	return strings.Join(lines, "\n")
+}
+
+// isInlineValue reports whether v survives the inline "#?/path value" form,
+// which trims surrounding whitespace and cannot span lines.
+func isInlineValue(v string) bool {
+	return !strings.Contains(v, "\n") && strings.TrimSpace(v) == v
+}
+
+// commentLine renders one continuation line, without trailing whitespace on
+// blank lines.
+func commentLine(s string) string {
+	if s == "" {
+		return " #\n"
+	}
+	return " # " + s + "\n"
+}
+
+// commentTag is a single @tag line: the tag, its spec (names, value notation,
+// or path), and its description.
+type commentTag struct {
+	tag, spec, desc string
+}
+
+func commentBlock(b *shedoc.Block) string {
+	var sb strings.Builder
+
+	switch b.Visibility {
+	case shedoc.VisibilitySubcommand:
+		sb.WriteString("#@/subcommand " + b.Name + "\n")
+	case shedoc.VisibilitySection, shedoc.VisibilityVar:
+		sb.WriteString("#@/" + string(b.Visibility) + " " + b.Name + "\n")
+	default:
+		sb.WriteString("#@/" + string(b.Visibility) + "\n")
+	}
+
+	if b.Description != "" {
+		for _, line := range strings.Split(b.Description, "\n") {
+			sb.WriteString(commentLine(line))
+		}
+	}
+
+	var inputs, outputs, metadata []commentTag
+	// @group applies to the flags and options after it, until the next.
+	var group string
+	setGroup := func(name string) {
+		if name != group {
+			inputs = append(inputs, commentTag{"@group", name, ""})
+			group = name
+		}
+	}
+	for _, f := range b.Flags {
+		setGroup(f.Group)
+		inputs = append(inputs, commentTag{"@flag", joinCommentNames(f.Short, f.Long), f.Description})
+	}
+	for _, o := range b.Options {
+		setGroup(o.Group)
+		spec := joinCommentNames(o.Short, o.Long) + " " + o.Value.String()
+		tag := "@option"
+		if o.Required {
+			tag = "@option!"
+		}
+		inputs = append(inputs, commentTag{tag, spec, o.Description})
+	}
+	for _, o := range b.Operands {
+		inputs = append(inputs, commentTag{"@operand", o.Value.String(), o.Description})
+	}
+	for _, e := range b.Env {
+		name := e.Name
+		if e.Default != "" {
+			name += " [=" + e.Default + "]"
+		}
+		inputs = append(inputs, commentTag{"@env", name, e.Description})
+	}
+	for _, r := range b.Reads {
+		inputs = append(inputs, commentTag{"@reads", r.Path, r.Description})
+	}
+	for _, r := range b.Requires {
+		inputs = append(inputs, commentTag{"@requires", r.Command, r.Description})
+	}
+	if b.Stdin != nil {
+		inputs = append(inputs, commentTag{"@stdin", "", b.Stdin.Description})
+	}
+
+	for _, e := range b.Exit {
+		outputs = append(outputs, commentTag{"@exit", e.Code, e.Description})
+	}
+	for _, r := range b.Return {
+		outputs = append(outputs, commentTag{"@return", r.Code, r.Description})
+	}
+	if b.Stdout != nil {
+		outputs = append(outputs, commentTag{"@stdout", "", b.Stdout.Description})
+	}
+	if b.Stderr != nil {
+		outputs = append(outputs, commentTag{"@stderr", "", b.Stderr.Description})
+	}
+	for _, s := range b.Sets {
+		outputs = append(outputs, commentTag{"@sets", s.Name, s.Description})
+	}
+	for _, wr := range b.Writes {
+		outputs = append(outputs, commentTag{"@writes", wr.Path, wr.Description})
+	}
+
+	if len(b.Aliases) > 0 {
+		metadata = append(metadata, commentTag{"@alias", strings.Join(b.Aliases, " "), ""})
+	}
+	if b.Hidden {
+		metadata = append(metadata, commentTag{"@hidden", "", ""})
+	}
+	for _, f := range b.Flags {
+		if f.Hidden {
+			metadata = append(metadata, commentTag{"@hidden", firstNonEmpty(f.Long, f.Short), ""})
+		}
+	}
+	for _, o := range b.Options {
+		if o.Hidden {
+			metadata = append(metadata, commentTag{"@hidden", firstNonEmpty(o.Long, o.Short), ""})
+		}
+	}
+	for _, o := range b.Options {
+		if o.Complete != "" {
+			target := firstNonEmpty(o.Long, o.Short)
+			metadata = append(metadata, commentTag{"@complete", target + " $(" + o.Complete + ")", ""})
+		}
+	}
+	for _, o := range b.Operands {
+		if o.Complete != "" {
+			target := "<" + o.Value.Name + ">"
+			metadata = append(metadata, commentTag{"@complete", target + " $(" + o.Complete + ")", ""})
+		}
+	}
+	if b.Since != "" {
+		metadata = append(metadata, commentTag{"@since", b.Since, ""})
+	}
+	if b.Deprecated != nil {
+		metadata = append(metadata, commentTag{"@deprecated", "", b.Deprecated.Message})
+	}
+	if len(b.See) > 0 {
+		metadata = append(metadata, commentTag{"@see", strings.Join(b.See, ", "), ""})
+	}
+
+	tagWidth, specWidth := 0, 0
+	for _, group := range [][]commentTag{inputs, outputs, metadata} {
+		for _, t := range group {
+			tagWidth = max(tagWidth, len(t.tag))
+			specWidth = max(specWidth, len(t.spec))
+		}
+	}
+
+	needBlank := b.Description != ""
+	for _, group := range [][]commentTag{inputs, outputs, metadata} {
+		if len(group) == 0 {
+			continue
+		}
+		if needBlank {
+			sb.WriteString(" #\n")
+		}
+		needBlank = true
+		for _, t := range group {
+			line := fmt.Sprintf("%-*s %-*s  %s", tagWidth, t.tag, specWidth, t.spec, t.desc)
+			sb.WriteString(commentLine(strings.TrimRight(line, " ")))
+		}
+	}
+
+	sb.WriteString(" ##\n")
+	return sb.String()
+}
+
+// joinCommentNames renders flag names in the "-s | --long" tag form.
+func joinCommentNames(short, long string) string {
+	switch {
+	case short != "" && long != "":
+		return short + " | " + long
+	case short != "":
+		return short
+	default:
+		return long
+	}
+}
+
+func firstNonEmpty(values ...string) string {
+	for _, v := range values {
+		if v != "" {
+			return v
+		}
+	}
+	return ""
+}
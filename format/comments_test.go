@@ -0,0 +1,151 @@
+package format
+
+import (
+	"bytes"
+	"slices"
+	"strings"
+	"testing"
+
+	"github.com/nickawilliams/shedoc"
+)
+
+func TestCommentsFormatter(t *testing.T) {
+	doc := &shedoc.Document{
+		Shebang: "/usr/bin/env bash",
+		Meta: shedoc.Meta{
+			Name:        "my-tool",
+			Version:     "1.0.0",
+			Description: "First line.\n\nSecond paragraph.",
+		},
+		Blocks: []shedoc.Block{
+			{
+				Visibility:  shedoc.VisibilityCommand,
+				Description: "Does things.",
+				Flags:       []shedoc.Flag{{Short: "-v", Long: "--verbose", Description: "Be loud"}, {Long: "--debug", Hidden: true}},
+				Options: []shedoc.Option{{
+					Long:     "--env",
+					Value:    shedoc.Value{Name: "name", Default: "dev", Variadic: true},
+					Complete: "ls envs",
+				}},
+				Exit:   []shedoc.Exit{{Code: "0", Description: "Success"}},
+				Stdout: &shedoc.Stdout{Description: "The result"},
+			},
+			{Visibility: shedoc.VisibilitySubcommand, Name: "push", Aliases: []string{"p"}, Hidden: true},
+		},
+	}
+
+	var buf bytes.Buffer
+	if err := (&CommentsFormatter{}).Format(&buf, doc); err != nil {
+		t.Fatal(err)
+	}
+
+	want := `#!/usr/bin/env bash
+
+#?/name    my-tool
+#?/version 1.0.0
+
+#?/description
+ # First line.
+ #
+ # Second paragraph.
+ ##
+
+#@/command
+ # Does things.
+ #
+ # @flag     -v | --verbose       Be loud
+ # @flag     --debug
+ # @option   --env [name=dev...]
+ #
+ # @exit     0                    Success
+ # @stdout                        The result
+ #
+ # @hidden   --debug
+ # @complete --env $(ls envs)
+ ##
+
+#@/subcommand push
+ # @alias  p
+ # @hidden
+ ##
+`
+	if got := buf.String(); got != want {
+		t.Errorf("got:\n%s\nwant:\n%s", got, want)
+	}
+}
+
+func TestCommentsFormatterReparses(t *testing.T) {
+	doc := &shedoc.Document{
+		Meta: shedoc.Meta{Requires: []shedoc.Requires{{Command: "jq", Description: "JSON processing"}, {Command: "git"}}},
+		Blocks: []shedoc.Block{{
+			Visibility: shedoc.VisibilityCommand,
+			Flags:      []shedoc.Flag{{Long: "--debug", Hidden: true}},
+			Options: []shedoc.Option{{
+				Short:    "-e",
+				Value:    shedoc.Value{Name: "name", Default: "dev", Variadic: true},
+				Complete: "ls envs",
+			}, {
+				Long:     "--token",
+				Value:    shedoc.Value{Name: "t", Required: true},
+				Required: true,
+				Group:    "Authentication",
+			}},
+			Operands:   []shedoc.Operand{{Value: shedoc.Value{Name: "file", Required: true}, Complete: "ls"}},
+			Requires:   []shedoc.Requires{{Command: "docker", Description: "Container runtime"}},
+			Since:      "1.2.0",
+			Deprecated: &shedoc.Deprecated{Message: "Use other-tool"},
+			See:        []string{"other-tool(1)", "https://example.com"},
+		}, {
+			Visibility:  shedoc.VisibilitySection,
+			Name:        "Database migrations",
+			Description: "Schema changes.",
+		}},
+	}
+
+	var buf bytes.Buffer
+	if err := (&CommentsFormatter{}).Format(&buf, doc); err != nil {
+		t.Fatal(err)
+	}
+	got, err := shedoc.ParseReader(strings.NewReader(buf.String()))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(got.Warnings) > 0 {
+		t.Fatalf("unexpected warnings: %v", got.Warnings)
+	}
+
+	if len(got.Meta.Requires) != 2 || got.Meta.Requires[0].Description != "JSON processing" || got.Meta.Requires[1].Command != "git" {
+		t.Errorf("meta requires = %+v", got.Meta.Requires)
+	}
+	b := got.Blocks[0]
+	if !b.Flags[0].Hidden {
+		t.Error("flag lost Hidden")
+	}
+	if b.Options[0].Value != doc.Blocks[0].Options[0].Value || b.Options[0].Complete != "ls envs" {
+		t.Errorf("option = %+v", b.Options[0])
+	}
+	if !b.Options[1].Required {
+		t.Errorf("option lost Required: %+v", b.Options[1])
+	}
+	if b.Options[0].Group != "" || b.Options[1].Group != "Authentication" {
+		t.Errorf("groups = %q, %q", b.Options[0].Group, b.Options[1].Group)
+	}
+	if b.Operands[0].Complete != "ls" {
+		t.Errorf("operand = %+v", b.Operands[0])
+	}
+	if len(b.Requires) != 1 || b.Requires[0].Command != "docker" || b.Requires[0].Description != "Container runtime" {
+		t.Errorf("requires = %+v", b.Requires)
+	}
+	if b.Since != "1.2.0" {
+		t.Errorf("since = %q", b.Since)
+	}
+	if b.Deprecated == nil || b.Deprecated.Message != "Use other-tool" {
+		t.Errorf("deprecated = %+v", b.Deprecated)
+	}
+	if !slices.Equal(b.See, doc.Blocks[0].See) {
+		t.Errorf("see = %v", b.See)
+	}
+	if s := got.Blocks[1]; s.Visibility != shedoc.VisibilitySection || s.Name != "Database migrations" {
+		t.Errorf("section = %+v", s)
+	}
+}
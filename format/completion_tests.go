@@ -0,0 +1,134 @@
+package format
+
+import (
+	"fmt"
+	"io"
+	"strings"
+
+	"github.com/nickawilliams/shedoc"
+	"github.com/nickawilliams/shedoc/internal/completionmodel"
+)
+
+func init() {
+	shedoc.RegisterFormatter("completion-tests", &CompletionTestsFormatter{})
+}
+
+// CompletionTestsFormatter generates a bash script that sources a bash
+// completion file, completes representative command lines, and checks that
+// the documented subcommands and flags are offered. It reports in TAP and
+// exits non-zero on failure, so packagers can check an installed completion.
+type CompletionTestsFormatter struct{}
+
+// completionCheck is a command line and the words completing it must offer.
+type completionCheck struct {
+	line  string
+	words []string
+}
+
+func (f *CompletionTestsFormatter) Format(w io.Writer, doc *shedoc.Document) error {
+	name := doc.Meta.Name
+	if name == "" {
+		return fmt.Errorf("completion test generation requires #?/name")
+	}
+
+	model := completionmodel.Build(doc, name)
+	globalFlags := completionmodel.FlagWords(model.Flags)
+
+	var checks []completionCheck
+	if len(model.Subcommands) > 0 {
+		var subNames []string
+		for _, sub := range model.Subcommands {
+			subNames = append(subNames, sub.Words()...)
+		}
+		checks = append(checks, completionCheck{name + " ", append(subNames, globalFlags...)})
+
+		first := model.Subcommands[0].Name
+		var matching []string
+		for _, word := range subNames {
+			if strings.HasPrefix(word, first[:1]) {
+				matching = append(matching, word)
+			}
+		}
+		checks = append(checks, completionCheck{name + " " + first[:1], matching})
+
+		for _, sub := range model.Subcommands {
+			if subFlags := completionmodel.FlagWords(sub.Flags); len(subFlags) > 0 {
+				checks = append(checks, completionCheck{name + " " + sub.Name + " ", subFlags})
+			}
+		}
+	} else if len(globalFlags) > 0 {
+		checks = append(checks, completionCheck{name + " ", globalFlags})
+	}
+
+	var longFlags []string
+	for _, fl := range model.Flags {
+		if fl.Long != "" {
+			longFlags = append(longFlags, fl.Long)
+		}
+	}
+	if len(longFlags) > 0 {
+		checks = append(checks, completionCheck{name + " --", longFlags})
+	}
+
+	fmt.Fprintln(w, "#!/usr/bin/env bash")
+	fmt.Fprintf(w, "# Completion tests for %s, generated by shedoc from its documentation.\n", name)
+	fmt.Fprintln(w, "#")
+	fmt.Fprintln(w, "# Usage: bash <this file> <bash-completion-file>")
+	fmt.Fprintln(w, "#")
+	fmt.Fprintln(w, "# Sources the completion file, completes representative command lines, and")
+	fmt.Fprintln(w, "# checks that the documented words are offered. Output is TAP.")
+	fmt.Fprintln(w)
+	fmt.Fprintln(w, `source "${1:?usage: $0 <bash-completion-file>}" || exit 1`)
+	fmt.Fprintf(w, "func=$(complete -p %s 2>/dev/null | sed -n 's/.* -F \\([^ ]*\\) .*/\\1/p')\n", shellQuote(name))
+	fmt.Fprintln(w, `if [[ -z $func ]]; then`)
+	fmt.Fprintf(w, "  echo \"Bail out! no completion function registered for %s\"\n", name)
+	fmt.Fprintln(w, "  exit 1")
+	fmt.Fprintln(w, "fi")
+	fmt.Fprintln(w)
+	fmt.Fprint(w, completionCheckFunc)
+	fmt.Fprintln(w)
+	for _, c := range checks {
+		words := make([]string, len(c.words))
+		for i, word := range c.words {
+			words[i] = shellQuote(word)
+		}
+		fmt.Fprintf(w, "check %s %s\n", shellQuote(c.line), strings.Join(words, " "))
+	}
+	fmt.Fprintln(w)
+	fmt.Fprintln(w, `echo "1..$tests"`)
+	fmt.Fprintln(w, "exit $((failures > 0))")
+	return nil
+}
+
+// completionCheckFunc completes the last word of a command line the way bash
+// does and compares COMPREPLY with the expected words.
+const completionCheckFunc = `tests=0 failures=0
+
+# check LINE WORD... completes the last word of LINE and checks that each WORD
+# is offered.
+check() {
+  local line=$1
+  shift
+  read -ra COMP_WORDS <<<"$line"
+  [[ $line == *" " ]] && COMP_WORDS+=("")
+  COMP_CWORD=$((${#COMP_WORDS[@]} - 1))
+  COMP_LINE=$line
+  COMP_POINT=${#line}
+  COMPREPLY=()
+  "$func" "${COMP_WORDS[0]}" "${COMP_WORDS[COMP_CWORD]}" "${COMP_WORDS[COMP_CWORD-1]}"
+
+  local word missing=()
+  for word in "$@"; do
+    [[ " ${COMPREPLY[*]} " == *" $word "* ]] || missing+=("$word")
+  done
+  tests=$((tests + 1))
+  if ((${#missing[@]} == 0)); then
+    echo "ok $tests - $line"
+  else
+    failures=$((failures + 1))
+    echo "not ok $tests - $line"
+    echo "#   missing: ${missing[*]}"
+    echo "#   offered: ${COMPREPLY[*]}"
+  fi
+}
+`
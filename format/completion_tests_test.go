@@ -0,0 +1,71 @@
+package format
+
+import (
+	"bytes"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/nickawilliams/shedoc"
+)
+
+func TestCompletionTestsFormatter(t *testing.T) {
+	var buf bytes.Buffer
+	f := &CompletionTestsFormatter{}
+	if err := f.Format(&buf, completionTestDoc); err != nil {
+		t.Fatal(err)
+	}
+
+	got := buf.String()
+	for _, check := range []string{
+		"#!/usr/bin/env bash",
+		"complete -p deploy",
+		"check 'deploy ' push status -v --verbose -c --config\n",
+		"check 'deploy p' push\n",
+		"check 'deploy push ' -f --force\n",
+		"check 'deploy --' --verbose --config\n",
+	} {
+		if !strings.Contains(got, check) {
+			t.Errorf("output missing %q\n\n%s", check, got)
+		}
+	}
+}
+
+func TestCompletionTestsFormatter_RequiresName(t *testing.T) {
+	f := &CompletionTestsFormatter{}
+	if err := f.Format(&bytes.Buffer{}, &shedoc.Document{}); err == nil {
+		t.Error("expected error without #?/name")
+	}
+}
+
+func TestCompletionTestsFormatter_RunsAgainstBashCompletion(t *testing.T) {
+	bash, err := exec.LookPath("bash")
+	if err != nil {
+		t.Skip("bash not installed")
+	}
+
+	dir := t.TempDir()
+	write := func(name string, f shedoc.Formatter) string {
+		var buf bytes.Buffer
+		if err := f.Format(&buf, completionTestDoc); err != nil {
+			t.Fatal(err)
+		}
+		path := filepath.Join(dir, name)
+		if err := os.WriteFile(path, buf.Bytes(), 0o644); err != nil {
+			t.Fatal(err)
+		}
+		return path
+	}
+	completion := write("deploy.bash", &BashCompletionFormatter{})
+	tests := write("deploy-tests.sh", &CompletionTestsFormatter{})
+
+	out, err := exec.Command(bash, "--norc", "--noprofile", tests, completion).CombinedOutput()
+	if err != nil {
+		t.Fatalf("completion tests failed: %v\n%s", err, out)
+	}
+	if strings.Contains(string(out), "not ok") || !strings.Contains(string(out), "1..") {
+		t.Errorf("unexpected TAP output:\n%s", out)
+	}
+}
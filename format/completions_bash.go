@@ -0,0 +1,99 @@
+package format
+
+import (
+	"fmt"
+	"io"
+	"strings"
+	"time"
+
+	"github.com/nickawilliams/shedoc"
+	"github.com/nickawilliams/shedoc/internal/completionmodel"
+)
+
+func init() {
+	shedoc.RegisterFormatter("completion:bash", &BashCompletionFormatter{})
+}
+
+// BashCompletionFormatter generates a bash completion script. It uses
+// bash-completion when it is loaded and reads COMP_WORDS directly otherwise.
+type BashCompletionFormatter struct {
+	// GeneratedAt, if set, is noted as the time the script was generated
+	// at in the comment at its top.
+	GeneratedAt time.Time
+}
+
+func (f *BashCompletionFormatter) Format(w io.Writer, doc *shedoc.Document) error {
+	name := doc.Meta.Name
+	if name == "" {
+		return fmt.Errorf("completion generation requires #?/name")
+	}
+
+	funcName := strings.ReplaceAll(name, "-", "_")
+	model := completionmodel.Build(doc, name)
+
+	fmt.Fprintf(w, "# bash completion for %s\n", name)
+	fmt.Fprintf(w, "# %s\n", generatedBy(doc, f.GeneratedAt))
+	fmt.Fprintf(w, "_%s() {\n", funcName)
+	fmt.Fprintf(w, "  local cur prev words cword\n")
+	writeBashInit(w)
+	fmt.Fprintln(w)
+
+	// Collect global flags/options
+	globalFlags := completionmodel.FlagWords(model.Flags)
+
+	if len(model.Subcommands) > 0 {
+		// Subcommand names
+		var subNames []string
+		for _, sub := range model.Subcommands {
+			subNames = append(subNames, sub.Words()...)
+		}
+
+		fmt.Fprintf(w, "  local commands=\"%s\"\n", strings.Join(subNames, " "))
+		fmt.Fprintln(w)
+
+		// Subcommand-specific completions
+		fmt.Fprintf(w, "  # Complete subcommand-specific flags\n")
+		fmt.Fprintf(w, "  local i cmd\n")
+		fmt.Fprintf(w, "  for ((i=1; i < cword; i++)); do\n")
+		fmt.Fprintf(w, "    case \"${words[i]}\" in\n")
+		for _, sub := range model.Subcommands {
+			subFlags := completionmodel.FlagWords(sub.Flags)
+			if len(subFlags) > 0 {
+				fmt.Fprintf(w, "      %s)\n", strings.Join(sub.Words(), "|"))
+				fmt.Fprintf(w, "        COMPREPLY=($(compgen -W \"%s\" -- \"$cur\"))\n", strings.Join(subFlags, " "))
+				fmt.Fprintf(w, "        return\n")
+				fmt.Fprintf(w, "        ;;\n")
+			}
+		}
+		fmt.Fprintf(w, "    esac\n")
+		fmt.Fprintf(w, "  done\n")
+		fmt.Fprintln(w)
+
+		// Top-level: complete subcommands and global flags
+		allCompletions := append(subNames, globalFlags...)
+		fmt.Fprintf(w, "  COMPREPLY=($(compgen -W \"%s\" -- \"$cur\"))\n", strings.Join(allCompletions, " "))
+	} else if len(globalFlags) > 0 {
+		fmt.Fprintf(w, "  COMPREPLY=($(compgen -W \"%s\" -- \"$cur\"))\n", strings.Join(globalFlags, " "))
+	}
+
+	fmt.Fprintf(w, "}\n\n")
+	fmt.Fprintf(w, "complete -F _%s %s\n", funcName, strings.Join(model.Names, " "))
+	return nil
+}
+
+// writeBashInit sets cur, prev, words, and cword with bash-completion 2.12's
+// _comp_initialize or the _init_completion of earlier releases, falling back
+// to COMP_WORDS where bash-completion is not installed. The fallback does not
+// rejoin words split at COMP_WORDBREAKS characters.
+func writeBashInit(w io.Writer) {
+	fmt.Fprintf(w, "  if declare -F _comp_initialize >/dev/null; then\n")
+	fmt.Fprintf(w, "    _comp_initialize -- \"$@\" || return\n")
+	fmt.Fprintf(w, "  elif declare -F _init_completion >/dev/null; then\n")
+	fmt.Fprintf(w, "    _init_completion || return\n")
+	fmt.Fprintf(w, "  else\n")
+	fmt.Fprintf(w, "    words=(\"${COMP_WORDS[@]}\")\n")
+	fmt.Fprintf(w, "    cword=$COMP_CWORD\n")
+	fmt.Fprintf(w, "    cur=${COMP_WORDS[COMP_CWORD]}\n")
+	fmt.Fprintf(w, "    prev=${COMP_WORDS[COMP_CWORD-1]}\n")
+	fmt.Fprintf(w, "  fi\n")
+}
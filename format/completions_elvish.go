@@ -0,0 +1,100 @@
+package format
+
+import (
+	"fmt"
+	"io"
+	"strings"
+	"time"
+
+	"github.com/nickawilliams/shedoc"
+	"github.com/nickawilliams/shedoc/internal/completionmodel"
+)
+
+func init() {
+	shedoc.RegisterFormatter("completion:elvish", &ElvishCompletionFormatter{})
+}
+
+// ElvishCompletionFormatter generates an elvish completion script: an
+// edit:completion:arg-completer that offers the subcommands and global flags
+// until a subcommand is typed, and that subcommand's flags after it.
+type ElvishCompletionFormatter struct {
+	// GeneratedAt, if set, is noted as the time the script was generated
+	// at in the comment at its top.
+	GeneratedAt time.Time
+}
+
+func (f *ElvishCompletionFormatter) Format(w io.Writer, doc *shedoc.Document) error {
+	name := doc.Meta.Name
+	if name == "" {
+		return fmt.Errorf("completion generation requires #?/name")
+	}
+
+	model := completionmodel.Build(doc, name)
+
+	fmt.Fprintf(w, "# elvish completion for %s\n", name)
+	fmt.Fprintf(w, "# %s\n\n", generatedBy(doc, f.GeneratedAt))
+	fmt.Fprintf(w, "set edit:completion:arg-completer[%s] = {|@words|\n", name)
+
+	// Top level: subcommands, then global flags and options.
+	var top []string
+	for _, sub := range model.Subcommands {
+		for _, word := range sub.Words() {
+			top = append(top, elvishCandidate(word, sub.Description))
+		}
+	}
+	top = append(top, elvishFlagCandidates(model.Flags)...)
+	fmt.Fprintf(w, "  var candidates = [%s]\n", strings.Join(top, " "))
+
+	// After a subcommand: its own flags and options.
+	if len(model.Subcommands) > 0 {
+		fmt.Fprintf(w, "  for word $words[1..-1] {\n")
+		for _, sub := range model.Subcommands {
+			var words []string
+			for _, word := range sub.Words() {
+				words = append(words, elvishQuote(word))
+			}
+			fmt.Fprintf(w, "    if (has-value [%s] $word) {\n", strings.Join(words, " "))
+			fmt.Fprintf(w, "      set candidates = [%s]\n", strings.Join(elvishFlagCandidates(sub.Flags), " "))
+			fmt.Fprintf(w, "      break\n")
+			fmt.Fprintf(w, "    }\n")
+		}
+		fmt.Fprintf(w, "  }\n")
+	}
+
+	fmt.Fprintf(w, "  for c $candidates {\n")
+	fmt.Fprintf(w, "    if (eq $c[1] '') {\n")
+	fmt.Fprintf(w, "      put $c[0]\n")
+	fmt.Fprintf(w, "    } else {\n")
+	fmt.Fprintf(w, "      edit:complex-candidate $c[0] &display=$c[0]' - '$c[1]\n")
+	fmt.Fprintf(w, "    }\n")
+	fmt.Fprintf(w, "  }\n")
+	fmt.Fprintf(w, "}\n")
+
+	// Aliases share the primary command's completer.
+	for _, alias := range model.Names[1:] {
+		fmt.Fprintf(w, "set edit:completion:arg-completer[%s] = $edit:completion:arg-completer[%s]\n", alias, name)
+	}
+	return nil
+}
+
+// elvishFlagCandidates returns a candidate pair for each form of each flag.
+func elvishFlagCandidates(flags []completionmodel.Flag) []string {
+	var cs []string
+	for _, f := range flags {
+		for _, word := range f.Words() {
+			cs = append(cs, elvishCandidate(word, f.Description))
+		}
+	}
+	return cs
+}
+
+// elvishCandidate returns a [word description] list literal.
+func elvishCandidate(word, description string) string {
+	return "[" + elvishQuote(word) + " " + elvishQuote(firstLine(description)) + "]"
+}
+
+// elvishQuote returns s as an elvish single-quoted string, in which a quote
+// is written twice.
+func elvishQuote(s string) string {
+	return "'" + strings.ReplaceAll(s, "'", "''") + "'"
+}
@@ -0,0 +1,153 @@
+package format
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"time"
+
+	"github.com/nickawilliams/shedoc"
+	"github.com/nickawilliams/shedoc/internal/completionmodel"
+)
+
+func init() {
+	shedoc.RegisterFormatter("completion:fig", &FigCompletionFormatter{})
+}
+
+// FigCompletionFormatter generates a Fig completion spec, as used by Fig and
+// Amazon Q: a TypeScript module whose default export describes the
+// subcommands, options, and arguments. Global options are persistent, so
+// they are offered after subcommands too. Fig specs have no command aliases,
+// so @alias names of the command are not included.
+type FigCompletionFormatter struct {
+	// GeneratedAt, if set, is noted as the time the script was generated
+	// at in the comment at its top.
+	GeneratedAt time.Time
+}
+
+// figSpec is a Fig.Spec or Fig.Subcommand. Name is a string or, for a
+// subcommand with aliases, a list of strings.
+type figSpec struct {
+	Name        any         `json:"name"`
+	Description string      `json:"description,omitempty"`
+	Subcommands []figSpec   `json:"subcommands,omitempty"`
+	Options     []figOption `json:"options,omitempty"`
+	Args        []figArg    `json:"args,omitempty"`
+}
+
+type figOption struct {
+	Name         any     `json:"name"`
+	Description  string  `json:"description,omitempty"`
+	Args         *figArg `json:"args,omitempty"`
+	IsRequired   bool    `json:"isRequired,omitempty"`
+	IsPersistent bool    `json:"isPersistent,omitempty"`
+}
+
+type figArg struct {
+	Name        string        `json:"name"`
+	Description string        `json:"description,omitempty"`
+	IsOptional  bool          `json:"isOptional,omitempty"`
+	IsVariadic  bool          `json:"isVariadic,omitempty"`
+	Default     string        `json:"default,omitempty"`
+	Template    string        `json:"template,omitempty"`
+	Generators  *figGenerator `json:"generators,omitempty"`
+}
+
+// figGenerator runs an @complete command and offers its output lines.
+type figGenerator struct {
+	Script  []string `json:"script"`
+	SplitOn string   `json:"splitOn"`
+}
+
+func (f *FigCompletionFormatter) Format(w io.Writer, doc *shedoc.Document) error {
+	name := doc.Meta.Name
+	if name == "" {
+		return fmt.Errorf("completion generation requires #?/name")
+	}
+
+	model := completionmodel.Build(doc, name)
+
+	spec := figSpec{
+		Name:        name,
+		Description: manBrief(doc),
+		Options:     figOptions(model.Flags, len(model.Subcommands) > 0),
+	}
+	for _, sub := range model.Subcommands {
+		spec.Subcommands = append(spec.Subcommands, figSpec{
+			Name:        figName(sub.Words()),
+			Description: sub.Description,
+			Options:     figOptions(sub.Flags, false),
+			Args:        figArgs(sub.Operands),
+		})
+	}
+	if len(model.Subcommands) == 0 {
+		spec.Args = figArgs(model.Operands)
+	}
+
+	data, err := json.MarshalIndent(spec, "", "  ")
+	if err != nil {
+		return err
+	}
+	fmt.Fprintf(w, "// Fig completion spec for %s\n", name)
+	fmt.Fprintf(w, "// %s\n", generatedBy(doc, f.GeneratedAt))
+	fmt.Fprintf(w, "const completionSpec: Fig.Spec = %s;\n\n", data)
+	fmt.Fprintln(w, "export default completionSpec;")
+	return nil
+}
+
+func figOptions(flags []completionmodel.Flag, persistent bool) []figOption {
+	var opts []figOption
+	for _, fl := range flags {
+		opt := figOption{
+			Name:         figName(fl.Words()),
+			Description:  firstLine(fl.Description),
+			IsRequired:   fl.Required,
+			IsPersistent: persistent,
+		}
+		if fl.Value != nil {
+			opt.Args = &figArg{
+				Name:       fl.Value.Name,
+				IsOptional: !fl.Value.Required,
+				IsVariadic: fl.Value.Variadic,
+				Default:    fl.Value.Default,
+				Generators: figGenerators(fl.Complete),
+			}
+		}
+		opts = append(opts, opt)
+	}
+	return opts
+}
+
+func figArgs(operands []completionmodel.Operand) []figArg {
+	var args []figArg
+	for _, op := range operands {
+		arg := figArg{
+			Name:        op.Name,
+			Description: firstLine(op.Description),
+			IsOptional:  op.Optional,
+			IsVariadic:  op.Variadic,
+			Default:     op.Default,
+			Generators:  figGenerators(op.Complete),
+		}
+		if op.Path && op.Complete == "" {
+			arg.Template = "filepaths"
+		}
+		args = append(args, arg)
+	}
+	return args
+}
+
+func figGenerators(command string) *figGenerator {
+	if command == "" {
+		return nil
+	}
+	return &figGenerator{Script: []string{"bash", "-c", command}, SplitOn: "\n"}
+}
+
+// figName returns a lone name as a string and several as a list.
+func figName(words []string) any {
+	if len(words) == 1 {
+		return words[0]
+	}
+	return words
+}
@@ -0,0 +1,120 @@
+package format
+
+import (
+	"fmt"
+	"io"
+	"strings"
+	"time"
+
+	"github.com/nickawilliams/shedoc"
+	"github.com/nickawilliams/shedoc/internal/completionmodel"
+)
+
+func init() {
+	shedoc.RegisterFormatter("completion:fish", &FishCompletionFormatter{})
+}
+
+// FishCompletionFormatter generates a fish completion script.
+type FishCompletionFormatter struct {
+	// Examples adds to each subcommand's description the first line of
+	// #?/examples that runs it, truncated.
+	Examples bool
+
+	// GeneratedAt, if set, is noted as the time the script was generated
+	// at in the comment at its top.
+	GeneratedAt time.Time
+}
+
+func (f *FishCompletionFormatter) Format(w io.Writer, doc *shedoc.Document) error {
+	name := doc.Meta.Name
+	if name == "" {
+		return fmt.Errorf("completion generation requires #?/name")
+	}
+
+	model := completionmodel.Build(doc, name)
+
+	fmt.Fprintf(w, "# fish completion for %s\n", name)
+	fmt.Fprintf(w, "# %s\n\n", generatedBy(doc, f.GeneratedAt))
+
+	hasSubcommands := len(model.Subcommands) > 0
+
+	// Global flags/options
+	writeFishFlags(w, name, model.Flags, hasSubcommands, nil)
+
+	// Subcommands
+	if hasSubcommands {
+		fmt.Fprintln(w)
+		fmt.Fprintf(w, "# Subcommands\n")
+		for _, sub := range model.Subcommands {
+			desc := sub.Description
+			if f.Examples {
+				desc = withExample(desc, doc.Meta.Examples, name, sub.Words())
+			}
+			for _, word := range sub.Words() {
+				fmt.Fprintf(w, "complete -c %s -n '__fish_use_subcommand' -a %s", name, word)
+				if desc != "" {
+					fmt.Fprintf(w, " -d '%s'", fishEscape(desc))
+				}
+				fmt.Fprintln(w)
+			}
+		}
+
+		// Per-subcommand flags
+		for _, sub := range model.Subcommands {
+			if len(sub.Flags) == 0 {
+				continue
+			}
+			fmt.Fprintln(w)
+			fmt.Fprintf(w, "# %s subcommand\n", sub.Name)
+			writeFishFlags(w, name, sub.Flags, false, sub.Words())
+		}
+	}
+
+	// Aliases share the primary command's completions.
+	if aliases := model.Names[1:]; len(aliases) > 0 {
+		fmt.Fprintln(w)
+		fmt.Fprintf(w, "# Aliases\n")
+		for _, alias := range aliases {
+			fmt.Fprintf(w, "complete -c %s -w %s\n", alias, name)
+		}
+	}
+
+	fmt.Fprintln(w)
+	return nil
+}
+
+func writeFishFlags(w io.Writer, cmd string, flags []completionmodel.Flag, noSubcmd bool, subWords []string) {
+	for _, f := range flags {
+		fmt.Fprintf(w, "complete -c %s", cmd)
+		if len(subWords) > 0 {
+			fmt.Fprintf(w, " -n '__fish_seen_subcommand_from %s'", strings.Join(subWords, " "))
+		} else if noSubcmd {
+			fmt.Fprintf(w, " -n '__fish_use_subcommand'")
+		}
+		if f.Short != "" {
+			fmt.Fprintf(w, " -s %s", f.Short[1:]) // strip leading -
+		}
+		if f.Long != "" {
+			fmt.Fprintf(w, " -l %s", f.Long[2:]) // strip leading --
+		}
+		if f.Value != nil {
+			fmt.Fprintf(w, " -r") // requires argument
+		}
+		if f.Description != "" {
+			fmt.Fprintf(w, " -d '%s'", fishEscape(f.Description))
+		}
+		fmt.Fprintln(w)
+	}
+}
+
+func fishEscape(s string) string {
+	result := make([]byte, 0, len(s))
+	for i := range len(s) {
+		if s[i] == '\'' {
+			result = append(result, '\\', '\'')
+		} else {
+			result = append(result, s[i])
+		}
+	}
+	return string(result)
+}
@@ -0,0 +1,650 @@
+package format
+
+import (
+	"bytes"
+	"encoding/json"
+	"os/exec"
+	"strings"
+	"testing"
+
+	"github.com/nickawilliams/shedoc"
+)
+
+var completionTestDoc = &shedoc.Document{
+	Meta: shedoc.Meta{
+		Name: "deploy",
+	},
+	Blocks: []shedoc.Block{
+		{
+			Visibility: shedoc.VisibilityCommand,
+			Flags: []shedoc.Flag{
+				{Short: "-v", Long: "--verbose", Description: "Enable verbose output"},
+			},
+			Options: []shedoc.Option{
+				{Short: "-c", Long: "--config", Value: shedoc.Value{Name: "path", Required: true}, Description: "Config file"},
+			},
+		},
+		{
+			Visibility:  shedoc.VisibilitySubcommand,
+			Name:        "push",
+			Description: "Deploy the application.",
+			Flags: []shedoc.Flag{
+				{Short: "-f", Long: "--force", Description: "Skip confirmation"},
+			},
+		},
+		{
+			Visibility:  shedoc.VisibilitySubcommand,
+			Name:        "status",
+			Description: "Show deployment status.",
+		},
+	},
+}
+
+func TestBashCompletionFormatter(t *testing.T) {
+	var buf bytes.Buffer
+	f := &BashCompletionFormatter{}
+	if err := f.Format(&buf, completionTestDoc); err != nil {
+		t.Fatal(err)
+	}
+
+	got := buf.String()
+	checks := []string{
+		"_deploy()",
+		"complete -F _deploy deploy",
+		"commands=\"push status\"",
+		"-v",
+		"--verbose",
+		"-c",
+		"--config",
+		"-f",
+		"--force",
+	}
+	for _, check := range checks {
+		if !strings.Contains(got, check) {
+			t.Errorf("bash output missing %q\n\n%s", check, got)
+		}
+	}
+}
+
+func TestZshCompletionFormatter(t *testing.T) {
+	var buf bytes.Buffer
+	f := &ZshCompletionFormatter{}
+	if err := f.Format(&buf, completionTestDoc); err != nil {
+		t.Fatal(err)
+	}
+
+	got := buf.String()
+	checks := []string{
+		"#compdef deploy",
+		"_deploy()",
+		"'push:Deploy the application.'",
+		"'status:Show deployment status.'",
+		"--verbose",
+		"--config",
+		"--force",
+	}
+	for _, check := range checks {
+		if !strings.Contains(got, check) {
+			t.Errorf("zsh output missing %q\n\n%s", check, got)
+		}
+	}
+}
+
+func TestFishCompletionFormatter(t *testing.T) {
+	var buf bytes.Buffer
+	f := &FishCompletionFormatter{}
+	if err := f.Format(&buf, completionTestDoc); err != nil {
+		t.Fatal(err)
+	}
+
+	got := buf.String()
+	checks := []string{
+		"complete -c deploy",
+		"-a push",
+		"-a status",
+		"-s v",
+		"-l verbose",
+		"-s c",
+		"-l config",
+		"-s f",
+		"-l force",
+		"__fish_use_subcommand",
+		"__fish_seen_subcommand_from push",
+	}
+	for _, check := range checks {
+		if !strings.Contains(got, check) {
+			t.Errorf("fish output missing %q\n\n%s", check, got)
+		}
+	}
+}
+
+func TestElvishCompletionFormatter(t *testing.T) {
+	var buf bytes.Buffer
+	f := &ElvishCompletionFormatter{}
+	if err := f.Format(&buf, completionTestDoc); err != nil {
+		t.Fatal(err)
+	}
+
+	got := buf.String()
+	checks := []string{
+		"set edit:completion:arg-completer[deploy] = {|@words|\n",
+		"  var candidates = [['push' 'Deploy the application.'] ['status' 'Show deployment status.'] ['-v' 'Enable verbose output'] ['--verbose' 'Enable verbose output'] ['-c' 'Config file'] ['--config' 'Config file']]\n",
+		"    if (has-value ['push'] $word) {\n      set candidates = [['-f' 'Skip confirmation'] ['--force' 'Skip confirmation']]\n",
+		"    if (has-value ['status'] $word) {\n      set candidates = []\n",
+		"edit:complex-candidate $c[0] &display=$c[0]' - '$c[1]\n",
+	}
+	for _, check := range checks {
+		if !strings.Contains(got, check) {
+			t.Errorf("elvish output missing %q\n\n%s", check, got)
+		}
+	}
+}
+
+func TestFigCompletionFormatter(t *testing.T) {
+	var buf bytes.Buffer
+	f := &FigCompletionFormatter{}
+	if err := f.Format(&buf, completionTestDoc); err != nil {
+		t.Fatal(err)
+	}
+
+	got := buf.String()
+	if !strings.HasPrefix(got, "// Fig completion spec for deploy\n// Generated by shedoc") || !strings.Contains(got, "\nconst completionSpec: Fig.Spec = {\n") {
+		t.Errorf("fig output missing spec declaration\n\n%s", got)
+	}
+	if !strings.HasSuffix(got, "};\n\nexport default completionSpec;\n") {
+		t.Errorf("fig output missing default export\n\n%s", got)
+	}
+
+	_, body, _ := strings.Cut(got, "\nconst completionSpec: Fig.Spec = ")
+	body = body[:strings.LastIndex(body, ";\n\nexport")]
+	var spec figSpec
+	if err := json.Unmarshal([]byte(body), &spec); err != nil {
+		t.Fatalf("fig spec is not JSON: %v\n\n%s", err, body)
+	}
+	if spec.Name != "deploy" {
+		t.Errorf("name = %v, want deploy", spec.Name)
+	}
+	if len(spec.Subcommands) != 2 || spec.Subcommands[0].Name != "push" || spec.Subcommands[1].Name != "status" {
+		t.Errorf("subcommands = %+v, want push and status", spec.Subcommands)
+	}
+	if len(spec.Options) != 2 {
+		t.Fatalf("options = %+v, want 2", spec.Options)
+	}
+	if !spec.Options[0].IsPersistent {
+		t.Errorf("global option %v is not persistent", spec.Options[0].Name)
+	}
+	if spec.Options[1].Args == nil || spec.Options[1].Args.Name != "path" {
+		t.Errorf("--config args = %+v, want path", spec.Options[1].Args)
+	}
+	if spec.Options[1].Description != "Config file" {
+		t.Errorf("--config description = %q, want %q", spec.Options[1].Description, "Config file")
+	}
+}
+
+func TestFigCompletionFormatter_Args(t *testing.T) {
+	doc := &shedoc.Document{
+		Meta: shedoc.Meta{Name: "tool"},
+		Blocks: []shedoc.Block{
+			{
+				Visibility: shedoc.VisibilityCommand,
+				Options: []shedoc.Option{
+					{Long: "--env", Value: shedoc.Value{Name: "name", Required: true}, Required: true, Complete: "tool envs"},
+				},
+				Operands: []shedoc.Operand{
+					{Value: shedoc.Value{Name: "file", Required: true}, Description: "Input file"},
+					{Value: shedoc.Value{Name: "rest", Variadic: true}},
+				},
+			},
+		},
+	}
+
+	var buf bytes.Buffer
+	if err := (&FigCompletionFormatter{}).Format(&buf, doc); err != nil {
+		t.Fatal(err)
+	}
+	got := buf.String()
+	for _, check := range []string{
+		`"isRequired": true`,
+		`"tool envs"`,
+		`"splitOn": "\n"`,
+		`"name": "file",
+      "description": "Input file",
+      "template": "filepaths"`,
+		`"name": "rest",
+      "isOptional": true,
+      "isVariadic": true`,
+	} {
+		if !strings.Contains(got, check) {
+			t.Errorf("fig output missing %q\n\n%s", check, got)
+		}
+	}
+	if strings.Contains(got, "isPersistent") {
+		t.Errorf("fig output marks options persistent without subcommands\n\n%s", got)
+	}
+}
+
+// Test with short-only and long-only flags to cover branch variants.
+var completionTestDocMixedFlags = &shedoc.Document{
+	Meta: shedoc.Meta{
+		Name: "tool",
+	},
+	Blocks: []shedoc.Block{
+		{
+			Visibility: shedoc.VisibilityCommand,
+			Flags: []shedoc.Flag{
+				{Short: "-v", Description: "Verbose"},
+				{Long: "--dry-run", Description: "Dry run"},
+			},
+			Options: []shedoc.Option{
+				{Short: "-o", Value: shedoc.Value{Name: "file", Required: true}, Description: "Output file"},
+				{Long: "--format", Value: shedoc.Value{Name: "fmt", Required: true}, Description: "Format"},
+			},
+		},
+	},
+}
+
+func TestBashCompletionFormatter_MixedFlags(t *testing.T) {
+	var buf bytes.Buffer
+	f := &BashCompletionFormatter{}
+	if err := f.Format(&buf, completionTestDocMixedFlags); err != nil {
+		t.Fatal(err)
+	}
+	got := buf.String()
+	for _, check := range []string{"-v", "--dry-run", "-o", "--format"} {
+		if !strings.Contains(got, check) {
+			t.Errorf("bash output missing %q\n\n%s", check, got)
+		}
+	}
+}
+
+func TestZshCompletionFormatter_MixedFlags(t *testing.T) {
+	var buf bytes.Buffer
+	f := &ZshCompletionFormatter{}
+	if err := f.Format(&buf, completionTestDocMixedFlags); err != nil {
+		t.Fatal(err)
+	}
+	got := buf.String()
+	for _, check := range []string{"-v", "--dry-run", "-o", "--format"} {
+		if !strings.Contains(got, check) {
+			t.Errorf("zsh output missing %q\n\n%s", check, got)
+		}
+	}
+}
+
+func TestFishCompletionFormatter_MixedFlags(t *testing.T) {
+	var buf bytes.Buffer
+	f := &FishCompletionFormatter{}
+	if err := f.Format(&buf, completionTestDocMixedFlags); err != nil {
+		t.Fatal(err)
+	}
+	got := buf.String()
+	for _, check := range []string{"-s v", "-l dry-run", "-s o", "-l format"} {
+		if !strings.Contains(got, check) {
+			t.Errorf("fish output missing %q\n\n%s", check, got)
+		}
+	}
+}
+
+func TestZshCompletionFormatter_NoSubcommands(t *testing.T) {
+	var buf bytes.Buffer
+	f := &ZshCompletionFormatter{}
+	if err := f.Format(&buf, completionTestDocMixedFlags); err != nil {
+		t.Fatal(err)
+	}
+	got := buf.String()
+	if !strings.Contains(got, "_arguments -s") {
+		t.Errorf("zsh output missing _arguments for no-subcommand case\n\n%s", got)
+	}
+}
+
+func TestFishCompletionFormatter_NoSubcommands(t *testing.T) {
+	var buf bytes.Buffer
+	f := &FishCompletionFormatter{}
+	if err := f.Format(&buf, completionTestDocMixedFlags); err != nil {
+		t.Fatal(err)
+	}
+	got := buf.String()
+	// Should not contain subcommand-related conditions.
+	if strings.Contains(got, "__fish_use_subcommand") {
+		t.Errorf("fish output should not contain __fish_use_subcommand for no-subcommand case\n\n%s", got)
+	}
+}
+
+func TestBashCompletionFormatter_NoSubcommands(t *testing.T) {
+	var buf bytes.Buffer
+	f := &BashCompletionFormatter{}
+	if err := f.Format(&buf, completionTestDocMixedFlags); err != nil {
+		t.Fatal(err)
+	}
+	got := buf.String()
+	if !strings.Contains(got, "COMPREPLY") {
+		t.Errorf("bash output missing COMPREPLY\n\n%s", got)
+	}
+}
+
+// Test with subcommands that have mixed flag forms to cover writeZshFlags/writeZshOptions
+// short-only, long-only, and both-short-and-long branches, plus collectFlags branches
+// and fishEscape with apostrophes.
+var completionTestDocSubcmdMixed = &shedoc.Document{
+	Meta: shedoc.Meta{
+		Name: "app",
+	},
+	Blocks: []shedoc.Block{
+		{
+			Visibility: shedoc.VisibilityCommand,
+			Flags: []shedoc.Flag{
+				{Short: "-v", Description: "Verbose"},
+				{Long: "--dry-run", Description: "Dry run"},
+			},
+			Options: []shedoc.Option{
+				{Short: "-o", Value: shedoc.Value{Name: "file", Required: true}, Description: "Output"},
+				{Long: "--format", Value: shedoc.Value{Name: "fmt", Required: true}, Description: "Format"},
+			},
+		},
+		{
+			Visibility:  shedoc.VisibilitySubcommand,
+			Name:        "run",
+			Description: "Run the app.",
+			Flags: []shedoc.Flag{
+				{Short: "-f", Long: "--force", Description: "Force it"},
+				{Short: "-q", Description: "Quiet"},
+				{Long: "--no-cache", Description: "It's uncached"},
+			},
+			Options: []shedoc.Option{
+				{Short: "-t", Long: "--target", Value: shedoc.Value{Name: "host", Required: true}, Description: "Target"},
+				{Short: "-p", Value: shedoc.Value{Name: "port", Required: true}, Description: "Port"},
+				{Long: "--timeout", Value: shedoc.Value{Name: "ms", Required: true}, Description: "Timeout"},
+			},
+		},
+		{
+			Visibility:  shedoc.VisibilitySubcommand,
+			Name:        "stop",
+			Description: "Stop the app.",
+		},
+	},
+}
+
+func TestBashCompletionFormatter_SubcmdMixed(t *testing.T) {
+	var buf bytes.Buffer
+	f := &BashCompletionFormatter{}
+	if err := f.Format(&buf, completionTestDocSubcmdMixed); err != nil {
+		t.Fatal(err)
+	}
+	got := buf.String()
+	// collectFlags should gather all flag variants from the "run" subcommand.
+	for _, check := range []string{"-f", "--force", "-q", "--no-cache", "-t", "--target", "-p", "--timeout"} {
+		if !strings.Contains(got, check) {
+			t.Errorf("bash output missing %q\n\n%s", check, got)
+		}
+	}
+}
+
+func TestZshCompletionFormatter_SubcmdMixed(t *testing.T) {
+	var buf bytes.Buffer
+	f := &ZshCompletionFormatter{}
+	if err := f.Format(&buf, completionTestDocSubcmdMixed); err != nil {
+		t.Fatal(err)
+	}
+	got := buf.String()
+	// writeZshFlags: short+long, short-only, long-only
+	for _, check := range []string{
+		"'-v[Verbose]'", "'--dry-run",      // global short-only and long-only flags
+		"(-f --force)", "'-q[Quiet]'",      // subcommand both and short-only flag
+		"--no-cache",                       // subcommand long-only flag
+		"(-t --target)", "'-p[Port]",       // subcommand both and short-only option
+		"'--timeout",                       // subcommand long-only option
+	} {
+		if !strings.Contains(got, check) {
+			t.Errorf("zsh output missing %q\n\n%s", check, got)
+		}
+	}
+}
+
+func TestFishCompletionFormatter_SubcmdMixed(t *testing.T) {
+	var buf bytes.Buffer
+	f := &FishCompletionFormatter{}
+	if err := f.Format(&buf, completionTestDocSubcmdMixed); err != nil {
+		t.Fatal(err)
+	}
+	got := buf.String()
+	// writeFishFlags/writeFishOptions with subcommand-specific flags
+	for _, check := range []string{
+		"-s f", "-l force",
+		"-s q",
+		"-l no-cache",
+		"-s t", "-l target",
+		"-s p",
+		"-l timeout",
+		"__fish_seen_subcommand_from run",
+		// fishEscape: apostrophe in "It's uncached" → "It\'s uncached"
+		"It\\'s uncached",
+	} {
+		if !strings.Contains(got, check) {
+			t.Errorf("fish output missing %q\n\n%s", check, got)
+		}
+	}
+}
+
+func TestCompletionFormatter_NoName(t *testing.T) {
+	doc := &shedoc.Document{}
+
+	formatters := []struct {
+		name string
+		f    shedoc.Formatter
+	}{
+		{"bash", &BashCompletionFormatter{}},
+		{"zsh", &ZshCompletionFormatter{}},
+		{"fish", &FishCompletionFormatter{}},
+		{"elvish", &ElvishCompletionFormatter{}},
+		{"fig", &FigCompletionFormatter{}},
+	}
+
+	for _, ff := range formatters {
+		t.Run(ff.name, func(t *testing.T) {
+			var buf bytes.Buffer
+			err := ff.f.Format(&buf, doc)
+			if err == nil {
+				t.Error("expected error for missing name")
+			}
+		})
+	}
+}
+
+var completionTestDocAliases = &shedoc.Document{
+	Meta: shedoc.Meta{
+		Name: "deploy",
+	},
+	Blocks: []shedoc.Block{
+		{
+			Visibility: shedoc.VisibilityCommand,
+			Aliases:    []string{"dep", "deployer"},
+			Flags: []shedoc.Flag{
+				{Short: "-v", Long: "--verbose", Description: "Enable verbose output"},
+			},
+		},
+	},
+}
+
+func TestBashCompletionFormatter_Aliases(t *testing.T) {
+	var buf bytes.Buffer
+	f := &BashCompletionFormatter{}
+	if err := f.Format(&buf, completionTestDocAliases); err != nil {
+		t.Fatal(err)
+	}
+	got := buf.String()
+	if !strings.Contains(got, "complete -F _deploy deploy dep deployer\n") {
+		t.Errorf("bash output missing alias registration\n\n%s", got)
+	}
+}
+
+func TestZshCompletionFormatter_Aliases(t *testing.T) {
+	var buf bytes.Buffer
+	f := &ZshCompletionFormatter{}
+	if err := f.Format(&buf, completionTestDocAliases); err != nil {
+		t.Fatal(err)
+	}
+	got := buf.String()
+	if !strings.HasPrefix(got, "#compdef deploy dep deployer\n") {
+		t.Errorf("zsh output missing alias registration\n\n%s", got)
+	}
+}
+
+func TestElvishCompletionFormatter_Aliases(t *testing.T) {
+	var buf bytes.Buffer
+	f := &ElvishCompletionFormatter{}
+	if err := f.Format(&buf, completionTestDocAliases); err != nil {
+		t.Fatal(err)
+	}
+	got := buf.String()
+	for _, check := range []string{
+		"set edit:completion:arg-completer[dep] = $edit:completion:arg-completer[deploy]\n",
+		"set edit:completion:arg-completer[deployer] = $edit:completion:arg-completer[deploy]\n",
+	} {
+		if !strings.Contains(got, check) {
+			t.Errorf("elvish output missing %q\n\n%s", check, got)
+		}
+	}
+}
+
+func TestCompletionFormatter_Examples(t *testing.T) {
+	doc := &shedoc.Document{
+		Meta: shedoc.Meta{
+			Name:     "deploy",
+			Examples: "$ deploy push --force --tag v1.2.3 --message 'hotfix' production\ndeploy push staging\ndeploy status",
+		},
+		Blocks: []shedoc.Block{
+			{Visibility: shedoc.VisibilityCommand},
+			{Visibility: shedoc.VisibilitySubcommand, Name: "push", Description: "Push a release"},
+			{Visibility: shedoc.VisibilitySubcommand, Name: "rollback"},
+		},
+	}
+
+	for _, tt := range []struct {
+		f          shedoc.Formatter
+		want, omit string
+	}{
+		{&FishCompletionFormatter{Examples: true}, "-a push -d 'Push a release (e.g. deploy push --force --tag v1.2.3 --me...)'", "-a rollback -d"},
+		{&ZshCompletionFormatter{Examples: true}, "'push:Push a release (e.g. deploy push --force --tag v1.2.3 --me...)'", "rollback:(e.g."},
+		{&FishCompletionFormatter{}, "-a push -d 'Push a release'\n", "e.g."},
+	} {
+		var buf bytes.Buffer
+		if err := tt.f.Format(&buf, doc); err != nil {
+			t.Fatal(err)
+		}
+		got := buf.String()
+		if !strings.Contains(got, tt.want) {
+			t.Errorf("%T output missing %q\n\n%s", tt.f, tt.want, got)
+		}
+		if strings.Contains(got, tt.omit) {
+			t.Errorf("%T output has %q\n\n%s", tt.f, tt.omit, got)
+		}
+	}
+}
+
+func TestFishCompletionFormatter_Aliases(t *testing.T) {
+	var buf bytes.Buffer
+	f := &FishCompletionFormatter{}
+	if err := f.Format(&buf, completionTestDocAliases); err != nil {
+		t.Fatal(err)
+	}
+	got := buf.String()
+	for _, check := range []string{"complete -c dep -w deploy", "complete -c deployer -w deploy"} {
+		if !strings.Contains(got, check) {
+			t.Errorf("fish output missing %q\n\n%s", check, got)
+		}
+	}
+}
+
+var completionTestDocHidden = &shedoc.Document{
+	Meta: shedoc.Meta{
+		Name: "deploy",
+	},
+	Blocks: []shedoc.Block{
+		{
+			Visibility: shedoc.VisibilityCommand,
+			Flags: []shedoc.Flag{
+				{Short: "-v", Long: "--verbose", Description: "Enable verbose output"},
+				{Long: "--debug", Description: "Dump internal state", Hidden: true},
+			},
+		},
+		{
+			Visibility: shedoc.VisibilitySubcommand,
+			Name:       "push",
+			Aliases:    []string{"p"},
+			Options: []shedoc.Option{
+				{Long: "--trace", Value: shedoc.Value{Name: "file", Required: true}, Hidden: true},
+				{Long: "--tag", Value: shedoc.Value{Name: "version"}},
+			},
+		},
+		{
+			Visibility: shedoc.VisibilitySubcommand,
+			Name:       "selftest",
+			Hidden:     true,
+		},
+	},
+}
+
+func TestCompletionFormatters_Hidden(t *testing.T) {
+	formatters := map[string]shedoc.Formatter{
+		"bash":   &BashCompletionFormatter{},
+		"zsh":    &ZshCompletionFormatter{},
+		"fish":   &FishCompletionFormatter{},
+		"elvish": &ElvishCompletionFormatter{},
+		"fig":    &FigCompletionFormatter{},
+	}
+	for shell, f := range formatters {
+		var buf bytes.Buffer
+		if err := f.Format(&buf, completionTestDocHidden); err != nil {
+			t.Fatal(err)
+		}
+		got := buf.String()
+		for _, hidden := range []string{"debug", "trace", "selftest"} {
+			if strings.Contains(got, hidden) {
+				t.Errorf("%s output contains hidden %q\n\n%s", shell, hidden, got)
+			}
+		}
+		if !strings.Contains(got, "tag") {
+			t.Errorf("%s output missing visible option --tag\n\n%s", shell, got)
+		}
+	}
+}
+
+func TestBashCompletionFormatter_SubcommandAliases(t *testing.T) {
+	var buf bytes.Buffer
+	f := &BashCompletionFormatter{}
+	if err := f.Format(&buf, completionTestDocHidden); err != nil {
+		t.Fatal(err)
+	}
+	got := buf.String()
+	for _, check := range []string{"commands=\"push p\"", "push|p)"} {
+		if !strings.Contains(got, check) {
+			t.Errorf("bash output missing %q\n\n%s", check, got)
+		}
+	}
+}
+
+func TestBashCompletionFormatter_WithoutBashCompletion(t *testing.T) {
+	bash, err := exec.LookPath("bash")
+	if err != nil {
+		t.Skip("bash not installed")
+	}
+
+	var buf bytes.Buffer
+	f := &BashCompletionFormatter{}
+	if err := f.Format(&buf, completionTestDoc); err != nil {
+		t.Fatal(err)
+	}
+
+	// A clean shell has neither _comp_initialize nor _init_completion.
+	script := buf.String() + `
+COMP_WORDS=(deploy push --f); COMP_CWORD=2; _deploy; echo "${COMPREPLY[*]}"
+COMP_WORDS=(deploy p); COMP_CWORD=1; _deploy; echo "${COMPREPLY[*]}"
+`
+	out, err := exec.Command(bash, "--norc", "--noprofile", "-c", script).CombinedOutput()
+	if err != nil {
+		t.Fatalf("bash: %v\n%s", err, out)
+	}
+	if want := "--force\npush\n"; string(out) != want {
+		t.Errorf("COMPREPLY = %q, want %q", out, want)
+	}
+}
@@ -0,0 +1,127 @@
+package format
+
+import (
+	"fmt"
+	"io"
+	"strings"
+	"time"
+
+	"github.com/nickawilliams/shedoc"
+	"github.com/nickawilliams/shedoc/internal/completionmodel"
+)
+
+func init() {
+	shedoc.RegisterFormatter("completion:zsh", &ZshCompletionFormatter{})
+}
+
+// ZshCompletionFormatter generates a zsh completion script.
+type ZshCompletionFormatter struct {
+	// Examples adds to each subcommand's description the first line of
+	// #?/examples that runs it, truncated.
+	Examples bool
+
+	// GeneratedAt, if set, is noted as the time the script was generated
+	// at in the comment at its top.
+	GeneratedAt time.Time
+}
+
+func (f *ZshCompletionFormatter) Format(w io.Writer, doc *shedoc.Document) error {
+	name := doc.Meta.Name
+	if name == "" {
+		return fmt.Errorf("completion generation requires #?/name")
+	}
+
+	model := completionmodel.Build(doc, name)
+
+	fmt.Fprintf(w, "#compdef %s\n", strings.Join(model.Names, " "))
+	fmt.Fprintf(w, "# %s\n\n", generatedBy(doc, f.GeneratedAt))
+	fmt.Fprintf(w, "_%s() {\n", name)
+
+	if len(model.Subcommands) > 0 {
+		// Global arguments
+		fmt.Fprintf(w, "  local -a global_args\n")
+		fmt.Fprintf(w, "  global_args=(\n")
+		for _, arg := range collectZshArgs(model.Flags) {
+			fmt.Fprintf(w, "    %s\n", arg)
+		}
+		fmt.Fprintf(w, "    '1:command:->commands'\n")
+		fmt.Fprintf(w, "    '*::arg:->args'\n")
+		fmt.Fprintf(w, "  )\n\n")
+
+		fmt.Fprintf(w, "  _arguments -s $global_args\n\n")
+		fmt.Fprintf(w, "  case $state in\n")
+		fmt.Fprintf(w, "    commands)\n")
+		fmt.Fprintf(w, "      local -a commands\n")
+		fmt.Fprintf(w, "      commands=(\n")
+		for _, sub := range model.Subcommands {
+			desc := sub.Description
+			if f.Examples {
+				desc = withExample(desc, doc.Meta.Examples, name, sub.Words())
+			}
+			desc = strings.ReplaceAll(desc, "'", "'\\''")
+			for _, word := range sub.Words() {
+				fmt.Fprintf(w, "        '%s:%s'\n", word, desc)
+			}
+		}
+		fmt.Fprintf(w, "      )\n")
+		fmt.Fprintf(w, "      _describe 'command' commands\n")
+		fmt.Fprintf(w, "      ;;\n")
+
+		fmt.Fprintf(w, "    args)\n")
+		fmt.Fprintf(w, "      case $words[1] in\n")
+		for _, sub := range model.Subcommands {
+			subFlags := collectZshArgs(sub.Flags)
+			if len(subFlags) > 0 {
+				fmt.Fprintf(w, "        %s)\n", strings.Join(sub.Words(), "|"))
+				fmt.Fprintf(w, "          _arguments -s \\\n")
+				for i, arg := range subFlags {
+					if i < len(subFlags)-1 {
+						fmt.Fprintf(w, "            %s \\\n", arg)
+					} else {
+						fmt.Fprintf(w, "            %s\n", arg)
+					}
+				}
+				fmt.Fprintf(w, "          ;;\n")
+			}
+		}
+		fmt.Fprintf(w, "      esac\n")
+		fmt.Fprintf(w, "      ;;\n")
+		fmt.Fprintf(w, "  esac\n")
+	} else {
+		// No subcommands — just flags/options
+		fmt.Fprintf(w, "  _arguments -s \\\n")
+		args := collectZshArgs(model.Flags)
+		for i, arg := range args {
+			if i < len(args)-1 {
+				fmt.Fprintf(w, "    %s \\\n", arg)
+			} else {
+				fmt.Fprintf(w, "    %s\n", arg)
+			}
+		}
+	}
+
+	fmt.Fprintf(w, "}\n\n")
+	fmt.Fprintf(w, "_%s\n", name)
+	return nil
+}
+
+func collectZshArgs(flags []completionmodel.Flag) []string {
+	var args []string
+	for _, f := range flags {
+		desc := strings.ReplaceAll(f.Description, "'", "'\\''")
+		var spec string
+		if f.Value != nil {
+			spec = fmt.Sprintf("[%s]:%s:", desc, f.Value.Name)
+		} else {
+			spec = fmt.Sprintf("[%s]", desc)
+		}
+		if f.Short != "" && f.Long != "" {
+			args = append(args, fmt.Sprintf("'(%s %s)'{%s,%s}'%s'", f.Short, f.Long, f.Short, f.Long, spec))
+		} else if f.Long != "" {
+			args = append(args, fmt.Sprintf("'%s%s'", f.Long, spec))
+		} else if f.Short != "" {
+			args = append(args, fmt.Sprintf("'%s%s'", f.Short, spec))
+		}
+	}
+	return args
+}
@@ -0,0 +1,13 @@
+// Package format implements shedoc's output formats. Importing it registers
+// every format the shedoc command offers, for use with shedoc.Render and
+// shedoc.GetFormatter:
+//
+//	doc, err := shedoc.Parse("deploy.sh")
+//	...
+//	err = shedoc.Render(doc, "man", os.Stdout)
+//
+// The formatter types can also be used directly, to set their options:
+//
+//	f := &format.HTMLFormatter{SourceURL: "https://example.com/{path}#L{line}"}
+//	err = f.Format(os.Stdout, doc)
+package format
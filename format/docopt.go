@@ -0,0 +1,180 @@
+package format
+
+import (
+	"fmt"
+	"io"
+	"slices"
+	"strings"
+
+	"github.com/nickawilliams/shedoc"
+	"github.com/nickawilliams/shedoc/internal/completionmodel"
+)
+
+func init() {
+	shedoc.RegisterFormatter("docopt", &DocoptFormatter{})
+}
+
+// DocoptFormatter generates a docopt usage message, for pasting into a
+// script's usage function: a pattern for the command, or one for each
+// subcommand, and an Options section listing every flag and option.
+//
+//	Usage:
+//	  deploy [options] push [--force] <environment>
+//	  deploy [options] status
+//
+//	Options:
+//	  -v, --verbose        Enable verbose output
+//	  -c, --config=<path>  Config file [default: ~/.deploy]
+//
+// Global options are covered by the [options] shortcut and a subcommand's own
+// options are spelled out in its pattern. Hidden blocks, flags, and options
+// are left out.
+type DocoptFormatter struct{}
+
+func (f *DocoptFormatter) Format(w io.Writer, doc *shedoc.Document) error {
+	name := doc.Meta.Name
+	if name == "" {
+		return fmt.Errorf("docopt usage requires #?/name")
+	}
+
+	model := completionmodel.Build(doc, name)
+
+	// Required global options are spelled out; [options] covers the rest.
+	prefix := []string{name}
+	var optional bool
+	for _, fl := range model.Flags {
+		if fl.Required {
+			prefix = append(prefix, docoptOption(fl))
+		} else {
+			optional = true
+		}
+	}
+	if optional {
+		prefix = append(prefix, "[options]")
+	}
+
+	fmt.Fprintln(w, "Usage:")
+	if len(model.Subcommands) == 0 {
+		pattern := append(prefix, docoptOperands(model.Operands)...)
+		fmt.Fprintf(w, "  %s\n", strings.Join(pattern, " "))
+	}
+	for _, sub := range model.Subcommands {
+		pattern := append(slices.Clone(prefix), docoptCommand(sub.Words()))
+		for _, fl := range sub.Flags {
+			pattern = append(pattern, docoptOption(fl))
+		}
+		pattern = append(pattern, docoptOperands(sub.Operands)...)
+		fmt.Fprintf(w, "  %s\n", strings.Join(pattern, " "))
+	}
+
+	// Every flag and option, once, in the order first documented.
+	var labels, descs []string
+	seen := map[string]bool{}
+	addFlags := func(flags []completionmodel.Flag) {
+		for _, fl := range flags {
+			label := docoptOptionLabel(fl)
+			if seen[label] {
+				continue
+			}
+			seen[label] = true
+			desc := firstLine(fl.Description)
+			if fl.Value != nil && fl.Value.Default != "" {
+				desc = strings.TrimSpace(desc + " [default: " + fl.Value.Default + "]")
+			}
+			labels = append(labels, label)
+			descs = append(descs, desc)
+		}
+	}
+	addFlags(model.Flags)
+	for _, sub := range model.Subcommands {
+		addFlags(sub.Flags)
+	}
+	if len(labels) == 0 {
+		return nil
+	}
+
+	width := 0
+	for _, label := range labels {
+		width = max(width, len(label))
+	}
+	fmt.Fprintln(w)
+	fmt.Fprintln(w, "Options:")
+	for i, label := range labels {
+		if descs[i] == "" {
+			fmt.Fprintf(w, "  %s\n", label)
+		} else {
+			fmt.Fprintf(w, "  %-*s  %s\n", width, label, descs[i])
+		}
+	}
+	return nil
+}
+
+// docoptCommand returns the pattern element for a subcommand invoked by any
+// of words: "push", or "(push | p)" with aliases.
+func docoptCommand(words []string) string {
+	if len(words) == 1 {
+		return words[0]
+	}
+	return "(" + strings.Join(words, " | ") + ")"
+}
+
+// docoptOption returns the pattern element for a flag or option, by its long
+// name if it has one: "[--force]", or "--env=<name>" when required.
+func docoptOption(fl completionmodel.Flag) string {
+	var elem string
+	switch {
+	case fl.Long != "" && fl.Value != nil:
+		elem = fl.Long + "=<" + fl.Value.Name + ">"
+	case fl.Long != "":
+		elem = fl.Long
+	case fl.Value != nil:
+		elem = fl.Short + " <" + fl.Value.Name + ">"
+	default:
+		elem = fl.Short
+	}
+	if fl.Value != nil && fl.Value.Variadic {
+		elem += "..."
+	}
+	if fl.Required {
+		return elem
+	}
+	return "[" + elem + "]"
+}
+
+// docoptOptionLabel returns the Options section label for a flag or option:
+// "-v, --verbose" or "-c, --config=<path>".
+func docoptOptionLabel(fl completionmodel.Flag) string {
+	var arg string
+	if fl.Value != nil {
+		arg = "<" + fl.Value.Name + ">"
+	}
+	switch {
+	case fl.Short != "" && fl.Long != "" && arg != "":
+		return fl.Short + ", " + fl.Long + "=" + arg
+	case fl.Short != "" && fl.Long != "":
+		return fl.Short + ", " + fl.Long
+	case fl.Long != "" && arg != "":
+		return fl.Long + "=" + arg
+	case fl.Short != "" && arg != "":
+		return fl.Short + " " + arg
+	default:
+		return fl.Short + fl.Long
+	}
+}
+
+// docoptOperands returns the pattern elements for operands: "<file>",
+// "[<dir>]", "<file>...", or "[<dir>...]".
+func docoptOperands(operands []completionmodel.Operand) []string {
+	var elems []string
+	for _, op := range operands {
+		elem := "<" + op.Name + ">"
+		if op.Variadic {
+			elem += "..."
+		}
+		if op.Optional {
+			elem = "[" + elem + "]"
+		}
+		elems = append(elems, elem)
+	}
+	return elems
+}
@@ -0,0 +1,97 @@
+package format
+
+import (
+	"bytes"
+	"testing"
+
+	"github.com/nickawilliams/shedoc"
+)
+
+func TestDocoptFormatter(t *testing.T) {
+	tests := []struct {
+		name string
+		doc  *shedoc.Document
+		want string
+	}{
+		{
+			name: "subcommands",
+			doc: &shedoc.Document{
+				Meta: shedoc.Meta{Name: "deploy"},
+				Blocks: []shedoc.Block{
+					{
+						Visibility: shedoc.VisibilityCommand,
+						Flags:      []shedoc.Flag{{Short: "-v", Long: "--verbose", Description: "Enable verbose output"}},
+						Options: []shedoc.Option{
+							{Long: "--env", Value: shedoc.Value{Name: "name", Required: true}, Required: true, Description: "Target environment"},
+							{Long: "--debug", Value: shedoc.Value{Name: "file", Required: true}, Hidden: true},
+						},
+					},
+					{
+						Visibility: shedoc.VisibilitySubcommand,
+						Name:       "push",
+						Aliases:    []string{"p"},
+						Flags:      []shedoc.Flag{{Short: "-f", Description: "Skip confirmation"}},
+						Options: []shedoc.Option{
+							{Short: "-t", Long: "--tag", Value: shedoc.Value{Name: "version", Default: "latest"}, Description: "Version tag"},
+						},
+						Operands: []shedoc.Operand{
+							{Value: shedoc.Value{Name: "service", Variadic: true}},
+						},
+					},
+					{Visibility: shedoc.VisibilitySubcommand, Name: "status"},
+					{Visibility: shedoc.VisibilitySubcommand, Name: "selftest", Hidden: true},
+				},
+			},
+			want: `Usage:
+  deploy --env=<name> [options] (push | p) [-f] [--tag=<version>] [<service>...]
+  deploy --env=<name> [options] status
+
+Options:
+  -v, --verbose        Enable verbose output
+  --env=<name>         Target environment
+  -f                   Skip confirmation
+  -t, --tag=<version>  Version tag [default: latest]
+`,
+		},
+		{
+			name: "operands",
+			doc: &shedoc.Document{
+				Meta: shedoc.Meta{Name: "copy"},
+				Blocks: []shedoc.Block{{
+					Visibility: shedoc.VisibilityCommand,
+					Options:    []shedoc.Option{{Short: "-m", Value: shedoc.Value{Name: "mode", Required: true}}},
+					Operands: []shedoc.Operand{
+						{Value: shedoc.Value{Name: "src", Required: true, Variadic: true}},
+						{Value: shedoc.Value{Name: "dest", Required: true}},
+					},
+				}},
+			},
+			want: "Usage:\n  copy [options] <src>... <dest>\n\nOptions:\n  -m <mode>\n",
+		},
+		{
+			name: "bare",
+			doc:  &shedoc.Document{Meta: shedoc.Meta{Name: "tool"}},
+			want: "Usage:\n  tool\n",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var buf bytes.Buffer
+			f := &DocoptFormatter{}
+			if err := f.Format(&buf, tt.doc); err != nil {
+				t.Fatal(err)
+			}
+			if got := buf.String(); got != tt.want {
+				t.Errorf("got:\n%s\nwant:\n%s", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestDocoptFormatter_NoName(t *testing.T) {
+	var buf bytes.Buffer
+	if err := (&DocoptFormatter{}).Format(&buf, &shedoc.Document{}); err == nil {
+		t.Error("expected error for missing name")
+	}
+}
@@ -0,0 +1,38 @@
+package format
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+
+	"github.com/nickawilliams/shedoc"
+)
+
+func TestRenderBuiltinFormats(t *testing.T) {
+	doc := &shedoc.Document{Meta: shedoc.Meta{Name: "tool", Description: "Does things."}}
+
+	tests := []struct {
+		format string
+		want   string
+	}{
+		{"man", ".TH TOOL 1"},
+		{"help", "tool - Does things.\n"},
+		{"whatis", "tool (1) - Does things.\n"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.format, func(t *testing.T) {
+			var buf bytes.Buffer
+			if err := shedoc.Render(doc, tt.format, &buf); err != nil {
+				t.Fatal(err)
+			}
+			if !strings.Contains(buf.String(), tt.want) {
+				t.Errorf("output missing %q:\n%s", tt.want, buf.String())
+			}
+		})
+	}
+
+	var f shedoc.Formatter = &HTMLFormatter{SourceURL: "https://example.com/{path}"}
+	if err := f.Format(&bytes.Buffer{}, doc); err != nil {
+		t.Errorf("HTMLFormatter.Format() error: %v", err)
+	}
+}
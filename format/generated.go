@@ -0,0 +1,24 @@
+package format
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/nickawilliams/shedoc"
+)
+
+// generatedBy returns a note of the script and version a generated file
+// comes from, and, unless at is zero, when it was generated, for a comment
+// at its top:
+//
+//	Generated by shedoc from deploy 2.1.0 at 2026-01-02T15:04:05Z
+func generatedBy(doc *shedoc.Document, at time.Time) string {
+	source := doc.Meta.Name
+	if doc.Meta.Version != "" {
+		source += " " + doc.Meta.Version
+	}
+	if at.IsZero() {
+		return "Generated by shedoc from " + source
+	}
+	return fmt.Sprintf("Generated by shedoc from %s at %s", source, at.UTC().Format(time.RFC3339))
+}
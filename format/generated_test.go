@@ -0,0 +1,45 @@
+package format
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/nickawilliams/shedoc"
+)
+
+func TestGeneratedBy(t *testing.T) {
+	doc := &shedoc.Document{Meta: shedoc.Meta{Name: "deploy", Version: "2.1.0"}}
+	at := time.Unix(1700000000, 0)
+
+	if got, want := generatedBy(doc, at), "Generated by shedoc from deploy 2.1.0 at 2023-11-14T22:13:20Z"; got != want {
+		t.Errorf("generatedBy() = %q, want %q", got, want)
+	}
+	if got, want := generatedBy(doc, time.Time{}), "Generated by shedoc from deploy 2.1.0"; got != want {
+		t.Errorf("generatedBy() = %q, want %q", got, want)
+	}
+
+	tests := []struct {
+		name      string
+		formatter shedoc.Formatter
+		want      string
+	}{
+		{"man", &ManPageFormatter{GeneratedAt: at}, ".\\\" Generated by shedoc from deploy 2.1.0 at 2023-11-14T22:13:20Z\n.TH DEPLOY 1 \"2023-11-14\" \"2.1.0\"\n"},
+		{"bash", &BashCompletionFormatter{GeneratedAt: at}, "# bash completion for deploy\n# Generated by shedoc from deploy 2.1.0 at 2023-11-14T22:13:20Z\n"},
+		{"zsh", &ZshCompletionFormatter{GeneratedAt: at}, "#compdef deploy\n# Generated by shedoc from deploy 2.1.0 at 2023-11-14T22:13:20Z\n"},
+		{"fish", &FishCompletionFormatter{GeneratedAt: at}, "# fish completion for deploy\n# Generated by shedoc from deploy 2.1.0 at 2023-11-14T22:13:20Z\n"},
+		{"elvish", &ElvishCompletionFormatter{GeneratedAt: at}, "# elvish completion for deploy\n# Generated by shedoc from deploy 2.1.0 at 2023-11-14T22:13:20Z\n"},
+		{"fig", &FigCompletionFormatter{GeneratedAt: at}, "// Fig completion spec for deploy\n// Generated by shedoc from deploy 2.1.0 at 2023-11-14T22:13:20Z\n"},
+		{"bash undated", &BashCompletionFormatter{}, "# bash completion for deploy\n# Generated by shedoc from deploy 2.1.0\n"},
+	}
+	for _, tt := range tests {
+		var buf bytes.Buffer
+		if err := tt.formatter.Format(&buf, doc); err != nil {
+			t.Fatalf("%s: %v", tt.name, err)
+		}
+		if !strings.HasPrefix(buf.String(), tt.want) {
+			t.Errorf("%s output does not start with %q\n%s", tt.name, tt.want, buf.String())
+		}
+	}
+}
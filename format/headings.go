@@ -0,0 +1,112 @@
+package format
+
+import (
+	"maps"
+	"slices"
+	"strings"
+
+	"github.com/nickawilliams/shedoc"
+)
+
+// Headings maps section keys to the section headings of the help and man
+// formats, for output in a language other than English. The keys are:
+//
+//	name, synopsis, description, usage, commands, options, global-options,
+//	operands, environment, files, dependencies, exit-status, examples,
+//	author, license, see-also
+//
+// Man pages print headings in upper case. A key that is missing keeps the
+// English heading.
+type Headings map[string]string
+
+// headingLanguages are the built-in headings, by language.
+var headingLanguages = map[string]Headings{
+	"de": {
+		"name":           "Name",
+		"synopsis":       "Übersicht",
+		"description":    "Beschreibung",
+		"usage":          "Aufruf",
+		"commands":       "Befehle",
+		"options":        "Optionen",
+		"global-options": "Globale Optionen",
+		"operands":       "Operanden",
+		"environment":    "Umgebung",
+		"files":          "Dateien",
+		"dependencies":   "Abhängigkeiten",
+		"exit-status":    "Exit-Status",
+		"examples":       "Beispiele",
+		"author":         "Autor",
+		"license":        "Lizenz",
+		"see-also":       "Siehe auch",
+	},
+	"es": {
+		"name":           "Nombre",
+		"synopsis":       "Sinopsis",
+		"description":    "Descripción",
+		"usage":          "Uso",
+		"commands":       "Órdenes",
+		"options":        "Opciones",
+		"global-options": "Opciones globales",
+		"operands":       "Operandos",
+		"environment":    "Entorno",
+		"files":          "Archivos",
+		"dependencies":   "Dependencias",
+		"exit-status":    "Estado de salida",
+		"examples":       "Ejemplos",
+		"author":         "Autor",
+		"license":        "Licencia",
+		"see-also":       "Véase también",
+	},
+	"fr": {
+		"name":           "Nom",
+		"synopsis":       "Synopsis",
+		"description":    "Description",
+		"usage":          "Utilisation",
+		"commands":       "Commandes",
+		"options":        "Options",
+		"global-options": "Options globales",
+		"operands":       "Opérandes",
+		"environment":    "Environnement",
+		"files":          "Fichiers",
+		"dependencies":   "Dépendances",
+		"exit-status":    "Code de retour",
+		"examples":       "Exemples",
+		"author":         "Auteur",
+		"license":        "Licence",
+		"see-also":       "Voir aussi",
+	},
+}
+
+// HeadingsFor returns a copy of the built-in headings for a language such as
+// "de" or "de-DE", or nil if there are none.
+func HeadingsFor(lang string) Headings {
+	base, _, _ := strings.Cut(strings.ReplaceAll(lang, "_", "-"), "-")
+	return maps.Clone(headingLanguages[strings.ToLower(base)])
+}
+
+// HeadingLanguages returns the languages that have built-in headings.
+func HeadingLanguages() []string {
+	return slices.Sorted(maps.Keys(headingLanguages))
+}
+
+// headingsFor returns h or, if it is nil, the built-in headings for the
+// document's #?/lang.
+func headingsFor(h Headings, doc *shedoc.Document) Headings {
+	if h == nil {
+		return HeadingsFor(doc.Meta.Lang)
+	}
+	return h
+}
+
+// get returns the heading for key, or english if h has none.
+func (h Headings) get(key, english string) string {
+	if s := h[key]; s != "" {
+		return s
+	}
+	return english
+}
+
+// man returns the heading for key as a man page section name.
+func (h Headings) man(key, english string) string {
+	return troffEscape(strings.ToUpper(h.get(key, english)))
+}
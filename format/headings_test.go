@@ -0,0 +1,62 @@
+package format
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+
+	"github.com/nickawilliams/shedoc"
+)
+
+func TestHeadingsFor(t *testing.T) {
+	tests := []struct {
+		lang string
+		want string
+	}{
+		{"de", "Optionen"},
+		{"de-DE", "Optionen"},
+		{"fr_FR", "Options"},
+		{"ES", "Opciones"},
+		{"", ""},
+		{"xx", ""},
+	}
+	for _, tt := range tests {
+		if got := HeadingsFor(tt.lang)["options"]; got != tt.want {
+			t.Errorf("HeadingsFor(%q)[options] = %q, want %q", tt.lang, got, tt.want)
+		}
+	}
+}
+
+func TestHeadings(t *testing.T) {
+	doc := &shedoc.Document{
+		Meta: shedoc.Meta{Name: "tool", Lang: "de"},
+		Blocks: []shedoc.Block{{
+			Visibility: shedoc.VisibilityCommand,
+			Flags:      []shedoc.Flag{{Short: "-v"}},
+			Exit:       []shedoc.Exit{{Code: "0"}},
+		}},
+	}
+
+	tests := []struct {
+		name string
+		f    shedoc.Formatter
+		want []string
+	}{
+		{"man from lang", &ManPageFormatter{}, []string{".SH OPTIONEN\n", ".SH EXIT\\-STATUS\n", ".SH \\[u00DC]BERSICHT\n"}},
+		{"help from lang", &HelpTextFormatter{}, []string{"Aufruf:\n", "Optionen:\n"}},
+		{"help override", &HelpTextFormatter{Headings: Headings{"options": "Flags"}}, []string{"Usage:\n", "Flags:\n", "Exit Codes:\n"}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var buf bytes.Buffer
+			if err := tt.f.Format(&buf, doc); err != nil {
+				t.Fatal(err)
+			}
+			for _, want := range tt.want {
+				if !strings.Contains(buf.String(), want) {
+					t.Errorf("output missing %q\n%s", want, buf.String())
+				}
+			}
+		})
+	}
+}
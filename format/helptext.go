@@ -0,0 +1,630 @@
+package format
+
+import (
+	"cmp"
+	"fmt"
+	"io"
+	"slices"
+	"strings"
+	"unicode/utf8"
+
+	"github.com/nickawilliams/shedoc"
+	"github.com/nickawilliams/shedoc/internal/completionmodel"
+)
+
+func init() {
+	shedoc.RegisterFormatter("help", &HelpTextFormatter{})
+}
+
+// HelpTextFormatter outputs a Document as --help style text.
+type HelpTextFormatter struct {
+	// Full lists each subcommand's own flags and options indented beneath
+	// it, rather than the subcommands alone.
+	Full bool
+
+	// Headings replaces the English section headings; by default they are
+	// those built in for the document's #?/lang, if any.
+	Headings Headings
+
+	// Width wraps descriptions to fit within this many columns, continuing
+	// them beneath the description column. Zero leaves them unwrapped.
+	Width int
+
+	// Color styles the output with ANSI escape sequences: section headings
+	// in color, command names in bold, and deprecation notes dimmed.
+	Color bool
+
+	// Subcommand, if set, is the name or alias of the one subcommand to
+	// show help for: its usage, operands, options, environment, and exit
+	// codes, followed by the command's options as global options.
+	Subcommand string
+
+	// focused marks the help of a single subcommand, which lists operands
+	// and the flags and options of global.
+	focused bool
+	global  *shedoc.Block
+}
+
+// ANSI SGR parameters used by HelpTextFormatter when Color is set.
+const (
+	ansiBold    = "1"
+	ansiDim     = "2"
+	ansiHeading = "1;33"
+)
+
+func (f *HelpTextFormatter) Format(w io.Writer, doc *shedoc.Document) error {
+	if f.Subcommand != "" {
+		return f.formatSubcommand(w, doc)
+	}
+	h := headingsFor(f.Headings, doc)
+
+	// Header: name - description
+	if doc.Meta.Name != "" {
+		if doc.Meta.Description != "" {
+			// Use first line of description as the brief.
+			brief := firstLine(doc.Meta.Description)
+			prefix := f.style(ansiBold, doc.Meta.Name) + " - "
+			f.writeRow(w, prefix, displayWidth(prefix), brief)
+		} else {
+			fmt.Fprintln(w, f.style(ansiBold, doc.Meta.Name))
+		}
+		fmt.Fprintln(w)
+	}
+
+	// Usage
+	if synopsis := synopsisLines(doc); len(synopsis) > 0 {
+		f.heading(w, h.get("usage", "Usage"))
+		for _, line := range synopsis {
+			fmt.Fprintf(w, "  %s\n", line)
+		}
+		fmt.Fprintln(w)
+	}
+
+	// Find the command block and subcommand blocks.
+	cmdBlock := doc.CommandBlock()
+	subcommands := doc.Subcommands()
+
+	// Commands section
+	if len(subcommands) > 0 {
+		f.heading(w, h.get("commands", "Commands"))
+		nameWidth := maxSubcommandNameWidth(subcommands)
+		for _, sub := range subcommands {
+			desc := firstLine(sub.Description)
+			if sub.Deprecated != nil {
+				if desc != "" {
+					desc = f.style(ansiDim, "[deprecated]") + " " + desc
+				} else {
+					desc = f.style(ansiDim, "[deprecated] "+sub.Deprecated.Message)
+				}
+			}
+			name := f.style(ansiBold, sub.Name)
+			if desc != "" {
+				pad := strings.Repeat(" ", nameWidth-len(sub.Name))
+				f.writeRow(w, "  "+name+pad+"  ", nameWidth+4, desc)
+			} else {
+				fmt.Fprintf(w, "  %s\n", name)
+			}
+			if f.Full {
+				f.printFlags(w, "      ", sub.Flags)
+				f.printOptions(w, "      ", sub.Options)
+			}
+		}
+		fmt.Fprintln(w)
+	}
+
+	// Operands section, in a subcommand's help
+	if f.focused && cmdBlock != nil && len(cmdBlock.Operands) > 0 {
+		f.heading(w, h.get("operands", "Operands"))
+		for _, op := range cmdBlock.Operands {
+			label := valueNotation(op.Value)
+			if desc := operandDescription(op); desc != "" {
+				f.writeRow(w, fmt.Sprintf("  %-*s", labelWidth, label), 2+labelWidth, desc)
+			} else {
+				fmt.Fprintf(w, "  %s\n", label)
+			}
+		}
+		fmt.Fprintln(w)
+	}
+
+	// Options section (flags and options from the command block), and a
+	// section of its own for each @group
+	if cmdBlock != nil {
+		for _, g := range optionGroups(cmdBlock.Flags, cmdBlock.Options) {
+			title := g.Name
+			if title == "" {
+				title = h.get("options", "Options")
+			}
+			f.heading(w, title)
+			f.printFlags(w, "  ", g.Flags)
+			f.printOptions(w, "  ", g.Options)
+			fmt.Fprintln(w)
+		}
+	}
+
+	// Global Options section, in a subcommand's help
+	if f.global != nil && (len(f.global.Flags) > 0 || len(f.global.Options) > 0) {
+		f.heading(w, h.get("global-options", "Global Options"))
+		f.printFlags(w, "  ", f.global.Flags)
+		f.printOptions(w, "  ", f.global.Options)
+		fmt.Fprintln(w)
+	}
+
+	// Environment section
+	if cmdBlock != nil && len(cmdBlock.Env) > 0 {
+		f.heading(w, h.get("environment", "Environment"))
+		nameWidth := maxEnvNameWidth(cmdBlock.Env)
+		for _, env := range cmdBlock.Env {
+			env.Description = firstLine(env.Description)
+			if desc := envDescription(env); desc != "" {
+				f.writeRow(w, fmt.Sprintf("  %-*s  ", nameWidth, env.Name), nameWidth+4, desc)
+			} else {
+				fmt.Fprintf(w, "  %s\n", env.Name)
+			}
+		}
+		fmt.Fprintln(w)
+	}
+
+	// Dependencies section
+	if reqs := doc.Requirements(); len(reqs) > 0 {
+		f.heading(w, h.get("dependencies", "Dependencies"))
+		nameWidth := maxCommandWidth(reqs)
+		for _, r := range reqs {
+			if desc := firstLine(r.Description); desc != "" {
+				f.writeRow(w, fmt.Sprintf("  %-*s  ", nameWidth, r.Command), nameWidth+4, desc)
+			} else {
+				fmt.Fprintf(w, "  %s\n", r.Command)
+			}
+		}
+		fmt.Fprintln(w)
+	}
+
+	// Exit Codes section
+	if cmdBlock != nil && len(cmdBlock.Exit) > 0 {
+		f.heading(w, h.get("exit-status", "Exit Codes"))
+		codeWidth := maxExitCodeWidth(cmdBlock.Exit)
+		for _, exit := range cmdBlock.Exit {
+			if exit.Description != "" {
+				f.writeRow(w, fmt.Sprintf("  %-*s  ", codeWidth, exit.Code), codeWidth+4, exit.Description)
+			} else {
+				fmt.Fprintf(w, "  %s\n", exit.Code)
+			}
+		}
+		fmt.Fprintln(w)
+	}
+
+	// See Also section
+	if refs := seeAlso(doc); len(refs) > 0 {
+		f.heading(w, h.get("see-also", "See Also"))
+		for _, ref := range refs {
+			fmt.Fprintf(w, "  %s\n", ref)
+		}
+		fmt.Fprintln(w)
+	}
+
+	return nil
+}
+
+// optionGroup is the flags and options of a block listed under one heading.
+type optionGroup struct {
+	Name    string
+	Flags   []shedoc.Flag
+	Options []shedoc.Option
+
+	line int // the smallest Line of its flags and options
+}
+
+// optionGroups divides flags and options by @group: the ungrouped first,
+// then each group in the order it first appears in the source, whether with
+// a flag or an option. Empty groups are left out.
+func optionGroups(flags []shedoc.Flag, options []shedoc.Option) []optionGroup {
+	groups := []optionGroup{{}}
+	index := map[string]int{"": 0}
+	group := func(name string, line int) *optionGroup {
+		i, ok := index[name]
+		if !ok {
+			i = len(groups)
+			index[name] = i
+			groups = append(groups, optionGroup{Name: name, line: line})
+		}
+		g := &groups[i]
+		g.line = min(g.line, line)
+		return g
+	}
+	for _, fl := range flags {
+		g := group(fl.Group, fl.Line)
+		g.Flags = append(g.Flags, fl)
+	}
+	for _, o := range options {
+		g := group(o.Group, o.Line)
+		g.Options = append(g.Options, o)
+	}
+	slices.SortStableFunc(groups[1:], func(a, b optionGroup) int {
+		return cmp.Compare(a.line, b.line)
+	})
+	return slices.DeleteFunc(groups, func(g optionGroup) bool {
+		return len(g.Flags) == 0 && len(g.Options) == 0
+	})
+}
+
+// formatSubcommand writes the help of f.Subcommand alone, rendered from a
+// document whose command block is the subcommand, as for its man page.
+func (f *HelpTextFormatter) formatSubcommand(w io.Writer, doc *shedoc.Document) error {
+	sub := doc.Subcommand(f.Subcommand)
+	if sub == nil {
+		return fmt.Errorf("no subcommand %q in %s", f.Subcommand, doc.Meta.Name)
+	}
+	d := subcommandManDocument(doc, sub)
+	d.Meta.Name = doc.Meta.Name + " " + sub.Name
+
+	focused := *f
+	focused.Subcommand = ""
+	focused.focused = true
+	focused.global = doc.CommandBlock()
+	return focused.Format(w, d)
+}
+
+// seeAlso returns the references of #?/see-also followed by those of the
+// command block's @see tags, without duplicates.
+func seeAlso(doc *shedoc.Document, more ...string) []string {
+	refs := slices.Clone(doc.Meta.SeeAlso)
+	if b := doc.CommandBlock(); b != nil {
+		refs = append(refs, b.See...)
+	}
+	refs = append(refs, more...)
+	var unique []string
+	for _, ref := range refs {
+		if !slices.Contains(unique, ref) {
+			unique = append(unique, ref)
+		}
+	}
+	return unique
+}
+
+// synopsisLines returns #?/synopsis or, when it is absent, one line per
+// command name and alias synthesized from what the command accepts: its
+// flags and options, or "[options]" when there are more than
+// maxSynopsisFlags, then its subcommands or operands.
+//
+//	deploy [-v] [-c <path>] <command> [args...]
+//	tool [options] <file> [dir...]
+//
+// It returns nil for a document without #?/name, and for libraries, which
+// have neither a command block nor subcommands.
+func synopsisLines(doc *shedoc.Document) []string {
+	if len(doc.Meta.Synopsis) > 0 {
+		return doc.Meta.Synopsis
+	}
+	if doc.Meta.Name == "" || doc.CommandBlock() == nil && len(doc.Subcommands()) == 0 {
+		return nil
+	}
+
+	model := completionmodel.Build(doc, doc.Meta.Name)
+	var args []string
+	if len(model.Flags) > maxSynopsisFlags {
+		args = append(args, "[options]")
+	} else {
+		for _, fl := range model.Flags {
+			args = append(args, helpNotation.flag(fl))
+		}
+	}
+	if len(model.Subcommands) > 0 {
+		args = append(args, "<command>", "[args...]")
+	} else if b := doc.CommandBlock(); b != nil && !b.Hidden {
+		for _, op := range b.Operands {
+			args = append(args, helpNotation.operand(op.Value))
+		}
+	}
+
+	var lines []string
+	for _, name := range model.Names {
+		lines = append(lines, strings.Join(append([]string{name}, args...), " "))
+	}
+	return lines
+}
+
+// labelWidth is the width of the flag and option label column.
+const labelWidth = 24
+
+func (f *HelpTextFormatter) printFlags(w io.Writer, indent string, flags []shedoc.Flag) {
+	for _, fl := range flags {
+		label := formatFlagLabel(fl.Short, fl.Long)
+		if fl.Description != "" {
+			f.writeRow(w, fmt.Sprintf("%s%-*s", indent, labelWidth, label), len(indent)+labelWidth, fl.Description)
+		} else {
+			fmt.Fprintf(w, "%s%s\n", indent, label)
+		}
+	}
+}
+
+func (f *HelpTextFormatter) printOptions(w io.Writer, indent string, options []shedoc.Option) {
+	for _, o := range options {
+		label := formatOptionLabel(o.Short, o.Long, o.Value)
+		if desc := optionDescription(o); desc != "" {
+			f.writeRow(w, fmt.Sprintf("%s%-*s", indent, labelWidth, label), len(indent)+labelWidth, desc)
+		} else {
+			fmt.Fprintf(w, "%s%s\n", indent, label)
+		}
+	}
+}
+
+// writeRow writes prefix followed by desc. With a Width, desc is wrapped at
+// word boundaries and its later lines are indented to column col; a word too
+// long for a line is left whole.
+func (f *HelpTextFormatter) writeRow(w io.Writer, prefix string, col int, desc string) {
+	if f.Width <= 0 {
+		fmt.Fprintf(w, "%s%s\n", prefix, desc)
+		return
+	}
+	lines := wrapWords(desc, f.Width-displayWidth(prefix), f.Width-col)
+	fmt.Fprintf(w, "%s%s\n", prefix, lines[0])
+	pad := strings.Repeat(" ", col)
+	for _, line := range lines[1:] {
+		if line == "" {
+			fmt.Fprintln(w)
+		} else {
+			fmt.Fprintf(w, "%s%s\n", pad, line)
+		}
+	}
+}
+
+// heading writes a section heading.
+func (f *HelpTextFormatter) heading(w io.Writer, title string) {
+	fmt.Fprintln(w, f.style(ansiHeading, title+":"))
+}
+
+// style wraps s in the ANSI escape sequence for the SGR parameters sgr, if
+// Color is set.
+func (f *HelpTextFormatter) style(sgr, s string) string {
+	if !f.Color {
+		return s
+	}
+	return "\x1b[" + sgr + "m" + s + "\x1b[0m"
+}
+
+// displayWidth returns the number of columns s takes up on a terminal,
+// not counting ANSI escape sequences.
+func displayWidth(s string) int {
+	n := 0
+	for i := 0; i < len(s); i++ {
+		if s[i] == '\x1b' {
+			for i < len(s) && s[i] != 'm' {
+				i++
+			}
+			continue
+		}
+		if utf8.RuneStart(s[i]) {
+			n++
+		}
+	}
+	return n
+}
+
+// wrapWords breaks s into lines of at most first columns for the first line
+// and rest for the others. Line breaks already in s are kept.
+func wrapWords(s string, first, rest int) []string {
+	var lines []string
+	limit := first
+	for _, para := range strings.Split(s, "\n") {
+		var line string
+		for _, word := range strings.Fields(para) {
+			if line != "" && displayWidth(line)+1+displayWidth(word) > limit {
+				lines = append(lines, line)
+				line, limit = "", rest
+			}
+			if line != "" {
+				line += " "
+			}
+			line += word
+		}
+		lines = append(lines, line)
+		limit = rest
+	}
+	return lines
+}
+
+func formatFlagLabel(short, long string) string {
+	switch {
+	case short != "" && long != "":
+		return short + ", " + long
+	case short != "":
+		return short
+	default:
+		return "    " + long
+	}
+}
+
+func formatOptionLabel(short, long string, val shedoc.Value) string {
+	valStr := formatValue(val)
+	switch {
+	case short != "" && long != "":
+		return short + ", " + long + " " + valStr
+	case short != "":
+		return short + " " + valStr
+	default:
+		return "    " + long + " " + valStr
+	}
+}
+
+// formatValue renders value notation for display. A default taken from an
+// environment variable is left to optionDescription.
+func formatValue(v shedoc.Value) string {
+	name := v.Name
+	if v.Variadic {
+		name += "..."
+	}
+	if v.Required {
+		return "<" + name + ">"
+	}
+	if v.Default != "" && v.DefaultEnv.Name == "" {
+		return "[" + name + "=" + v.Default + "]"
+	}
+	return "[" + name + "]"
+}
+
+// maxSynopsisFlags is the most flags and options a synthesized synopsis
+// lists one by one.
+const maxSynopsisFlags = 4
+
+// synopsisNotation is how a synthesized synopsis or usage line writes
+// flags, options, and operands.
+type synopsisNotation int
+
+const (
+	// helpNotation, for synopses, names a flag by its short name, or else
+	// its long one, and leaves option defaults to the option list:
+	// "[-c <path>]".
+	helpNotation synopsisNotation = iota
+	// usageNotation, for one-line usage messages, names a flag by both its
+	// names and shows defaults: "[-c|--config <path>]", "[-l [n=3]]".
+	usageNotation
+)
+
+// flag returns a flag or option, and its value, in brackets unless the
+// option is required: "[-v]", "[-c <path>]", "--token <t>".
+func (n synopsisNotation) flag(fl completionmodel.Flag) string {
+	var s string
+	switch n {
+	case usageNotation:
+		s = joinFlagNames(fl.Short, fl.Long)
+		if fl.Value != nil {
+			s += " " + formatValue(*fl.Value)
+		}
+	default:
+		s = fl.Short
+		if s == "" {
+			s = fl.Long
+		}
+		if fl.Value != nil {
+			s += " " + shedoc.Value{Name: fl.Value.Name, Required: fl.Value.Required, Variadic: fl.Value.Variadic}.String()
+		}
+	}
+	if fl.Required {
+		return s
+	}
+	return "[" + s + "]"
+}
+
+// operand returns an operand: "<file>", "[dir...]".
+func (n synopsisNotation) operand(v shedoc.Value) string {
+	if n == usageNotation {
+		return formatValue(v)
+	}
+	return valueNotation(v)
+}
+
+// joinFlagNames joins a flag's short and long names: "-v|--verbose".
+func joinFlagNames(short, long string) string {
+	if short != "" && long != "" {
+		return short + "|" + long
+	}
+	return short + long
+}
+
+// optionDescription returns the option's description followed by a note of
+// the unit of its value, "in seconds", and of "required" for a required
+// option, or its default, "default: X", or for an environment variable
+// default, "default: $VAR, or X if unset": "(in seconds, default: 30)".
+func optionDescription(o shedoc.Option) string {
+	var notes []string
+	if o.Value.Unit != "" {
+		notes = append(notes, "in "+o.Value.Unit)
+	}
+	if o.Required {
+		notes = append(notes, "required")
+	} else if ref := o.Value.DefaultEnv; ref.Name != "" {
+		note := "default: $" + ref.Name
+		if ref.Fallback != "" {
+			note += ", or " + ref.Fallback + " if unset"
+		}
+		notes = append(notes, note)
+	} else if o.Value.Default != "" {
+		notes = append(notes, "default: "+o.Value.Default)
+	}
+	return withNotes(o.Description, notes)
+}
+
+// operandDescription returns the operand's description followed by the
+// unit of its value, "(in seconds)".
+func operandDescription(op shedoc.Operand) string {
+	var notes []string
+	if op.Value.Unit != "" {
+		notes = append(notes, "in "+op.Value.Unit)
+	}
+	return withNotes(op.Description, notes)
+}
+
+// envDescription returns the environment variable's description followed by
+// its default, "(default: us-east-1)".
+func envDescription(e shedoc.Env) string {
+	var notes []string
+	if e.Default != "" {
+		notes = append(notes, "default: "+e.Default)
+	}
+	return withNotes(e.Description, notes)
+}
+
+// withNotes returns desc followed by notes in parentheses.
+func withNotes(desc string, notes []string) string {
+	if len(notes) == 0 {
+		return desc
+	}
+	note := "(" + strings.Join(notes, ", ") + ")"
+	if desc == "" {
+		return note
+	}
+	return desc + " " + note
+}
+
+// valueNotation returns v in value notation without its unit, which
+// descriptions note instead.
+func valueNotation(v shedoc.Value) string {
+	v.Unit = ""
+	return v.String()
+}
+
+func firstLine(s string) string {
+	if idx := strings.IndexByte(s, '\n'); idx >= 0 {
+		return s[:idx]
+	}
+	return s
+}
+
+func maxSubcommandNameWidth(subs []shedoc.Block) int {
+	max := 0
+	for _, s := range subs {
+		if len(s.Name) > max {
+			max = len(s.Name)
+		}
+	}
+	return max
+}
+
+func maxEnvNameWidth(envs []shedoc.Env) int {
+	max := 0
+	for _, e := range envs {
+		if len(e.Name) > max {
+			max = len(e.Name)
+		}
+	}
+	return max
+}
+
+func maxCommandWidth(reqs []shedoc.Requires) int {
+	max := 0
+	for _, r := range reqs {
+		if len(r.Command) > max {
+			max = len(r.Command)
+		}
+	}
+	return max
+}
+
+func maxExitCodeWidth(exits []shedoc.Exit) int {
+	max := 0
+	for _, e := range exits {
+		if len(e.Code) > max {
+			max = len(e.Code)
+		}
+	}
+	return max
+}
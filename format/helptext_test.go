@@ -0,0 +1,694 @@
+package format
+
+import (
+	"bytes"
+	"slices"
+	"strings"
+	"testing"
+
+	"github.com/nickawilliams/shedoc"
+	"github.com/nickawilliams/shedoc/internal/completionmodel"
+)
+
+func TestHelpTextFormatter_Comprehensive(t *testing.T) {
+	doc := &shedoc.Document{
+		Meta: shedoc.Meta{
+			Name:        "deploy",
+			Version:     "2.1.0",
+			Synopsis:    []string{"deploy [-v] [-c config] <command> [args...]"},
+			Description: "A deployment tool for managing application releases.\nSupports multiple environments.",
+		},
+		Blocks: []shedoc.Block{
+			{
+				Visibility:  shedoc.VisibilityCommand,
+				Description: "Manages application deployments.",
+				Flags: []shedoc.Flag{
+					{Short: "-v", Long: "--verbose", Description: "Enable verbose output"},
+				},
+				Options: []shedoc.Option{
+					{Short: "-c", Long: "--config", Value: shedoc.Value{Name: "path", Required: true}, Description: "Path to configuration file"},
+				},
+				Env: []shedoc.Env{
+					{Name: "DEPLOY_TOKEN", Description: "Authentication token"},
+				},
+				Exit: []shedoc.Exit{
+					{Code: "0", Description: "Success"},
+					{Code: "1", Description: "General error"},
+				},
+			},
+			{
+				Visibility:  shedoc.VisibilitySubcommand,
+				Name:        "push",
+				Description: "Deploys the application.",
+			},
+			{
+				Visibility:  shedoc.VisibilitySubcommand,
+				Name:        "status",
+				Description: "Shows deployment status.",
+			},
+			{
+				Visibility: shedoc.VisibilitySubcommand,
+				Name:       "migrate",
+				Deprecated: &shedoc.Deprecated{Message: "Use 'deploy push --migrate' instead."},
+			},
+		},
+	}
+
+	var buf bytes.Buffer
+	f := &HelpTextFormatter{}
+	if err := f.Format(&buf, doc); err != nil {
+		t.Fatal(err)
+	}
+
+	got := buf.String()
+
+	// Verify key sections are present.
+	checks := []string{
+		"deploy - A deployment tool for managing application releases.",
+		"Usage:",
+		"deploy [-v] [-c config] <command> [args...]",
+		"Commands:",
+		"push",
+		"status",
+		"migrate",
+		"[deprecated]",
+		"Options:",
+		"-v, --verbose",
+		"-c, --config <path>",
+		"Environment:",
+		"DEPLOY_TOKEN",
+		"Exit Codes:",
+		"0",
+		"1",
+	}
+
+	for _, check := range checks {
+		if !strings.Contains(got, check) {
+			t.Errorf("output missing %q\n\nfull output:\n%s", check, got)
+		}
+	}
+}
+
+func TestHelpTextFormatter_Minimal(t *testing.T) {
+	doc := &shedoc.Document{
+		Meta: shedoc.Meta{
+			Name: "greet",
+		},
+	}
+
+	var buf bytes.Buffer
+	f := &HelpTextFormatter{}
+	if err := f.Format(&buf, doc); err != nil {
+		t.Fatal(err)
+	}
+
+	got := buf.String()
+	if !strings.Contains(got, "greet") {
+		t.Errorf("output missing name\n%s", got)
+	}
+}
+
+func TestHelpTextFormatter_MultiLineSynopsis(t *testing.T) {
+	doc := &shedoc.Document{
+		Meta: shedoc.Meta{
+			Name:     "tool",
+			Synopsis: []string{"tool [-v] <file>", "tool --version"},
+		},
+	}
+
+	var buf bytes.Buffer
+	f := &HelpTextFormatter{}
+	if err := f.Format(&buf, doc); err != nil {
+		t.Fatal(err)
+	}
+
+	want := "Usage:\n  tool [-v] <file>\n  tool --version\n\n"
+	if got := buf.String(); !strings.Contains(got, want) {
+		t.Errorf("output missing %q\n%s", want, got)
+	}
+}
+
+func TestHelpTextFormatter_LongOnlyFlag(t *testing.T) {
+	doc := &shedoc.Document{
+		Blocks: []shedoc.Block{
+			{
+				Visibility: shedoc.VisibilityCommand,
+				Flags: []shedoc.Flag{
+					{Long: "--dry-run", Description: "Preview changes"},
+				},
+			},
+		},
+	}
+
+	var buf bytes.Buffer
+	f := &HelpTextFormatter{}
+	if err := f.Format(&buf, doc); err != nil {
+		t.Fatal(err)
+	}
+
+	got := buf.String()
+	if !strings.Contains(got, "    --dry-run") {
+		t.Errorf("long-only flag not indented correctly\n%s", got)
+	}
+}
+
+func TestHelpTextFormatter_ShortOnlyFlagAndOption(t *testing.T) {
+	doc := &shedoc.Document{
+		Blocks: []shedoc.Block{
+			{
+				Visibility: shedoc.VisibilityCommand,
+				Flags: []shedoc.Flag{
+					{Short: "-v", Description: "Verbose"},
+				},
+				Options: []shedoc.Option{
+					{Short: "-o", Value: shedoc.Value{Name: "file", Required: true}, Description: "Output"},
+					{Long: "--format", Value: shedoc.Value{Name: "fmt", Required: true}, Description: "Format"},
+				},
+			},
+		},
+	}
+
+	var buf bytes.Buffer
+	f := &HelpTextFormatter{}
+	if err := f.Format(&buf, doc); err != nil {
+		t.Fatal(err)
+	}
+
+	got := buf.String()
+	if !strings.Contains(got, "-v") {
+		t.Errorf("missing short-only flag\n%s", got)
+	}
+	if !strings.Contains(got, "-o <file>") {
+		t.Errorf("missing short-only option\n%s", got)
+	}
+	if !strings.Contains(got, "    --format <fmt>") {
+		t.Errorf("missing long-only option\n%s", got)
+	}
+}
+
+func TestHelpTextFormatter_NoDescription(t *testing.T) {
+	doc := &shedoc.Document{
+		Blocks: []shedoc.Block{
+			{
+				Visibility: shedoc.VisibilityCommand,
+				Flags: []shedoc.Flag{
+					{Short: "-v"},
+				},
+				Options: []shedoc.Option{
+					{Long: "--format", Value: shedoc.Value{Name: "fmt", Required: true}},
+				},
+				Env: []shedoc.Env{
+					{Name: "MY_VAR"},
+				},
+				Exit: []shedoc.Exit{
+					{Code: "0"},
+				},
+			},
+			{
+				Visibility: shedoc.VisibilitySubcommand,
+				Name:       "sub",
+			},
+		},
+	}
+
+	var buf bytes.Buffer
+	f := &HelpTextFormatter{}
+	if err := f.Format(&buf, doc); err != nil {
+		t.Fatal(err)
+	}
+
+	got := buf.String()
+	if !strings.Contains(got, "-v\n") {
+		t.Errorf("flag without description not rendered\n%s", got)
+	}
+	if !strings.Contains(got, "sub\n") {
+		t.Errorf("subcommand without description not rendered\n%s", got)
+	}
+}
+
+func TestHelpTextFormatter_Full(t *testing.T) {
+	doc := &shedoc.Document{
+		Meta: shedoc.Meta{Name: "tool"},
+		Blocks: []shedoc.Block{
+			{Visibility: shedoc.VisibilityCommand},
+			{
+				Visibility:  shedoc.VisibilitySubcommand,
+				Name:        "push",
+				Description: "Push a release",
+				Flags:       []shedoc.Flag{{Short: "-f", Long: "--force", Description: "Overwrite"}},
+				Options:     []shedoc.Option{{Long: "--tag", Value: shedoc.Value{Name: "t", Required: true}}},
+			},
+			{Visibility: shedoc.VisibilitySubcommand, Name: "status"},
+		},
+	}
+
+	want := "Commands:\n" +
+		"  push    Push a release\n" +
+		"      -f, --force             Overwrite\n" +
+		"          --tag <t>\n" +
+		"  status\n\n"
+	var buf bytes.Buffer
+	if err := (&HelpTextFormatter{Full: true}).Format(&buf, doc); err != nil {
+		t.Fatal(err)
+	}
+	if got := buf.String(); !strings.Contains(got, want) {
+		t.Errorf("output missing %q\n%s", want, got)
+	}
+
+	buf.Reset()
+	if err := (&HelpTextFormatter{}).Format(&buf, doc); err != nil {
+		t.Fatal(err)
+	}
+	if got := buf.String(); strings.Contains(got, "--force") {
+		t.Errorf("subcommand flags listed without Full\n%s", got)
+	}
+}
+
+func TestHelpTextFormatter_Subcommand(t *testing.T) {
+	doc := &shedoc.Document{
+		Meta: shedoc.Meta{Name: "tool"},
+		Blocks: []shedoc.Block{
+			{
+				Visibility: shedoc.VisibilityCommand,
+				Flags:      []shedoc.Flag{{Short: "-v", Long: "--verbose", Description: "Verbose output"}},
+			},
+			{
+				Visibility:  shedoc.VisibilitySubcommand,
+				Name:        "push",
+				Aliases:     []string{"p"},
+				Description: "Push a release",
+				Flags:       []shedoc.Flag{{Short: "-f", Long: "--force", Description: "Overwrite"}},
+				Operands:    []shedoc.Operand{{Value: shedoc.Value{Name: "env", Required: true}, Description: "Target environment"}},
+			},
+			{Visibility: shedoc.VisibilitySubcommand, Name: "status"},
+		},
+	}
+
+	want := "tool push - Push a release\n\n" +
+		"Usage:\n" +
+		"  tool push [-f] <env>\n" +
+		"  tool p [-f] <env>\n\n" +
+		"Operands:\n" +
+		"  <env>                   Target environment\n\n" +
+		"Options:\n" +
+		"  -f, --force             Overwrite\n\n" +
+		"Global Options:\n" +
+		"  -v, --verbose           Verbose output\n\n"
+	for _, name := range []string{"push", "p"} {
+		var buf bytes.Buffer
+		if err := (&HelpTextFormatter{Subcommand: name}).Format(&buf, doc); err != nil {
+			t.Fatal(err)
+		}
+		if got := buf.String(); got != want {
+			t.Errorf("Subcommand %q:\ngot:\n%s\nwant:\n%s", name, got, want)
+		}
+	}
+
+	var buf bytes.Buffer
+	if err := (&HelpTextFormatter{Subcommand: "pull"}).Format(&buf, doc); err == nil {
+		t.Error("expected an error for an unknown subcommand")
+	}
+}
+
+func TestHelpTextFormatter_SeeAlso(t *testing.T) {
+	doc := &shedoc.Document{
+		Meta: shedoc.Meta{Name: "tool", SeeAlso: []string{"git(1)"}},
+		Blocks: []shedoc.Block{{
+			Visibility: shedoc.VisibilityCommand,
+			See:        []string{"https://example.com/tool"},
+		}},
+	}
+
+	var buf bytes.Buffer
+	f := &HelpTextFormatter{}
+	if err := f.Format(&buf, doc); err != nil {
+		t.Fatal(err)
+	}
+
+	want := "See Also:\n  git(1)\n  https://example.com/tool\n\n"
+	if got := buf.String(); !strings.HasSuffix(got, want) {
+		t.Errorf("output does not end with %q\n%s", want, got)
+	}
+}
+
+func TestHelpTextFormatter_Width(t *testing.T) {
+	doc := &shedoc.Document{
+		Meta: shedoc.Meta{Name: "tool"},
+		Blocks: []shedoc.Block{{
+			Visibility: shedoc.VisibilityCommand,
+			Flags: []shedoc.Flag{
+				{Short: "-f", Long: "--force", Description: "Overwrite files that already exist without asking first"},
+				{Long: "--a-very-long-flag-name", Description: "Keep going"},
+			},
+			Exit: []shedoc.Exit{{Code: "1", Description: "The release could not be pushed to the remote server"}},
+		}},
+	}
+
+	var buf bytes.Buffer
+	if err := (&HelpTextFormatter{Width: 50}).Format(&buf, doc); err != nil {
+		t.Fatal(err)
+	}
+	got := buf.String()
+	for _, want := range []string{
+		"  -f, --force             Overwrite files that\n" +
+			"                          already exist without\n" +
+			"                          asking first\n",
+		"      --a-very-long-flag-nameKeep going\n",
+		"  1  The release could not be pushed to the remote\n" +
+			"     server\n",
+	} {
+		if !strings.Contains(got, want) {
+			t.Errorf("output missing %q\n%s", want, got)
+		}
+	}
+	for _, line := range strings.Split(got, "\n") {
+		if len(line) > 50 && !strings.Contains(line, "--a-very-long-flag-name") {
+			t.Errorf("line longer than 50 columns: %q", line)
+		}
+	}
+}
+
+func TestHelpTextFormatter_Groups(t *testing.T) {
+	doc := &shedoc.Document{
+		Meta: shedoc.Meta{Name: "tool"},
+		Blocks: []shedoc.Block{{
+			Visibility: shedoc.VisibilityCommand,
+			Flags: []shedoc.Flag{
+				{Short: "-v", Long: "--verbose", Description: "Verbose"},
+				{Long: "--tls", Description: "Use TLS", Group: "Connection"},
+			},
+			Options: []shedoc.Option{
+				{Long: "--host", Value: shedoc.Value{Name: "host", Required: true}, Description: "Host", Group: "Connection"},
+			},
+		}},
+	}
+
+	var buf bytes.Buffer
+	if err := (&HelpTextFormatter{}).Format(&buf, doc); err != nil {
+		t.Fatal(err)
+	}
+	got := buf.String()
+	want := "Options:\n" +
+		"  -v, --verbose           Verbose\n" +
+		"\n" +
+		"Connection:\n" +
+		"      --tls               Use TLS\n" +
+		"      --host <host>       Host\n"
+	if !strings.Contains(got, want) {
+		t.Errorf("output missing %q\n%s", want, got)
+	}
+}
+
+func TestOptionGroups_SourceOrder(t *testing.T) {
+	flags := []shedoc.Flag{
+		{Long: "--verbose", Line: 2},
+		{Long: "--tls", Group: "Connection", Line: 6},
+	}
+	options := []shedoc.Option{
+		{Long: "--format", Group: "Output", Line: 4},
+		{Long: "--host", Group: "Connection", Line: 7},
+	}
+
+	var names []string
+	for _, g := range optionGroups(flags, options) {
+		names = append(names, g.Name)
+	}
+	if want := []string{"", "Output", "Connection"}; !slices.Equal(names, want) {
+		t.Errorf("groups = %q, want %q", names, want)
+	}
+}
+
+func TestHelpTextFormatter_EnvDefault(t *testing.T) {
+	doc := &shedoc.Document{
+		Meta: shedoc.Meta{Name: "tool"},
+		Blocks: []shedoc.Block{{
+			Visibility: shedoc.VisibilityCommand,
+			Env: []shedoc.Env{
+				{Name: "REGION", Default: "us-east-1", Description: "Region to use"},
+				{Name: "HOST", Default: "localhost"},
+			},
+		}},
+	}
+
+	var buf bytes.Buffer
+	if err := (&HelpTextFormatter{}).Format(&buf, doc); err != nil {
+		t.Fatal(err)
+	}
+	got := buf.String()
+	want := "Environment:\n" +
+		"  REGION  Region to use (default: us-east-1)\n" +
+		"  HOST    (default: localhost)\n"
+	if !strings.Contains(got, want) {
+		t.Errorf("output missing %q\n%s", want, got)
+	}
+}
+
+func TestHelpTextFormatter_Color(t *testing.T) {
+	doc := &shedoc.Document{
+		Meta: shedoc.Meta{Name: "tool"},
+		Blocks: []shedoc.Block{
+			{Visibility: shedoc.VisibilityCommand},
+			{Visibility: shedoc.VisibilitySubcommand, Name: "push", Description: "Push a release"},
+			{
+				Visibility:  shedoc.VisibilitySubcommand,
+				Name:        "up",
+				Description: "Push",
+				Deprecated:  &shedoc.Deprecated{},
+			},
+		},
+	}
+
+	var buf bytes.Buffer
+	if err := (&HelpTextFormatter{Color: true}).Format(&buf, doc); err != nil {
+		t.Fatal(err)
+	}
+	got := buf.String()
+	for _, want := range []string{
+		"\x1b[1mtool\x1b[0m\n",
+		"\x1b[1;33mCommands:\x1b[0m\n",
+		"  \x1b[1mpush\x1b[0m  Push a release\n",
+		"  \x1b[1mup\x1b[0m    \x1b[2m[deprecated]\x1b[0m Push\n",
+	} {
+		if !strings.Contains(got, want) {
+			t.Errorf("output missing %q\n%s", want, got)
+		}
+	}
+
+	buf.Reset()
+	if err := (&HelpTextFormatter{}).Format(&buf, doc); err != nil {
+		t.Fatal(err)
+	}
+	if strings.Contains(buf.String(), "\x1b") {
+		t.Errorf("escape sequences without Color:\n%q", buf.String())
+	}
+}
+
+func TestDisplayWidth(t *testing.T) {
+	for s, want := range map[string]int{
+		"":                            0,
+		"push":                        4,
+		"\x1b[1mpush\x1b[0m":          4,
+		"\x1b[1;33mÜbersicht:\x1b[0m": 10,
+	} {
+		if got := displayWidth(s); got != want {
+			t.Errorf("displayWidth(%q) = %d, want %d", s, got, want)
+		}
+	}
+}
+
+func TestWrapWords(t *testing.T) {
+	tests := []struct {
+		s           string
+		first, rest int
+		want        []string
+	}{
+		{"one two three", 7, 7, []string{"one two", "three"}},
+		{"one two three", 3, 20, []string{"one", "two three"}},
+		{"unbreakable word", 4, 4, []string{"unbreakable", "word"}},
+		{"kept\nbreaks", 40, 40, []string{"kept", "breaks"}},
+		{"", 10, 10, []string{""}},
+	}
+
+	for _, tt := range tests {
+		got := wrapWords(tt.s, tt.first, tt.rest)
+		if !slices.Equal(got, tt.want) {
+			t.Errorf("wrapWords(%q, %d, %d) = %q, want %q", tt.s, tt.first, tt.rest, got, tt.want)
+		}
+	}
+}
+
+func TestFormatValue(t *testing.T) {
+	tests := []struct {
+		name string
+		val  shedoc.Value
+		want string
+	}{
+		{"required", shedoc.Value{Name: "path", Required: true}, "<path>"},
+		{"optional", shedoc.Value{Name: "name", Required: false}, "[name]"},
+		{"optional with default", shedoc.Value{Name: "fmt", Required: false, Default: "text"}, "[fmt=text]"},
+		{"required variadic", shedoc.Value{Name: "files", Required: true, Variadic: true}, "<files...>"},
+		{"optional variadic", shedoc.Value{Name: "args", Required: false, Variadic: true}, "[args...]"},
+		{"env default", shedoc.Value{Name: "region", Default: "$AWS_REGION", DefaultEnv: shedoc.EnvRef{Name: "AWS_REGION"}}, "[region]"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := formatValue(tt.val)
+			if got != tt.want {
+				t.Errorf("formatValue(%+v) = %q, want %q", tt.val, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestSynopsisNotation(t *testing.T) {
+	config := &shedoc.Value{Name: "path", Required: true, Default: "."}
+	tests := []struct {
+		name        string
+		fl          completionmodel.Flag
+		help, usage string
+	}{
+		{"flag", completionmodel.Flag{Short: "-v", Long: "--verbose"}, "[-v]", "[-v|--verbose]"},
+		{"long only", completionmodel.Flag{Long: "--dry-run"}, "[--dry-run]", "[--dry-run]"},
+		{"option", completionmodel.Flag{Short: "-c", Long: "--config", Value: config}, "[-c <path>]", "[-c|--config <path>]"},
+		{"required option", completionmodel.Flag{Long: "--token", Value: &shedoc.Value{Name: "t", Required: true}, Required: true}, "--token <t>", "--token <t>"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := helpNotation.flag(tt.fl); got != tt.help {
+				t.Errorf("helpNotation.flag() = %q, want %q", got, tt.help)
+			}
+			if got := usageNotation.flag(tt.fl); got != tt.usage {
+				t.Errorf("usageNotation.flag() = %q, want %q", got, tt.usage)
+			}
+		})
+	}
+
+	operand := shedoc.Value{Name: "dir", Unit: "path", Required: true, Default: "."}
+	if got, want := helpNotation.operand(operand), "<dir=.>"; got != want {
+		t.Errorf("helpNotation.operand() = %q, want %q", got, want)
+	}
+	if got, want := usageNotation.operand(operand), "<dir>"; got != want {
+		t.Errorf("usageNotation.operand() = %q, want %q", got, want)
+	}
+}
+
+func TestOptionDescription(t *testing.T) {
+	tests := []struct {
+		name string
+		opt  shedoc.Option
+		want string
+	}{
+		{"no default", shedoc.Option{Description: "Region", Value: shedoc.Value{Name: "region"}}, "Region"},
+		{"default", shedoc.Option{Description: "Region", Value: shedoc.Value{Name: "region", Default: "eu"}}, "Region (default: eu)"},
+		{"required", shedoc.Option{Description: "Token", Required: true, Value: shedoc.Value{Name: "t", Required: true}}, "Token (required)"},
+		{"default only", shedoc.Option{Value: shedoc.Value{Name: "region", Default: "eu"}}, "(default: eu)"},
+		{"env", shedoc.Option{Description: "Region", Value: shedoc.Value{Name: "region", DefaultEnv: shedoc.EnvRef{Name: "AWS_REGION"}}}, "Region (default: $AWS_REGION)"},
+		{"unit", shedoc.Option{Description: "Timeout", Value: shedoc.Value{Name: "t", Required: true, Unit: "seconds"}}, "Timeout (in seconds)"},
+		{"unit and default", shedoc.Option{Description: "Timeout", Value: shedoc.Value{Name: "t", Default: "30", Unit: "seconds"}}, "Timeout (in seconds, default: 30)"},
+		{"env fallback", shedoc.Option{Value: shedoc.Value{Name: "region", DefaultEnv: shedoc.EnvRef{Name: "AWS_REGION", Fallback: "us-east-1"}}}, "(default: $AWS_REGION, or us-east-1 if unset)"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := optionDescription(tt.opt); got != tt.want {
+				t.Errorf("optionDescription() = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestSynopsisLines(t *testing.T) {
+	tests := []struct {
+		name string
+		doc  *shedoc.Document
+		want []string
+	}{
+		{
+			name: "documented",
+			doc: &shedoc.Document{
+				Meta:   shedoc.Meta{Name: "tool", Synopsis: []string{"tool <file>"}},
+				Blocks: []shedoc.Block{{Visibility: shedoc.VisibilityCommand}},
+			},
+			want: []string{"tool <file>"},
+		},
+		{
+			name: "subcommands and aliases",
+			doc: &shedoc.Document{
+				Meta: shedoc.Meta{Name: "deploy"},
+				Blocks: []shedoc.Block{
+					{
+						Visibility: shedoc.VisibilityCommand,
+						Aliases:    []string{"dep"},
+						Flags:      []shedoc.Flag{{Short: "-v"}},
+						Operands:   []shedoc.Operand{{Value: shedoc.Value{Name: "ignored", Required: true}}},
+					},
+					{Visibility: shedoc.VisibilitySubcommand, Name: "push"},
+				},
+			},
+			want: []string{"deploy [-v] <command> [args...]", "dep [-v] <command> [args...]"},
+		},
+		{
+			name: "operands",
+			doc: &shedoc.Document{
+				Meta: shedoc.Meta{Name: "copy"},
+				Blocks: []shedoc.Block{{
+					Visibility: shedoc.VisibilityCommand,
+					Flags:      []shedoc.Flag{{Long: "--debug", Hidden: true}},
+					Operands: []shedoc.Operand{
+						{Value: shedoc.Value{Name: "src", Required: true}},
+						{Value: shedoc.Value{Name: "dest", Default: "out", Variadic: true}},
+					},
+				}},
+			},
+			want: []string{"copy <src> [dest=out...]"},
+		},
+		{
+			name: "flags and options",
+			doc: &shedoc.Document{
+				Meta: shedoc.Meta{Name: "deploy"},
+				Blocks: []shedoc.Block{{
+					Visibility: shedoc.VisibilityCommand,
+					Flags:      []shedoc.Flag{{Short: "-v", Long: "--verbose"}, {Long: "--dry-run"}},
+					Options: []shedoc.Option{
+						{Short: "-c", Value: shedoc.Value{Name: "path", Required: true}},
+						{Long: "--token", Required: true, Value: shedoc.Value{Name: "t", Required: true, Unit: "chars"}},
+					},
+				}},
+			},
+			want: []string{"deploy [-v] [--dry-run] [-c <path>] --token <t>"},
+		},
+		{
+			name: "too many options",
+			doc: &shedoc.Document{
+				Meta: shedoc.Meta{Name: "deploy"},
+				Blocks: []shedoc.Block{{
+					Visibility: shedoc.VisibilityCommand,
+					Flags:      []shedoc.Flag{{Short: "-a"}, {Short: "-b"}, {Short: "-c"}, {Short: "-d"}, {Short: "-e"}},
+				}},
+			},
+			want: []string{"deploy [options]"},
+		},
+		{
+			name: "library",
+			doc: &shedoc.Document{
+				Meta:   shedoc.Meta{Name: "lib"},
+				Blocks: []shedoc.Block{{Visibility: shedoc.VisibilityPublic, FunctionName: "f"}},
+			},
+		},
+		{
+			name: "unnamed",
+			doc:  &shedoc.Document{Blocks: []shedoc.Block{{Visibility: shedoc.VisibilityCommand}}},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := synopsisLines(tt.doc)
+			if strings.Join(got, "\n") != strings.Join(tt.want, "\n") {
+				t.Errorf("synopsisLines() = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,350 @@
+package format
+
+import (
+	"fmt"
+	"html"
+	htmltemplate "html/template"
+	"io"
+	"strings"
+
+	"github.com/nickawilliams/shedoc"
+)
+
+func init() {
+	shedoc.RegisterFormatter("html", &HTMLFormatter{})
+}
+
+// HTMLFormatter generates a self-contained HTML page: the stylesheet is
+// embedded, each block is a section with its own anchor, and flags, options,
+// and the other tags are laid out as tables.
+type HTMLFormatter struct {
+	// SourceURL, if set, is a template for links from each block to its
+	// source, such as "https://github.com/o/r/blob/{ref}/{path}#L{line}".
+	// {ref} links to HEAD; replace it first to link to a tag or commit.
+	SourceURL string
+
+	// Stylesheet, if set, replaces the built-in stylesheet.
+	Stylesheet string
+
+	// Page, if set, is executed against the Document in place of the
+	// built-in page; see ParseHTMLPage.
+	Page *htmltemplate.Template
+}
+
+// ParseHTMLPage parses an html/template for HTMLFormatter.Page, with
+// TemplateFuncs available to it. The name is used in error messages.
+func ParseHTMLPage(name, text string) (*htmltemplate.Template, error) {
+	return htmltemplate.New(name).Funcs(htmltemplate.FuncMap(TemplateFuncs())).Parse(text)
+}
+
+// htmlStyle is the stylesheet embedded in every page.
+const htmlStyle = `body { font-family: system-ui, sans-serif; line-height: 1.5; max-width: 56rem; margin: 2rem auto; padding: 0 1rem; color: #222; }
+h1, h2, h3 { line-height: 1.2; }
+h2 { border-bottom: 1px solid #ddd; padding-bottom: .25rem; margin-top: 2.5rem; }
+a { color: #0b5cad; }
+code, pre { font-family: ui-monospace, monospace; font-size: .9em; }
+pre { background: #f6f8fa; padding: .75rem 1rem; overflow-x: auto; }
+table { border-collapse: collapse; width: 100%; margin: .5rem 0 1rem; }
+th, td { border: 1px solid #ddd; padding: .35rem .6rem; text-align: left; vertical-align: top; }
+th { background: #f6f8fa; }
+.meta { color: #555; }
+.note { color: #8a4b00; font-weight: 600; }
+nav ul { padding-left: 1.25rem; }
+`
+
+func (f *HTMLFormatter) Format(w io.Writer, doc *shedoc.Document) error {
+	if f.Page != nil {
+		return f.Page.Execute(w, doc)
+	}
+	// Read the license first so a missing file fails before any output.
+	var license string
+	if doc.Meta.LicenseFile != "" {
+		text, err := readLicenseFile(doc)
+		if err != nil {
+			return err
+		}
+		license = strings.TrimRight(text, "\n")
+	}
+	style := htmlStyle
+	if f.Stylesheet != "" {
+		style = f.Stylesheet
+	}
+
+	name := doc.Meta.Name
+	if name == "" {
+		name = "UNKNOWN"
+	}
+	lang := doc.Meta.Lang
+	if lang == "" {
+		lang = "en"
+	}
+
+	fmt.Fprintln(w, "<!DOCTYPE html>")
+	fmt.Fprintf(w, "<html lang=\"%s\">\n", html.EscapeString(lang))
+	fmt.Fprintln(w, "<head>")
+	fmt.Fprintln(w, `<meta charset="utf-8">`)
+	fmt.Fprintln(w, `<meta name="viewport" content="width=device-width, initial-scale=1">`)
+	fmt.Fprintf(w, "<title>%s</title>\n", html.EscapeString(name))
+	fmt.Fprintf(w, "<style>\n%s</style>\n", style)
+	fmt.Fprintln(w, "</head>")
+	fmt.Fprintln(w, "<body>")
+
+	fmt.Fprintf(w, "<h1>%s</h1>\n", html.EscapeString(name))
+	var meta []string
+	if doc.Meta.Version != "" {
+		meta = append(meta, "Version "+html.EscapeString(doc.Meta.Version))
+	}
+	if doc.Meta.Author != "" {
+		meta = append(meta, html.EscapeString(firstLine(doc.Meta.Author)))
+	}
+	if doc.Meta.License != "" {
+		meta = append(meta, html.EscapeString(doc.Meta.License))
+	}
+	if len(meta) > 0 {
+		fmt.Fprintf(w, "<p class=\"meta\">%s</p>\n", strings.Join(meta, " &middot; "))
+	}
+	if doc.Meta.Description != "" {
+		writeHTMLText(w, doc.Meta.Description)
+	}
+
+	if synopsis := synopsisLines(doc); len(synopsis) > 0 {
+		fmt.Fprintln(w, `<h2 id="synopsis">Synopsis</h2>`)
+		writeHTMLPre(w, strings.Join(synopsis, "\n"))
+	}
+	if doc.Meta.Examples != "" {
+		fmt.Fprintln(w, `<h2 id="examples">Examples</h2>`)
+		writeHTMLPre(w, doc.Meta.Examples)
+	}
+	writeHTMLTable(w, 2, "Dependencies", []string{"Command", "Description"}, htmlRequires(doc.Meta.Requires))
+
+	// Table of contents, when there is more than one block to link to. The
+	// blocks in a section are listed under it.
+	depths := blockDepths(doc)
+	if len(doc.Blocks) > 1 {
+		fmt.Fprint(w, "<nav>\n<ul>\n")
+		for i := range doc.Blocks {
+			b := &doc.Blocks[i]
+			switch {
+			case i == 0:
+			case depths[i] > depths[i-1]:
+				fmt.Fprint(w, "\n<ul>\n")
+			case depths[i] < depths[i-1]:
+				fmt.Fprint(w, "</li>\n</ul>\n</li>\n")
+			default:
+				fmt.Fprint(w, "</li>\n")
+			}
+			fmt.Fprintf(w, "<li><a href=\"#%s\">%s</a>", blockAnchor(b), html.EscapeString(blockHeading(b)))
+		}
+		fmt.Fprint(w, "</li>\n")
+		if depths[len(depths)-1] > 0 {
+			fmt.Fprint(w, "</ul>\n</li>\n")
+		}
+		fmt.Fprintln(w, "</ul>\n</nav>")
+	}
+
+	for i := range doc.Blocks {
+		b := &doc.Blocks[i]
+		writeHTMLBlock(w, b, depths[i], sourceURL(f.SourceURL, doc, b))
+	}
+
+	if license != "" {
+		fmt.Fprintln(w, `<h2 id="license">License</h2>`)
+		writeHTMLPre(w, license)
+	}
+	if refs := seeAlso(doc); len(refs) > 0 {
+		fmt.Fprintln(w, `<h2 id="see-also">See Also</h2>`)
+		fmt.Fprintln(w, "<ul>")
+		for _, ref := range refs {
+			fmt.Fprintf(w, "<li>%s</li>\n", html.EscapeString(ref))
+		}
+		fmt.Fprintln(w, "</ul>")
+	}
+
+	fmt.Fprintln(w, "</body>")
+	fmt.Fprintln(w, "</html>")
+	return nil
+}
+
+// writeHTMLBlock writes a block as a section whose heading is nested depth
+// levels below the top-level h2, linking to source if it is not empty.
+func writeHTMLBlock(w io.Writer, b *shedoc.Block, depth int, source string) {
+	level := 2 + depth
+	fmt.Fprintf(w, "<section id=\"%s\">\n", blockAnchor(b))
+	fmt.Fprintf(w, "<h%d><a href=\"#%s\">%s</a></h%d>\n", level, blockAnchor(b), html.EscapeString(blockHeading(b)), level)
+
+	var notes []string
+	if len(b.Aliases) > 0 {
+		notes = append(notes, "Aliases: "+htmlCode(b.Aliases...))
+	}
+	if b.FunctionName != "" && b.Visibility != shedoc.VisibilityCommand {
+		notes = append(notes, "Function: "+htmlCode(b.FunctionName))
+	}
+	if b.Default != "" {
+		notes = append(notes, "Default: "+htmlCode(b.Default))
+	}
+	if b.Readonly {
+		notes = append(notes, "Read-only")
+	}
+	if b.Since != "" {
+		notes = append(notes, "Since "+html.EscapeString(b.Since))
+	}
+	if len(b.See) > 0 && b.Visibility != shedoc.VisibilityCommand {
+		notes = append(notes, "See also: "+htmlCode(b.See...))
+	}
+	if source != "" {
+		notes = append(notes, fmt.Sprintf("<a href=\"%s\">Source</a>", html.EscapeString(source)))
+	}
+	if len(notes) > 0 {
+		fmt.Fprintf(w, "<p class=\"meta\">%s</p>\n", strings.Join(notes, " &middot; "))
+	}
+	if b.Deprecated != nil {
+		msg := b.Deprecated.Message
+		if msg == "" {
+			msg = "This command is deprecated."
+		}
+		fmt.Fprintf(w, "<p class=\"note\">Deprecated: %s</p>\n", html.EscapeString(msg))
+	}
+	if b.Hidden {
+		fmt.Fprintln(w, `<p class="note">Hidden</p>`)
+	}
+	if b.Description != "" {
+		writeHTMLText(w, b.Description)
+	}
+
+	var rows [][]string
+	for _, fl := range b.Flags {
+		rows = append(rows, []string{htmlCode(fl.Short, fl.Long), "", htmlHidden(fl.Description, fl.Hidden)})
+	}
+	for _, o := range b.Options {
+		rows = append(rows, []string{htmlCode(o.Short, o.Long), htmlCode(o.Value.String()), htmlHidden(optionDescription(o), o.Hidden)})
+	}
+	writeHTMLTable(w, level+1, "Options", []string{"Option", "Value", "Description"}, rows)
+
+	rows = nil
+	for _, o := range b.Operands {
+		rows = append(rows, []string{htmlCode(o.Value.String()), html.EscapeString(o.Description)})
+	}
+	writeHTMLTable(w, level+1, "Operands", []string{"Operand", "Description"}, rows)
+
+	rows = nil
+	for _, e := range b.Env {
+		rows = append(rows, []string{htmlCode(e.Name), html.EscapeString(envDescription(e))})
+	}
+	writeHTMLTable(w, level+1, "Environment", []string{"Variable", "Description"}, rows)
+	writeHTMLTable(w, level+1, "Dependencies", []string{"Command", "Description"}, htmlRequires(b.Requires))
+
+	rows = nil
+	for _, r := range b.Reads {
+		rows = append(rows, []string{htmlCode(r.Path), "read", html.EscapeString(r.Description)})
+	}
+	for _, wr := range b.Writes {
+		rows = append(rows, []string{htmlCode(wr.Path), "written", html.EscapeString(wr.Description)})
+	}
+	writeHTMLTable(w, level+1, "Files", []string{"Path", "Access", "Description"}, rows)
+
+	rows = nil
+	if b.Stdin != nil {
+		rows = append(rows, []string{"standard input", html.EscapeString(b.Stdin.Description)})
+	}
+	if b.Stdout != nil {
+		rows = append(rows, []string{"standard output", html.EscapeString(b.Stdout.Description)})
+	}
+	if b.Stderr != nil {
+		rows = append(rows, []string{"standard error", html.EscapeString(b.Stderr.Description)})
+	}
+	for _, s := range b.Sets {
+		rows = append(rows, []string{htmlCode(s.Name) + " (set)", html.EscapeString(s.Description)})
+	}
+	writeHTMLTable(w, level+1, "Input and Output", []string{"Stream", "Description"}, rows)
+
+	rows = nil
+	for _, e := range b.Exit {
+		rows = append(rows, []string{htmlCode(e.Code), html.EscapeString(e.Description)})
+	}
+	writeHTMLTable(w, level+1, "Exit Status", []string{"Code", "Description"}, rows)
+
+	rows = nil
+	for _, r := range b.Return {
+		rows = append(rows, []string{htmlCode(r.Code), html.EscapeString(r.Description)})
+	}
+	writeHTMLTable(w, level+1, "Returns", []string{"Code", "Description"}, rows)
+
+	fmt.Fprintln(w, "</section>")
+}
+
+// writeHTMLTable writes a table under a heading of the given level, if there
+// are any rows. Cells are already escaped.
+func writeHTMLTable(w io.Writer, level int, title string, header []string, rows [][]string) {
+	if len(rows) == 0 {
+		return
+	}
+
+	// Drop columns that are empty in every row.
+	var cols []int
+	for c := range header {
+		for _, row := range rows {
+			if row[c] != "" {
+				cols = append(cols, c)
+				break
+			}
+		}
+	}
+
+	fmt.Fprintf(w, "<h%d>%s</h%d>\n<table>\n<thead><tr>", level, title, level)
+	for _, c := range cols {
+		fmt.Fprintf(w, "<th>%s</th>", header[c])
+	}
+	fmt.Fprintln(w, "</tr></thead>\n<tbody>")
+	for _, row := range rows {
+		fmt.Fprint(w, "<tr>")
+		for _, c := range cols {
+			fmt.Fprintf(w, "<td>%s</td>", row[c])
+		}
+		fmt.Fprintln(w, "</tr>")
+	}
+	fmt.Fprintln(w, "</tbody>\n</table>")
+}
+
+// htmlRequires returns the table rows for requirements.
+func htmlRequires(reqs []shedoc.Requires) [][]string {
+	var rows [][]string
+	for _, r := range reqs {
+		rows = append(rows, []string{htmlCode(r.Command), html.EscapeString(r.Description)})
+	}
+	return rows
+}
+
+// writeHTMLText writes text as paragraphs separated by blank lines.
+func writeHTMLText(w io.Writer, text string) {
+	for _, para := range strings.Split(strings.TrimSpace(text), "\n\n") {
+		if para = strings.TrimSpace(para); para != "" {
+			fmt.Fprintf(w, "<p>%s</p>\n", html.EscapeString(para))
+		}
+	}
+}
+
+// writeHTMLPre writes text as preformatted code.
+func writeHTMLPre(w io.Writer, text string) {
+	fmt.Fprintf(w, "<pre><code>%s</code></pre>\n", html.EscapeString(text))
+}
+
+// htmlCode marks up each non-empty value as code and joins them with commas.
+func htmlCode(values ...string) string {
+	var parts []string
+	for _, v := range values {
+		if v != "" {
+			parts = append(parts, "<code>"+html.EscapeString(v)+"</code>")
+		}
+	}
+	return strings.Join(parts, ", ")
+}
+
+// htmlHidden escapes the description of a flag or option, marking it when
+// hidden.
+func htmlHidden(desc string, hidden bool) string {
+	desc = html.EscapeString(desc)
+	if !hidden {
+		return desc
+	}
+	return strings.TrimSpace(`<span class="note">(hidden)</span> ` + desc)
+}
@@ -0,0 +1,186 @@
+package format
+
+import (
+	"bytes"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/nickawilliams/shedoc"
+)
+
+func TestHTMLFormatter_Sections(t *testing.T) {
+	doc := &shedoc.Document{
+		Meta: shedoc.Meta{Name: "lib"},
+		Blocks: []shedoc.Block{
+			{Visibility: shedoc.VisibilityPublic, FunctionName: "setup"},
+			{Visibility: shedoc.VisibilitySection, Name: "Database migrations", Description: "Schema changes."},
+			{Visibility: shedoc.VisibilityPublic, FunctionName: "migrate_up", Env: []shedoc.Env{{Name: "DB_URL"}}},
+		},
+	}
+
+	var buf bytes.Buffer
+	if err := (&HTMLFormatter{}).Format(&buf, doc); err != nil {
+		t.Fatal(err)
+	}
+	got := buf.String()
+
+	for _, want := range []string{
+		"<nav>\n<ul>\n" +
+			"<li><a href=\"#fn-setup\">setup</a></li>\n" +
+			"<li><a href=\"#section-Database-migrations\">Database migrations</a>\n" +
+			"<ul>\n<li><a href=\"#fn-migrate_up\">migrate_up</a></li>\n</ul>\n</li>\n" +
+			"</ul>\n</nav>\n",
+		"<h2><a href=\"#fn-setup\">setup</a></h2>\n",
+		"<h2><a href=\"#section-Database-migrations\">Database migrations</a></h2>\n<p>Schema changes.</p>\n",
+		"<h3><a href=\"#fn-migrate_up\">migrate_up</a></h3>\n",
+		"<h4>Environment</h4>\n",
+	} {
+		if !strings.Contains(got, want) {
+			t.Errorf("output missing %q\n\nfull output:\n%s", want, got)
+		}
+	}
+}
+
+func TestHTMLFormatter(t *testing.T) {
+	doc := &shedoc.Document{
+		Meta: shedoc.Meta{
+			Name:        "deploy",
+			Version:     "2.1.0",
+			Description: "Deploys <things>.\n\nSecond paragraph.",
+			SeeAlso:     []string{"git(1)"},
+		},
+		Blocks: []shedoc.Block{
+			{
+				Visibility: shedoc.VisibilityCommand,
+				See:        []string{"deploy-push(1)"},
+				Flags:      []shedoc.Flag{{Short: "-v", Long: "--verbose", Description: "Verbose"}},
+				Options:    []shedoc.Option{{Long: "--format", Value: shedoc.Value{Name: "fmt", Default: "text"}, Description: "Output format"}},
+			},
+			{
+				Visibility: shedoc.VisibilitySubcommand,
+				Name:       "migrate",
+				Deprecated: &shedoc.Deprecated{Message: "Use push."},
+				Operands:   []shedoc.Operand{{Value: shedoc.Value{Name: "env", Required: true}}},
+			},
+			{Visibility: shedoc.VisibilityPublic, FunctionName: "helper", See: []string{"setup"}, Return: []shedoc.Return{{Code: "1", Description: "Not found"}}},
+			{Visibility: shedoc.VisibilityPublic, Name: "FORMAT_VERSION", Default: "3", Readonly: true},
+		},
+	}
+
+	var buf bytes.Buffer
+	if err := (&HTMLFormatter{}).Format(&buf, doc); err != nil {
+		t.Fatal(err)
+	}
+	got := buf.String()
+
+	for _, want := range []string{
+		"<!DOCTYPE html>\n<html lang=\"en\">\n",
+		"<style>\n",
+		"<title>deploy</title>\n",
+		"<p>Deploys &lt;things&gt;.</p>\n<p>Second paragraph.</p>\n",
+		"<h2 id=\"synopsis\">Synopsis</h2>\n<pre><code>deploy [-v] [--format [fmt]] &lt;command&gt; [args...]</code></pre>\n",
+		"<li><a href=\"#sub-migrate\">migrate</a></li>\n",
+		"<section id=\"command\">\n",
+		"<tr><td><code>-v</code>, <code>--verbose</code></td><td></td><td>Verbose</td></tr>\n",
+		"<tr><td><code>--format</code></td><td><code>[fmt=text]</code></td><td>Output format (default: text)</td></tr>\n",
+		"<section id=\"sub-migrate\">\n",
+		"<p class=\"note\">Deprecated: Use push.</p>\n",
+		"<thead><tr><th>Operand</th></tr></thead>\n",
+		"<section id=\"fn-helper\">\n",
+		"<p class=\"meta\">Function: <code>helper</code> &middot; See also: <code>setup</code></p>\n",
+		"Returns</h3>\n",
+		"<tr><td><code>1</code></td><td>Not found</td></tr>\n",
+		"<section id=\"var-FORMAT_VERSION\">\n",
+		"<p class=\"meta\">Default: <code>3</code> &middot; Read-only</p>\n",
+		"<h2 id=\"see-also\">See Also</h2>\n<ul>\n<li>git(1)</li>\n<li>deploy-push(1)</li>\n</ul>\n</body>\n</html>\n",
+	} {
+		if !strings.Contains(got, want) {
+			t.Errorf("output missing %q\n\nfull output:\n%s", want, got)
+		}
+	}
+}
+
+func TestHTMLFormatter_SourceURL(t *testing.T) {
+	doc := &shedoc.Document{
+		Path: "./bin/lib.sh",
+		Meta: shedoc.Meta{Name: "lib"},
+		Blocks: []shedoc.Block{
+			{Visibility: shedoc.VisibilityPublic, FunctionName: "lib::to_upper", Line: 12},
+		},
+	}
+
+	var buf bytes.Buffer
+	f := &HTMLFormatter{SourceURL: "https://example.com/blob/{ref}/{path}#L{line}"}
+	if err := f.Format(&buf, doc); err != nil {
+		t.Fatal(err)
+	}
+	got := buf.String()
+
+	for _, want := range []string{
+		"<section id=\"fn-lib-to_upper\">\n",
+		"<a href=\"https://example.com/blob/HEAD/bin/lib.sh#L12\">Source</a>",
+	} {
+		if !strings.Contains(got, want) {
+			t.Errorf("output missing %q\n\nfull output:\n%s", want, got)
+		}
+	}
+}
+
+func TestHTMLFormatter_Theme(t *testing.T) {
+	doc := &shedoc.Document{
+		Meta:   shedoc.Meta{Name: "deploy"},
+		Blocks: []shedoc.Block{{Visibility: shedoc.VisibilitySubcommand, Name: "push", Description: "Push <it>"}},
+	}
+
+	var buf bytes.Buffer
+	if err := (&HTMLFormatter{Stylesheet: "body { color: teal; }\n"}).Format(&buf, doc); err != nil {
+		t.Fatal(err)
+	}
+	if got := buf.String(); !strings.Contains(got, "<style>\nbody { color: teal; }\n</style>") || strings.Contains(got, "font-family") {
+		t.Errorf("stylesheet not replaced:\n%s", got)
+	}
+
+	page, err := ParseHTMLPage("page.html", `<h1>{{.Meta.Name}}</h1>{{range .Blocks}}<p id="{{anchor .}}">{{.Description}}</p>{{end}}`)
+	if err != nil {
+		t.Fatal(err)
+	}
+	buf.Reset()
+	if err := (&HTMLFormatter{Page: page}).Format(&buf, doc); err != nil {
+		t.Fatal(err)
+	}
+	if got, want := buf.String(), `<h1>deploy</h1><p id="sub-push">Push &lt;it&gt;</p>`; got != want {
+		t.Errorf("got %q, want %q", got, want)
+	}
+}
+
+func TestHTMLFormatter_LicenseFile(t *testing.T) {
+	dir := t.TempDir()
+	if err := os.WriteFile(filepath.Join(dir, "LICENSE"), []byte("MIT License\n\nCopyright <me>\n"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	doc := &shedoc.Document{
+		Path: filepath.Join(dir, "tool.sh"),
+		Meta: shedoc.Meta{Name: "tool", LicenseFile: "LICENSE", SeeAlso: []string{"git(1)"}},
+	}
+
+	var buf bytes.Buffer
+	f := &HTMLFormatter{}
+	if err := f.Format(&buf, doc); err != nil {
+		t.Fatal(err)
+	}
+
+	want := "<h2 id=\"license\">License</h2>\n<pre><code>MIT License\n\nCopyright &lt;me&gt;</code></pre>\n<h2 id=\"see-also\">"
+	if got := buf.String(); !strings.Contains(got, want) {
+		t.Errorf("output missing %q\n%s", want, got)
+	}
+
+	doc.Meta.LicenseFile = "MISSING"
+	buf.Reset()
+	if err := f.Format(&buf, doc); err == nil {
+		t.Error("expected error for missing license file")
+	} else if buf.Len() > 0 {
+		t.Errorf("wrote output before failing:\n%s", buf.String())
+	}
+}
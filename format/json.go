@@ -0,0 +1,88 @@
+package format
+
+import (
+	"bytes"
+	"encoding/json"
+	"io"
+
+	"github.com/nickawilliams/shedoc"
+)
+
+func init() {
+	shedoc.RegisterFormatter("json", &JSONFormatter{})
+}
+
+// JSONFormatter outputs a Document as JSON. It is a StreamFormatter, writing
+// the same JSON a block at a time, except that metadata tags following the
+// first block are left out; a streamed document must be finished before the
+// next is begun.
+type JSONFormatter struct {
+	blocks int // blocks written in the current stream
+}
+
+func (f *JSONFormatter) Format(w io.Writer, doc *shedoc.Document) error {
+	enc := json.NewEncoder(w)
+	enc.SetEscapeHTML(false)
+	return enc.Encode(doc)
+}
+
+// BeginDocument writes the document's fields before its blocks, leaving the
+// object open.
+func (f *JSONFormatter) BeginDocument(w io.Writer, doc *shedoc.Document) error {
+	f.blocks = 0
+	head := *doc
+	head.Blocks, head.Warnings = nil, nil
+	data, err := encodeJSON(head)
+	if err != nil {
+		return err
+	}
+	_, err = w.Write(bytes.TrimSuffix(data, []byte("}")))
+	return err
+}
+
+func (f *JSONFormatter) Block(w io.Writer, b *shedoc.Block) error {
+	data, err := encodeJSON(b)
+	if err != nil {
+		return err
+	}
+	sep := ","
+	if f.blocks == 0 {
+		sep = `,"blocks":[`
+	}
+	f.blocks++
+	if _, err := io.WriteString(w, sep); err != nil {
+		return err
+	}
+	_, err = w.Write(data)
+	return err
+}
+
+// EndDocument closes the blocks and writes the warnings.
+func (f *JSONFormatter) EndDocument(w io.Writer, doc *shedoc.Document) error {
+	var tail bytes.Buffer
+	if f.blocks > 0 {
+		tail.WriteString("]")
+	}
+	if len(doc.Warnings) > 0 {
+		data, err := encodeJSON(doc.Warnings)
+		if err != nil {
+			return err
+		}
+		tail.WriteString(`,"warnings":`)
+		tail.Write(data)
+	}
+	tail.WriteString("}\n")
+	_, err := w.Write(tail.Bytes())
+	return err
+}
+
+// encodeJSON encodes v as Format does, without the trailing newline.
+func encodeJSON(v any) ([]byte, error) {
+	var buf bytes.Buffer
+	enc := json.NewEncoder(&buf)
+	enc.SetEscapeHTML(false)
+	if err := enc.Encode(v); err != nil {
+		return nil, err
+	}
+	return bytes.TrimSuffix(buf.Bytes(), []byte("\n")), nil
+}
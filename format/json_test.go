@@ -0,0 +1,82 @@
+package format
+
+import (
+	"bytes"
+	"encoding/json"
+	"path/filepath"
+	"testing"
+
+	"github.com/nickawilliams/shedoc"
+)
+
+func TestJSONFormatter(t *testing.T) {
+	doc := &shedoc.Document{
+		Meta: shedoc.Meta{
+			Name:    "test-script",
+			Version: "1.0.0",
+		},
+		Blocks: []shedoc.Block{
+			{
+				Visibility:  shedoc.VisibilityCommand,
+				Description: "A test command.",
+				Flags: []shedoc.Flag{
+					{Short: "-v", Long: "--verbose", Description: "Verbose"},
+				},
+			},
+		},
+	}
+
+	var buf bytes.Buffer
+	f := &JSONFormatter{}
+	if err := f.Format(&buf, doc); err != nil {
+		t.Fatal(err)
+	}
+
+	// Verify output is valid JSON.
+	var roundtrip shedoc.Document
+	if err := json.Unmarshal(buf.Bytes(), &roundtrip); err != nil {
+		t.Fatalf("output is not valid JSON: %v\noutput: %s", err, buf.String())
+	}
+
+	if roundtrip.Meta.Name != "test-script" {
+		t.Errorf("Meta.Name = %q, want %q", roundtrip.Meta.Name, "test-script")
+	}
+	if len(roundtrip.Blocks) != 1 {
+		t.Fatalf("got %d blocks, want 1", len(roundtrip.Blocks))
+	}
+}
+
+func TestJSONFormatter_Stream(t *testing.T) {
+	paths, err := filepath.Glob(filepath.Join("..", "testdata", "*.sh"))
+	if err != nil || len(paths) == 0 {
+		t.Fatalf("no testdata: %v", err)
+	}
+
+	for _, path := range paths {
+		t.Run(filepath.Base(path), func(t *testing.T) {
+			doc, err := shedoc.Parse(path)
+			if err != nil {
+				t.Fatal(err)
+			}
+			var want bytes.Buffer
+			if err := (&JSONFormatter{}).Format(&want, doc); err != nil {
+				t.Fatal(err)
+			}
+
+			var streamed, formatted bytes.Buffer
+			f := &JSONFormatter{}
+			if _, err := shedoc.ParseStream(path, &streamed, f); err != nil {
+				t.Fatal(err)
+			}
+			if streamed.String() != want.String() {
+				t.Errorf("ParseStream wrote\n%s\nwant\n%s", streamed.String(), want.String())
+			}
+			if err := shedoc.FormatStream(&formatted, doc, f); err != nil {
+				t.Fatal(err)
+			}
+			if formatted.String() != want.String() {
+				t.Errorf("FormatStream wrote\n%s\nwant\n%s", formatted.String(), want.String())
+			}
+		})
+	}
+}
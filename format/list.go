@@ -0,0 +1,47 @@
+package format
+
+import (
+	"fmt"
+	"io"
+	"strings"
+
+	"github.com/nickawilliams/shedoc"
+)
+
+func init() {
+	shedoc.RegisterFormatter("list", &ListFormatter{})
+}
+
+// ListFormatter writes one line per block, an inventory for reviews and
+// audits that can be concatenated across scripts:
+//
+//	subcommand push deploy_push bin/deploy.sh:46 — Deploy the application.
+//
+// A block without a name or function has "-" in its place; the description
+// part is left out when the block has none.
+type ListFormatter struct{}
+
+func (f *ListFormatter) Format(w io.Writer, doc *shedoc.Document) error {
+	source := doc.Path
+	if source == "" {
+		source = "<stdin>"
+	}
+	for _, b := range doc.Blocks {
+		line := fmt.Sprintf("%s %s %s %s:%d", b.Visibility, orDash(b.Name), orDash(b.FunctionName), source, b.Line)
+		if desc := strings.TrimSpace(firstLine(b.Description)); desc != "" {
+			line += " — " + desc
+		}
+		if _, err := fmt.Fprintln(w, line); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// orDash returns s, or "-" if it is empty.
+func orDash(s string) string {
+	if s == "" {
+		return "-"
+	}
+	return s
+}
@@ -0,0 +1,53 @@
+package format
+
+import (
+	"bytes"
+	"testing"
+
+	"github.com/nickawilliams/shedoc"
+)
+
+func TestListFormatter(t *testing.T) {
+	tests := []struct {
+		name string
+		doc  *shedoc.Document
+		want string
+	}{
+		{
+			name: "blocks",
+			doc: &shedoc.Document{
+				Path: "bin/deploy.sh",
+				Blocks: []shedoc.Block{
+					{Visibility: shedoc.VisibilityCommand, Line: 3, Description: "Deploy releases.\nMore detail."},
+					{Visibility: shedoc.VisibilitySubcommand, Name: "push", FunctionName: "deploy_push", Line: 12},
+					{Visibility: shedoc.VisibilityPrivate, FunctionName: "_log", Line: 30, Description: "Log a line."},
+				},
+			},
+			want: "command - - bin/deploy.sh:3 — Deploy releases.\n" +
+				"subcommand push deploy_push bin/deploy.sh:12\n" +
+				"private - _log bin/deploy.sh:30 — Log a line.\n",
+		},
+		{
+			name: "stdin",
+			doc:  &shedoc.Document{Blocks: []shedoc.Block{{Visibility: shedoc.VisibilityPublic, FunctionName: "f", Line: 1}}},
+			want: "public - f <stdin>:1\n",
+		},
+		{
+			name: "no blocks",
+			doc:  &shedoc.Document{Path: "lib.sh"},
+			want: "",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var buf bytes.Buffer
+			if err := (&ListFormatter{}).Format(&buf, tt.doc); err != nil {
+				t.Fatal(err)
+			}
+			if got := buf.String(); got != tt.want {
+				t.Errorf("got:\n%s\nwant:\n%s", got, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,340 @@
+package format
+
+import (
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+	"unicode"
+
+	"github.com/nickawilliams/shedoc"
+)
+
+func init() {
+	shedoc.RegisterFormatter("man", &ManPageFormatter{})
+}
+
+// ManPageFormatter outputs a Document as a troff/groff man page.
+type ManPageFormatter struct {
+	// Headings replaces the English section headings; by default they are
+	// those built in for the document's #?/lang, if any.
+	Headings Headings
+
+	// SeeAlso lists related pages, such as "deploy-push(1)", for the SEE
+	// ALSO section, after those of #?/see-also and @see.
+	SeeAlso []string
+
+	// GeneratedAt, if set, is the date of the page and is noted as the time
+	// it was generated at in the comment at its top; by default the page is
+	// dated today.
+	GeneratedAt time.Time
+}
+
+func (f *ManPageFormatter) Format(w io.Writer, doc *shedoc.Document) error {
+	h := headingsFor(f.Headings, doc)
+	section := doc.Meta.Section
+	if section == "" {
+		section = "1"
+	}
+
+	name := doc.Meta.Name
+	if name == "" {
+		name = "UNKNOWN"
+	}
+
+	date := time.Now().Format("2006-01-02")
+	if !f.GeneratedAt.IsZero() {
+		date = f.GeneratedAt.UTC().Format("2006-01-02")
+	}
+	version := doc.Meta.Version
+
+	fmt.Fprintf(w, ".\\\" %s\n", generatedBy(doc, f.GeneratedAt))
+
+	// .TH header
+	fmt.Fprintf(w, ".TH %s %s %q %q\n",
+		troffEscape(strings.ToUpper(name)),
+		section,
+		date,
+		version,
+	)
+
+	// NAME section
+	fmt.Fprintf(w, ".SH %s\n", h.man("name", "NAME"))
+	if brief := manBrief(doc); brief != "" {
+		fmt.Fprintf(w, "%s \\- %s\n", troffEscape(name), troffEscape(brief))
+	} else {
+		fmt.Fprintln(w, troffEscape(name))
+	}
+
+	// SYNOPSIS section
+	if synopsis := synopsisLines(doc); len(synopsis) > 0 {
+		fmt.Fprintf(w, ".SH %s\n", h.man("synopsis", "SYNOPSIS"))
+		for i, line := range synopsis {
+			if i > 0 {
+				fmt.Fprintln(w, ".br")
+			}
+			fmt.Fprintf(w, ".B %s\n", troffEscape(line))
+		}
+	}
+
+	// DESCRIPTION section
+	if doc.Meta.Description != "" {
+		fmt.Fprintf(w, ".SH %s\n", h.man("description", "DESCRIPTION"))
+		writeManText(w, doc.Meta.Description)
+	}
+
+	// Find command block and subcommands.
+	cmdBlock := doc.CommandBlock()
+	subcommands := doc.Subcommands()
+
+	// OPTIONS section, with a subsection for each @group
+	if cmdBlock != nil && (len(cmdBlock.Flags) > 0 || len(cmdBlock.Options) > 0) {
+		fmt.Fprintf(w, ".SH %s\n", h.man("options", "OPTIONS"))
+		for _, g := range optionGroups(cmdBlock.Flags, cmdBlock.Options) {
+			if g.Name != "" {
+				fmt.Fprintf(w, ".SS %s\n", troffEscape(g.Name))
+			}
+			writeManOptions(w, g.Flags, g.Options)
+		}
+	}
+
+	// OPERANDS section
+	if cmdBlock != nil && len(cmdBlock.Operands) > 0 {
+		fmt.Fprintf(w, ".SH %s\n", h.man("operands", "OPERANDS"))
+		for _, op := range cmdBlock.Operands {
+			fmt.Fprintf(w, ".TP\n.B %s\n", troffEscape(valueNotation(op.Value)))
+			if desc := operandDescription(op); desc != "" {
+				writeManText(w, desc)
+			}
+		}
+	}
+
+	// COMMANDS section
+	if len(subcommands) > 0 {
+		fmt.Fprintf(w, ".SH %s\n", h.man("commands", "COMMANDS"))
+		for _, sub := range subcommands {
+			fmt.Fprintf(w, ".TP\n.B %s\n", troffEscape(sub.Name))
+			if sub.Deprecated != nil {
+				msg := sub.Deprecated.Message
+				if msg == "" {
+					msg = "This command is deprecated."
+				}
+				fmt.Fprintf(w, "[deprecated] %s\n", troffEscape(msg))
+			} else if sub.Description != "" {
+				writeManText(w, sub.Description)
+			}
+			if sub.Since != "" {
+				fmt.Fprintf(w, "Since %s.\n", troffEscape(sub.Since))
+			}
+
+			// Subcommand flags and options
+			for _, flag := range sub.Flags {
+				label := formatFlagLabel(flag.Short, flag.Long)
+				fmt.Fprintf(w, ".RS\n.TP\n.B %s\n", troffEscape(label))
+				if flag.Description != "" {
+					writeManText(w, flag.Description)
+				}
+				fmt.Fprintln(w, ".RE")
+			}
+			for _, opt := range sub.Options {
+				label := formatOptionLabel(opt.Short, opt.Long, opt.Value)
+				fmt.Fprintf(w, ".RS\n.TP\n.B %s\n", troffEscape(label))
+				if desc := optionDescription(opt); desc != "" {
+					writeManText(w, desc)
+				}
+				fmt.Fprintln(w, ".RE")
+			}
+		}
+	}
+
+	// ENVIRONMENT section
+	var envVars []shedoc.Env
+	if cmdBlock != nil {
+		envVars = cmdBlock.Env
+	}
+	if len(envVars) > 0 {
+		fmt.Fprintf(w, ".SH %s\n", h.man("environment", "ENVIRONMENT"))
+		for _, env := range envVars {
+			fmt.Fprintf(w, ".TP\n.B %s\n", troffEscape(env.Name))
+			if desc := envDescription(env); desc != "" {
+				writeManText(w, desc)
+			}
+		}
+	}
+
+	// FILES section
+	var files []struct{ path, desc string }
+	if cmdBlock != nil {
+		for _, r := range cmdBlock.Reads {
+			files = append(files, struct{ path, desc string }{r.Path, r.Description})
+		}
+		for _, wr := range cmdBlock.Writes {
+			files = append(files, struct{ path, desc string }{wr.Path, wr.Description})
+		}
+	}
+	if len(files) > 0 {
+		fmt.Fprintf(w, ".SH %s\n", h.man("files", "FILES"))
+		for _, f := range files {
+			fmt.Fprintf(w, ".TP\n.B %s\n", troffEscape(f.path))
+			if f.desc != "" {
+				writeManText(w, f.desc)
+			}
+		}
+	}
+
+	// DEPENDENCIES section
+	if reqs := doc.Requirements(); len(reqs) > 0 {
+		fmt.Fprintf(w, ".SH %s\n", h.man("dependencies", "DEPENDENCIES"))
+		for _, r := range reqs {
+			fmt.Fprintf(w, ".TP\n.B %s\n", troffEscape(r.Command))
+			if r.Description != "" {
+				writeManText(w, r.Description)
+			}
+		}
+	}
+
+	// EXIT STATUS section
+	if cmdBlock != nil && len(cmdBlock.Exit) > 0 {
+		fmt.Fprintf(w, ".SH %s\n", h.man("exit-status", "EXIT STATUS"))
+		for _, exit := range cmdBlock.Exit {
+			fmt.Fprintf(w, ".TP\n.B %s\n", troffEscape(exit.Code))
+			if exit.Description != "" {
+				writeManText(w, exit.Description)
+			}
+		}
+	}
+
+	// EXAMPLES section, as an example block (.EX/.EE, supported by groff and
+	// mandoc) so that multi-line examples keep their lines and indentation.
+	if doc.Meta.Examples != "" {
+		fmt.Fprintf(w, ".SH %s\n", h.man("examples", "EXAMPLES"))
+		fmt.Fprintln(w, ".EX")
+		for _, line := range strings.Split(strings.TrimRight(doc.Meta.Examples, "\n"), "\n") {
+			fmt.Fprintln(w, troffLine(line))
+		}
+		fmt.Fprintln(w, ".EE")
+	}
+
+	// AUTHOR section
+	if doc.Meta.Author != "" {
+		fmt.Fprintf(w, ".SH %s\n", h.man("author", "AUTHOR"))
+		writeManText(w, doc.Meta.Author)
+	}
+
+	// LICENSE section
+	if doc.Meta.LicenseFile != "" {
+		text, err := readLicenseFile(doc)
+		if err != nil {
+			return err
+		}
+		fmt.Fprintf(w, ".SH %s\n", h.man("license", "LICENSE"))
+		fmt.Fprintln(w, ".nf")
+		for _, line := range strings.Split(strings.TrimRight(text, "\n"), "\n") {
+			fmt.Fprintln(w, troffLine(line))
+		}
+		fmt.Fprintln(w, ".fi")
+	}
+
+	// SEE ALSO section
+	if seeAlso := seeAlso(doc, f.SeeAlso...); len(seeAlso) > 0 {
+		fmt.Fprintf(w, ".SH %s\n", h.man("see-also", "SEE ALSO"))
+		var refs []string
+		for _, ref := range seeAlso {
+			page, sect, ok := strings.Cut(ref, "(")
+			if ok {
+				sect = "(" + sect
+			}
+			refs = append(refs, "\\fB"+troffEscape(page)+"\\fR"+sect)
+		}
+		fmt.Fprintln(w, strings.Join(refs, ",\n"))
+	}
+
+	return nil
+}
+
+// manBrief returns the one-line summary for the NAME section, which whatis
+// and apropos index: the first sentence of the description's first paragraph
+// or, failing that, the first line of the synopsis.
+func manBrief(doc *shedoc.Document) string {
+	if doc.Meta.Description != "" {
+		para, _, _ := strings.Cut(strings.TrimSpace(doc.Meta.Description), "\n\n")
+		brief := strings.Join(strings.Fields(para), " ")
+		if i := strings.Index(brief, ". "); i >= 0 {
+			brief = brief[:i+1]
+		}
+		return brief
+	}
+	if len(doc.Meta.Synopsis) > 0 {
+		return strings.TrimSpace(doc.Meta.Synopsis[0])
+	}
+	return ""
+}
+
+// readLicenseFile reads the document's license file. A relative path is
+// resolved against the directory of the documented script.
+func readLicenseFile(doc *shedoc.Document) (string, error) {
+	path := doc.Meta.LicenseFile
+	if !filepath.IsAbs(path) && doc.Path != "" {
+		path = filepath.Join(filepath.Dir(doc.Path), path)
+	}
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return "", fmt.Errorf("failed to read license file: %w", err)
+	}
+	return string(data), nil
+}
+
+// troffLine escapes a line of literal text, guarding leading control
+// characters so the line is not read as a request.
+func troffLine(s string) string {
+	s = troffEscape(s)
+	if strings.HasPrefix(s, ".") || strings.HasPrefix(s, "'") {
+		s = "\\&" + s
+	}
+	return s
+}
+
+// troffEscape escapes special troff characters. Characters outside ASCII
+// become \[uXXXX], as preconv(1) would write them, so that pages in any
+// language render without depending on the input encoding troff assumes.
+func troffEscape(s string) string {
+	var b strings.Builder
+	for _, r := range s {
+		switch {
+		case r == '\\':
+			b.WriteString("\\\\")
+		case r == '-':
+			b.WriteString("\\-")
+		case r > unicode.MaxASCII:
+			fmt.Fprintf(&b, "\\[u%04X]", r)
+		default:
+			b.WriteRune(r)
+		}
+	}
+	return b.String()
+}
+
+// writeManOptions writes a tagged paragraph for each flag and option.
+func writeManOptions(w io.Writer, flags []shedoc.Flag, options []shedoc.Option) {
+	for _, flag := range flags {
+		label := formatFlagLabel(flag.Short, flag.Long)
+		fmt.Fprintf(w, ".TP\n.B %s\n", troffEscape(label))
+		if flag.Description != "" {
+			writeManText(w, flag.Description)
+		}
+	}
+	for _, opt := range options {
+		label := formatOptionLabel(opt.Short, opt.Long, opt.Value)
+		fmt.Fprintf(w, ".TP\n.B %s\n", troffEscape(label))
+		if desc := optionDescription(opt); desc != "" {
+			writeManText(w, desc)
+		}
+	}
+}
+
+// writeManText writes a block of text as troff paragraphs.
+func writeManText(w io.Writer, text string) {
+	fmt.Fprintln(w, troffEscape(text))
+}
@@ -0,0 +1,166 @@
+package format
+
+import (
+	"bytes"
+	"fmt"
+	"slices"
+	"strings"
+
+	"github.com/nickawilliams/shedoc"
+)
+
+// ManPage is a rendered man page and the file name it is installed under.
+type ManPage struct {
+	File    string
+	Content []byte
+}
+
+// SplitManPages renders the man page for doc followed by a page of its own
+// for each visible subcommand, named after git's: "deploy.1" and
+// "deploy-push.1". A subcommand's page carries its full description,
+// options, operands, environment, files, and exit statuses, and the lines of
+// the document's examples that invoke it. It fails if #?/name, #?/section, or
+// a subcommand's name would make a page's file name a path.
+func SplitManPages(doc *shedoc.Document) ([]ManPage, error) {
+	return (&ManPageFormatter{}).Split(doc)
+}
+
+// Split renders the pages of SplitManPages with the settings of f, adding
+// each page's related pages to f.SeeAlso.
+func (f *ManPageFormatter) Split(doc *shedoc.Document) ([]ManPage, error) {
+	name := doc.Meta.Name
+	if name == "" {
+		name = "UNKNOWN"
+	}
+	section := doc.Meta.Section
+	if section == "" {
+		section = "1"
+	}
+
+	var subs []shedoc.Block
+	var refs []string
+	for _, sub := range doc.Subcommands() {
+		if sub.Hidden {
+			continue
+		}
+		subs = append(subs, sub)
+		refs = append(refs, name+"-"+sub.Name+"("+section+")")
+	}
+
+	var pages []ManPage
+	render := func(d *shedoc.Document, seeAlso []string) error {
+		var buf bytes.Buffer
+		file := d.Meta.Name + "." + section
+		if err := checkFileName(file); err != nil {
+			return err
+		}
+		page := *f
+		page.SeeAlso = append(slices.Clip(f.SeeAlso), seeAlso...)
+		if err := page.Format(&buf, d); err != nil {
+			return err
+		}
+		pages = append(pages, ManPage{File: file, Content: buf.Bytes()})
+		return nil
+	}
+
+	top := *doc
+	top.Meta.Name = name
+	if err := render(&top, refs); err != nil {
+		return nil, err
+	}
+	for i := range subs {
+		if err := render(subcommandManDocument(&top, &subs[i]), []string{name + "(" + section + ")"}); err != nil {
+			return nil, err
+		}
+	}
+	return pages, nil
+}
+
+// subcommandManDocument returns a document whose command block is sub, for
+// rendering sub's own man page.
+func subcommandManDocument(doc *shedoc.Document, sub *shedoc.Block) *shedoc.Document {
+	name := doc.Meta.Name
+
+	block := *sub
+	block.Visibility = shedoc.VisibilityCommand
+	block.Name = ""
+	block.Aliases = nil
+	for _, alias := range sub.Aliases {
+		block.Aliases = append(block.Aliases, name+" "+alias)
+	}
+
+	description := sub.Description
+	if sub.Deprecated != nil {
+		msg := sub.Deprecated.Message
+		if msg == "" {
+			msg = "This command is deprecated."
+		}
+		description = strings.TrimSpace("[deprecated] " + msg + "\n\n" + description)
+	}
+	if sub.Since != "" {
+		description = strings.TrimSpace(description + "\n\nSince " + sub.Since + ".")
+	}
+
+	d := &shedoc.Document{
+		Path: doc.Path,
+		Meta: shedoc.Meta{
+			Name:        name + " " + sub.Name,
+			Version:     doc.Meta.Version,
+			Description: description,
+			Examples:    subcommandExamples(doc.Meta.Examples, name, append([]string{sub.Name}, sub.Aliases...)),
+			Section:     doc.Meta.Section,
+			Author:      doc.Meta.Author,
+			License:     doc.Meta.License,
+			LicenseFile: doc.Meta.LicenseFile,
+			Lang:        doc.Meta.Lang,
+		},
+		Blocks: []shedoc.Block{block},
+	}
+	d.Meta.Synopsis = synopsisLines(d)
+	d.Meta.Name = name + "-" + sub.Name
+	return d
+}
+
+// subcommandExamples returns the lines of examples that run name with one of
+// the subcommand words, allowing a leading "$ " prompt.
+func subcommandExamples(examples, name string, words []string) string {
+	var lines []string
+	for _, line := range strings.Split(examples, "\n") {
+		fields := strings.Fields(line)
+		if len(fields) > 0 && fields[0] == "$" {
+			fields = fields[1:]
+		}
+		if len(fields) >= 2 && fields[0] == name && slices.Contains(words, fields[1]) {
+			lines = append(lines, line)
+		}
+	}
+	return strings.Join(lines, "\n")
+}
+
+// maxExampleWidth is the most characters of an example a completion
+// description shows.
+const maxExampleWidth = 40
+
+// withExample returns desc followed by the first line of examples that runs
+// name with one of the subcommand words, without its prompt and truncated to
+// maxExampleWidth: "Push a release (e.g. deploy push prod)".
+func withExample(desc, examples, name string, words []string) string {
+	ex, _, _ := strings.Cut(subcommandExamples(examples, name, words), "\n")
+	ex = strings.TrimPrefix(strings.TrimSpace(ex), "$ ")
+	if ex == "" {
+		return desc
+	}
+	if r := []rune(ex); len(r) > maxExampleWidth {
+		ex = strings.TrimSpace(string(r[:maxExampleWidth-3])) + "..."
+	}
+	return withNotes(desc, []string{"e.g. " + ex})
+}
+
+// checkFileName returns an error unless name is a plain file name: one that,
+// joined to a directory, names a file in that directory.
+func checkFileName(name string) error {
+	if name == "." || name == ".." || strings.ContainsAny(name, `/\`) {
+		return fmt.Errorf("man page file name %q is not a plain file name", name)
+	}
+	return nil
+}
@@ -0,0 +1,89 @@
+package format
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/nickawilliams/shedoc"
+)
+
+func TestSplitManPages(t *testing.T) {
+	doc := &shedoc.Document{
+		Meta: shedoc.Meta{
+			Name:     "deploy",
+			Version:  "2.1.0",
+			Examples: "deploy status production\n$ deploy p --force staging\ndeploy push-all",
+		},
+		Blocks: []shedoc.Block{
+			{Visibility: shedoc.VisibilityCommand, Flags: []shedoc.Flag{{Short: "-v", Description: "Verbose"}}},
+			{
+				Visibility:  shedoc.VisibilitySubcommand,
+				Name:        "push",
+				Aliases:     []string{"p"},
+				Description: "Deploys the application. Really.\n\nSecond paragraph.",
+				Flags:       []shedoc.Flag{{Short: "-f", Long: "--force", Description: "Skip confirmation"}},
+				Operands:    []shedoc.Operand{{Value: shedoc.Value{Name: "env", Required: true}, Description: "Target"}},
+				Env:         []shedoc.Env{{Name: "DEPLOY_TOKEN", Description: "Token"}},
+				Exit:        []shedoc.Exit{{Code: "3", Description: "Rejected"}},
+			},
+			{Visibility: shedoc.VisibilitySubcommand, Name: "secret", Hidden: true},
+			{Visibility: shedoc.VisibilitySubcommand, Name: "migrate", Deprecated: &shedoc.Deprecated{Message: "Use push."}},
+		},
+	}
+
+	pages, err := SplitManPages(doc)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var files []string
+	for _, p := range pages {
+		files = append(files, p.File)
+	}
+	if got := strings.Join(files, " "); got != "deploy.1 deploy-push.1 deploy-migrate.1" {
+		t.Fatalf("files = %q", got)
+	}
+
+	top := string(pages[0].Content)
+	if !strings.Contains(top, ".SH SEE ALSO\n\\fBdeploy\\-push\\fR(1),\n\\fBdeploy\\-migrate\\fR(1)\n") {
+		t.Errorf("top page missing SEE ALSO:\n%s", top)
+	}
+
+	push := string(pages[1].Content)
+	for _, want := range []string{
+		".TH DEPLOY\\-PUSH 1",
+		"deploy\\-push \\- Deploys the application.\n",
+		".SH SYNOPSIS\n.B deploy push [\\-f] <env>\n.br\n.B deploy p [\\-f] <env>\n",
+		"Second paragraph.",
+		".B \\-f, \\-\\-force\n",
+		".SH OPERANDS\n.TP\n.B <env>\nTarget\n",
+		".SH ENVIRONMENT\n.TP\n.B DEPLOY_TOKEN\n",
+		".SH EXIT STATUS\n.TP\n.B 3\n",
+		".SH EXAMPLES\n.EX\n$ deploy p \\-\\-force staging\n.EE\n.SH",
+		".SH SEE ALSO\n\\fBdeploy\\fR(1)\n",
+	} {
+		if !strings.Contains(push, want) {
+			t.Errorf("push page missing %q\n\nfull output:\n%s", want, push)
+		}
+	}
+	if strings.Contains(push, "\\-v") {
+		t.Errorf("push page should not list the command's own flags:\n%s", push)
+	}
+
+	if migrate := string(pages[2].Content); !strings.Contains(migrate, "deploy\\-migrate \\- [deprecated] Use push.\n") {
+		t.Errorf("migrate page missing deprecation:\n%s", migrate)
+	}
+}
+
+func TestSplitManPagesRejectsPaths(t *testing.T) {
+	for _, doc := range []*shedoc.Document{
+		{Meta: shedoc.Meta{Name: "../esc/pwned"}},
+		{Meta: shedoc.Meta{Name: "deploy", Section: "1/../../x"}},
+		{Meta: shedoc.Meta{Name: "deploy"}, Blocks: []shedoc.Block{
+			{Visibility: shedoc.VisibilitySubcommand, Name: "x/../../y"},
+		}},
+	} {
+		if _, err := SplitManPages(doc); err == nil || !strings.Contains(err.Error(), "is not a plain file name") {
+			t.Errorf("SplitManPages(%+v) err = %v, want plain file name error", doc, err)
+		}
+	}
+}
@@ -0,0 +1,383 @@
+package format
+
+import (
+	"bytes"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/nickawilliams/shedoc"
+)
+
+func TestManPageFormatter_Comprehensive(t *testing.T) {
+	doc := &shedoc.Document{
+		Meta: shedoc.Meta{
+			Name:        "deploy",
+			Version:     "2.1.0",
+			Synopsis:    []string{"deploy [-v] [-c config] <command> [args...]"},
+			Description: "A deployment tool for managing application releases.",
+			Section:     "1",
+			Author:      "Jane Developer",
+			License:     "MIT",
+			Examples:    "deploy status production\ndeploy push --force staging",
+		},
+		Blocks: []shedoc.Block{
+			{
+				Visibility:  shedoc.VisibilityCommand,
+				Description: "Manages application deployments.",
+				Flags: []shedoc.Flag{
+					{Short: "-v", Long: "--verbose", Description: "Enable verbose output"},
+				},
+				Options: []shedoc.Option{
+					{Short: "-c", Long: "--config", Value: shedoc.Value{Name: "path", Required: true}, Description: "Config file"},
+				},
+				Env: []shedoc.Env{
+					{Name: "DEPLOY_TOKEN", Description: "Authentication token"},
+				},
+				Reads: []shedoc.Reads{
+					{Path: "~/.deployrc", Description: "User configuration"},
+				},
+				Exit: []shedoc.Exit{
+					{Code: "0", Description: "Success"},
+					{Code: "1", Description: "General error"},
+				},
+			},
+			{
+				Visibility:  shedoc.VisibilitySubcommand,
+				Name:        "push",
+				Description: "Deploys the application.",
+				Flags: []shedoc.Flag{
+					{Short: "-f", Long: "--force", Description: "Skip confirmation"},
+				},
+			},
+			{
+				Visibility: shedoc.VisibilitySubcommand,
+				Name:       "migrate",
+				Deprecated: &shedoc.Deprecated{Message: "Use 'deploy push --migrate' instead."},
+			},
+		},
+	}
+
+	var buf bytes.Buffer
+	f := &ManPageFormatter{}
+	if err := f.Format(&buf, doc); err != nil {
+		t.Fatal(err)
+	}
+
+	got := buf.String()
+
+	checks := []struct {
+		label string
+		text  string
+	}{
+		{"TH header", ".TH DEPLOY 1"},
+		{"NAME section", ".SH NAME"},
+		{"name with brief", "deploy \\- A deployment tool"},
+		{"SYNOPSIS section", ".SH SYNOPSIS"},
+		{"DESCRIPTION section", ".SH DESCRIPTION"},
+		{"OPTIONS section", ".SH OPTIONS"},
+		{"verbose flag", "\\-v, \\-\\-verbose"},
+		{"config option", "\\-c, \\-\\-config"},
+		{"COMMANDS section", ".SH COMMANDS"},
+		{"push subcommand", ".B push"},
+		{"migrate deprecated", "[deprecated]"},
+		{"ENVIRONMENT section", ".SH ENVIRONMENT"},
+		{"DEPLOY_TOKEN", "DEPLOY_TOKEN"},
+		{"FILES section", ".SH FILES"},
+		{"deployrc", ".deployrc"},
+		{"EXIT STATUS section", ".SH EXIT STATUS"},
+		{"EXAMPLES section", ".SH EXAMPLES"},
+		{"AUTHOR section", ".SH AUTHOR"},
+		{"author name", "Jane Developer"},
+	}
+
+	for _, check := range checks {
+		if !strings.Contains(got, check.text) {
+			t.Errorf("[%s] output missing %q\n\nfull output:\n%s", check.label, check.text, got)
+		}
+	}
+}
+
+func TestManPageFormatter_Minimal(t *testing.T) {
+	doc := &shedoc.Document{
+		Meta: shedoc.Meta{
+			Name: "greet",
+		},
+	}
+
+	var buf bytes.Buffer
+	f := &ManPageFormatter{}
+	if err := f.Format(&buf, doc); err != nil {
+		t.Fatal(err)
+	}
+
+	got := buf.String()
+	if !strings.Contains(got, ".TH GREET 1") {
+		t.Errorf("output missing .TH header\n%s", got)
+	}
+}
+
+func TestManPageFormatter_NameFallsBackToSynopsis(t *testing.T) {
+	doc := &shedoc.Document{
+		Meta: shedoc.Meta{
+			Name:     "tool",
+			Synopsis: []string{"tool [-v] <file>", "tool --version"},
+		},
+	}
+
+	var buf bytes.Buffer
+	f := &ManPageFormatter{}
+	if err := f.Format(&buf, doc); err != nil {
+		t.Fatal(err)
+	}
+
+	want := ".SH NAME\ntool \\- tool [\\-v] <file>\n"
+	if got := buf.String(); !strings.Contains(got, want) {
+		t.Errorf("output missing %q\n%s", want, got)
+	}
+}
+
+func TestManPageFormatter_SynthesizedSynopsis(t *testing.T) {
+	doc := &shedoc.Document{
+		Meta: shedoc.Meta{Name: "deploy"},
+		Blocks: []shedoc.Block{
+			{Visibility: shedoc.VisibilityCommand, Flags: []shedoc.Flag{{Short: "-v"}}},
+			{Visibility: shedoc.VisibilitySubcommand, Name: "push"},
+		},
+	}
+
+	var buf bytes.Buffer
+	f := &ManPageFormatter{}
+	if err := f.Format(&buf, doc); err != nil {
+		t.Fatal(err)
+	}
+
+	want := ".SH SYNOPSIS\n.B deploy [\\-v] <command> [args...]\n"
+	if got := buf.String(); !strings.Contains(got, want) {
+		t.Errorf("output missing %q\n%s", want, got)
+	}
+}
+
+func TestManPageFormatter_Units(t *testing.T) {
+	doc := &shedoc.Document{
+		Meta: shedoc.Meta{Name: "wait"},
+		Blocks: []shedoc.Block{{
+			Visibility: shedoc.VisibilityCommand,
+			Options: []shedoc.Option{
+				{Long: "--timeout", Description: "Give up after this long", Value: shedoc.Value{Name: "t", Default: "30", Unit: "seconds"}},
+			},
+			Operands: []shedoc.Operand{
+				{Value: shedoc.Value{Name: "size", Required: true, Unit: "bytes"}, Description: "Size to wait for"},
+			},
+		}},
+	}
+
+	var buf bytes.Buffer
+	if err := (&ManPageFormatter{}).Format(&buf, doc); err != nil {
+		t.Fatal(err)
+	}
+	got := buf.String()
+	for _, want := range []string{
+		".B wait [\\-\\-timeout [t]] <size>\n",
+		"Give up after this long (in seconds, default: 30)\n",
+		".B <size>\nSize to wait for (in bytes)\n",
+	} {
+		if !strings.Contains(got, want) {
+			t.Errorf("output missing %q\n%s", want, got)
+		}
+	}
+}
+
+func TestManPageFormatter_Dependencies(t *testing.T) {
+	doc := &shedoc.Document{
+		Meta: shedoc.Meta{Name: "tool", Requires: []shedoc.Requires{{Command: "jq", Description: "JSON processing"}}},
+		Blocks: []shedoc.Block{
+			{Visibility: shedoc.VisibilityCommand, Requires: []shedoc.Requires{{Command: "git"}}},
+			{Visibility: shedoc.VisibilitySubcommand, Name: "push", Requires: []shedoc.Requires{{Command: "docker"}, {Command: "jq"}}},
+		},
+	}
+
+	var buf bytes.Buffer
+	if err := (&ManPageFormatter{}).Format(&buf, doc); err != nil {
+		t.Fatal(err)
+	}
+	want := ".SH DEPENDENCIES\n.TP\n.B jq\nJSON processing\n.TP\n.B git\n.TP\n.B docker\n"
+	if !strings.Contains(buf.String(), want) {
+		t.Errorf("output missing %q\n%s", want, buf.String())
+	}
+}
+
+func TestManPageFormatter_Since(t *testing.T) {
+	doc := &shedoc.Document{
+		Meta: shedoc.Meta{Name: "tool"},
+		Blocks: []shedoc.Block{
+			{Visibility: shedoc.VisibilityCommand},
+			{Visibility: shedoc.VisibilitySubcommand, Name: "push", Description: "Push a release", Since: "2.1.0"},
+		},
+	}
+
+	var buf bytes.Buffer
+	if err := (&ManPageFormatter{}).Format(&buf, doc); err != nil {
+		t.Fatal(err)
+	}
+	if want := ".B push\nPush a release\nSince 2.1.0.\n"; !strings.Contains(buf.String(), want) {
+		t.Errorf("output missing %q\n%s", want, buf.String())
+	}
+}
+
+func TestManPageFormatter_Groups(t *testing.T) {
+	doc := &shedoc.Document{
+		Meta: shedoc.Meta{Name: "tool"},
+		Blocks: []shedoc.Block{{
+			Visibility: shedoc.VisibilityCommand,
+			Flags: []shedoc.Flag{
+				{Long: "--verbose", Description: "Verbose"},
+				{Long: "--tls", Description: "Use TLS", Group: "Connection"},
+			},
+		}},
+	}
+
+	var buf bytes.Buffer
+	if err := (&ManPageFormatter{}).Format(&buf, doc); err != nil {
+		t.Fatal(err)
+	}
+	got := buf.String()
+	verbose := strings.Index(got, "\\-\\-verbose\n")
+	group := strings.Index(got, ".SS Connection\n")
+	tls := strings.Index(got, "\\-\\-tls\n")
+	if verbose < 0 || group < 0 || tls < 0 || !(verbose < group && group < tls) {
+		t.Errorf("want --verbose, then .SS Connection, then --tls\n%s", got)
+	}
+}
+
+func TestManPageFormatter_MultiLineSynopsis(t *testing.T) {
+	doc := &shedoc.Document{
+		Meta: shedoc.Meta{
+			Name:     "tool",
+			Synopsis: []string{"tool [-v] <file>", "tool --version"},
+		},
+	}
+
+	var buf bytes.Buffer
+	f := &ManPageFormatter{}
+	if err := f.Format(&buf, doc); err != nil {
+		t.Fatal(err)
+	}
+
+	want := ".SH SYNOPSIS\n.B tool [\\-v] <file>\n.br\n.B tool \\-\\-version\n"
+	if got := buf.String(); !strings.Contains(got, want) {
+		t.Errorf("output missing %q\n%s", want, got)
+	}
+}
+
+func TestManPageFormatter_LicenseFile(t *testing.T) {
+	dir := t.TempDir()
+	if err := os.WriteFile(filepath.Join(dir, "LICENSE"), []byte("MIT License\n\n.Permission is granted\n"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	doc := &shedoc.Document{
+		Path: filepath.Join(dir, "tool.sh"),
+		Meta: shedoc.Meta{Name: "tool", LicenseFile: "LICENSE"},
+	}
+
+	var buf bytes.Buffer
+	f := &ManPageFormatter{}
+	if err := f.Format(&buf, doc); err != nil {
+		t.Fatal(err)
+	}
+
+	want := ".SH LICENSE\n.nf\nMIT License\n\n\\&.Permission is granted\n.fi\n"
+	if got := buf.String(); !strings.HasSuffix(got, want) {
+		t.Errorf("output does not end with %q\n%s", want, got)
+	}
+
+	doc.Meta.LicenseFile = "MISSING"
+	if err := f.Format(&bytes.Buffer{}, doc); err == nil {
+		t.Error("expected error for missing license file")
+	}
+}
+
+func TestManPageFormatter_DeprecatedEmptyMessage(t *testing.T) {
+	doc := &shedoc.Document{
+		Meta: shedoc.Meta{Name: "tool"},
+		Blocks: []shedoc.Block{
+			{Visibility: shedoc.VisibilityCommand},
+			{
+				Visibility: shedoc.VisibilitySubcommand,
+				Name:       "old",
+				Deprecated: &shedoc.Deprecated{Message: ""},
+			},
+		},
+	}
+
+	var buf bytes.Buffer
+	f := &ManPageFormatter{}
+	if err := f.Format(&buf, doc); err != nil {
+		t.Fatal(err)
+	}
+
+	got := buf.String()
+	if !strings.Contains(got, "This command is deprecated.") {
+		t.Errorf("missing default deprecated message\n%s", got)
+	}
+}
+
+func TestManPageFormatter_Examples(t *testing.T) {
+	doc := &shedoc.Document{Meta: shedoc.Meta{
+		Name:     "tool",
+		Examples: "# Deploy, then check\ntool push \\\n  --force\n.hidden\n",
+	}}
+
+	var buf bytes.Buffer
+	if err := (&ManPageFormatter{}).Format(&buf, doc); err != nil {
+		t.Fatal(err)
+	}
+
+	want := ".SH EXAMPLES\n.EX\n# Deploy, then check\ntool push \\\\\n  \\-\\-force\n\\&.hidden\n.EE\n"
+	if got := buf.String(); !strings.Contains(got, want) {
+		t.Errorf("output missing %q\n%s", want, got)
+	}
+}
+
+func TestManPageFormatter_SeeAlso(t *testing.T) {
+	doc := &shedoc.Document{
+		Meta: shedoc.Meta{Name: "tool", SeeAlso: []string{"git(1)"}},
+		Blocks: []shedoc.Block{{
+			Visibility: shedoc.VisibilityCommand,
+			See:        []string{"tool.conf(5)", "git(1)"},
+		}},
+	}
+
+	var buf bytes.Buffer
+	f := &ManPageFormatter{SeeAlso: []string{"tool-run(1)"}}
+	if err := f.Format(&buf, doc); err != nil {
+		t.Fatal(err)
+	}
+
+	want := ".SH SEE ALSO\n\\fBgit\\fR(1),\n\\fBtool.conf\\fR(5),\n\\fBtool\\-run\\fR(1)\n"
+	if got := buf.String(); !strings.HasSuffix(got, want) {
+		t.Errorf("output does not end with %q\n%s", want, got)
+	}
+}
+
+func TestTroffEscape(t *testing.T) {
+	tests := []struct {
+		input string
+		want  string
+	}{
+		{"--verbose", "\\-\\-verbose"},
+		{"-v", "\\-v"},
+		{"plain text", "plain text"},
+		{"back\\slash", "back\\\\slash"},
+		{"Größe", "Gr\\[u00F6]\\[u00DF]e"},
+		{"日本 😀", "\\[u65E5]\\[u672C] \\[u1F600]"},
+	}
+
+	for _, tt := range tests {
+		got := troffEscape(tt.input)
+		if got != tt.want {
+			t.Errorf("troffEscape(%q) = %q, want %q", tt.input, got, tt.want)
+		}
+	}
+}
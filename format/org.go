@@ -0,0 +1,262 @@
+package format
+
+import (
+	"fmt"
+	"io"
+	"strings"
+
+	"github.com/nickawilliams/shedoc"
+)
+
+func init() {
+	shedoc.RegisterFormatter("org", &OrgFormatter{})
+}
+
+// OrgFormatter generates an Emacs Org-mode document: file metadata as
+// keywords and a property drawer, then one heading per block with its own
+// property drawer and a subheading per kind of tag.
+type OrgFormatter struct{}
+
+func (f *OrgFormatter) Format(w io.Writer, doc *shedoc.Document) error {
+	name := doc.Meta.Name
+	if name == "" {
+		name = "UNKNOWN"
+	}
+
+	fmt.Fprintf(w, "#+TITLE: %s\n", name)
+	if doc.Meta.Author != "" {
+		fmt.Fprintf(w, "#+AUTHOR: %s\n", firstLine(doc.Meta.Author))
+	}
+	if doc.Meta.Lang != "" {
+		fmt.Fprintf(w, "#+LANGUAGE: %s\n", doc.Meta.Lang)
+	}
+	fmt.Fprintln(w)
+
+	// Top-level heading for the script
+	fmt.Fprintf(w, "* %s\n", name)
+	writeOrgProperties(w, [][2]string{
+		{"VERSION", doc.Meta.Version},
+		{"SECTION", doc.Meta.Section},
+		{"LICENSE", doc.Meta.License},
+		{"LICENSE_FILE", doc.Meta.LicenseFile},
+		{"SHEBANG", doc.Shebang},
+	})
+	if doc.Meta.Description != "" {
+		writeOrgText(w, doc.Meta.Description)
+	}
+
+	if len(doc.Meta.Synopsis) > 0 {
+		fmt.Fprintln(w, "** Synopsis")
+		writeOrgExample(w, strings.Join(doc.Meta.Synopsis, "\n"))
+	}
+	if doc.Meta.Examples != "" {
+		fmt.Fprintln(w, "** Examples")
+		writeOrgExample(w, doc.Meta.Examples)
+	}
+	writeOrgList(w, "**", "Dependencies", orgRequires(doc.Meta.Requires))
+
+	depths := blockDepths(doc)
+	for i := range doc.Blocks {
+		writeOrgBlock(w, &doc.Blocks[i], depths[i])
+	}
+
+	return nil
+}
+
+// writeOrgBlock writes a block as a heading nested depth levels below the
+// script's second-level headings.
+func writeOrgBlock(w io.Writer, b *shedoc.Block, depth int) {
+	stars := strings.Repeat("*", 2+depth)
+	var heading string
+	switch {
+	case b.Visibility == shedoc.VisibilityCommand:
+		heading = "Command"
+	case b.Name != "":
+		heading = b.Name
+	case b.FunctionName != "":
+		heading = b.FunctionName
+	default:
+		heading = fmt.Sprintf("Block at line %d", b.Line)
+	}
+
+	var deprecated, hidden, readonly string
+	if b.Deprecated != nil {
+		deprecated = b.Deprecated.Message
+		if deprecated == "" {
+			deprecated = "t"
+		}
+	}
+	if b.Hidden {
+		hidden = "t"
+	}
+	if b.Readonly {
+		readonly = "t"
+	}
+
+	fmt.Fprintf(w, "%s %s\n", stars, heading)
+	writeOrgProperties(w, [][2]string{
+		{"VISIBILITY", string(b.Visibility)},
+		{"FUNCTION", b.FunctionName},
+		{"DEFAULT", b.Default},
+		{"READONLY", readonly},
+		{"ALIASES", strings.Join(b.Aliases, " ")},
+		{"HIDDEN", hidden},
+		{"SINCE", b.Since},
+		{"DEPRECATED", deprecated},
+		{"LINE", fmt.Sprint(b.Line)},
+	})
+	if b.Description != "" {
+		writeOrgText(w, b.Description)
+	}
+
+	var items []orgItem
+	for _, f := range b.Flags {
+		items = append(items, orgItem{orgCode(f.Short, f.Long), orgHidden(f.Description, f.Hidden)})
+	}
+	for _, o := range b.Options {
+		term := orgCode(o.Short, o.Long) + " " + orgCode(o.Value.String())
+		items = append(items, orgItem{term, orgHidden(o.Description, o.Hidden)})
+	}
+	writeOrgList(w, stars+"*", "Options", items)
+
+	items = nil
+	for _, o := range b.Operands {
+		items = append(items, orgItem{orgCode(o.Value.String()), o.Description})
+	}
+	writeOrgList(w, stars+"*", "Operands", items)
+
+	items = nil
+	for _, e := range b.Env {
+		items = append(items, orgItem{orgCode(e.Name), envDescription(e)})
+	}
+	writeOrgList(w, stars+"*", "Environment", items)
+	writeOrgList(w, stars+"*", "Dependencies", orgRequires(b.Requires))
+
+	items = nil
+	for _, r := range b.Reads {
+		items = append(items, orgItem{orgCode(r.Path) + " (read)", r.Description})
+	}
+	for _, wr := range b.Writes {
+		items = append(items, orgItem{orgCode(wr.Path) + " (written)", wr.Description})
+	}
+	writeOrgList(w, stars+"*", "Files", items)
+
+	items = nil
+	if b.Stdin != nil {
+		items = append(items, orgItem{"standard input", b.Stdin.Description})
+	}
+	if b.Stdout != nil {
+		items = append(items, orgItem{"standard output", b.Stdout.Description})
+	}
+	if b.Stderr != nil {
+		items = append(items, orgItem{"standard error", b.Stderr.Description})
+	}
+	for _, s := range b.Sets {
+		items = append(items, orgItem{orgCode(s.Name) + " (set)", s.Description})
+	}
+	writeOrgList(w, stars+"*", "Input and Output", items)
+
+	items = nil
+	for _, e := range b.Exit {
+		items = append(items, orgItem{orgCode(e.Code), e.Description})
+	}
+	writeOrgList(w, stars+"*", "Exit Status", items)
+
+	items = nil
+	for _, r := range b.Return {
+		items = append(items, orgItem{orgCode(r.Code), r.Description})
+	}
+	writeOrgList(w, stars+"*", "Returns", items)
+}
+
+// orgItem is an entry in an Org description list.
+type orgItem struct {
+	term, description string
+}
+
+// writeOrgList writes a subheading, with the given stars, holding a
+// description list, if there are any items.
+func writeOrgList(w io.Writer, stars, heading string, items []orgItem) {
+	if len(items) == 0 {
+		return
+	}
+	fmt.Fprintf(w, "%s %s\n", stars, heading)
+	for _, it := range items {
+		if it.description == "" {
+			fmt.Fprintf(w, "- %s\n", it.term)
+		} else {
+			fmt.Fprintf(w, "- %s :: %s\n", it.term, it.description)
+		}
+	}
+}
+
+// writeOrgProperties writes a property drawer with the non-empty properties.
+func writeOrgProperties(w io.Writer, props [][2]string) {
+	var set [][2]string
+	for _, p := range props {
+		if p[1] != "" {
+			set = append(set, p)
+		}
+	}
+	if len(set) == 0 {
+		return
+	}
+	fmt.Fprintln(w, ":PROPERTIES:")
+	for _, p := range set {
+		fmt.Fprintf(w, ":%s: %s\n", p[0], firstLine(p[1]))
+	}
+	fmt.Fprintln(w, ":END:")
+}
+
+// writeOrgText writes paragraph text, guarding lines that Org would read as
+// headings or keywords.
+func writeOrgText(w io.Writer, text string) {
+	for _, line := range strings.Split(text, "\n") {
+		if strings.HasPrefix(line, "*") || strings.HasPrefix(line, "#+") {
+			line = " " + line
+		}
+		fmt.Fprintln(w, line)
+	}
+}
+
+// writeOrgExample writes text in an example block, comma-escaping lines that
+// would otherwise end the block or start a heading.
+func writeOrgExample(w io.Writer, text string) {
+	fmt.Fprintln(w, "#+begin_example")
+	for _, line := range strings.Split(text, "\n") {
+		if strings.HasPrefix(line, "*") || strings.HasPrefix(line, "#+") || strings.HasPrefix(line, ",*") || strings.HasPrefix(line, ",#+") {
+			line = "," + line
+		}
+		fmt.Fprintln(w, line)
+	}
+	fmt.Fprintln(w, "#+end_example")
+}
+
+// orgCode marks up each non-empty value as verbatim and joins them with
+// commas.
+func orgCode(values ...string) string {
+	var parts []string
+	for _, v := range values {
+		if v != "" {
+			parts = append(parts, "="+v+"=")
+		}
+	}
+	return strings.Join(parts, ", ")
+}
+
+// orgHidden marks the description of a hidden flag or option.
+func orgHidden(desc string, hidden bool) string {
+	if !hidden {
+		return desc
+	}
+	return strings.TrimSpace("(hidden) " + desc)
+}
+
+// orgRequires returns the list items for requirements.
+func orgRequires(reqs []shedoc.Requires) []orgItem {
+	var items []orgItem
+	for _, r := range reqs {
+		items = append(items, orgItem{orgCode(r.Command), r.Description})
+	}
+	return items
+}
@@ -0,0 +1,57 @@
+package format
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+
+	"github.com/nickawilliams/shedoc"
+)
+
+func TestOrgFormatter(t *testing.T) {
+	doc := &shedoc.Document{
+		Meta: shedoc.Meta{
+			Name:        "deploy",
+			Version:     "2.1.0",
+			Description: "Deploys things.\n* not a heading",
+			Examples:    "deploy push\n#+end_example",
+		},
+		Blocks: []shedoc.Block{
+			{
+				Visibility: shedoc.VisibilityCommand,
+				Line:       3,
+				Flags:      []shedoc.Flag{{Short: "-v", Long: "--verbose", Description: "Verbose"}},
+				Exit:       []shedoc.Exit{{Code: "0", Description: "Success"}},
+			},
+			{
+				Visibility:   shedoc.VisibilitySubcommand,
+				Name:         "migrate",
+				FunctionName: "cmd_migrate",
+				Line:         9,
+				Deprecated:   &shedoc.Deprecated{Message: "Use push."},
+				Operands:     []shedoc.Operand{{Value: shedoc.Value{Name: "env", Required: true}}},
+			},
+		},
+	}
+
+	var buf bytes.Buffer
+	if err := (&OrgFormatter{}).Format(&buf, doc); err != nil {
+		t.Fatal(err)
+	}
+	got := buf.String()
+
+	for _, want := range []string{
+		"#+TITLE: deploy\n",
+		"* deploy\n:PROPERTIES:\n:VERSION: 2.1.0\n:END:\nDeploys things.\n * not a heading\n",
+		"** Examples\n#+begin_example\ndeploy push\n,#+end_example\n#+end_example\n",
+		"** Command\n:PROPERTIES:\n:VISIBILITY: command\n:LINE: 3\n:END:\n",
+		"*** Options\n- =-v=, =--verbose= :: Verbose\n",
+		"*** Exit Status\n- =0= :: Success\n",
+		"** migrate\n:PROPERTIES:\n:VISIBILITY: subcommand\n:FUNCTION: cmd_migrate\n:DEPRECATED: Use push.\n:LINE: 9\n:END:\n",
+		"*** Operands\n- =<env>=\n",
+	} {
+		if !strings.Contains(got, want) {
+			t.Errorf("output missing %q\n\nfull output:\n%s", want, got)
+		}
+	}
+}
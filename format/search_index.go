@@ -0,0 +1,101 @@
+package format
+
+import (
+	"encoding/json"
+	"io"
+	"strings"
+
+	"github.com/nickawilliams/shedoc"
+)
+
+// SearchIndex is a JSON index of a suite of documents for client-side
+// search, such as with Lunr or Pagefind, over pages rendered from them by
+// the html format or a template.
+type SearchIndex struct {
+	Documents []SearchDocument `json:"documents"`
+}
+
+// SearchDocument is the index entry for one script.
+type SearchDocument struct {
+	Name        string        `json:"name"`
+	Path        string        `json:"path,omitempty"`
+	Description string        `json:"description,omitempty"`
+	Keywords    []string      `json:"keywords,omitempty"`
+	Blocks      []SearchBlock `json:"blocks,omitempty"`
+}
+
+// SearchBlock is the index entry for one block. Anchor is the fragment
+// identifier of the block's section in html output.
+type SearchBlock struct {
+	Anchor      string   `json:"anchor"`
+	Heading     string   `json:"heading"`
+	Visibility  string   `json:"visibility"`
+	Description string   `json:"description,omitempty"`
+	Keywords    []string `json:"keywords,omitempty"`
+	Line        int      `json:"line"`
+}
+
+// BuildSearchIndex returns the search index of docs. Keywords are the names
+// a reader might search for: the script's name, and each block's name,
+// function, aliases, flags and options, operands, and environment
+// variables.
+func BuildSearchIndex(docs []*shedoc.Document) *SearchIndex {
+	index := &SearchIndex{Documents: []SearchDocument{}}
+	for _, doc := range docs {
+		d := SearchDocument{
+			Name:        doc.Meta.Name,
+			Path:        doc.Path,
+			Description: strings.TrimSpace(doc.Meta.Description),
+		}
+		if d.Name != "" {
+			d.Keywords = append(d.Keywords, d.Name)
+		}
+		for i := range doc.Blocks {
+			d.Blocks = append(d.Blocks, searchBlock(&doc.Blocks[i]))
+		}
+		index.Documents = append(index.Documents, d)
+	}
+	return index
+}
+
+func searchBlock(b *shedoc.Block) SearchBlock {
+	var keywords []string
+	add := func(words ...string) {
+		for _, w := range words {
+			if w != "" {
+				keywords = append(keywords, w)
+			}
+		}
+	}
+	add(b.Name, b.FunctionName)
+	add(b.Aliases...)
+	for _, fl := range b.Flags {
+		add(fl.Short, fl.Long)
+	}
+	for _, o := range b.Options {
+		add(o.Short, o.Long)
+	}
+	for _, op := range b.Operands {
+		add(op.Value.Name)
+	}
+	for _, e := range b.Env {
+		add(e.Name)
+	}
+
+	return SearchBlock{
+		Anchor:      blockAnchor(b),
+		Heading:     blockHeading(b),
+		Visibility:  string(b.Visibility),
+		Description: strings.TrimSpace(b.Description),
+		Keywords:    keywords,
+		Line:        b.Line,
+	}
+}
+
+// WriteSearchIndex writes the search index of docs to w as JSON.
+func WriteSearchIndex(w io.Writer, docs []*shedoc.Document) error {
+	enc := json.NewEncoder(w)
+	enc.SetEscapeHTML(false)
+	enc.SetIndent("", "  ")
+	return enc.Encode(BuildSearchIndex(docs))
+}
@@ -0,0 +1,62 @@
+package format
+
+import (
+	"bytes"
+	"encoding/json"
+	"slices"
+	"testing"
+
+	"github.com/nickawilliams/shedoc"
+)
+
+func TestBuildSearchIndex(t *testing.T) {
+	doc := &shedoc.Document{
+		Path: "bin/deploy.sh",
+		Meta: shedoc.Meta{Name: "deploy", Description: "Deploy things.\nAnywhere."},
+		Blocks: []shedoc.Block{{
+			Visibility:  shedoc.VisibilitySubcommand,
+			Name:        "push",
+			Description: "Push a release.",
+			Aliases:     []string{"p"},
+			Flags:       []shedoc.Flag{{Short: "-f", Long: "--force"}},
+			Options:     []shedoc.Option{{Long: "--tag", Value: shedoc.Value{Name: "tag"}}},
+			Operands:    []shedoc.Operand{{Value: shedoc.Value{Name: "environment", Required: true}}},
+			Env:         []shedoc.Env{{Name: "DEPLOY_TOKEN"}},
+			Line:        12,
+		}},
+	}
+
+	index := BuildSearchIndex([]*shedoc.Document{doc})
+	if len(index.Documents) != 1 {
+		t.Fatalf("documents = %+v", index.Documents)
+	}
+	d := index.Documents[0]
+	if d.Name != "deploy" || d.Path != "bin/deploy.sh" || d.Description != "Deploy things.\nAnywhere." {
+		t.Errorf("document = %+v", d)
+	}
+	if len(d.Blocks) != 1 {
+		t.Fatalf("blocks = %+v", d.Blocks)
+	}
+	b := d.Blocks[0]
+	if b.Anchor != "sub-push" || b.Heading != "push" || b.Visibility != "subcommand" || b.Line != 12 {
+		t.Errorf("block = %+v", b)
+	}
+	want := []string{"push", "p", "-f", "--force", "--tag", "environment", "DEPLOY_TOKEN"}
+	if !slices.Equal(b.Keywords, want) {
+		t.Errorf("keywords = %q, want %q", b.Keywords, want)
+	}
+}
+
+func TestWriteSearchIndex_Empty(t *testing.T) {
+	var buf bytes.Buffer
+	if err := WriteSearchIndex(&buf, nil); err != nil {
+		t.Fatal(err)
+	}
+	var got map[string]any
+	if err := json.Unmarshal(buf.Bytes(), &got); err != nil {
+		t.Fatal(err)
+	}
+	if docs, ok := got["documents"].([]any); !ok || len(docs) != 0 {
+		t.Errorf("documents = %v, want []", got["documents"])
+	}
+}
@@ -0,0 +1,149 @@
+package format
+
+import (
+	"fmt"
+	"io"
+	"os"
+	"strings"
+	"unicode/utf8"
+
+	"github.com/nickawilliams/shedoc"
+)
+
+func init() {
+	shedoc.RegisterFormatter("table", &TableFormatter{})
+}
+
+// TableFormatter prints bordered tables of a document's flags, options, and
+// subcommands for reading in a terminal. Borders use box-drawing characters
+// when the locale is UTF-8 and ASCII otherwise, unless ASCII is set.
+type TableFormatter struct {
+	ASCII bool
+}
+
+// tableBorders holds the characters used to draw a table.
+type tableBorders struct {
+	horizontal, vertical               string
+	topLeft, topMid, topRight          string
+	midLeft, midMid, midRight          string
+	bottomLeft, bottomMid, bottomRight string
+}
+
+var (
+	unicodeBorders = tableBorders{"─", "│", "┌", "┬", "┐", "├", "┼", "┤", "└", "┴", "┘"}
+	asciiBorders   = tableBorders{"-", "|", "+", "+", "+", "+", "+", "+", "+", "+", "+"}
+)
+
+func (f *TableFormatter) Format(w io.Writer, doc *shedoc.Document) error {
+	borders := asciiBorders
+	if !f.ASCII && localeIsUTF8() {
+		borders = unicodeBorders
+	}
+
+	var flagRows, subRows [][]string
+	for _, b := range doc.Blocks {
+		var command string
+		switch b.Visibility {
+		case shedoc.VisibilityCommand:
+		case shedoc.VisibilitySubcommand:
+			command = b.Name
+			subRows = append(subRows, []string{b.Name, strings.Join(b.Aliases, ", "), tableNote(firstLine(b.Description), b.Hidden, b.Deprecated)})
+		default:
+			continue
+		}
+		for _, fl := range b.Flags {
+			flagRows = append(flagRows, []string{command, joinCommentNames(fl.Short, fl.Long), "", tableNote(firstLine(fl.Description), fl.Hidden, nil)})
+		}
+		for _, o := range b.Options {
+			flagRows = append(flagRows, []string{command, joinCommentNames(o.Short, o.Long), o.Value.String(), tableNote(firstLine(o.Description), o.Hidden, nil)})
+		}
+	}
+
+	var tables []string
+	if len(flagRows) > 0 {
+		tables = append(tables, renderTable("Flags and options", []string{"Command", "Flag", "Value", "Description"}, flagRows, borders))
+	}
+	if len(subRows) > 0 {
+		tables = append(tables, renderTable("Subcommands", []string{"Name", "Aliases", "Description"}, subRows, borders))
+	}
+	_, err := io.WriteString(w, strings.Join(tables, "\n"))
+	return err
+}
+
+// tableNote prefixes desc with the hidden and deprecated markers that apply.
+func tableNote(desc string, hidden bool, deprecated *shedoc.Deprecated) string {
+	if deprecated != nil {
+		if desc == "" {
+			desc = deprecated.Message
+		}
+		desc = strings.TrimSpace("[deprecated] " + desc)
+	}
+	if hidden {
+		desc = strings.TrimSpace("[hidden] " + desc)
+	}
+	return desc
+}
+
+// renderTable draws a titled table. Columns that are empty in every row are
+// omitted.
+func renderTable(title string, header []string, rows [][]string, b tableBorders) string {
+	var cols []int
+	for c := range header {
+		for _, row := range rows {
+			if row[c] != "" {
+				cols = append(cols, c)
+				break
+			}
+		}
+	}
+
+	widths := make([]int, len(header))
+	for _, c := range cols {
+		widths[c] = utf8.RuneCountInString(header[c])
+		for _, row := range rows {
+			widths[c] = max(widths[c], utf8.RuneCountInString(row[c]))
+		}
+	}
+
+	var sb strings.Builder
+	rule := func(left, mid, right string) {
+		sb.WriteString(left)
+		for i, c := range cols {
+			if i > 0 {
+				sb.WriteString(mid)
+			}
+			sb.WriteString(strings.Repeat(b.horizontal, widths[c]+2))
+		}
+		sb.WriteString(right + "\n")
+	}
+	line := func(cells []string) {
+		sb.WriteString(b.vertical)
+		for _, c := range cols {
+			pad := widths[c] - utf8.RuneCountInString(cells[c])
+			sb.WriteString(" " + cells[c] + strings.Repeat(" ", pad) + " " + b.vertical)
+		}
+		sb.WriteString("\n")
+	}
+
+	fmt.Fprintf(&sb, "%s:\n", title)
+	rule(b.topLeft, b.topMid, b.topRight)
+	line(header)
+	rule(b.midLeft, b.midMid, b.midRight)
+	for _, row := range rows {
+		line(row)
+	}
+	rule(b.bottomLeft, b.bottomMid, b.bottomRight)
+	return sb.String()
+}
+
+// localeIsUTF8 reports whether the locale, from the first of LC_ALL,
+// LC_CTYPE, and LANG that is set, uses UTF-8.
+func localeIsUTF8() bool {
+	for _, name := range []string{"LC_ALL", "LC_CTYPE", "LANG"} {
+		if v := os.Getenv(name); v != "" {
+			v = strings.ToLower(v)
+			return strings.Contains(v, "utf-8") || strings.Contains(v, "utf8")
+		}
+	}
+	return false
+}
@@ -0,0 +1,103 @@
+package format
+
+import (
+	"bytes"
+	"testing"
+
+	"github.com/nickawilliams/shedoc"
+)
+
+func tableTestDoc() *shedoc.Document {
+	return &shedoc.Document{
+		Blocks: []shedoc.Block{
+			{
+				Visibility: shedoc.VisibilityCommand,
+				Flags:      []shedoc.Flag{{Short: "-v", Long: "--verbose", Description: "Verbose"}},
+			},
+			{
+				Visibility:  shedoc.VisibilitySubcommand,
+				Name:        "push",
+				Description: "Deploys",
+				Options:     []shedoc.Option{{Long: "--tag", Value: shedoc.Value{Name: "v"}, Hidden: true}},
+			},
+		},
+	}
+}
+
+func TestTableFormatter(t *testing.T) {
+	tests := []struct {
+		name   string
+		lang   string
+		format *TableFormatter
+		want   string
+	}{
+		{
+			name:   "ascii locale",
+			lang:   "C",
+			format: &TableFormatter{},
+			want: `Flags and options:
++---------+----------------+-------+-------------+
+| Command | Flag           | Value | Description |
++---------+----------------+-------+-------------+
+|         | -v | --verbose |       | Verbose     |
+| push    | --tag          | [v]   | [hidden]    |
++---------+----------------+-------+-------------+
+
+Subcommands:
++------+-------------+
+| Name | Description |
++------+-------------+
+| push | Deploys     |
++------+-------------+
+`,
+		},
+		{
+			name:   "utf-8 locale",
+			lang:   "en_US.UTF-8",
+			format: &TableFormatter{},
+			want: `Flags and options:
+┌─────────┬────────────────┬───────┬─────────────┐
+│ Command │ Flag           │ Value │ Description │
+├─────────┼────────────────┼───────┼─────────────┤
+│         │ -v | --verbose │       │ Verbose     │
+│ push    │ --tag          │ [v]   │ [hidden]    │
+└─────────┴────────────────┴───────┴─────────────┘
+
+Subcommands:
+┌──────┬─────────────┐
+│ Name │ Description │
+├──────┼─────────────┤
+│ push │ Deploys     │
+└──────┴─────────────┘
+`,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Setenv("LC_ALL", "")
+			t.Setenv("LC_CTYPE", "")
+			t.Setenv("LANG", tt.lang)
+
+			var buf bytes.Buffer
+			if err := tt.format.Format(&buf, tableTestDoc()); err != nil {
+				t.Fatal(err)
+			}
+			if got := buf.String(); got != tt.want {
+				t.Errorf("got:\n%s\nwant:\n%s", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestTableFormatterForcedASCII(t *testing.T) {
+	t.Setenv("LANG", "en_US.UTF-8")
+
+	var buf bytes.Buffer
+	if err := (&TableFormatter{ASCII: true}).Format(&buf, tableTestDoc()); err != nil {
+		t.Fatal(err)
+	}
+	if bytes.ContainsRune(buf.Bytes(), '│') {
+		t.Errorf("expected ASCII borders, got:\n%s", buf.String())
+	}
+}
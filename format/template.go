@@ -0,0 +1,69 @@
+package format
+
+import (
+	"errors"
+	"io"
+	"strings"
+	"text/template"
+
+	"github.com/nickawilliams/shedoc"
+)
+
+func init() {
+	shedoc.RegisterFormatter("template", &TemplateFormatter{})
+}
+
+// TemplateFormatter executes a text/template against the Document, for
+// bespoke output that none of the built-in formats produce. The template is
+// given the *shedoc.Document as its data and may call the helpers in
+// TemplateFuncs.
+//
+//	{{.Meta.Name}} - {{firstLine .Meta.Description}}
+//	{{range .Blocks}}{{range .Flags}}  {{flagLabel .}}
+//	{{end}}{{end}}
+type TemplateFormatter struct {
+	// Template is the parsed template; see ParseTemplate.
+	Template *template.Template
+}
+
+// ParseTemplate returns a TemplateFormatter for the template text, with
+// TemplateFuncs available to it. The name is used in error messages.
+func ParseTemplate(name, text string) (*TemplateFormatter, error) {
+	tmpl, err := template.New(name).Funcs(TemplateFuncs()).Parse(text)
+	if err != nil {
+		return nil, err
+	}
+	return &TemplateFormatter{Template: tmpl}, nil
+}
+
+func (f *TemplateFormatter) Format(w io.Writer, doc *shedoc.Document) error {
+	if f.Template == nil {
+		return errors.New("template format: no template given")
+	}
+	return f.Template.Execute(w, doc)
+}
+
+// TemplateFuncs returns the helpers available to templates: the ones the
+// built-in formats use to render values, labels, and descriptions, plus a
+// few string functions.
+func TemplateFuncs() template.FuncMap {
+	return template.FuncMap{
+		"firstLine":         firstLine,
+		"formatValue":       formatValue,
+		"flagLabel":         func(fl shedoc.Flag) string { return formatFlagLabel(fl.Short, fl.Long) },
+		"optionLabel":       func(o shedoc.Option) string { return formatOptionLabel(o.Short, o.Long, o.Value) },
+		"optionDescription": optionDescription,
+		"synopsis":          synopsisLines,
+		"brief":             manBrief,
+		"anchor":            func(b shedoc.Block) string { return blockAnchor(&b) },
+		"heading":           func(b shedoc.Block) string { return blockHeading(&b) },
+		"join":              strings.Join,
+		"lower":             strings.ToLower,
+		"upper":             strings.ToUpper,
+		"trim":              strings.TrimSpace,
+		"indent": func(n int, s string) string {
+			pad := strings.Repeat(" ", n)
+			return pad + strings.ReplaceAll(s, "\n", "\n"+pad)
+		},
+	}
+}
@@ -0,0 +1,73 @@
+package format
+
+import (
+	"bytes"
+	"testing"
+
+	"github.com/nickawilliams/shedoc"
+)
+
+func TestTemplateFormatter(t *testing.T) {
+	doc := &shedoc.Document{
+		Meta: shedoc.Meta{Name: "deploy", Description: "Deploy releases.\nMore detail."},
+		Blocks: []shedoc.Block{{
+			Visibility: shedoc.VisibilityCommand,
+			Flags:      []shedoc.Flag{{Short: "-v", Long: "--verbose"}},
+			Options: []shedoc.Option{{
+				Long:        "--env",
+				Value:       shedoc.Value{Name: "name", Default: "prod"},
+				Description: "Target",
+			}},
+		}},
+	}
+
+	tests := []struct {
+		name string
+		text string
+		want string
+	}{
+		{
+			name: "fields",
+			text: "{{.Meta.Name}}: {{firstLine .Meta.Description}}\n",
+			want: "deploy: Deploy releases.\n",
+		},
+		{
+			name: "labels",
+			text: "{{range .Blocks}}{{range .Flags}}{{flagLabel .}}\n{{end}}{{range .Options}}{{optionLabel .}} {{optionDescription .}}\n{{end}}{{end}}",
+			want: "-v, --verbose\n    --env [name=prod] Target (default: prod)\n",
+		},
+		{
+			name: "string helpers",
+			text: `{{upper .Meta.Name}} {{join (synopsis .) "|"}}{{"\n"}}{{indent 2 "a\nb"}}`,
+			want: "DEPLOY deploy [-v] [--env [name]]\n  a\n  b",
+		},
+		{
+			name: "blocks",
+			text: `{{range .Blocks}}{{anchor .}} {{heading .}}{{end}}`,
+			want: "command Command",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			f, err := ParseTemplate(tt.name, tt.text)
+			if err != nil {
+				t.Fatal(err)
+			}
+			var buf bytes.Buffer
+			if err := f.Format(&buf, doc); err != nil {
+				t.Fatal(err)
+			}
+			if got := buf.String(); got != tt.want {
+				t.Errorf("got:\n%s\nwant:\n%s", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestTemplateFormatter_NoTemplate(t *testing.T) {
+	f := &TemplateFormatter{}
+	if err := f.Format(&bytes.Buffer{}, &shedoc.Document{}); err == nil {
+		t.Error("expected an error without a template")
+	}
+}
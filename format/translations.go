@@ -0,0 +1,32 @@
+package format
+
+import (
+	"fmt"
+	"io"
+
+	"github.com/nickawilliams/shedoc"
+)
+
+func init() {
+	shedoc.RegisterFormatter("translations", &TranslationsFormatter{})
+}
+
+// TranslationsFormatter writes a translation catalog holding every
+// translatable text in the document, for use with --translations once the
+// values are translated.
+type TranslationsFormatter struct{}
+
+func (f *TranslationsFormatter) Format(w io.Writer, doc *shedoc.Document) error {
+	name := doc.Meta.Name
+	if name == "" {
+		name = "this script"
+	}
+	fmt.Fprintf(w, "# Translation catalog for %s, generated by shedoc.\n", name)
+	if doc.Meta.Lang != "" {
+		fmt.Fprintf(w, "# Source language: %s. Add a lang entry and translate each value.\n", doc.Meta.Lang)
+	} else {
+		fmt.Fprintln(w, "# Add a lang entry and translate each value.")
+	}
+	fmt.Fprintln(w)
+	return shedoc.WriteCatalog(w, shedoc.Translations(doc))
+}
@@ -0,0 +1,34 @@
+package format
+
+import (
+	"bytes"
+	"testing"
+
+	"github.com/nickawilliams/shedoc"
+)
+
+func TestTranslationsFormatter(t *testing.T) {
+	doc := &shedoc.Document{
+		Meta: shedoc.Meta{Name: "deploy", Lang: "en"},
+		Blocks: []shedoc.Block{{
+			Visibility:  shedoc.VisibilityCommand,
+			Description: "Manages deployments.",
+			Flags:       []shedoc.Flag{{Long: "--verbose", Description: "Verbose output"}},
+		}},
+	}
+
+	var buf bytes.Buffer
+	if err := (&TranslationsFormatter{}).Format(&buf, doc); err != nil {
+		t.Fatal(err)
+	}
+
+	want := `# Translation catalog for deploy, generated by shedoc.
+# Source language: en. Add a lang entry and translate each value.
+
+command.description: "Manages deployments."
+command.flag.--verbose: "Verbose output"
+`
+	if got := buf.String(); got != want {
+		t.Errorf("got:\n%s\nwant:\n%s", got, want)
+	}
+}
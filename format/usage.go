@@ -0,0 +1,34 @@
+package format
+
+import (
+	"fmt"
+	"io"
+
+	"github.com/nickawilliams/shedoc"
+)
+
+func init() {
+	shedoc.RegisterFormatter("usage", &UsageFormatter{})
+}
+
+// UsageFormatter outputs short help, for -h when --help is too long: the
+// usage lines and the first sentence of the description.
+//
+//	Usage: deploy [options] <command>
+//	   or: dep [options] <command>
+//	Deploy releases to production.
+type UsageFormatter struct{}
+
+func (f *UsageFormatter) Format(w io.Writer, doc *shedoc.Document) error {
+	for i, line := range synopsisLines(doc) {
+		if i == 0 {
+			fmt.Fprintf(w, "Usage: %s\n", line)
+		} else {
+			fmt.Fprintf(w, "   or: %s\n", line)
+		}
+	}
+	if doc.Meta.Description != "" {
+		fmt.Fprintln(w, manBrief(doc))
+	}
+	return nil
+}
@@ -0,0 +1,104 @@
+package format
+
+import (
+	"fmt"
+	"io"
+	"regexp"
+	"strings"
+
+	"github.com/nickawilliams/shedoc"
+	"github.com/nickawilliams/shedoc/internal/completionmodel"
+)
+
+func init() {
+	shedoc.RegisterFormatter("usage-errors", &UsageErrorsFormatter{})
+}
+
+// UsageErrorsFormatter generates a shell snippet to be sourced by the
+// documented script. It defines functions that print uniformly formatted
+// usage errors followed by the usage line for the command or subcommand, as
+// derived from the documentation.
+type UsageErrorsFormatter struct{}
+
+var reNonIdent = regexp.MustCompile(`[^A-Za-z0-9_]`)
+
+func (f *UsageErrorsFormatter) Format(w io.Writer, doc *shedoc.Document) error {
+	name := doc.Meta.Name
+	if name == "" {
+		return fmt.Errorf("usage error generation requires #?/name")
+	}
+	prefix := reNonIdent.ReplaceAllString(name, "_")
+
+	cmdBlock := doc.CommandBlock()
+	subcommands := doc.Subcommands()
+
+	fmt.Fprintf(w, "# Usage error messages for %s, generated by shedoc.\n", name)
+	fmt.Fprintln(w, "# Source this file. Each function prints to stderr and returns 2; pass the")
+	fmt.Fprintln(w, "# subcommand as the last argument to show its usage line.")
+	fmt.Fprintln(w)
+
+	// Usage lines
+	fmt.Fprintf(w, "%s_usage() {\n", prefix)
+	fmt.Fprintln(w, "  case \"$1\" in")
+	for _, sub := range subcommands {
+		words := append([]string{sub.Name}, sub.Aliases...)
+		fmt.Fprintf(w, "    %s) printf '%%s\\n' %s ;;\n",
+			strings.Join(words, "|"), shellQuote("usage: "+usageLine(name+" "+sub.Name, &sub)))
+	}
+	fmt.Fprintf(w, "    *) printf '%%s\\n' %s ;;\n", shellQuote("usage: "+usageLine(name, cmdBlock)))
+	fmt.Fprintln(w, "  esac")
+	fmt.Fprintln(w, "}")
+	fmt.Fprintln(w)
+
+	fmt.Fprintln(w, "# MESSAGE [SUBCOMMAND]")
+	fmt.Fprintf(w, "%s_usage_error() {\n", prefix)
+	fmt.Fprintf(w, "  printf '%%s: %%s\\n' %s \"$1\" >&2\n", shellQuote(name))
+	fmt.Fprintf(w, "  %s_usage \"$2\" >&2\n", prefix)
+	fmt.Fprintln(w, "  return 2")
+	fmt.Fprintln(w, "}")
+
+	messages := []struct{ fn, args, message string }{
+		{"missing_operand", "NAME [SUBCOMMAND]", `"missing required operand: $1" "$2"`},
+		{"missing_option", "OPTION [SUBCOMMAND]", `"missing required option: $1" "$2"`},
+		{"unknown_option", "OPTION [SUBCOMMAND]", `"unknown option: $1" "$2"`},
+		{"missing_value", "OPTION [SUBCOMMAND]", `"option requires a value: $1" "$2"`},
+		{"invalid_value", "OPTION VALUE [SUBCOMMAND]", `"invalid value for $1: '$2'" "$3"`},
+	}
+	if len(subcommands) > 0 {
+		messages = append(messages, struct{ fn, args, message string }{
+			"unknown_command", "COMMAND", `"unknown command: $1"`,
+		})
+	}
+	for _, m := range messages {
+		fmt.Fprintln(w)
+		fmt.Fprintf(w, "# %s\n", m.args)
+		fmt.Fprintf(w, "%s_%s() { %s_usage_error %s; }\n", prefix, m.fn, prefix, m.message)
+	}
+
+	return nil
+}
+
+// usageLine renders a one-line usage pattern from a block's flags, options,
+// and operands. A nil block yields just the command.
+func usageLine(command string, b *shedoc.Block) string {
+	parts := []string{command}
+	if b == nil {
+		return command
+	}
+	for _, fl := range b.Flags {
+		if !fl.Hidden {
+			parts = append(parts, usageNotation.flag(completionmodel.Flag{Short: fl.Short, Long: fl.Long}))
+		}
+	}
+	// A required option is shown even when hidden.
+	for _, o := range b.Options {
+		if o.Required || !o.Hidden {
+			v := o.Value
+			parts = append(parts, usageNotation.flag(completionmodel.Flag{Short: o.Short, Long: o.Long, Value: &v, Required: o.Required}))
+		}
+	}
+	for _, op := range b.Operands {
+		parts = append(parts, usageNotation.operand(op.Value))
+	}
+	return strings.Join(parts, " ")
+}
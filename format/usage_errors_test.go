@@ -0,0 +1,96 @@
+package format
+
+import (
+	"bytes"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/nickawilliams/shedoc"
+)
+
+func usageErrorsTestDoc() *shedoc.Document {
+	return &shedoc.Document{
+		Meta: shedoc.Meta{Name: "my-tool"},
+		Blocks: []shedoc.Block{
+			{
+				Visibility: shedoc.VisibilityCommand,
+				Flags:      []shedoc.Flag{{Short: "-v", Long: "--verbose"}, {Long: "--debug", Hidden: true}},
+				Operands:   []shedoc.Operand{{Value: shedoc.Value{Name: "command", Required: true}}},
+			},
+			{
+				Visibility: shedoc.VisibilitySubcommand,
+				Name:       "push",
+				Aliases:    []string{"p"},
+				Options: []shedoc.Option{
+					{Long: "--tag", Value: shedoc.Value{Name: "version", Required: true}},
+					{Short: "-t", Value: shedoc.Value{Name: "token", Required: true}, Required: true},
+				},
+				Operands: []shedoc.Operand{{Value: shedoc.Value{Name: "env", Required: true}}},
+			},
+		},
+	}
+}
+
+func TestUsageErrorsFormatter(t *testing.T) {
+	var buf bytes.Buffer
+	f := &UsageErrorsFormatter{}
+	if err := f.Format(&buf, usageErrorsTestDoc()); err != nil {
+		t.Fatal(err)
+	}
+	got := buf.String()
+
+	for _, want := range []string{
+		"my_tool_usage() {\n",
+		"    push|p) printf '%s\\n' 'usage: my-tool push [--tag <version>] -t <token> <env>' ;;\n",
+		"    *) printf '%s\\n' 'usage: my-tool [-v|--verbose] <command>' ;;\n",
+		"my_tool_missing_operand() { my_tool_usage_error \"missing required operand: $1\" \"$2\"; }\n",
+		"my_tool_missing_option() { my_tool_usage_error \"missing required option: $1\" \"$2\"; }\n",
+		"my_tool_unknown_command() {",
+	} {
+		if !strings.Contains(got, want) {
+			t.Errorf("output missing %q\n\nfull output:\n%s", want, got)
+		}
+	}
+}
+
+func TestUsageErrorsFormatter_Sourced(t *testing.T) {
+	bash, err := exec.LookPath("bash")
+	if err != nil {
+		t.Skip("bash not available")
+	}
+
+	var buf bytes.Buffer
+	f := &UsageErrorsFormatter{}
+	if err := f.Format(&buf, usageErrorsTestDoc()); err != nil {
+		t.Fatal(err)
+	}
+	snippet := filepath.Join(t.TempDir(), "errors.sh")
+	if err := os.WriteFile(snippet, buf.Bytes(), 0o644); err != nil {
+		t.Fatal(err)
+	}
+
+	cmd := exec.Command(bash, "-c", `source "$1"; my_tool_invalid_value --tag "x y" p; echo "status=$?"`, "bash", snippet)
+	var stdout, stderr bytes.Buffer
+	cmd.Stdout, cmd.Stderr = &stdout, &stderr
+	if err := cmd.Run(); err != nil {
+		t.Fatalf("bash failed: %v\n%s", err, stderr.String())
+	}
+
+	wantErr := "my-tool: invalid value for --tag: 'x y'\nusage: my-tool push [--tag <version>] -t <token> <env>\n"
+	if stderr.String() != wantErr {
+		t.Errorf("stderr = %q, want %q", stderr.String(), wantErr)
+	}
+	if stdout.String() != "status=2\n" {
+		t.Errorf("stdout = %q, want status=2", stdout.String())
+	}
+}
+
+func TestUsageErrorsFormatter_RequiresName(t *testing.T) {
+	f := &UsageErrorsFormatter{}
+	if err := f.Format(&bytes.Buffer{}, &shedoc.Document{}); err == nil {
+		t.Error("expected error without #?/name")
+	}
+}
@@ -0,0 +1,56 @@
+package format
+
+import (
+	"bytes"
+	"testing"
+
+	"github.com/nickawilliams/shedoc"
+)
+
+func TestUsageFormatter(t *testing.T) {
+	tests := []struct {
+		name string
+		doc  *shedoc.Document
+		want string
+	}{
+		{
+			name: "synopsis and description",
+			doc: &shedoc.Document{Meta: shedoc.Meta{
+				Name:        "deploy",
+				Synopsis:    []string{"deploy [-v] <env>", "deploy --list"},
+				Description: "Deploy releases to\nproduction. Supports rollback.\n\nMore detail.",
+			}},
+			want: "Usage: deploy [-v] <env>\n   or: deploy --list\nDeploy releases to production.\n",
+		},
+		{
+			name: "synthesized synopsis",
+			doc: &shedoc.Document{
+				Meta: shedoc.Meta{Name: "tool"},
+				Blocks: []shedoc.Block{{
+					Visibility: shedoc.VisibilityCommand,
+					Flags:      []shedoc.Flag{{Short: "-v"}},
+					Operands:   []shedoc.Operand{{Value: shedoc.Value{Name: "file", Required: true}}},
+				}},
+			},
+			want: "Usage: tool [-v] <file>\n",
+		},
+		{
+			name: "library",
+			doc:  &shedoc.Document{Meta: shedoc.Meta{Name: "lib", Description: "Helpers."}},
+			want: "Helpers.\n",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var buf bytes.Buffer
+			f := &UsageFormatter{}
+			if err := f.Format(&buf, tt.doc); err != nil {
+				t.Fatal(err)
+			}
+			if got := buf.String(); got != tt.want {
+				t.Errorf("got %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,35 @@
+package format
+
+import (
+	"fmt"
+	"io"
+
+	"github.com/nickawilliams/shedoc"
+)
+
+func init() {
+	shedoc.RegisterFormatter("whatis", &WhatisFormatter{})
+}
+
+// WhatisFormatter writes a whatis index line, "name (section) - brief", with
+// the same summary as the man page's NAME section. Concatenating the lines
+// for a suite of scripts gives an index that apropos and whatis can search.
+type WhatisFormatter struct{}
+
+func (f *WhatisFormatter) Format(w io.Writer, doc *shedoc.Document) error {
+	name := doc.Meta.Name
+	if name == "" {
+		name = "UNKNOWN"
+	}
+	section := doc.Meta.Section
+	if section == "" {
+		section = "1"
+	}
+
+	if brief := manBrief(doc); brief != "" {
+		_, err := fmt.Fprintf(w, "%s (%s) - %s\n", name, section, brief)
+		return err
+	}
+	_, err := fmt.Fprintf(w, "%s (%s)\n", name, section)
+	return err
+}
@@ -0,0 +1,45 @@
+package format
+
+import (
+	"bytes"
+	"testing"
+
+	"github.com/nickawilliams/shedoc"
+)
+
+func TestWhatisFormatter(t *testing.T) {
+	tests := []struct {
+		name string
+		meta shedoc.Meta
+		want string
+	}{
+		{
+			name: "description",
+			meta: shedoc.Meta{Name: "deploy", Section: "8", Description: "Deploy releases to\nproduction. Supports rollback.\n\nMore detail."},
+			want: "deploy (8) - Deploy releases to production.\n",
+		},
+		{
+			name: "synopsis fallback",
+			meta: shedoc.Meta{Name: "tool", Synopsis: []string{"tool [-v] <file>"}},
+			want: "tool (1) - tool [-v] <file>\n",
+		},
+		{
+			name: "no summary",
+			meta: shedoc.Meta{Name: "bare"},
+			want: "bare (1)\n",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var buf bytes.Buffer
+			f := &WhatisFormatter{}
+			if err := f.Format(&buf, &shedoc.Document{Meta: tt.meta}); err != nil {
+				t.Fatal(err)
+			}
+			if got := buf.String(); got != tt.want {
+				t.Errorf("got %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
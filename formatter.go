@@ -7,6 +7,34 @@ type Formatter interface {
 	Format(w io.Writer, doc *Document) error
 }
 
+// StreamFormatter is implemented by formatters that can write a document a
+// block at a time, so that ParseStream and ParseReaderStream need not hold a
+// large document in memory. BeginDocument is called once with the metadata
+// and no blocks, Block once for each block in source order, and EndDocument
+// once with the complete metadata and the warnings but no blocks.
+type StreamFormatter interface {
+	Formatter
+	BeginDocument(w io.Writer, doc *Document) error
+	Block(w io.Writer, b *Block) error
+	EndDocument(w io.Writer, doc *Document) error
+}
+
+// FormatStream writes doc with the callbacks of f, as a streaming parse
+// would. A StreamFormatter can implement Format with it.
+func FormatStream(w io.Writer, doc *Document, f StreamFormatter) error {
+	head := *doc
+	head.Blocks = nil
+	if err := f.BeginDocument(w, &head); err != nil {
+		return err
+	}
+	for i := range doc.Blocks {
+		if err := f.Block(w, &doc.Blocks[i]); err != nil {
+			return err
+		}
+	}
+	return f.EndDocument(w, &head)
+}
+
 var formatters = map[string]Formatter{}
 
 // RegisterFormatter adds a formatter under the given name.
@@ -0,0 +1,58 @@
+package cli
+
+import (
+	"encoding/json"
+
+	"github.com/nickawilliams/shedoc/internal/report"
+	"github.com/spf13/cobra"
+)
+
+var (
+	flagBadgeTo    string
+	flagBadgeLabel string
+)
+
+func newBadgeCmd() *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "badge [flags] <path...>",
+		Short: "Draw a documentation coverage badge",
+		Long: `Measures documentation coverage across the given files and directories, as
+in "shedoc report coverage", and prints a badge such as "docs 87%".
+
+Formats:
+  svg       a self-contained SVG image
+  shields   a shields.io endpoint response, for https://img.shields.io/endpoint`,
+		Args:          cobra.MinimumNArgs(1),
+		RunE:          runBadge,
+		SilenceUsage:  true,
+		SilenceErrors: true,
+	}
+
+	cmd.Flags().StringVarP(&flagBadgeTo, "to", "t", "svg", "output format (svg, shields)")
+	cmd.Flags().StringVar(&flagBadgeLabel, "label", "docs", "text on the left of the badge")
+
+	return cmd
+}
+
+func runBadge(cmd *cobra.Command, args []string) error {
+	if flagBadgeTo != "svg" && flagBadgeTo != "shields" {
+		return usageErrorf("unknown badge format: %q (available: svg, shields)", flagBadgeTo)
+	}
+
+	scripts, err := collectScripts(cmd, args)
+	if err != nil {
+		return err
+	}
+	docs, err := parseFiles(scripts)
+	if err != nil {
+		return err
+	}
+
+	badge := report.CoverageBadge(flagBadgeLabel, report.TotalCoverage(report.Coverage(docs)))
+	if flagBadgeTo == "svg" {
+		return badge.WriteSVG(cmd.OutOrStdout())
+	}
+	enc := json.NewEncoder(cmd.OutOrStdout())
+	enc.SetEscapeHTML(false)
+	return enc.Encode(badge)
+}
@@ -0,0 +1,90 @@
+package cli
+
+import (
+	"bytes"
+	"fmt"
+	"os"
+	"testing"
+	"time"
+
+	"github.com/nickawilliams/shedoc"
+	"github.com/spf13/cobra"
+)
+
+var flagBenchBudget time.Duration
+
+func newBenchCmd() *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "bench [flags] <path...>",
+		Short: "Measure how fast the scripts under the given paths parse",
+		Long: `Reads every script under the given files and directories, as "shedoc report"
+finds them, and benchmarks parsing all of them from memory. With --budget,
+exits with status 4 if one pass takes longer than the budget.`,
+		Args:          cobra.MinimumNArgs(1),
+		RunE:          runBench,
+		Hidden:        true,
+		SilenceUsage:  true,
+		SilenceErrors: true,
+	}
+
+	cmd.Flags().DurationVar(&flagBenchBudget, "budget", 0, "fail if parsing every script once takes longer than this, such as 50ms")
+
+	return cmd
+}
+
+func runBench(cmd *cobra.Command, args []string) error {
+	scripts, err := collectScripts(cmd, args)
+	if err != nil {
+		return err
+	}
+	opts, err := parseOptions()
+	if err != nil {
+		return err
+	}
+	inputs := make([][]byte, len(scripts))
+	var size int64
+	var lines int
+	for i, path := range scripts {
+		if inputs[i], err = os.ReadFile(path); err != nil {
+			return err
+		}
+		size += int64(len(inputs[i]))
+		lines += bytes.Count(inputs[i], []byte("\n"))
+	}
+
+	var parseErr error
+	result := testing.Benchmark(func(b *testing.B) {
+		b.SetBytes(size)
+		b.ReportAllocs()
+		for b.Loop() {
+			for i, in := range inputs {
+				if _, err := shedoc.ParseReader(bytes.NewReader(in), opts...); err != nil {
+					parseErr = fmt.Errorf("failed to parse %s: %w", scripts[i], err)
+					b.SkipNow()
+				}
+			}
+		}
+	})
+	if parseErr != nil {
+		return parseErr
+	}
+
+	perPass := time.Duration(result.NsPerOp())
+	w := cmd.OutOrStdout()
+	fmt.Fprintf(w, "files: %d  lines: %d  bytes: %d\n", len(scripts), lines, size)
+	fmt.Fprintf(w, "parse: %s per pass, %s per file, %.2f MB/s, %d allocs (%d bytes) per pass, over %d passes\n",
+		perPass, perPass/time.Duration(max(len(scripts), 1)), mbPerSecond(size, perPass), result.AllocsPerOp(), result.AllocedBytesPerOp(), result.N)
+
+	if flagBenchBudget > 0 && perPass > flagBenchBudget {
+		return findingsErrorf("parsing took %s per pass, over the %s budget", perPass, flagBenchBudget)
+	}
+	return nil
+}
+
+// mbPerSecond returns the throughput of parsing size bytes in d.
+func mbPerSecond(size int64, d time.Duration) float64 {
+	if d <= 0 {
+		return 0
+	}
+	return float64(size) / 1e6 / d.Seconds()
+}
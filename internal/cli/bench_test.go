@@ -0,0 +1,21 @@
+package cli
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestCLI_Bench(t *testing.T) {
+	stdout, _, err := runCLI("bench", "--budget", "1ns", testdataPath(t, "minimal.sh"))
+	if got := ExitCode(err); got != ExitFindings {
+		t.Errorf("ExitCode = %d, want %d (err: %v)", got, ExitFindings, err)
+	}
+	if err == nil || !strings.Contains(err.Error(), "over the 1ns budget") {
+		t.Errorf("unexpected error: %v", err)
+	}
+	for _, want := range []string{"files: 1  lines: ", "per pass", "per file"} {
+		if !strings.Contains(stdout, want) {
+			t.Errorf("output missing %q:\n%s", want, stdout)
+		}
+	}
+}
@@ -0,0 +1,185 @@
+package cli
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"io/fs"
+	"os"
+	"regexp"
+	"sort"
+	"strings"
+
+	"github.com/nickawilliams/shedoc"
+	"github.com/spf13/cobra"
+	"github.com/spf13/pflag"
+)
+
+// flagArtifacts maps each output format to the committed artifact to check
+// against it, one flag per registered format.
+var flagArtifacts map[string]*string
+
+func newCheckArtifactsCmd() *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "check-artifacts <file> --<format> <path>...",
+		Short: "Check that committed generated files are up to date",
+		Long: `Regenerates each given output format for a script in memory and compares it
+with the committed file, failing if any is missing or stale. Every output
+format has a flag of the same name, except help, which is --help-text, and
+translations, which is --translations-template:
+
+  shedoc check-artifacts tool.sh --man contrib/man/tool.1 \
+      --completion:bash contrib/completions/tool.bash
+
+Each format is rendered as "shedoc -t <format>" renders it, with the same
+rendering flags, such as --format-option, --command-name, --headings,
+--translations, --license-file, and --source-url. Pass the flags
+the artifacts were generated with, and check artifacts generated with
+different flags in separate runs.
+
+The date in a man page's .TH line, and the time, if any, in the "Generated
+by shedoc" comment of man pages and completion scripts, are not compared.`,
+		Args:          cobra.ExactArgs(1),
+		RunE:          runCheckArtifacts,
+		SilenceUsage:  true,
+		SilenceErrors: true,
+	}
+
+	formats := shedoc.RegisteredFormats()
+	sort.Strings(formats)
+	flagArtifacts = make(map[string]*string, len(formats))
+	for _, format := range formats {
+		flagArtifacts[format] = cmd.Flags().String(artifactFlag(format), "", "committed "+format+" output to check")
+	}
+	addRenderFlags(cmd.Flags())
+
+	return cmd
+}
+
+// artifactFlag returns the name of the check-artifacts flag for format,
+// renamed where format is also the name of a rendering flag or of --help.
+func artifactFlag(format string) string {
+	switch format {
+	case "help":
+		return "help-text"
+	case "translations":
+		return "translations-template"
+	}
+	return format
+}
+
+func runCheckArtifacts(cmd *cobra.Command, args []string) error {
+	formats := make([]string, 0, len(flagArtifacts))
+	for format, path := range flagArtifacts {
+		if *path != "" {
+			formats = append(formats, format)
+		}
+	}
+	if len(formats) == 0 {
+		return usageErrorf("no artifacts given; pass --<format> <path> for each generated file")
+	}
+	sort.Strings(formats)
+
+	w := cmd.OutOrStdout()
+	var stale int
+	for _, format := range formats {
+		path := *flagArtifacts[format]
+		problem, err := checkArtifact(cmd, args[0], format, path)
+		if err != nil {
+			return err
+		}
+		if problem != "" {
+			fmt.Fprintf(w, "%s: %s (regenerate with: %s)\n", path, problem, regenerateCommand(cmd, args[0], format, path))
+			stale++
+		}
+	}
+
+	if stale > 0 {
+		return findingsErrorf("%d of %d artifacts are out of date", stale, len(formats))
+	}
+	return nil
+}
+
+// checkArtifact renders the script at file in format, as the root command
+// does with the rendering flags of cmd, and compares it with the file at
+// path. It returns a description of the mismatch, or "" if the file is
+// current.
+func checkArtifact(cmd *cobra.Command, file, format, path string) (string, error) {
+	docs, err := parseFiles([]string{file})
+	if err != nil {
+		return "", err
+	}
+	// Render as the root command does for -t format.
+	flagTo = format
+	var buf bytes.Buffer
+	if err := writeDocuments(cmd, &buf, docs); err != nil {
+		return "", fmt.Errorf("%s: %w", format, err)
+	}
+
+	committed, err := os.ReadFile(path)
+	if errors.Is(err, fs.ErrNotExist) {
+		return "missing", nil
+	}
+	if err != nil {
+		return "", err
+	}
+
+	if !bytes.Equal(normalizeArtifact(format, buf.Bytes()), normalizeArtifact(format, committed)) {
+		return "stale", nil
+	}
+	return "", nil
+}
+
+// regenerateCommand returns the shedoc command line that writes the
+// artifact at path for format: the root command, with every flag given to
+// cmd other than the artifacts to check.
+func regenerateCommand(cmd *cobra.Command, file, format, path string) string {
+	artifacts := make(map[string]bool, len(flagArtifacts))
+	for format := range flagArtifacts {
+		artifacts[artifactFlag(format)] = true
+	}
+
+	args := []string{"shedoc", "-t", shellQuote(format)}
+	cmd.Flags().Visit(func(f *pflag.Flag) {
+		if artifacts[f.Name] {
+			return
+		}
+		switch v := f.Value.(type) {
+		case pflag.SliceValue:
+			for _, s := range v.GetSlice() {
+				args = append(args, "--"+f.Name+"="+shellQuote(s))
+			}
+		default:
+			if f.Value.Type() == "bool" && f.Value.String() == "true" {
+				args = append(args, "--"+f.Name)
+			} else {
+				args = append(args, "--"+f.Name+"="+shellQuote(f.Value.String()))
+			}
+		}
+	})
+	return strings.Join(append(args, shellQuote(file), ">", shellQuote(path)), " ")
+}
+
+// shellQuote quotes s for a shell command line when it contains anything
+// other than safe word characters.
+func shellQuote(s string) string {
+	if s != "" && strings.Trim(s, "abcdefghijklmnopqrstuvwxyzABCDEFGHIJKLMNOPQRSTUVWXYZ0123456789-_./:") == "" {
+		return s
+	}
+	return "'" + strings.ReplaceAll(s, "'", `'\''`) + "'"
+}
+
+var (
+	reManDate       = regexp.MustCompile(`(?m)^(\.TH \S+ \S+ )"[^"]*"`)
+	reGeneratedTime = regexp.MustCompile(`(?m)^(\S+ Generated by shedoc from .*?)(?: at \S+)?$`)
+)
+
+// normalizeArtifact removes the parts of generated output that change from
+// run to run, such as the date in a man page header and the time in the
+// generated-by comment.
+func normalizeArtifact(format string, data []byte) []byte {
+	if format == "man" {
+		data = reManDate.ReplaceAll(data, []byte(`$1""`))
+	}
+	return reGeneratedTime.ReplaceAll(data, []byte("$1"))
+}
@@ -0,0 +1,87 @@
+package cli
+
+import (
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestCLI_CheckArtifacts(t *testing.T) {
+	script := testdataPath(t, "comprehensive.sh")
+
+	render := func(format string, flags ...string) string {
+		t.Helper()
+		out, _, err := runCLI(append([]string{"--to", format, script}, flags...)...)
+		if err != nil {
+			t.Fatalf("rendering %s: %v", format, err)
+		}
+		return out
+	}
+
+	// A man page generated on another day is still current.
+	man := writeTemp(t, "deploy.1", reGeneratedTime.ReplaceAllString(
+		reManDate.ReplaceAllString(render("man"), `$1"1999-01-01"`), "$1 at 1999-01-01T00:00:00Z"))
+	help := writeTemp(t, "deploy.txt", render("help"))
+	bash := writeTemp(t, "deploy.bash", render("completion:bash")+"# edited\n")
+	sorted := writeTemp(t, "sorted.txt", render("help", "--format-option", "sort=alpha"))
+	aliased := writeTemp(t, "dp.bash", render("completion:bash", "--command-name", "dp"))
+
+	tests := []struct {
+		name    string
+		args    []string
+		want    []string
+		wantErr string
+	}{
+		{
+			name: "current",
+			args: []string{"--man", man, "--help-text", help},
+		},
+		{
+			name:    "stale and missing",
+			args:    []string{"--man", man, "--completion:bash", bash, "--json", filepath.Join(t.TempDir(), "deploy.json")},
+			want:    []string{bash + ": stale", "deploy.json: missing"},
+			wantErr: "2 of 3 artifacts are out of date",
+		},
+		{
+			name: "rendering flags",
+			args: []string{"--help-text", sorted, "--format-option", "sort=alpha"},
+		},
+		{
+			name: "completion rendering flags",
+			args: []string{"--completion:bash", aliased, "--command-name", "dp"},
+		},
+		{
+			name:    "rendering flags missing",
+			args:    []string{"--completion:bash", aliased},
+			want:    []string{aliased + ": stale (regenerate with: shedoc -t completion:bash "},
+			wantErr: "1 of 1 artifacts are out of date",
+		},
+		{
+			name:    "rendering flags in regenerate hint",
+			args:    []string{"--help-text", help, "--format-option", "sort=alpha"},
+			want:    []string{help + ": stale (regenerate with: shedoc -t help --format-option='sort=alpha' "},
+			wantErr: "1 of 1 artifacts are out of date",
+		},
+		{
+			name:    "no artifacts",
+			wantErr: "no artifacts given",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			stdout, _, err := runCLI(append([]string{"check-artifacts", script}, tt.args...)...)
+			if tt.wantErr == "" && err != nil {
+				t.Fatalf("unexpected error: %v\n%s", err, stdout)
+			}
+			if tt.wantErr != "" && (err == nil || !strings.Contains(err.Error(), tt.wantErr)) {
+				t.Fatalf("expected error containing %q, got %v", tt.wantErr, err)
+			}
+			for _, want := range tt.want {
+				if !strings.Contains(stdout, want) {
+					t.Errorf("expected %q in output, got:\n%s", want, stdout)
+				}
+			}
+		})
+	}
+}
@@ -0,0 +1,180 @@
+package cli
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/nickawilliams/shedoc"
+	"github.com/spf13/cobra"
+)
+
+var flagContractTimeout time.Duration
+
+func newCheckContractCmd() *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "check-contract [flags] <file>",
+		Short: "Run a script's --help and --version and compare them with its docs",
+		Long: `Runs the script with --help and, when #?/version is documented, --version,
+then checks that each exits with status 0 and that the output mentions the
+documented name, version, and every visible flag and option of the command
+block.
+
+This executes the script. It runs through the shebang's interpreter in an
+empty temporary directory, with stdin closed, a minimal environment (as for
+@complete commands), and a timeout.`,
+		Args:          cobra.ExactArgs(1),
+		RunE:          runCheckContract,
+		SilenceUsage:  true,
+		SilenceErrors: true,
+	}
+
+	cmd.Flags().DurationVar(&flagContractTimeout, "timeout", 5*time.Second, "how long each invocation may run")
+
+	return cmd
+}
+
+func runCheckContract(cmd *cobra.Command, args []string) error {
+	docs, err := parseFiles(args)
+	if err != nil {
+		return err
+	}
+	doc := docs[0]
+
+	script, err := filepath.Abs(args[0])
+	if err != nil {
+		return err
+	}
+	run := func(arg string) (string, error) {
+		return runScriptSandboxed(doc.Shebang, script, arg, flagContractTimeout)
+	}
+
+	problems, err := checkContract(doc, run)
+	if err != nil {
+		return err
+	}
+	w := cmd.OutOrStdout()
+	for _, p := range problems {
+		fmt.Fprintf(w, "%s: %s\n", args[0], p)
+	}
+	if len(problems) > 0 {
+		return findingsErrorf("%d contract problems found", len(problems))
+	}
+	return nil
+}
+
+// checkContract runs --help and --version through run and describes each way
+// the output disagrees with doc. run returns the combined output and an
+// *exec.ExitError for a non-zero status; any other error aborts the check.
+func checkContract(doc *shedoc.Document, run func(arg string) (string, error)) ([]string, error) {
+	var problems []string
+
+	invoke := func(arg string) (string, error) {
+		out, err := run(arg)
+		var exitErr *exec.ExitError
+		if errors.As(err, &exitErr) {
+			problems = append(problems, fmt.Sprintf("%s exited with status %d", arg, exitErr.ExitCode()))
+			return out, nil
+		}
+		return out, err
+	}
+
+	help, err := invoke("--help")
+	if err != nil {
+		return nil, err
+	}
+	if name := doc.Meta.Name; name != "" && !strings.Contains(help, name) {
+		problems = append(problems, fmt.Sprintf("--help output does not mention the name %q", name))
+	}
+	if b := doc.CommandBlock(); b != nil {
+		var names []string
+		for _, f := range b.Flags {
+			if !f.Hidden {
+				names = append(names, f.Short, f.Long)
+			}
+		}
+		for _, o := range b.Options {
+			if !o.Hidden {
+				names = append(names, o.Short, o.Long)
+			}
+		}
+		for _, name := range names {
+			if name != "" && !containsWord(help, name) {
+				problems = append(problems, fmt.Sprintf("--help output does not mention %s", name))
+			}
+		}
+	}
+
+	if version := doc.Meta.Version; version != "" {
+		out, err := invoke("--version")
+		if err != nil {
+			return nil, err
+		}
+		if !strings.Contains(out, version) {
+			problems = append(problems, fmt.Sprintf("--version output does not mention the version %q", version))
+		}
+	}
+
+	return problems, nil
+}
+
+// containsWord reports whether s contains word delimited by characters that
+// cannot be part of a flag name, so that "-v" does not match "--verbose".
+func containsWord(s, word string) bool {
+	for i := 0; ; {
+		j := strings.Index(s[i:], word)
+		if j < 0 {
+			return false
+		}
+		start, end := i+j, i+j+len(word)
+		if (start == 0 || !isFlagChar(s[start-1])) && (end == len(s) || !isFlagChar(s[end])) {
+			return true
+		}
+		i = start + 1
+	}
+}
+
+func isFlagChar(c byte) bool {
+	return c == '-' || c == '_' || c >= '0' && c <= '9' || c >= 'a' && c <= 'z' || c >= 'A' && c <= 'Z'
+}
+
+// runScriptSandboxed runs script with a single argument through the
+// interpreter named by shebang (sh if there is none), in a fresh temporary
+// directory with stdin closed and the sandbox environment. It returns stdout
+// and stderr combined.
+func runScriptSandboxed(shebang, script, arg string, timeout time.Duration) (string, error) {
+	dir, err := os.MkdirTemp("", "shedoc-contract-")
+	if err != nil {
+		return "", err
+	}
+	defer os.RemoveAll(dir)
+
+	interpreter := strings.Fields(shebang)
+	if len(interpreter) == 0 {
+		interpreter = []string{"sh"}
+	}
+	argv := append(interpreter, script, arg)
+
+	ctx, cancel := context.WithTimeout(context.Background(), timeout)
+	defer cancel()
+
+	cmd := exec.CommandContext(ctx, argv[0], argv[1:]...)
+	cmd.Dir = dir
+	cmd.Env = sandboxEnv()
+	cmd.WaitDelay = 100 * time.Millisecond
+	var out bytes.Buffer
+	cmd.Stdout = &out
+	cmd.Stderr = &out
+
+	err = cmd.Run()
+	if ctx.Err() != nil {
+		return out.String(), fmt.Errorf("%s timed out after %s", arg, timeout)
+	}
+	return out.String(), err
+}
@@ -0,0 +1,113 @@
+package cli
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/nickawilliams/shedoc"
+)
+
+func TestCheckContract(t *testing.T) {
+	doc := &shedoc.Document{
+		Meta: shedoc.Meta{Name: "deploy", Version: "2.1.0"},
+		Blocks: []shedoc.Block{{
+			Visibility: shedoc.VisibilityCommand,
+			Flags:      []shedoc.Flag{{Short: "-v", Long: "--verbose"}, {Long: "--debug", Hidden: true}},
+			Options:    []shedoc.Option{{Short: "-c", Long: "--config"}},
+		}},
+	}
+
+	tests := []struct {
+		name    string
+		outputs map[string]string
+		want    []string
+	}{
+		{
+			name: "matching",
+			outputs: map[string]string{
+				"--help":    "Usage: deploy [-v|--verbose] [-c|--config <path>]",
+				"--version": "deploy 2.1.0",
+			},
+		},
+		{
+			name: "drifted",
+			outputs: map[string]string{
+				"--help":    "Usage: deploy [--verbose] [-c|--config <path>]",
+				"--version": "deploy 2.0.0",
+			},
+			want: []string{
+				"--help output does not mention -v",
+				`--version output does not mention the version "2.1.0"`,
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			run := func(arg string) (string, error) { return tt.outputs[arg], nil }
+			got, err := checkContract(doc, run)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if strings.Join(got, "\n") != strings.Join(tt.want, "\n") {
+				t.Errorf("got:\n%s\nwant:\n%s", strings.Join(got, "\n"), strings.Join(tt.want, "\n"))
+			}
+		})
+	}
+}
+
+func TestContainsWord(t *testing.T) {
+	tests := []struct {
+		s, word string
+		want    bool
+	}{
+		{"  -v, --verbose", "-v", true},
+		{"  --verbose", "-v", false},
+		{"  --verbose", "--verbose", true},
+		{"  --verbose-mode", "--verbose", false},
+		{"[-c|--config]", "-c", true},
+	}
+	for _, tt := range tests {
+		if got := containsWord(tt.s, tt.word); got != tt.want {
+			t.Errorf("containsWord(%q, %q) = %v, want %v", tt.s, tt.word, got, tt.want)
+		}
+	}
+}
+
+func TestCLI_CheckContract(t *testing.T) {
+	script := filepath.Join(t.TempDir(), "greet.sh")
+	content := `#!/usr/bin/env bash
+#?/name    greet
+#?/version 1.0.0
+
+#@/command
+ # @flag -l | --loud  Shout
+ ##
+case "$1" in
+    --help)    echo "Usage: greet [-l|--loud]" ;;
+    --version) echo "greet 1.0.0" ;;
+    *)         exit 2 ;;
+esac
+`
+	if err := os.WriteFile(script, []byte(content), 0o644); err != nil {
+		t.Fatal(err)
+	}
+
+	stdout, _, err := runCLI("check-contract", script)
+	if err != nil {
+		t.Fatalf("unexpected error: %v\n%s", err, stdout)
+	}
+
+	// The script runs outside the caller's directory and environment.
+	out, err := runScriptSandboxed("/bin/sh", writeTemp(t, "env.sh", `pwd; echo "secret=$SHEDOC_TEST_SECRET"`), "", time.Second)
+	if err != nil {
+		t.Fatal(err)
+	}
+	cwd, _ := os.Getwd()
+	if strings.Contains(out, cwd+"\n") || !strings.Contains(out, "secret=\n") {
+		t.Errorf("script was not isolated:\n%s", out)
+	}
+}
@@ -2,13 +2,17 @@ package cli
 
 import (
 	"bytes"
+	"crypto/sha256"
 	"encoding/json"
+	"fmt"
 	"os"
 	"path/filepath"
+	"reflect"
 	"strings"
 	"testing"
 
 	"github.com/nickawilliams/shedoc"
+	"github.com/nickawilliams/shedoc/format"
 )
 
 // testdataPath returns the absolute path to a testdata file.
@@ -99,6 +103,53 @@ func TestCLI_JSONMultipleFiles(t *testing.T) {
 	}
 }
 
+// --- --block flag ---
+
+func TestCLI_BlockByName(t *testing.T) {
+	stdout, _, err := runCLI("--block", "push", "--to", "json", testdataPath(t, "comprehensive.sh"))
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	var block shedoc.Block
+	if err := json.Unmarshal([]byte(stdout), &block); err != nil {
+		t.Fatalf("output is not valid JSON: %v\n%s", err, stdout)
+	}
+	if block.Name != "push" || block.FunctionName != "cmd_push" {
+		t.Errorf("block = %q (%q), want push (cmd_push)", block.Name, block.FunctionName)
+	}
+}
+
+func TestCLI_BlockByFunctionAndIndex(t *testing.T) {
+	for _, ref := range []string{"main", "0"} {
+		stdout, _, err := runCLI("--block", ref, testdataPath(t, "comprehensive.sh"))
+		if err != nil {
+			t.Fatalf("--block %s: unexpected error: %v", ref, err)
+		}
+		var block shedoc.Block
+		if err := json.Unmarshal([]byte(stdout), &block); err != nil {
+			t.Fatalf("--block %s: output is not valid JSON: %v", ref, err)
+		}
+		if block.Visibility != shedoc.VisibilityCommand {
+			t.Errorf("--block %s: Visibility = %q, want command", ref, block.Visibility)
+		}
+	}
+}
+
+func TestCLI_BlockNotFound(t *testing.T) {
+	_, _, err := runCLI("--block", "nope", testdataPath(t, "comprehensive.sh"))
+	if err == nil || !strings.Contains(err.Error(), `block "nope" not found`) {
+		t.Errorf("expected block not found error, got %v", err)
+	}
+}
+
+func TestCLI_BlockRequiresJSON(t *testing.T) {
+	_, _, err := runCLI("--block", "push", "--to", "help", testdataPath(t, "comprehensive.sh"))
+	if err == nil {
+		t.Fatal("expected error for --block with non-JSON format")
+	}
+}
+
 // --- --get flag ---
 
 func TestCLI_GetName(t *testing.T) {
@@ -131,6 +182,63 @@ func TestCLI_GetDescription(t *testing.T) {
 	}
 }
 
+func TestCLI_GetExitCodes(t *testing.T) {
+	stdout, _, err := runCLI("--get", "exit-codes", testdataPath(t, "comprehensive.sh"))
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if got := strings.TrimSpace(stdout); got != "0\n1\n2" {
+		t.Errorf("--get exit-codes = %q, want deduplicated 0, 1, 2", got)
+	}
+}
+
+func TestCLI_GetFlagsTSV(t *testing.T) {
+	stdout, _, err := runCLI("--get", "flags", "--tsv", testdataPath(t, "comprehensive.sh"))
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	lines := strings.Split(strings.TrimSpace(stdout), "\n")
+	want := []string{
+		"-v, --verbose\tEnable verbose output",
+		"-c, --config\tPath to configuration file",
+		"-f, --force\tSkip confirmation prompt",
+		"--dry-run\tPreview changes without deploying",
+		"--tag\tVersion tag (default: latest git tag)",
+		"--format\tOutput format (text, json, yaml)",
+	}
+	if strings.Join(lines, "\n") != strings.Join(want, "\n") {
+		t.Errorf("--get flags --tsv =\n%s\nwant\n%s", stdout, strings.Join(want, "\n"))
+	}
+
+	for _, args := range [][]string{
+		{"--tsv"},
+		{"--tsv", "--get", "name"},
+	} {
+		_, _, err := runCLI(append(args, testdataPath(t, "comprehensive.sh"))...)
+		if got := ExitCode(err); got != ExitUsage {
+			t.Errorf("%v: ExitCode = %d, want %d (err: %v)", args, got, ExitUsage, err)
+		}
+	}
+}
+
+func TestCLI_GetEnvAndSubcommands(t *testing.T) {
+	stdout, _, err := runCLI("--get", "env", testdataPath(t, "comprehensive.sh"))
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if got := strings.TrimSpace(stdout); got != "DEPLOY_TOKEN" {
+		t.Errorf("--get env = %q, want DEPLOY_TOKEN", got)
+	}
+
+	stdout, _, err = runCLI("--get", "subcommands", testdataPath(t, "comprehensive.sh"))
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if got := strings.Fields(stdout); strings.Join(got, " ") != "push status rollback migrate" {
+		t.Errorf("--get subcommands = %v", got)
+	}
+}
+
 func TestCLI_GetUnknownTag(t *testing.T) {
 	_, _, err := runCLI("--get", "nonexistent", testdataPath(t, "comprehensive.sh"))
 	if err == nil {
@@ -186,6 +294,455 @@ func TestCLI_HelpFormat(t *testing.T) {
 	}
 }
 
+func TestCLI_HelpStyleFull(t *testing.T) {
+	stdout, _, err := runCLI("--to", "help", "--help-style", "full", testdataPath(t, "comprehensive.sh"))
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if !strings.Contains(stdout, "      -f, --force             Skip confirmation prompt") {
+		t.Errorf("full help missing subcommand flags:\n%s", stdout)
+	}
+
+	for _, args := range [][]string{
+		{"--to", "help", "--help-style", "long"},
+		{"--to", "man", "--help-style", "full"},
+	} {
+		_, _, err := runCLI(append(args, testdataPath(t, "comprehensive.sh"))...)
+		if got := ExitCode(err); got != ExitUsage {
+			t.Errorf("%v: ExitCode = %d, want %d (err: %v)", args, got, ExitUsage, err)
+		}
+	}
+}
+
+func TestCLI_Width(t *testing.T) {
+	stdout, _, err := runCLI("--to", "help", "--width", "50", testdataPath(t, "comprehensive.sh"))
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	want := "  -c, --config <path>     Path to configuration\n                          file\n"
+	if !strings.Contains(stdout, want) {
+		t.Errorf("output missing %q\n%s", want, stdout)
+	}
+
+	// Output that is not a terminal is left unwrapped.
+	stdout, _, err = runCLI("--to", "help", testdataPath(t, "comprehensive.sh"))
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if !strings.Contains(stdout, "Path to configuration file\n") {
+		t.Errorf("output wrapped without --width:\n%s", stdout)
+	}
+
+	for _, args := range [][]string{
+		{"--to", "help", "--width", "-1"},
+		{"--to", "man", "--width", "72"},
+	} {
+		_, _, err := runCLI(append(args, testdataPath(t, "comprehensive.sh"))...)
+		if got := ExitCode(err); got != ExitUsage {
+			t.Errorf("%v: ExitCode = %d, want %d (err: %v)", args, got, ExitUsage, err)
+		}
+	}
+}
+
+func TestCLI_Color(t *testing.T) {
+	stdout, _, err := runCLI("--to", "help", "--color", "always", testdataPath(t, "comprehensive.sh"))
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if !strings.Contains(stdout, "\x1b[1;33mCommands:\x1b[0m") {
+		t.Errorf("--color always did not color headings:\n%q", stdout)
+	}
+
+	// Output that is not a terminal is not colored.
+	stdout, _, err = runCLI("--to", "help", testdataPath(t, "comprehensive.sh"))
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if strings.Contains(stdout, "\x1b") {
+		t.Errorf("output colored without --color:\n%q", stdout)
+	}
+
+	for _, args := range [][]string{
+		{"--to", "help", "--color", "sometimes"},
+		{"--to", "man", "--color", "always"},
+	} {
+		_, _, err := runCLI(append(args, testdataPath(t, "comprehensive.sh"))...)
+		if got := ExitCode(err); got != ExitUsage {
+			t.Errorf("%v: ExitCode = %d, want %d (err: %v)", args, got, ExitUsage, err)
+		}
+	}
+}
+
+func TestCLI_FormatOptions(t *testing.T) {
+	stdout, _, err := runCLI("--to", "list", "--format-option", "include=commands,subcommands", "--format-option", "sort=alpha", testdataPath(t, "comprehensive.sh"))
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	var got []string
+	for _, line := range strings.Split(strings.TrimSpace(stdout), "\n") {
+		fields := strings.Fields(line)
+		got = append(got, fields[0]+":"+fields[1])
+	}
+	want := []string{"command:-", "subcommand:migrate", "subcommand:push", "subcommand:rollback", "subcommand:status"}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("blocks = %v, want %v", got, want)
+	}
+
+	for _, args := range [][]string{
+		{"--to", "help", "--format-option", "sort=random"},
+		{"--to", "help", "--format-option", "include=functions"},
+		{"--to", "help", "--format-option", "width"},
+		{"--to", "json", "--format-option", "sort=alpha"},
+		{"--to", "help", "--format-option", "examples=true"},
+		{"--to", "completion:fish", "--format-option", "examples=yes"},
+	} {
+		_, _, err := runCLI(append(args, testdataPath(t, "comprehensive.sh"))...)
+		if got := ExitCode(err); got != ExitUsage {
+			t.Errorf("%v: ExitCode = %d, want %d (err: %v)", args, got, ExitUsage, err)
+		}
+	}
+}
+
+func TestCLI_FormatOptionExamples(t *testing.T) {
+	stdout, _, err := runCLI("--to", "completion:fish", "--format-option", "examples=true", testdataPath(t, "comprehensive.sh"))
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if !strings.Contains(stdout, "(e.g. deploy status production)") {
+		t.Errorf("expected the status example in its description:\n%s", stdout)
+	}
+}
+
+func TestCLI_SortSubcommands(t *testing.T) {
+	stdout, _, err := runCLI("--to", "help", "--sort-subcommands", testdataPath(t, "comprehensive.sh"))
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	migrate := strings.Index(stdout, "  migrate")
+	push := strings.Index(stdout, "  push")
+	status := strings.Index(stdout, "  status")
+	if migrate < 0 || !(migrate < push && push < status) {
+		t.Errorf("expected subcommands in alphabetical order:\n%s", stdout)
+	}
+
+	// Like --format-option, it does not apply to JSON.
+	_, _, err = runCLI("--sort-subcommands", testdataPath(t, "comprehensive.sh"))
+	if err == nil || ExitCode(err) != ExitUsage {
+		t.Errorf("expected a usage error for JSON, got %v", err)
+	}
+}
+
+func TestCLI_JSONArray(t *testing.T) {
+	stdout, _, err := runCLI("--array", testdataPath(t, "comprehensive.sh"), testdataPath(t, "standalone.sh"))
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	var docs []shedoc.Document
+	if err := json.Unmarshal([]byte(stdout), &docs); err != nil {
+		t.Fatalf("output is not a JSON array: %v\n%s", err, stdout)
+	}
+	if len(docs) != 2 || docs[0].Meta.Name != "deploy" || docs[1].Meta.Name != "greet" {
+		t.Errorf("unexpected documents: %+v", docs)
+	}
+
+	// Filtering out every document still gives an array.
+	stdout, _, err = runCLI("--array", "--filter", "name=nothing", testdataPath(t, "standalone.sh"))
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if stdout != "[]\n" {
+		t.Errorf("got %q, want %q", stdout, "[]\n")
+	}
+
+	_, _, err = runCLI("--array", "--to", "man", testdataPath(t, "standalone.sh"))
+	if got := ExitCode(err); got != ExitUsage {
+		t.Errorf("--array --to man ExitCode = %d, want %d (err: %v)", got, ExitUsage, err)
+	}
+}
+
+func TestCLI_Template(t *testing.T) {
+	tmpl := writeTemp(t, "names.tmpl", "{{.Meta.Name}}: {{firstLine .Meta.Description}}\n")
+	stdout, _, err := runCLI("--to", "template", "--template", tmpl, testdataPath(t, "comprehensive.sh"), testdataPath(t, "standalone.sh"))
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	lines := strings.Split(strings.TrimSuffix(stdout, "\n"), "\n")
+	if len(lines) != 2 || !strings.HasPrefix(lines[0], "deploy: ") || !strings.HasPrefix(lines[1], "greet: ") {
+		t.Errorf("unexpected output:\n%s", stdout)
+	}
+
+	tests := []struct {
+		name string
+		args []string
+	}{
+		{"missing template", []string{"--to", "template"}},
+		{"wrong format", []string{"--to", "man", "--template", tmpl}},
+		{"parse error", []string{"--to", "template", "--template", writeTemp(t, "bad.tmpl", "{{.Meta.Name")}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, _, err := runCLI(append(tt.args, testdataPath(t, "standalone.sh"))...)
+			if got := ExitCode(err); got != ExitUsage {
+				t.Errorf("ExitCode = %d, want %d (err: %v)", got, ExitUsage, err)
+			}
+		})
+	}
+}
+
+func TestCLI_Directory(t *testing.T) {
+	dir := t.TempDir()
+	files := map[string]string{
+		"tool.sh":    "#!/bin/bash\n#?/name tool\n",
+		"lib.sh":     "#?/name lib\n",
+		"prompt.zsh": "#?/name prompt\n",
+		"run":        "#!/usr/bin/env bash\n#?/name run\n",
+		"split":      "#!/usr/bin/env -S bash -e\n#?/name split\n",
+		".bashrc":    "#!/bin/bash\n#?/name bashrc\n",
+		"greet":      "#!/usr/bin/fish\n",
+		"notes":      "just text\n",
+		"image.sh":   "\x89PNG\x00\x00",
+		"README.md":  "# readme\n",
+	}
+	for name, content := range files {
+		if err := os.WriteFile(filepath.Join(dir, name), []byte(content), 0o644); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	tests := []struct {
+		name    string
+		args    []string
+		want    []string
+		skipped []string
+	}{
+		{
+			name:    "default",
+			want:    []string{"bashrc", "lib", "prompt", "run", "split", "tool"},
+			skipped: []string{"greet: no shell shebang", "image.sh: binary file", "notes: no shell shebang", "README.md: not a shell script"},
+		},
+		{
+			name:    "require shebang",
+			args:    []string{"--require-shebang"},
+			want:    []string{"bashrc", "run", "split", "tool"},
+			skipped: []string{"lib.sh: no shell shebang", "prompt.zsh: no shell shebang"},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			stdout, stderr, err := runCLI(append(tt.args, "--array", dir)...)
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			var docs []shedoc.Document
+			if err := json.Unmarshal([]byte(stdout), &docs); err != nil {
+				t.Fatalf("output is not a JSON array: %v\n%s", err, stdout)
+			}
+			var names []string
+			for _, doc := range docs {
+				names = append(names, doc.Meta.Name)
+			}
+			if !reflect.DeepEqual(names, tt.want) {
+				t.Errorf("names = %v, want %v", names, tt.want)
+			}
+			for _, s := range tt.skipped {
+				if !strings.Contains(stderr, "skipping "+filepath.Join(dir, s)) {
+					t.Errorf("stderr missing skip note for %q:\n%s", s, stderr)
+				}
+			}
+		})
+	}
+}
+
+func TestCLI_DirectorySymlinks(t *testing.T) {
+	dir := t.TempDir()
+	real := filepath.Join(dir, "real")
+	if err := os.Mkdir(real, 0o755); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(filepath.Join(real, "tool.sh"), []byte("#?/name tool\n"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	links := map[string]string{
+		"link.sh": filepath.Join(real, "tool.sh"),
+		"linked":  real,
+		"loop":    dir,
+		"broken":  filepath.Join(dir, "missing"),
+	}
+	for name, target := range links {
+		if err := os.Symlink(target, filepath.Join(dir, name)); err != nil {
+			t.Skipf("symlinks unsupported: %v", err)
+		}
+	}
+
+	tests := []struct {
+		name    string
+		args    []string
+		skipped []string
+	}{
+		{
+			name:    "not followed",
+			skipped: []string{"link.sh: symbolic link", "linked: symbolic link", "loop: symbolic link"},
+		},
+		{
+			name: "followed",
+			args: []string{"--follow-symlinks"},
+			skipped: []string{
+				"broken: ",
+				"linked/tool.sh: same file as " + filepath.Join(dir, "link.sh"),
+				"real: same directory as " + filepath.Join(dir, "linked"),
+				"loop: same directory as " + dir,
+			},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			stdout, stderr, err := runCLI(append(tt.args, "--array", dir)...)
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			var docs []shedoc.Document
+			if err := json.Unmarshal([]byte(stdout), &docs); err != nil {
+				t.Fatalf("output is not a JSON array: %v\n%s", err, stdout)
+			}
+			if len(docs) != 1 || docs[0].Meta.Name != "tool" {
+				t.Errorf("want tool parsed once, got %+v", docs)
+			}
+			for _, s := range tt.skipped {
+				if !strings.Contains(stderr, "skipping "+filepath.Join(dir, s)) {
+					t.Errorf("stderr missing skip note for %q:\n%s", s, stderr)
+				}
+			}
+		})
+	}
+}
+
+func TestCLI_LicenseFile(t *testing.T) {
+	stdout, _, err := runCLI("--to", "man", "--license-file", filepath.Join("..", "..", "LICENSE.md"), testdataPath(t, "minimal.sh"))
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if !strings.Contains(stdout, ".SH LICENSE\n.nf\n") {
+		t.Errorf("man output missing LICENSE section:\n%s", stdout)
+	}
+}
+
+func TestCLI_ReportEnv(t *testing.T) {
+	stdout, _, err := runCLI("report", "env", "--to", "json", filepath.Join("..", "..", "testdata"))
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	var vars []struct {
+		Name   string   `json:"name"`
+		ReadBy []string `json:"readBy"`
+		SetBy  []string `json:"setBy"`
+	}
+	if err := json.Unmarshal([]byte(stdout), &vars); err != nil {
+		t.Fatalf("output is not valid JSON: %v\n%s", err, stdout)
+	}
+	if len(vars) != 2 || vars[0].Name != "DEPLOY_LAST_ROLLBACK" || vars[1].Name != "DEPLOY_TOKEN" {
+		t.Fatalf("unexpected variables: %+v", vars)
+	}
+	if len(vars[1].ReadBy) != 1 || !strings.HasSuffix(vars[1].ReadBy[0], "comprehensive.sh") {
+		t.Errorf("DEPLOY_TOKEN ReadBy = %v", vars[1].ReadBy)
+	}
+}
+
+func TestCLI_ReportFilesMarkdown(t *testing.T) {
+	stdout, _, err := runCLI("report", "files", "--to", "markdown", testdataPath(t, "comprehensive.sh"))
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	for _, want := range []string{
+		"| Path | Readers | Writers | Description |\n",
+		"| /var/log/deploy.log |  | " + testdataPath(t, "comprehensive.sh") + " | Deployment log; Rollback log entry |\n",
+	} {
+		if !strings.Contains(stdout, want) {
+			t.Errorf("output missing %q:\n%s", want, stdout)
+		}
+	}
+}
+
+func TestCLI_ReportAudit(t *testing.T) {
+	stdout, _, err := runCLI("report", "audit", testdataPath(t, "comprehensive.sh"))
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	lines := strings.Split(strings.TrimSpace(stdout), "\n")
+	if len(lines) != 3 {
+		t.Fatalf("expected header and two findings, got:\n%s", stdout)
+	}
+	for _, want := range []string{"comprehensive.sh:60", "system-write", "/var/log/deploy.log"} {
+		if !strings.Contains(lines[1], want) {
+			t.Errorf("first finding missing %q: %s", want, lines[1])
+		}
+	}
+}
+
+func TestCLI_ReportCoverage(t *testing.T) {
+	stdout, _, err := runCLI("report", "coverage", "--to", "json", testdataPath(t, "library.sh"))
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	var scripts []struct {
+		Described int `json:"described"`
+		Total     int `json:"total"`
+	}
+	if err := json.Unmarshal([]byte(stdout), &scripts); err != nil {
+		t.Fatalf("output is not valid JSON: %v\n%s", err, stdout)
+	}
+	if len(scripts) != 1 || scripts[0].Total == 0 || scripts[0].Described != scripts[0].Total {
+		t.Errorf("unexpected coverage: %+v", scripts)
+	}
+}
+
+func TestCLI_Badge(t *testing.T) {
+	stdout, _, err := runCLI("badge", "--to", "shields", "--label", "shedoc", testdataPath(t, "library.sh"))
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	want := `{"schemaVersion":1,"label":"shedoc","message":"100%","color":"brightgreen"}` + "\n"
+	if stdout != want {
+		t.Errorf("got %q, want %q", stdout, want)
+	}
+
+	stdout, _, err = runCLI("badge", testdataPath(t, "library.sh"))
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if !strings.HasPrefix(stdout, "<svg ") || !strings.Contains(stdout, "docs: 100%") {
+		t.Errorf("unexpected SVG:\n%s", stdout)
+	}
+
+	_, _, err = runCLI("badge", "--to", "png", testdataPath(t, "library.sh"))
+	if got := ExitCode(err); got != ExitUsage {
+		t.Errorf("unknown format ExitCode = %d, want %d (err: %v)", got, ExitUsage, err)
+	}
+}
+
+func TestCLI_Schema(t *testing.T) {
+	stdout, _, err := runCLI("schema")
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	var schema struct {
+		Schema string         `json:"$schema"`
+		Defs   map[string]any `json:"$defs"`
+	}
+	if err := json.Unmarshal([]byte(stdout), &schema); err != nil {
+		t.Fatalf("output is not valid JSON: %v\n%s", err, stdout)
+	}
+	if schema.Schema == "" || schema.Defs["Block"] == nil {
+		t.Errorf("unexpected schema:\n%s", stdout)
+	}
+}
+
+func TestCLI_ReportUnknownKind(t *testing.T) {
+	_, _, err := runCLI("report", "bogus", testdataPath(t, "minimal.sh"))
+	if err == nil || !strings.Contains(err.Error(), "unknown report") {
+		t.Errorf("expected unknown report error, got %v", err)
+	}
+}
+
 func TestCLI_ManFormat(t *testing.T) {
 	stdout, _, err := runCLI("--to", "man", testdataPath(t, "comprehensive.sh"))
 	if err != nil {
@@ -258,6 +815,176 @@ func TestCLI_OutputFile(t *testing.T) {
 	}
 }
 
+func TestCLI_OutputDir(t *testing.T) {
+	tests := []struct {
+		format string
+		files  []string
+	}{
+		{"completion:bash", []string{"deploy", "greet"}},
+		{"completion:zsh", []string{"_deploy", "_greet"}},
+		{"completion:fish", []string{"deploy.fish", "greet.fish"}},
+		{"completion:elvish", []string{"deploy.elv", "greet.elv"}},
+		{"completion:fig", []string{"deploy.ts", "greet.ts"}},
+		{"html", []string{"deploy.html", "greet.html", "search-index.json"}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.format, func(t *testing.T) {
+			dir := filepath.Join(t.TempDir(), "completions")
+			_, _, err := runCLI("--to", tt.format, "--output-dir", dir, testdataPath(t, "comprehensive.sh"), testdataPath(t, "standalone.sh"))
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			for _, name := range tt.files {
+				data, err := os.ReadFile(filepath.Join(dir, name))
+				if err != nil {
+					t.Fatal(err)
+				}
+				if len(data) == 0 {
+					t.Errorf("%s is empty", name)
+				}
+			}
+		})
+	}
+
+	_, _, err := runCLI("--to", "man", "--output-dir", t.TempDir(), testdataPath(t, "standalone.sh"))
+	if got := ExitCode(err); got != ExitUsage {
+		t.Errorf("--to man --output-dir ExitCode = %d, want %d (err: %v)", got, ExitUsage, err)
+	}
+}
+
+func TestCLI_OutputDirRejectsPaths(t *testing.T) {
+	for _, name := range []string{"../esc/pwned", "..", "sub/tool"} {
+		t.Run(name, func(t *testing.T) {
+			dir := t.TempDir()
+			script := filepath.Join(dir, "tool.sh")
+			if err := os.WriteFile(script, []byte("#!/bin/bash\n#?/name "+name+"\n"), 0o644); err != nil {
+				t.Fatal(err)
+			}
+			_, _, err := runCLI("--to", "completion:bash", "--output-dir", filepath.Join(dir, "out"), script)
+			if got := ExitCode(err); got != ExitUsage {
+				t.Errorf("ExitCode = %d, want %d (err: %v)", got, ExitUsage, err)
+			}
+			if _, err := os.Stat(filepath.Join(dir, "esc", "pwned")); !os.IsNotExist(err) {
+				t.Errorf("wrote outside the output directory (stat err: %v)", err)
+			}
+		})
+	}
+}
+
+func TestCLI_Theme(t *testing.T) {
+	theme := t.TempDir()
+	for name, content := range map[string]string{
+		"page.html":    `<title>{{.Meta.Name}}</title><link rel="stylesheet" href="brand.css">`,
+		"brand.css":    "body {}\n",
+		"img/logo.svg": "<svg/>\n",
+		".git/HEAD":    "ref\n",
+		"style.css":    "unused\n",
+	} {
+		path := filepath.Join(theme, name)
+		if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.WriteFile(path, []byte(content), 0o644); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	dir := filepath.Join(t.TempDir(), "site")
+	if _, _, err := runCLI("--to", "html", "--output-dir", dir, "--theme", theme, testdataPath(t, "standalone.sh")); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	page, err := os.ReadFile(filepath.Join(dir, "greet.html"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if want := `<title>greet</title><link rel="stylesheet" href="brand.css">`; string(page) != want {
+		t.Errorf("greet.html = %q, want %q", page, want)
+	}
+	for _, name := range []string{"brand.css", "img/logo.svg", "search-index.json"} {
+		if _, err := os.Stat(filepath.Join(dir, name)); err != nil {
+			t.Errorf("%s not written: %v", name, err)
+		}
+	}
+	for _, name := range []string{"page.html", "style.css", ".git"} {
+		if _, err := os.Stat(filepath.Join(dir, name)); err == nil {
+			t.Errorf("%s copied from the theme", name)
+		}
+	}
+
+	_, _, err = runCLI("--to", "html", "--theme", theme, testdataPath(t, "standalone.sh"))
+	if got := ExitCode(err); got != ExitUsage {
+		t.Errorf("--theme without --output-dir ExitCode = %d, want %d (err: %v)", got, ExitUsage, err)
+	}
+}
+
+func TestCLI_SearchIndex(t *testing.T) {
+	tmpl := writeTemp(t, "page.tmpl", "<h1>{{.Meta.Name}}</h1>\n")
+	index := filepath.Join(t.TempDir(), "search-index.json")
+	stdout, _, err := runCLI("--to", "template", "--template", tmpl, "--search-index", index,
+		testdataPath(t, "comprehensive.sh"), testdataPath(t, "standalone.sh"))
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if stdout != "<h1>deploy</h1>\n<h1>greet</h1>\n" {
+		t.Errorf("stdout = %q", stdout)
+	}
+
+	data, err := os.ReadFile(index)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var got format.SearchIndex
+	if err := json.Unmarshal(data, &got); err != nil {
+		t.Fatalf("index is not valid JSON: %v\n%s", err, data)
+	}
+	if len(got.Documents) != 2 || got.Documents[0].Name != "deploy" || got.Documents[1].Name != "greet" {
+		t.Errorf("documents = %+v", got.Documents)
+	}
+}
+
+func TestCLI_Manifest(t *testing.T) {
+	dir := filepath.Join(t.TempDir(), "site")
+	if _, _, err := runCLI("--to", "html", "--output-dir", dir, "--manifest", testdataPath(t, "standalone.sh")); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	var want strings.Builder
+	for _, name := range []string{"greet.html", "search-index.json"} {
+		data, err := os.ReadFile(filepath.Join(dir, name))
+		if err != nil {
+			t.Fatal(err)
+		}
+		fmt.Fprintf(&want, "%x  %s\n", sha256.Sum256(data), name)
+	}
+	got, err := os.ReadFile(filepath.Join(dir, "SHA256SUMS"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if string(got) != want.String() {
+		t.Errorf("SHA256SUMS = %q, want %q", got, want.String())
+	}
+
+	man := filepath.Join(t.TempDir(), "man1")
+	if _, _, err := runCLI("man", "--split", man, "--manifest", testdataPath(t, "comprehensive.sh")); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	sums, err := os.ReadFile(filepath.Join(man, "SHA256SUMS"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !strings.Contains(string(sums), "  deploy.1\n") || !strings.Contains(string(sums), "  deploy-push.1\n") {
+		t.Errorf("SHA256SUMS = %q, want deploy.1 and deploy-push.1", sums)
+	}
+
+	_, _, err = runCLI("--to", "html", "--manifest", testdataPath(t, "standalone.sh"))
+	if got := ExitCode(err); got != ExitUsage {
+		t.Errorf("--manifest without --output-dir ExitCode = %d, want %d (err: %v)", got, ExitUsage, err)
+	}
+	_, _, err = runCLI("man", "--manifest", testdataPath(t, "comprehensive.sh"))
+	if got := ExitCode(err); got != ExitUsage {
+		t.Errorf("man --manifest without --split ExitCode = %d, want %d (err: %v)", got, ExitUsage, err)
+	}
+}
+
 // --- Warnings ---
 
 func TestCLI_WarningsIncluded(t *testing.T) {
@@ -285,6 +1012,46 @@ func TestCLI_QuietSuppressesStderr(t *testing.T) {
 	}
 }
 
+func TestCLI_TagAlias(t *testing.T) {
+	path := writeTemp(t, "tool.sh", "#!/bin/bash\n#@/command\n # @param <file> File to read\n ##\n")
+
+	stdout, stderr, err := runCLI("--tag-alias", "@param=@operand", "--warn-tag-aliases", path)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if !strings.Contains(stdout, `"operands":[{"value":{"name":"file"`) {
+		t.Errorf("@param not parsed as @operand:\n%s", stdout)
+	}
+	if !strings.Contains(stderr, "@param is an alias; use @operand") {
+		t.Errorf("stderr missing the alias warning:\n%s", stderr)
+	}
+
+	for _, args := range [][]string{
+		{"--tag-alias", "param", path},
+		{"--tag-alias", "param=", path},
+		{"--warn-tag-aliases", path},
+	} {
+		if _, _, err := runCLI(args...); ExitCode(err) != ExitUsage {
+			t.Errorf("%v: exit code %d, want %d (%v)", args, ExitCode(err), ExitUsage, err)
+		}
+	}
+}
+
+func TestCLI_WarnTagOrder(t *testing.T) {
+	path := writeTemp(t, "tool.sh", "#!/bin/bash\n#@/command\n # @exit 0 Success\n # @flag -v Verbose\n ##\n")
+
+	if _, _, err := runCLI("--fail-on-warnings", path); err != nil {
+		t.Errorf("warned without --warn-tag-order: %v", err)
+	}
+	_, stderr, err := runCLI("--warn-tag-order", "--fail-on-warnings", path)
+	if ExitCode(err) != ExitWarnings {
+		t.Errorf("exit code %d, want %d (%v)", ExitCode(err), ExitWarnings, err)
+	}
+	if !strings.Contains(stderr, "tool.sh:4: warning: @flag should come before @exit") {
+		t.Errorf("stderr missing the order warning:\n%s", stderr)
+	}
+}
+
 // --- Error cases ---
 
 func TestCLI_NoArgs(t *testing.T) {
@@ -324,6 +1091,63 @@ func TestCLI_NonJSONMultipleFiles(t *testing.T) {
 	}
 }
 
+func TestCLI_WhatisMultipleFiles(t *testing.T) {
+	stdout, _, err := runCLI("--to", "whatis", "-q",
+		testdataPath(t, "comprehensive.sh"),
+		testdataPath(t, "standalone.sh"),
+	)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	lines := strings.Split(strings.TrimSpace(stdout), "\n")
+	if len(lines) != 2 {
+		t.Fatalf("expected 2 whatis lines, got %d:\n%s", len(lines), stdout)
+	}
+	if !strings.HasPrefix(lines[0], "deploy (1) - ") {
+		t.Errorf("unexpected first line: %q", lines[0])
+	}
+}
+
+func TestCLI_ListMultipleFiles(t *testing.T) {
+	comprehensive, standalone := testdataPath(t, "comprehensive.sh"), testdataPath(t, "standalone.sh")
+	stdout, _, err := runCLI("--to", "list", "-q", comprehensive, standalone)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	for _, want := range []string{"subcommand push ", " " + comprehensive + ":", " " + standalone + ":"} {
+		if !strings.Contains(stdout, want) {
+			t.Errorf("list output missing %q:\n%s", want, stdout)
+		}
+	}
+}
+
+func TestCLI_SourceURL(t *testing.T) {
+	stdout, _, err := runCLI("--to", "html", "--source-url", "https://example.com/{line}", testdataPath(t, "comprehensive.sh"))
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if !strings.Contains(stdout, `<a href="https://example.com/`) {
+		t.Errorf("expected source links, got:\n%s", stdout)
+	}
+
+	_, _, err = runCLI("--to", "man", "--source-url", "https://example.com/{line}", testdataPath(t, "comprehensive.sh"))
+	if ExitCode(err) != ExitUsage {
+		t.Errorf("expected usage error for --source-url with man, got %v", err)
+	}
+
+	stdout, _, err = runCLI("--to", "html", "--source-url", "https://example.com/blob/{ref}/{path}", "--source-ref", "v1.2.0", testdataPath(t, "standalone.sh"))
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if !strings.Contains(stdout, `<a href="https://example.com/blob/v1.2.0/`) {
+		t.Errorf("expected links to v1.2.0, got:\n%s", stdout)
+	}
+	_, _, err = runCLI("--to", "html", "--source-ref", "v1.2.0", testdataPath(t, "standalone.sh"))
+	if ExitCode(err) != ExitUsage {
+		t.Errorf("expected usage error for --source-ref without --source-url, got %v", err)
+	}
+}
+
 func TestCLI_ToAndGetMutuallyExclusive(t *testing.T) {
 	_, _, err := runCLI("--to", "help", "--get", "name", testdataPath(t, "comprehensive.sh"))
 	if err == nil {
@@ -438,6 +1262,40 @@ func TestCLI_CompleteSetupFish(t *testing.T) {
 	}
 }
 
+func TestCLI_CompleteSetupCommandName(t *testing.T) {
+	stdout, _, err := runCLI("complete", "--setup", "bash", "--command-name", "dep", testdataPath(t, "comprehensive.sh"))
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if !strings.HasSuffix(strings.TrimSpace(stdout), " deploy dep") {
+		t.Errorf("bash setup missing alias registration: %s", stdout)
+	}
+}
+
+func TestCLI_CompletionCommandName(t *testing.T) {
+	stdout, _, err := runCLI("--to", "completion:bash", "--command-name", "dep", testdataPath(t, "comprehensive.sh"))
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if !strings.Contains(stdout, "complete -F _deploy deploy dep") {
+		t.Errorf("bash completion missing alias registration: %s", stdout)
+	}
+}
+
+func TestCLI_CommandNameCompletionOnly(t *testing.T) {
+	_, _, err := runCLI("--to", "json", "--command-name", "dep", testdataPath(t, "comprehensive.sh"))
+	if err == nil || !strings.Contains(err.Error(), "--command-name supports only the completion formats") {
+		t.Errorf("err = %v, want --command-name rejected for json", err)
+	}
+}
+
+func TestCLI_CommandNameRequiresCommandBlock(t *testing.T) {
+	_, _, err := runCLI("--to", "completion:bash", "--command-name", "su", testdataPath(t, "library.sh"))
+	if err == nil || !strings.Contains(err.Error(), "requires a #@/command block") {
+		t.Errorf("err = %v, want missing command block error", err)
+	}
+}
+
 func TestCLI_CompleteNoArgs(t *testing.T) {
 	_, _, err := runCLI("complete")
 	if err == nil {
@@ -473,6 +1331,49 @@ func TestCLI_Stdin(t *testing.T) {
 	}
 }
 
+func TestCLI_StdinName(t *testing.T) {
+	tests := []struct {
+		name  string
+		input string
+		args  []string
+		want  string
+	}{
+		{"path", "#?/name tool\n", []string{"--stdin-name", "bin/tool.sh"}, `"path":"bin/tool.sh"`},
+		{"name fallback", "#!/bin/bash\n", []string{"--stdin-name", "bin/tool.sh", "--get", "name"}, "tool\n"},
+		{"man page", "#!/bin/bash\n", []string{"--stdin-name", "deploy.sh", "--to", "man"}, ".TH DEPLOY "},
+		{"bash completion", "#!/bin/bash\n", []string{"--stdin-name", "deploy.sh", "--to", "completion:bash"}, "complete -F _deploy deploy\n"},
+		{"bats script", "#?/name tool\n", []string{"--stdin-name", "bin/tool.sh", "--to", "bats"}, "$BATS_TEST_DIRNAME/tool.sh"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			r, w, err := os.Pipe()
+			if err != nil {
+				t.Fatal(err)
+			}
+			go func() {
+				_, _ = w.WriteString(tt.input)
+				w.Close()
+			}()
+			oldStdin := os.Stdin
+			os.Stdin = r
+			defer func() { os.Stdin = oldStdin }()
+
+			stdout, _, err := runCLI(append(tt.args, "-")...)
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if !strings.Contains(stdout, tt.want) {
+				t.Errorf("output missing %q:\n%s", tt.want, stdout)
+			}
+		})
+	}
+
+	_, _, err := runCLI("--stdin-name", "tool.sh", testdataPath(t, "minimal.sh"))
+	if got := ExitCode(err); got != ExitUsage {
+		t.Errorf("--stdin-name without - ExitCode = %d, want %d (err: %v)", got, ExitUsage, err)
+	}
+}
+
 // --- Version ---
 
 func TestCLI_Version(t *testing.T) {
@@ -484,3 +1385,79 @@ func TestCLI_Version(t *testing.T) {
 		t.Errorf("version output missing 'test-version': %s", stdout)
 	}
 }
+
+func TestCLI_SourceDateEpoch(t *testing.T) {
+	script := testdataPath(t, "comprehensive.sh")
+
+	stdout, _, err := runCLI("--to", "completion:bash", script)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if !strings.Contains(stdout, "\n# Generated by shedoc from deploy 2.1.0\n") {
+		t.Errorf("expected an undated generated-by comment, got:\n%s", stdout)
+	}
+
+	t.Setenv("SOURCE_DATE_EPOCH", "1700000000")
+	for _, args := range [][]string{
+		{"--to", "completion:bash", script},
+		{"--to", "man", "--headings", "fr", script},
+		{"man", script},
+	} {
+		stdout, _, err := runCLI(args...)
+		if err != nil {
+			t.Fatalf("%v: unexpected error: %v", args, err)
+		}
+		if !strings.Contains(stdout, " Generated by shedoc from deploy 2.1.0 at 2023-11-14T22:13:20Z\n") {
+			t.Errorf("%v: expected a dated generated-by comment, got:\n%s", args, stdout)
+		}
+	}
+}
+
+func TestCLI_Translations(t *testing.T) {
+	catalog := writeTemp(t, "de.yaml", "lang: de\ncommand.flag.--verbose: Ausführliche Ausgabe\n")
+
+	stdout, _, err := runCLI("--to", "help", "--translations", catalog, testdataPath(t, "comprehensive.sh"))
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if !strings.Contains(stdout, "-v, --verbose           Ausführliche Ausgabe\n") {
+		t.Errorf("help output not translated:\n%s", stdout)
+	}
+
+	stdout, _, err = runCLI("--get", "lang", "--translations", catalog, testdataPath(t, "comprehensive.sh"))
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if stdout != "de\n" {
+		t.Errorf("lang = %q, want %q", stdout, "de\n")
+	}
+}
+
+func TestCLI_Headings(t *testing.T) {
+	stdout, _, err := runCLI("--to", "help", "--headings", "fr", testdataPath(t, "comprehensive.sh"))
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if !strings.Contains(stdout, "Utilisation:\n") || !strings.Contains(stdout, "Commandes:\n") {
+		t.Errorf("help headings not in French:\n%s", stdout)
+	}
+
+	catalog := writeTemp(t, "headings.yaml", "heading.commands: Subcommands\n")
+	stdout, _, err = runCLI("--to", "man", "--headings", "fr", "--translations", catalog, testdataPath(t, "comprehensive.sh"))
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if !strings.Contains(stdout, ".SH SUBCOMMANDS\n") || !strings.Contains(stdout, ".SH OPTIONS\n") || !strings.Contains(stdout, ".SH ENVIRONNEMENT\n") {
+		t.Errorf("man headings not replaced:\n%s", stdout)
+	}
+
+	for _, args := range [][]string{
+		{"--to", "help", "--headings", "xx"},
+		{"--to", "json", "--headings", "de"},
+	} {
+		_, _, err := runCLI(append(args, testdataPath(t, "comprehensive.sh"))...)
+		if got := ExitCode(err); got != ExitUsage {
+			t.Errorf("%v: ExitCode = %d, want %d (err: %v)", args, got, ExitUsage, err)
+		}
+	}
+}
@@ -1,19 +1,26 @@
 package cli
 
 import (
+	"cmp"
+	"encoding/json"
 	"fmt"
 	"io"
 	"os"
 	"path/filepath"
+	"slices"
 	"strings"
 
 	"github.com/nickawilliams/shedoc"
+	"github.com/nickawilliams/shedoc/internal/completionmodel"
 	"github.com/spf13/cobra"
 )
 
 var (
-	flagCompleteShell string
-	flagCompleteSetup string
+	flagCompleteShell  string
+	flagCompleteSetup  string
+	flagCompleteNoExec bool
+
+	flagCompleteCommandNames []string
 )
 
 func newCompleteCmd() *cobra.Command {
@@ -25,6 +32,17 @@ func newCompleteCmd() *cobra.Command {
   Handler mode (invoked at tab-press time by the shell):
     shedoc complete deploy.sh
     shedoc complete --shell fish deploy.sh
+    shedoc complete --shell json deploy.sh
+
+    With --shell json, candidates are printed as a JSON array of objects
+    with word, description, kind, and needsArgument, for custom front ends
+    and tests. Kinds are subcommand, flag, option, value, operand, file, and
+    directory. Candidates for an operand also give its name and the 1-based
+    position being completed. Operands after a variadic operand are
+    completed as that operand, and their positions keep counting up.
+
+    Commands documented with @complete are run with a short timeout and a
+    minimal environment; pass --no-exec to skip them.
 
   Setup mode (run once to configure your shell):
     shedoc complete --setup bash deploy.sh
@@ -36,8 +54,10 @@ func newCompleteCmd() *cobra.Command {
 		SilenceErrors: true,
 	}
 
-	cmd.Flags().StringVar(&flagCompleteShell, "shell", "bash", "output format for handler mode (bash, fish)")
+	cmd.Flags().StringVar(&flagCompleteShell, "shell", "bash", "output format for handler mode (bash, zsh, fish, json)")
 	cmd.Flags().StringVar(&flagCompleteSetup, "setup", "", "output shell registration code (bash, zsh, fish)")
+	cmd.Flags().BoolVar(&flagCompleteNoExec, "no-exec", false, "do not run @complete commands in handler mode")
+	cmd.Flags().StringArrayVar(&flagCompleteCommandNames, "command-name", nil, "additional name to register completions for (repeatable)")
 
 	cmd.MarkFlagsMutuallyExclusive("shell", "setup")
 
@@ -58,7 +78,11 @@ func runComplete(cmd *cobra.Command, args []string) error {
 
 // runCompleteSetup outputs shell-specific registration code.
 func runCompleteSetup(w io.Writer, scriptPath, shell string) error {
-	doc, err := shedoc.Parse(scriptPath)
+	opts, err := parseOptions()
+	if err != nil {
+		return err
+	}
+	doc, err := shedoc.Parse(scriptPath, opts...)
 	if err != nil {
 		return fmt.Errorf("failed to parse %s: %w", scriptPath, err)
 	}
@@ -72,25 +96,34 @@ func runCompleteSetup(w io.Writer, scriptPath, shell string) error {
 	if cmdName == "" {
 		cmdName = strings.TrimSuffix(filepath.Base(scriptPath), filepath.Ext(scriptPath))
 	}
+	if err := addCommandAliases(doc, flagCompleteCommandNames); err != nil {
+		return err
+	}
+	names := completionmodel.Build(doc, cmdName).Names
 
 	switch shell {
 	case "bash":
-		fmt.Fprintf(w, "complete -C \"shedoc complete %s\" %s\n", absPath, cmdName)
+		fmt.Fprintf(w, "complete -C \"shedoc complete %s\" %s\n", absPath, strings.Join(names, " "))
 	case "zsh":
 		funcName := "_" + strings.ReplaceAll(cmdName, "-", "_") + "_shedoc"
 		fmt.Fprintf(w, "%s() {\n", funcName)
 		fmt.Fprintf(w, "  local COMP_LINE COMP_POINT\n")
-		fmt.Fprintf(w, "  COMP_LINE=\"${words[*]}\"\n")
-		fmt.Fprintf(w, "  COMP_POINT=${#COMP_LINE}\n")
-		fmt.Fprintf(w, "  local completions\n")
-		fmt.Fprintf(w, "  completions=($(COMP_LINE=\"$COMP_LINE\" COMP_POINT=\"$COMP_POINT\" shedoc complete %s))\n", absPath)
+		fmt.Fprintf(w, "  COMP_LINE=\"$BUFFER\"\n")
+		fmt.Fprintf(w, "  COMP_POINT=$CURSOR\n")
+		fmt.Fprintf(w, "  local -a completions options\n")
+		fmt.Fprintf(w, "  completions=(${(f)\"$(COMP_LINE=\"$COMP_LINE\" COMP_POINT=\"$COMP_POINT\" shedoc complete --shell zsh %s)\"})\n", absPath)
+		fmt.Fprintf(w, "  options=(${${(M)completions:#--*=}%%=})\n")
+		fmt.Fprintf(w, "  completions=(${completions:#--*=})\n")
 		fmt.Fprintf(w, "  compadd -a completions\n")
+		fmt.Fprintf(w, "  compadd -S '=' -a options\n")
 		fmt.Fprintf(w, "}\n")
-		fmt.Fprintf(w, "compdef %s %s\n", funcName, cmdName)
+		fmt.Fprintf(w, "compdef %s %s\n", funcName, strings.Join(names, " "))
 	case "fish":
-		fmt.Fprintf(w, "complete -c %s -a '(COMP_LINE=(commandline) COMP_POINT=(commandline -C) shedoc complete --shell fish %s)'\n", cmdName, absPath)
+		for _, name := range names {
+			fmt.Fprintf(w, "complete -c %s -a '(COMP_LINE=(commandline) COMP_POINT=(commandline -C) shedoc complete --shell fish %s)'\n", name, absPath)
+		}
 	default:
-		return fmt.Errorf("unsupported shell: %q (supported: bash, zsh, fish)", shell)
+		return usageErrorf("unsupported shell: %q (supported: bash, zsh, fish)", shell)
 	}
 
 	return nil
@@ -101,6 +134,9 @@ func runCompleteSetup(w io.Writer, scriptPath, shell string) error {
 func runCompleteHandler(w io.Writer, scriptPath, shell string) error {
 	compLine := os.Getenv("COMP_LINE")
 	if compLine == "" {
+		if shell == "json" {
+			return writeJSONCandidates(w, nil)
+		}
 		return nil // no completion context, nothing to output
 	}
 
@@ -109,177 +145,387 @@ func runCompleteHandler(w io.Writer, scriptPath, shell string) error {
 		_, _ = fmt.Sscanf(cp, "%d", &compPoint)
 	}
 
-	doc, err := shedoc.Parse(scriptPath)
+	opts, err := parseOptions()
+	if err != nil {
+		return err
+	}
+	doc, err := shedoc.Parse(scriptPath, opts...)
 	if err != nil {
+		if shell == "json" {
+			return err
+		}
 		return nil // silently fail during completion
 	}
 
-	candidates := completionCandidates(doc, compLine, compPoint)
+	var run commandRunner
+	if !flagCompleteNoExec {
+		run = execRunner(filepath.Dir(scriptPath))
+	}
+
+	candidates := completionCandidates(doc, compLine, compPoint, run)
+
+	if shell == "json" {
+		return writeJSONCandidates(w, candidates)
+	}
+
+	// Bash replaces only the part of the current word after the last
+	// wordbreak character, so drop what precedes it from each candidate.
+	var prefix string
+	if shell == "bash" {
+		wordbreaks := defaultWordbreaks
+		if wb, ok := os.LookupEnv("COMP_WORDBREAKS"); ok {
+			wordbreaks = wb
+		}
+		if compPoint < len(compLine) {
+			compLine = compLine[:compPoint]
+		}
+		words := splitCommandLine(compLine)
+		prefix = wordbreakPrefix(words[len(words)-1], wordbreaks)
+	}
+
 	for _, c := range candidates {
+		word := strings.TrimPrefix(c.word, prefix)
+		if c.needsValue && shell != "bash" {
+			word += "="
+		}
 		if shell == "fish" {
 			desc := strings.ReplaceAll(c.description, "\t", " ")
-			fmt.Fprintf(w, "%s\t%s\n", c.word, desc)
+			fmt.Fprintf(w, "%s\t%s\n", word, desc)
 		} else {
-			fmt.Fprintln(w, c.word)
+			fmt.Fprintln(w, word)
 		}
 	}
 	return nil
 }
 
+// writeJSONCandidates prints candidates as a JSON array, whole words and
+// without shell-specific suffixes.
+func writeJSONCandidates(w io.Writer, candidates []candidate) error {
+	out := make([]jsonCandidate, 0, len(candidates))
+	for _, c := range candidates {
+		out = append(out, jsonCandidate{
+			Word:          c.word,
+			Description:   c.description,
+			Kind:          c.kind,
+			NeedsArgument: c.kind == kindOption,
+			Operand:       c.operand,
+			Position:      c.position,
+		})
+	}
+	enc := json.NewEncoder(w)
+	enc.SetEscapeHTML(false)
+	enc.SetIndent("", "  ")
+	return enc.Encode(out)
+}
+
 type candidate struct {
 	word        string
 	description string
+	kind        string
+	// needsValue marks long options that take a value, which shells that
+	// support it complete with a trailing "=".
+	needsValue bool
+	// operand and position name the operand an operand candidate is for and
+	// the 1-based position of the word being completed.
+	operand  string
+	position int
+}
+
+// Candidate kinds, as reported by --shell json.
+const (
+	kindSubcommand = "subcommand"
+	kindFlag       = "flag"
+	kindOption     = "option"
+	kindValue      = "value"
+	kindOperand    = "operand"
+	kindFile       = "file"
+	kindDirectory  = "directory"
+)
+
+// jsonCandidate is a candidate as printed by --shell json.
+type jsonCandidate struct {
+	Word          string `json:"word"`
+	Description   string `json:"description,omitempty"`
+	Kind          string `json:"kind"`
+	NeedsArgument bool   `json:"needsArgument"`
+	Operand       string `json:"operand,omitempty"`
+	Position      int    `json:"position,omitempty"`
 }
 
 // completionCandidates determines the available completions given the document
-// and current input state.
-func completionCandidates(doc *shedoc.Document, compLine string, compPoint int) []candidate {
+// and current input state. run executes @complete commands; when nil, they
+// are skipped.
+func completionCandidates(doc *shedoc.Document, compLine string, compPoint int, run commandRunner) []candidate {
 	// Truncate at cursor position.
 	if compPoint < len(compLine) {
 		compLine = compLine[:compPoint]
 	}
 
-	words := strings.Fields(compLine)
-
-	// Determine if we're completing a new (empty) word or a partial word.
-	// If the line ends with whitespace, cursor is on a new empty word.
-	endsWithSpace := len(compLine) > 0 && compLine[len(compLine)-1] == ' '
-
-	var curWord string
-	if !endsWithSpace && len(words) > 1 {
-		curWord = words[len(words)-1]
-		words = words[:len(words)-1]
-	} else if !endsWithSpace && len(words) == 1 {
+	// The last word is the one under the cursor (empty after whitespace).
+	words := splitCommandLine(compLine)
+	curWord := words[len(words)-1]
+	words = words[:len(words)-1]
+	if len(words) == 0 {
 		// Only the command name, partially typed — nothing to complete
 		return nil
 	}
 
 	// Skip words[0] — it's the command name itself.
-	if len(words) > 0 {
-		words = words[1:]
-	}
-
-	// Extract command and subcommand blocks.
-	var cmdBlock *shedoc.Block
-	var subcommands []shedoc.Block
-	for i := range doc.Blocks {
-		switch doc.Blocks[i].Visibility {
-		case shedoc.VisibilityCommand:
-			cmdBlock = &doc.Blocks[i]
-		case shedoc.VisibilitySubcommand:
-			subcommands = append(subcommands, doc.Blocks[i])
-		}
-	}
+	words = words[1:]
+
+	model := completionmodel.Build(doc, "")
 
-	// No command block and no subcommands — nothing to complete.
-	if cmdBlock == nil && len(subcommands) == 0 {
+	// No command flags and no subcommands — nothing to complete.
+	if len(model.Flags) == 0 && len(model.Subcommands) == 0 && len(model.Operands) == 0 {
 		return nil
 	}
 
-	// Find if a subcommand has been specified.
-	var matchedSub *shedoc.Block
+	// Walk the typed words to find the subcommand, the positional index of
+	// the word being completed, and whether it is an option's value. Once
+	// "--" ends the options, every word is an operand.
+	var matchedSub *completionmodel.Subcommand
+	var pendingOption *completionmodel.Flag
+	flags := model.Flags
+	operands := model.Operands
+	position := 0
+	endOfOptions := false
 	for _, w := range words {
-		for i := range subcommands {
-			if subcommands[i].Name == w {
-				matchedSub = &subcommands[i]
-				break
+		if pendingOption != nil {
+			pendingOption = nil
+			continue
+		}
+		if w == "--" && !endOfOptions {
+			endOfOptions = true
+			continue
+		}
+		if strings.HasPrefix(w, "-") && !endOfOptions {
+			pendingOption = awaitedOption(w, flags)
+			continue
+		}
+		if matchedSub == nil && !endOfOptions {
+			if matchedSub = model.Subcommand(w); matchedSub != nil {
+				flags = append(slices.Clone(matchedSub.Flags), model.Flags...)
+				operands = matchedSub.Operands
+				position = 0
+				continue
 			}
 		}
-		if matchedSub != nil {
-			break
+		position++
+	}
+
+	// Completing a value attached with "=": offer @complete output or nothing.
+	if name, _, ok := strings.Cut(curWord, "="); ok && strings.HasPrefix(name, "--") && !endOfOptions {
+		opt := valueOption(name, flags)
+		if opt == nil || opt.Complete == "" || run == nil {
+			return nil
+		}
+		var candidates []candidate
+		for _, c := range commandCandidates(run, opt.Complete, kindValue) {
+			candidates = append(candidates, candidate{word: name + "=" + c.word, kind: kindValue})
 		}
+		return filterCandidates(candidates, curWord)
 	}
 
-	// Check if prevWord is an option that takes a value — suppress completions.
-	prevWord := ""
-	if len(words) > 0 {
-		prevWord = words[len(words)-1]
+	// Completing an option's value: offer @complete output or nothing.
+	if pendingOption != nil {
+		if pendingOption.Complete == "" || run == nil {
+			return nil
+		}
+		return filterCandidates(commandCandidates(run, pendingOption.Complete, kindValue), curWord)
 	}
-	// When !endsWithSpace && curWord != "", curWord is part of words
-	// and prevWord stays empty — no special handling needed.
 
-	if prevWord != "" && isValueOption(prevWord, cmdBlock, matchedSub) {
-		return nil
+	// Completing a cluster of short flags: offer the cluster extended by
+	// each flag it does not already hold.
+	if !endOfOptions {
+		if cs := clusterCandidates(curWord, flags); cs != nil {
+			return cs
+		}
 	}
 
 	// Build candidate list.
 	var candidates []candidate
 
-	if matchedSub != nil {
-		// Inside a subcommand: subcommand-specific flags + global flags.
-		candidates = append(candidates, flagCandidates(matchedSub)...)
-		if cmdBlock != nil {
-			candidates = append(candidates, flagCandidates(cmdBlock)...)
-		}
-	} else {
+	if matchedSub == nil && len(model.Subcommands) > 0 && !endOfOptions {
 		// Top-level: subcommand names + global flags.
-		for _, sub := range subcommands {
-			desc := firstLineCli(sub.Description)
-			if sub.Deprecated != nil {
-				desc = "[deprecated] " + sub.Deprecated.Message
+		for _, sub := range model.Subcommands {
+			for _, word := range sub.Words() {
+				candidates = append(candidates, candidate{word: word, description: sub.Description, kind: kindSubcommand})
 			}
-			candidates = append(candidates, candidate{word: sub.Name, description: desc})
 		}
-		if cmdBlock != nil {
-			candidates = append(candidates, flagCandidates(cmdBlock)...)
+	} else if op := completionmodel.OperandAt(operands, position); op != nil {
+		for _, c := range operandCandidates(op, curWord, run) {
+			c.operand, c.position = op.Name, position+1
+			candidates = append(candidates, c)
 		}
 	}
 
-	// Filter by prefix.
-	if curWord != "" {
-		var filtered []candidate
-		for _, c := range candidates {
-			if strings.HasPrefix(c.word, curWord) {
-				filtered = append(filtered, c)
-			}
+	// Flags are offered until the first operand is typed; after that, only
+	// when the word being completed starts with "-".
+	if !endOfOptions && (position == 0 || strings.HasPrefix(curWord, "-")) {
+		candidates = append(candidates, flagCandidates(flags)...)
+	}
+
+	return filterCandidates(candidates, curWord)
+}
+
+// operandCandidates returns the candidates for an operand: the output of its
+// @complete command or, failing that, its default and, for path operands,
+// the files matching prefix.
+func operandCandidates(op *completionmodel.Operand, prefix string, run commandRunner) []candidate {
+	if op.Complete != "" {
+		if run == nil {
+			return nil
+		}
+		return commandCandidates(run, op.Complete, kindOperand)
+	}
+	var cs []candidate
+	if op.Default != "" {
+		cs = append(cs, candidate{word: op.Default, kind: kindOperand})
+	}
+	if op.Path {
+		cs = append(cs, fileCandidates(prefix)...)
+	}
+	return cs
+}
+
+// fileCandidates lists the files and directories that complete prefix,
+// relative to the working directory. Directories end in "/", and dotfiles are
+// listed only when prefix names one.
+func fileCandidates(prefix string) []candidate {
+	dir, base := filepath.Split(prefix)
+	entries, err := os.ReadDir(cmp.Or(dir, "."))
+	if err != nil {
+		return nil
+	}
+	var cs []candidate
+	for _, e := range entries {
+		name := e.Name()
+		if !strings.HasPrefix(name, base) || strings.HasPrefix(name, ".") && !strings.HasPrefix(base, ".") {
+			continue
+		}
+		c := candidate{word: dir + name, kind: kindFile}
+		if e.IsDir() {
+			c.word += "/"
+			c.kind = kindDirectory
+		}
+		cs = append(cs, c)
+	}
+	return cs
+}
+
+// filterCandidates returns the candidates whose word starts with prefix.
+func filterCandidates(candidates []candidate, prefix string) []candidate {
+	if prefix == "" {
+		return candidates
+	}
+	var filtered []candidate
+	for _, c := range candidates {
+		if strings.HasPrefix(c.word, prefix) {
+			filtered = append(filtered, c)
 		}
-		return filtered
 	}
+	return filtered
+}
 
-	return candidates
+// commandCandidates runs an @complete command and returns its output lines
+// as candidates of the given kind.
+func commandCandidates(run commandRunner, command, kind string) []candidate {
+	var cs []candidate
+	for _, line := range run(command) {
+		cs = append(cs, candidate{word: line, kind: kind})
+	}
+	return cs
 }
 
-// flagCandidates returns completion candidates for all flags and options in a block.
-func flagCandidates(block *shedoc.Block) []candidate {
+// flagCandidates returns completion candidates for the given flags and options.
+func flagCandidates(flags []completionmodel.Flag) []candidate {
 	var cs []candidate
-	for _, f := range block.Flags {
+	for _, f := range flags {
+		kind := kindFlag
+		if f.Value != nil {
+			kind = kindOption
+		}
 		if f.Short != "" {
-			cs = append(cs, candidate{word: f.Short, description: f.Description})
+			cs = append(cs, candidate{word: f.Short, description: f.Description, kind: kind})
 		}
 		if f.Long != "" {
-			cs = append(cs, candidate{word: f.Long, description: f.Description})
+			cs = append(cs, candidate{word: f.Long, description: f.Description, kind: kind, needsValue: f.Value != nil})
+		}
+	}
+	return cs
+}
+
+// clusterCandidates returns the candidates for a word that bundles two or
+// more documented short flags, as in "-vf": the word itself, followed by the
+// word extended with each short flag or option not yet in it. An option ends
+// the cluster, since the rest of the word would be its value. It returns nil
+// when word is not such a cluster.
+func clusterCandidates(word string, flags []completionmodel.Flag) []candidate {
+	if len(word) < 3 || word[0] != '-' || word[1] == '-' {
+		return nil
+	}
+	for i := 1; i < len(word); i++ {
+		f := shortFlag(word[i:i+1], flags)
+		if f == nil || f.Value != nil && i < len(word)-1 {
+			return nil
+		}
+		if f.Value != nil {
+			return []candidate{{word: word, description: f.Description, kind: kindOption}}
 		}
 	}
-	for _, o := range block.Options {
-		if o.Short != "" {
-			cs = append(cs, candidate{word: o.Short, description: o.Description})
+
+	cs := []candidate{{word: word, kind: kindFlag}}
+	for _, f := range flags {
+		if len(f.Short) != 2 || strings.Contains(word, f.Short[1:]) {
+			continue
 		}
-		if o.Long != "" {
-			cs = append(cs, candidate{word: o.Long, description: o.Description})
+		kind := kindFlag
+		if f.Value != nil {
+			kind = kindOption
 		}
+		cs = append(cs, candidate{word: word + f.Short[1:], description: f.Description, kind: kind})
 	}
 	return cs
 }
 
-// isValueOption checks if the given word is an option (not flag) that expects a value.
-func isValueOption(word string, blocks ...*shedoc.Block) bool {
-	for _, b := range blocks {
-		if b == nil {
-			continue
+// shortFlag returns the flag or option with the short name "-" + letter, or
+// nil if none is documented.
+func shortFlag(letter string, flags []completionmodel.Flag) *completionmodel.Flag {
+	for i := range flags {
+		if flags[i].Short == "-"+letter {
+			return &flags[i]
 		}
-		for _, o := range b.Options {
-			if o.Short == word || o.Long == word {
-				return true
+	}
+	return nil
+}
+
+// awaitedOption returns the option whose value is expected in the word after
+// word, or nil if word already holds it or names no value option. A value may
+// be attached as "--name=value", "-nvalue", or at the end of a cluster of
+// short flags, as in "-vn value".
+func awaitedOption(word string, flags []completionmodel.Flag) *completionmodel.Flag {
+	if strings.HasPrefix(word, "--") || strings.Contains(word, "=") || len(word) <= 2 {
+		return valueOption(word, flags)
+	}
+	for i := 1; i < len(word); i++ {
+		if opt := valueOption("-"+word[i:i+1], flags); opt != nil {
+			if i == len(word)-1 {
+				return opt
 			}
+			return nil
 		}
 	}
-	return false
+	return nil
 }
 
-// firstLineCli returns the first line of a potentially multi-line string.
-func firstLineCli(s string) string {
-	if idx := strings.IndexByte(s, '\n'); idx >= 0 {
-		return s[:idx]
+// valueOption returns the option named by word if it expects a value, or nil
+// for flags and unknown words.
+func valueOption(word string, flags []completionmodel.Flag) *completionmodel.Flag {
+	for i := range flags {
+		f := &flags[i]
+		if f.Value != nil && (f.Short == word || f.Long == word) {
+			return f
+		}
 	}
-	return s
+	return nil
 }
@@ -0,0 +1,60 @@
+package cli
+
+import (
+	"context"
+	"os"
+	"os/exec"
+	"strings"
+	"time"
+)
+
+// completeExecTimeout bounds how long an @complete command may run before it
+// is killed, so a slow command never stalls the shell.
+const completeExecTimeout = 2 * time.Second
+
+// sandboxEnvVars lists the environment variables passed through to @complete
+// commands. Everything else is withheld.
+var sandboxEnvVars = []string{"PATH", "HOME", "USER", "LANG", "LC_ALL", "TMPDIR"}
+
+// commandRunner runs an @complete command and returns its non-empty output
+// lines.
+type commandRunner func(command string) []string
+
+// execRunner returns a commandRunner that executes commands with sh in dir,
+// under a timeout and with a minimal environment. Failures yield no lines.
+func execRunner(dir string) commandRunner {
+	return func(command string) []string {
+		ctx, cancel := context.WithTimeout(context.Background(), completeExecTimeout)
+		defer cancel()
+
+		cmd := exec.CommandContext(ctx, "sh", "-c", command)
+		cmd.Dir = dir
+		cmd.Env = sandboxEnv()
+		cmd.WaitDelay = 100 * time.Millisecond
+
+		out, err := cmd.Output()
+		if err != nil {
+			return nil
+		}
+
+		var lines []string
+		for _, line := range strings.Split(string(out), "\n") {
+			if line = strings.TrimSpace(line); line != "" {
+				lines = append(lines, line)
+			}
+		}
+		return lines
+	}
+}
+
+// sandboxEnv returns the subset of the current environment listed in
+// sandboxEnvVars.
+func sandboxEnv() []string {
+	var env []string
+	for _, name := range sandboxEnvVars {
+		if v, ok := os.LookupEnv(name); ok {
+			env = append(env, name+"="+v)
+		}
+	}
+	return env
+}
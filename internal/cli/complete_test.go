@@ -2,8 +2,11 @@ package cli
 
 import (
 	"bytes"
+	"encoding/json"
 	"os"
 	"path/filepath"
+	"reflect"
+	"strconv"
 	"strings"
 	"testing"
 
@@ -19,11 +22,20 @@ func parseTestDoc(t *testing.T) *shedoc.Document {
 	return doc
 }
 
+func mustParseString(t *testing.T, input string) *shedoc.Document {
+	t.Helper()
+	doc, err := shedoc.ParseReader(strings.NewReader(input))
+	if err != nil {
+		t.Fatalf("ParseReader() error: %v", err)
+	}
+	return doc
+}
+
 func TestCompletionCandidates_TopLevel(t *testing.T) {
 	doc := parseTestDoc(t)
 
 	// "deploy " — cursor after space, should get subcommands + global flags
-	candidates := completionCandidates(doc, "deploy ", 7)
+	candidates := completionCandidates(doc, "deploy ", 7, nil)
 
 	// Should contain subcommand names
 	names := candidateWords(candidates)
@@ -44,7 +56,7 @@ func TestCompletionCandidates_TopLevelPrefix(t *testing.T) {
 	doc := parseTestDoc(t)
 
 	// "deploy p" — partial word "p", should match "push"
-	candidates := completionCandidates(doc, "deploy p", 8)
+	candidates := completionCandidates(doc, "deploy p", 8, nil)
 	names := candidateWords(candidates)
 	if !contains(names, "push") {
 		t.Errorf("expected 'push' in candidates, got %v", names)
@@ -58,7 +70,7 @@ func TestCompletionCandidates_FlagPrefix(t *testing.T) {
 	doc := parseTestDoc(t)
 
 	// "deploy --" — partial word "--", should match --verbose and --config
-	candidates := completionCandidates(doc, "deploy --", 9)
+	candidates := completionCandidates(doc, "deploy --", 9, nil)
 	names := candidateWords(candidates)
 	for _, want := range []string{"--verbose", "--config"} {
 		if !contains(names, want) {
@@ -75,7 +87,7 @@ func TestCompletionCandidates_Subcommand(t *testing.T) {
 	doc := parseTestDoc(t)
 
 	// "deploy push " — inside push subcommand, should get push flags + global flags
-	candidates := completionCandidates(doc, "deploy push ", 12)
+	candidates := completionCandidates(doc, "deploy push ", 12, nil)
 	names := candidateWords(candidates)
 	// push-specific flags
 	for _, want := range []string{"-f", "--force", "--dry-run", "--tag"} {
@@ -99,7 +111,7 @@ func TestCompletionCandidates_SubcommandFlagPrefix(t *testing.T) {
 	doc := parseTestDoc(t)
 
 	// "deploy push --d" — filtering push flags by --d
-	candidates := completionCandidates(doc, "deploy push --d", 15)
+	candidates := completionCandidates(doc, "deploy push --d", 15, nil)
 	names := candidateWords(candidates)
 	if !contains(names, "--dry-run") {
 		t.Errorf("expected '--dry-run' in candidates, got %v", names)
@@ -109,11 +121,49 @@ func TestCompletionCandidates_SubcommandFlagPrefix(t *testing.T) {
 	}
 }
 
+func TestCompletionCandidates_HiddenAndAliases(t *testing.T) {
+	doc := mustParseString(t, `#@/command
+ # @flag -v | --verbose Verbose output
+ # @flag --debug Dump internal state
+ # @hidden --debug
+ ##
+#@/subcommand push
+ # Deploys.
+ # @alias p
+ # @option --tag <version> Version tag
+ ##
+#@/subcommand selftest
+ # @hidden
+ ##
+`)
+
+	names := candidateWords(completionCandidates(doc, "deploy ", 7, nil))
+	for _, want := range []string{"push", "p", "--verbose"} {
+		if !contains(names, want) {
+			t.Errorf("expected %q in candidates, got %v", want, names)
+		}
+	}
+	for _, hidden := range []string{"--debug", "selftest"} {
+		if contains(names, hidden) {
+			t.Errorf("hidden %q should not be a candidate, got %v", hidden, names)
+		}
+	}
+
+	// The alias selects the subcommand.
+	names = candidateWords(completionCandidates(doc, "deploy p ", 9, nil))
+	if !contains(names, "--tag") {
+		t.Errorf("expected push flag '--tag' after alias, got %v", names)
+	}
+	if candidates := completionCandidates(doc, "deploy p --tag ", 15, nil); len(candidates) != 0 {
+		t.Errorf("expected no candidates after value option, got %v", candidateWords(candidates))
+	}
+}
+
 func TestCompletionCandidates_AfterValueOption(t *testing.T) {
 	doc := parseTestDoc(t)
 
 	// "deploy --config " — --config takes a value, should suppress completions
-	candidates := completionCandidates(doc, "deploy --config ", 16)
+	candidates := completionCandidates(doc, "deploy --config ", 16, nil)
 	if len(candidates) != 0 {
 		t.Errorf("expected no candidates after value option, got %v", candidateWords(candidates))
 	}
@@ -123,7 +173,7 @@ func TestCompletionCandidates_AfterValueOptionShort(t *testing.T) {
 	doc := parseTestDoc(t)
 
 	// "deploy -c " — -c takes a value, should suppress completions
-	candidates := completionCandidates(doc, "deploy -c ", 10)
+	candidates := completionCandidates(doc, "deploy -c ", 10, nil)
 	if len(candidates) != 0 {
 		t.Errorf("expected no candidates after short value option, got %v", candidateWords(candidates))
 	}
@@ -133,7 +183,7 @@ func TestCompletionCandidates_AfterValueOptionInSubcommand(t *testing.T) {
 	doc := parseTestDoc(t)
 
 	// "deploy push --tag " — --tag takes a value, should suppress
-	candidates := completionCandidates(doc, "deploy push --tag ", 18)
+	candidates := completionCandidates(doc, "deploy push --tag ", 18, nil)
 	if len(candidates) != 0 {
 		t.Errorf("expected no candidates after subcommand value option, got %v", candidateWords(candidates))
 	}
@@ -143,7 +193,7 @@ func TestCompletionCandidates_NoBlocks(t *testing.T) {
 	doc := &shedoc.Document{
 		Meta: shedoc.Meta{Name: "empty"},
 	}
-	candidates := completionCandidates(doc, "empty ", 6)
+	candidates := completionCandidates(doc, "empty ", 6, nil)
 	if len(candidates) != 0 {
 		t.Errorf("expected no candidates for script with no blocks, got %v", candidateWords(candidates))
 	}
@@ -153,12 +203,244 @@ func TestCompletionCandidates_OnlyCommandName(t *testing.T) {
 	doc := parseTestDoc(t)
 
 	// "deploy" — just the command name, no space, nothing to complete
-	candidates := completionCandidates(doc, "deploy", 6)
+	candidates := completionCandidates(doc, "deploy", 6, nil)
 	if len(candidates) != 0 {
 		t.Errorf("expected no candidates for bare command name, got %v", candidateWords(candidates))
 	}
 }
 
+const completeExecScript = `#@/command
+ # @option   -e | --env <name>  Environment
+ # @option   --config <path>    Config file
+ # @operand  <target>           Deploy target
+ # @complete --env $(list-envs)
+ # @complete <target> $(list-targets)
+ ##
+`
+
+// fakeRunner returns a commandRunner that answers from a fixed table and
+// records the commands it was asked to run.
+func fakeRunner(ran *[]string) commandRunner {
+	outputs := map[string][]string{
+		"list-envs":    {"production", "staging"},
+		"list-targets": {"web", "worker"},
+	}
+	return func(command string) []string {
+		*ran = append(*ran, command)
+		return outputs[command]
+	}
+}
+
+func TestCompletionCandidates_CompleteOptionValue(t *testing.T) {
+	doc := mustParseString(t, completeExecScript)
+
+	var ran []string
+	names := candidateWords(completionCandidates(doc, "deploy --env ", 13, fakeRunner(&ran)))
+	if strings.Join(names, " ") != "production staging" {
+		t.Errorf("candidates = %v, want [production staging]", names)
+	}
+
+	names = candidateWords(completionCandidates(doc, "deploy -e st", 12, fakeRunner(&ran)))
+	if strings.Join(names, " ") != "staging" {
+		t.Errorf("filtered candidates = %v, want [staging]", names)
+	}
+
+	// Options without @complete stay silent.
+	if candidates := completionCandidates(doc, "deploy --config ", 16, fakeRunner(&ran)); len(candidates) != 0 {
+		t.Errorf("expected no candidates for --config, got %v", candidateWords(candidates))
+	}
+}
+
+func TestCompletionCandidates_CompleteOperand(t *testing.T) {
+	doc := mustParseString(t, completeExecScript)
+
+	var ran []string
+	names := candidateWords(completionCandidates(doc, "deploy --env prod ", 18, fakeRunner(&ran)))
+	for _, want := range []string{"web", "worker", "--env"} {
+		if !contains(names, want) {
+			t.Errorf("expected %q in candidates, got %v", want, names)
+		}
+	}
+
+	// The operand is already supplied; nothing more to run.
+	ran = nil
+	completionCandidates(doc, "deploy web ", 11, fakeRunner(&ran))
+	if len(ran) != 0 {
+		t.Errorf("expected no commands past the last operand, ran %v", ran)
+	}
+}
+
+func TestCompletionCandidates_SubcommandOperands(t *testing.T) {
+	doc := mustParseString(t, `#@/subcommand deploy
+ # @flag    -f | --force  Skip confirmation
+ # @operand [env=staging] Environment
+ # @operand <file>        Manifest
+ ##
+`)
+	dir := t.TempDir()
+	for _, name := range []string{"app.yaml", "api.yaml", ".hidden"} {
+		if err := os.WriteFile(filepath.Join(dir, name), nil, 0o644); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if err := os.Mkdir(filepath.Join(dir, "conf"), 0o755); err != nil {
+		t.Fatal(err)
+	}
+	t.Chdir(dir)
+
+	tests := []struct {
+		line string
+		want string
+	}{
+		// Before any operand: the first operand's default and the flags.
+		{"tool deploy ", "staging -f --force"},
+		// Past the first operand: files for <file>, and no flags.
+		{"tool deploy prod ", "api.yaml app.yaml conf/"},
+		{"tool deploy prod ap", "api.yaml app.yaml"},
+		{"tool deploy prod .", ".hidden"},
+		// Flags stay available when asked for.
+		{"tool deploy prod -", "-f --force"},
+		// After "--", nothing is a flag.
+		{"tool deploy -- -", ""},
+		// Past the last operand: nothing.
+		{"tool deploy prod app.yaml ", ""},
+	}
+	for _, tt := range tests {
+		names := candidateWords(completionCandidates(doc, tt.line, len(tt.line), nil))
+		if got := strings.Join(names, " "); got != tt.want {
+			t.Errorf("%q: candidates = %q, want %q", tt.line, got, tt.want)
+		}
+	}
+}
+
+func TestCompletionCandidates_AttachedValue(t *testing.T) {
+	doc := mustParseString(t, completeExecScript)
+
+	var ran []string
+	names := candidateWords(completionCandidates(doc, "deploy --env=", 13, fakeRunner(&ran)))
+	if strings.Join(names, " ") != "--env=production --env=staging" {
+		t.Errorf("candidates = %v, want --env=production --env=staging", names)
+	}
+
+	names = candidateWords(completionCandidates(doc, "deploy --env=p", 14, fakeRunner(&ran)))
+	if strings.Join(names, " ") != "--env=production" {
+		t.Errorf("filtered candidates = %v, want --env=production", names)
+	}
+
+	// No choices for --config and unknown options: stay silent.
+	for _, line := range []string{"deploy --config=", "deploy --bogus=x"} {
+		if candidates := completionCandidates(doc, line, len(line), fakeRunner(&ran)); len(candidates) != 0 {
+			t.Errorf("%q: expected no candidates, got %v", line, candidateWords(candidates))
+		}
+	}
+}
+
+func TestCompletionCandidates_AfterSuppliedValue(t *testing.T) {
+	doc := mustParseString(t, `#@/command
+ # @flag     -v | --verbose  Verbose output
+ # @option   -e | --env <name>  Environment
+ # @operand  <target>       Deploy target
+ # @complete --env $(list-envs)
+ # @complete <target> $(list-targets)
+ ##
+`)
+
+	tests := []struct {
+		line string
+		want string
+	}{
+		// The value is part of the previous word: complete the operand.
+		{"deploy --env=prod ", "web worker -v --verbose -e --env"},
+		{"deploy -eprod ", "web worker -v --verbose -e --env"},
+		{"deploy -e=prod ", "web worker -v --verbose -e --env"},
+		{"deploy -veprod w", "web worker"},
+		// The value follows in its own word.
+		{"deploy --env prod w", "web worker"},
+		// The option ends a cluster of short flags: complete its value.
+		{"deploy -ve ", "production staging"},
+		{"deploy -ve prod ", "web worker -v --verbose -e --env"},
+	}
+	for _, tt := range tests {
+		var ran []string
+		names := candidateWords(completionCandidates(doc, tt.line, len(tt.line), fakeRunner(&ran)))
+		if got := strings.Join(names, " "); got != tt.want {
+			t.Errorf("%q: candidates = %q, want %q", tt.line, got, tt.want)
+		}
+	}
+}
+
+func TestCompletionCandidates_ShortFlagCluster(t *testing.T) {
+	doc := mustParseString(t, `#@/command
+ # @flag     -v | --verbose  Verbose output
+ # @flag     -f | --force    Force
+ # @flag     -q              Quiet
+ # @option   -o | --out <file>  Output file
+ # @operand  <target>       Deploy target
+ ##
+`)
+
+	tests := []struct {
+		line string
+		want string
+	}{
+		{"deploy -vf", "-vf -vfq -vfo"},
+		{"deploy -fqv", "-fqv -fqvo"},
+		// An option ends the cluster.
+		{"deploy -vo", "-vo"},
+		// Unknown letters and attached values are not clusters.
+		{"deploy -vx", ""},
+		{"deploy -ov", ""},
+		// A single flag completes as before.
+		{"deploy -v", "-v"},
+		// A cluster typed earlier does not hide the operand.
+		{"deploy -vf ", "-v --verbose -f --force -q -o --out"},
+		{"deploy -- -vf", ""},
+	}
+	for _, tt := range tests {
+		names := candidateWords(completionCandidates(doc, tt.line, len(tt.line), nil))
+		if got := strings.Join(names, " "); got != tt.want {
+			t.Errorf("%q: candidates = %q, want %q", tt.line, got, tt.want)
+		}
+	}
+}
+
+func TestRunCompleteHandler_ValueOptionSuffix(t *testing.T) {
+	scriptPath := filepath.Join("..", "..", "testdata", "comprehensive.sh")
+	t.Setenv("COMP_LINE", "deploy --con")
+	t.Setenv("COMP_POINT", "12")
+
+	for shell, want := range map[string]string{"bash": "--config", "zsh": "--config=", "fish": "--config=\tPath to configuration file"} {
+		var buf bytes.Buffer
+		if err := runCompleteHandler(&buf, scriptPath, shell); err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+		if got := strings.TrimSuffix(buf.String(), "\n"); got != want {
+			t.Errorf("%s output = %q, want %q", shell, got, want)
+		}
+	}
+}
+
+func TestCompletionCandidates_NoExec(t *testing.T) {
+	doc := mustParseString(t, completeExecScript)
+	if candidates := completionCandidates(doc, "deploy --env ", 13, nil); len(candidates) != 0 {
+		t.Errorf("expected no candidates without a runner, got %v", candidateWords(candidates))
+	}
+}
+
+func TestExecRunner(t *testing.T) {
+	t.Setenv("SHEDOC_TEST_SECRET", "leaked")
+	run := execRunner(t.TempDir())
+
+	got := run("printf 'a\\n\\n b \\n'; echo \"${SHEDOC_TEST_SECRET:-}\"")
+	if strings.Join(got, ",") != "a,b" {
+		t.Errorf("execRunner output = %q, want [a b] without leaked environment", got)
+	}
+
+	if got := run("exit 3"); got != nil {
+		t.Errorf("execRunner on failure = %q, want nil", got)
+	}
+}
+
 func TestRunCompleteHandler_BashOutput(t *testing.T) {
 	scriptPath := filepath.Join("..", "..", "testdata", "comprehensive.sh")
 
@@ -199,6 +481,95 @@ func TestRunCompleteHandler_FishOutput(t *testing.T) {
 	}
 }
 
+func TestRunCompleteHandler_JSONOutput(t *testing.T) {
+	scriptPath := filepath.Join("..", "..", "testdata", "comprehensive.sh")
+
+	tests := []struct {
+		line string
+		want []jsonCandidate
+	}{
+		{"deploy pu", []jsonCandidate{
+			{Word: "push", Description: "Deploys the application to the specified environment.", Kind: "subcommand"},
+		}},
+		{"deploy status --f", []jsonCandidate{
+			{Word: "--format", Description: "Output format (text, json, yaml)", Kind: "option", NeedsArgument: true},
+		}},
+		{"deploy --verb", []jsonCandidate{
+			{Word: "--verbose", Description: "Enable verbose output", Kind: "flag"},
+		}},
+		{"deploy --format x", []jsonCandidate{}},
+	}
+	for _, tt := range tests {
+		t.Setenv("COMP_LINE", tt.line)
+		t.Setenv("COMP_POINT", strconv.Itoa(len(tt.line)))
+
+		var buf bytes.Buffer
+		if err := runCompleteHandler(&buf, scriptPath, "json"); err != nil {
+			t.Fatalf("%q: unexpected error: %v", tt.line, err)
+		}
+		var got []jsonCandidate
+		if err := json.Unmarshal(buf.Bytes(), &got); err != nil {
+			t.Fatalf("%q: invalid JSON: %v\n%s", tt.line, err, buf.String())
+		}
+		if !reflect.DeepEqual(got, tt.want) {
+			t.Errorf("%q: got %+v, want %+v", tt.line, got, tt.want)
+		}
+	}
+}
+
+func TestCompletionCandidates_Kinds(t *testing.T) {
+	doc := mustParseString(t, completeExecScript)
+
+	var ran []string
+	tests := map[string]string{
+		"deploy --env ":   "value",
+		"deploy --env=":   "value",
+		"deploy -e prod ": "operand",
+		"deploy web -":    "option",
+	}
+	for line, want := range tests {
+		cs := completionCandidates(doc, line, len(line), fakeRunner(&ran))
+		if len(cs) == 0 || cs[0].kind != want {
+			t.Errorf("%q: first candidate = %+v, want kind %q", line, cs, want)
+		}
+	}
+}
+
+func TestCompletionCandidates_OperandPosition(t *testing.T) {
+	doc := mustParseString(t, `#@/command
+ # @operand  <env>         Environment
+ # @operand  <target...>   Deploy targets
+ # @complete <env> $(list-envs)
+ # @complete <target> $(list-targets)
+ ##
+`)
+
+	var ran []string
+	tests := []struct {
+		line     string
+		words    []string
+		operand  string
+		position int
+	}{
+		{"deploy ", []string{"production", "staging"}, "env", 1},
+		{"deploy staging ", []string{"web", "worker"}, "target", 2},
+		{"deploy staging web wo", []string{"worker"}, "target", 3},
+	}
+	for _, tt := range tests {
+		cs := completionCandidates(doc, tt.line, len(tt.line), fakeRunner(&ran))
+		var words []string
+		for _, c := range cs {
+			words = append(words, c.word)
+			if c.operand != tt.operand || c.position != tt.position {
+				t.Errorf("%q: %q is for %s at %d, want %s at %d", tt.line, c.word, c.operand, c.position, tt.operand, tt.position)
+			}
+		}
+		if !reflect.DeepEqual(words, tt.words) {
+			t.Errorf("%q: words = %v, want %v", tt.line, words, tt.words)
+		}
+	}
+}
+
 func TestRunCompleteHandler_NoCompLine(t *testing.T) {
 	scriptPath := filepath.Join("..", "..", "testdata", "comprehensive.sh")
 
@@ -321,7 +692,7 @@ func TestRunCompleteSetup_FallbackName(t *testing.T) {
 func TestCompletionCandidates_FishDescriptions(t *testing.T) {
 	doc := parseTestDoc(t)
 
-	candidates := completionCandidates(doc, "deploy ", 7)
+	candidates := completionCandidates(doc, "deploy ", 7, nil)
 
 	// Subcommands should have descriptions
 	for _, c := range candidates {
@@ -338,7 +709,7 @@ func TestCompletionCandidates_StatusSubcommand(t *testing.T) {
 	doc := parseTestDoc(t)
 
 	// "deploy status " — inside status subcommand
-	candidates := completionCandidates(doc, "deploy status ", 14)
+	candidates := completionCandidates(doc, "deploy status ", 14, nil)
 	names := candidateWords(candidates)
 	if !contains(names, "--format") {
 		t.Errorf("expected '--format' in status candidates, got %v", names)
@@ -349,7 +720,7 @@ func TestCompletionCandidates_AfterFormatOption(t *testing.T) {
 	doc := parseTestDoc(t)
 
 	// "deploy status --format " — --format takes value, suppress
-	candidates := completionCandidates(doc, "deploy status --format ", 23)
+	candidates := completionCandidates(doc, "deploy status --format ", 23, nil)
 	if len(candidates) != 0 {
 		t.Errorf("expected no candidates after --format (value option), got %v", candidateWords(candidates))
 	}
@@ -0,0 +1,77 @@
+package cli
+
+import "strings"
+
+// defaultWordbreaks mirrors bash's default COMP_WORDBREAKS. Bash does not
+// export the variable to `complete -C` commands, so it is only overridden
+// when the user exports it explicitly.
+const defaultWordbreaks = " \t\n\"'><=;|&(:"
+
+// splitCommandLine splits a partial command line into words following shell
+// quoting rules: single quotes are literal, double quotes honor backslash
+// escapes of \ " $ and `, and an unquoted backslash escapes the next
+// character. The final element is the word under the cursor, which is empty
+// when the line ends in unquoted whitespace. An unterminated quote is treated
+// as part of the word under the cursor.
+func splitCommandLine(line string) []string {
+	var words []string
+	var cur strings.Builder
+	inWord := false
+	var quote byte
+
+	for i := 0; i < len(line); i++ {
+		c := line[i]
+		switch {
+		case quote == '\'':
+			if c == '\'' {
+				quote = 0
+			} else {
+				cur.WriteByte(c)
+			}
+		case quote == '"':
+			switch {
+			case c == '"':
+				quote = 0
+			case c == '\\' && i+1 < len(line) && strings.IndexByte("\\\"$`", line[i+1]) >= 0:
+				i++
+				cur.WriteByte(line[i])
+			default:
+				cur.WriteByte(c)
+			}
+		case c == '\'' || c == '"':
+			quote = c
+			inWord = true
+		case c == '\\':
+			inWord = true
+			if i+1 < len(line) {
+				i++
+				cur.WriteByte(line[i])
+			}
+		case c == ' ' || c == '\t' || c == '\n':
+			if inWord {
+				words = append(words, cur.String())
+				cur.Reset()
+				inWord = false
+			}
+		default:
+			cur.WriteByte(c)
+			inWord = true
+		}
+	}
+
+	return append(words, cur.String())
+}
+
+// wordbreakPrefix returns the leading part of word that bash treats as
+// already complete because it ends in one of the wordbreak characters that
+// can occur inside an argument (: and =). Bash replaces only the text after
+// it, so candidates must be reported without this prefix.
+func wordbreakPrefix(word, wordbreaks string) string {
+	idx := -1
+	for _, c := range ":=" {
+		if strings.ContainsRune(wordbreaks, c) {
+			idx = max(idx, strings.LastIndexByte(word, byte(c)))
+		}
+	}
+	return word[:idx+1]
+}
@@ -0,0 +1,111 @@
+package cli
+
+import (
+	"bytes"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+	"testing"
+)
+
+func TestSplitCommandLine(t *testing.T) {
+	tests := []struct {
+		line string
+		want []string
+	}{
+		{"", []string{""}},
+		{"deploy", []string{"deploy"}},
+		{"deploy ", []string{"deploy", ""}},
+		{"deploy  push\t-f ", []string{"deploy", "push", "-f", ""}},
+		{`deploy "my file" `, []string{"deploy", "my file", ""}},
+		{`deploy 'it''s' x`, []string{"deploy", "its", "x"}},
+		{`deploy my\ file`, []string{"deploy", "my file"}},
+		{`deploy "a \"b\" \n"`, []string{"deploy", `a "b" \n`}},
+		{`deploy 'single \ "`, []string{"deploy", `single \ "`}},
+		{`deploy "unterminated`, []string{"deploy", "unterminated"}},
+		{`deploy ""`, []string{"deploy", ""}},
+		{`deploy "" `, []string{"deploy", "", ""}},
+		{"deploy host:port", []string{"deploy", "host:port"}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.line, func(t *testing.T) {
+			if got := splitCommandLine(tt.line); !slices.Equal(got, tt.want) {
+				t.Errorf("splitCommandLine(%q) = %q, want %q", tt.line, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestWordbreakPrefix(t *testing.T) {
+	tests := []struct {
+		word, wordbreaks, want string
+	}{
+		{"push", defaultWordbreaks, ""},
+		{"host:", defaultWordbreaks, "host:"},
+		{"a:b:c", defaultWordbreaks, "a:b:"},
+		{"--config=foo", defaultWordbreaks, "--config="},
+		{"--opt=a:b", defaultWordbreaks, "--opt=a:"},
+		{"a:b", " \t\n", ""},
+	}
+
+	for _, tt := range tests {
+		if got := wordbreakPrefix(tt.word, tt.wordbreaks); got != tt.want {
+			t.Errorf("wordbreakPrefix(%q, %q) = %q, want %q", tt.word, tt.wordbreaks, got, tt.want)
+		}
+	}
+}
+
+func TestCompletionCandidates_QuotedOperand(t *testing.T) {
+	doc := mustParseString(t, `#@/command
+ # @option --config <path> Config file
+ # @flag   --force         Force
+ ##
+`)
+
+	// The quoted value containing a space is a single word consumed by
+	// --config, so flag completion resumes afterwards.
+	names := candidateWords(completionCandidates(doc, `deploy --config "my file" --f`, 29, nil))
+	if !contains(names, "--force") {
+		t.Errorf("expected '--force' after quoted option value, got %v", names)
+	}
+
+	// Still inside the quoted value: nothing to offer.
+	if candidates := completionCandidates(doc, `deploy --config "my fi`, 22, nil); len(candidates) != 0 {
+		t.Errorf("expected no candidates inside quoted option value, got %v", candidateWords(candidates))
+	}
+}
+
+func TestRunCompleteHandler_ColonWordbreak(t *testing.T) {
+	dir := t.TempDir()
+	script := filepath.Join(dir, "deploy.sh")
+	err := os.WriteFile(script, []byte(`#@/command
+ # @option   --target <host>  Target host
+ # @complete --target $(printf 'db:5432\ndb:6432\nweb:80\n')
+ ##
+`), 0o644)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	t.Setenv("COMP_LINE", "deploy --target db:")
+	t.Setenv("COMP_POINT", "19")
+	t.Setenv("COMP_WORDBREAKS", defaultWordbreaks)
+
+	var buf bytes.Buffer
+	if err := runCompleteHandler(&buf, script, "bash"); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if got := strings.Fields(buf.String()); !slices.Equal(got, []string{"5432", "6432"}) {
+		t.Errorf("bash candidates = %q, want colon prefix trimmed", got)
+	}
+
+	buf.Reset()
+	if err := runCompleteHandler(&buf, script, "zsh"); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if got := strings.Fields(buf.String()); !slices.Equal(got, []string{"db:5432", "db:6432"}) {
+		t.Errorf("zsh candidates = %q, want full words", got)
+	}
+}
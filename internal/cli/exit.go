@@ -0,0 +1,82 @@
+package cli
+
+import (
+	"errors"
+	"fmt"
+
+	"github.com/spf13/cobra"
+)
+
+// Exit statuses of the shedoc command.
+const (
+	ExitOK       = 0 // success
+	ExitError    = 1 // runtime error, such as an unreadable file
+	ExitUsage    = 2 // invalid arguments or flags
+	ExitWarnings = 3 // parse warnings, with --fail-on-warnings
+	ExitFindings = 4 // problems found by validate, check-*, or roundtrip
+)
+
+// exitError is an error that carries the exit status it should end the
+// process with.
+type exitError struct {
+	code int
+	err  error
+}
+
+func (e *exitError) Error() string { return e.err.Error() }
+func (e *exitError) Unwrap() error { return e.err }
+
+// ExitCode returns the exit status for an error returned by the root
+// command: ExitOK for nil, the status an error carries, or ExitError.
+func ExitCode(err error) int {
+	if err == nil {
+		return ExitOK
+	}
+	var e *exitError
+	if errors.As(err, &e) {
+		return e.code
+	}
+	return ExitError
+}
+
+// usageErrorf returns an error that exits with ExitUsage.
+func usageErrorf(format string, args ...any) error {
+	return &exitError{code: ExitUsage, err: fmt.Errorf(format, args...)}
+}
+
+// findingsErrorf returns an error that exits with ExitFindings.
+func findingsErrorf(format string, args ...any) error {
+	return &exitError{code: ExitFindings, err: fmt.Errorf(format, args...)}
+}
+
+// markUsageErrors makes the flag and argument errors that cobra reports for
+// root and its subcommands exit with ExitUsage.
+func markUsageErrors(root *cobra.Command) {
+	root.SetFlagErrorFunc(func(_ *cobra.Command, err error) error {
+		return &exitError{code: ExitUsage, err: err}
+	})
+	markArgErrors(root)
+}
+
+// markArgErrors wraps the argument validation of cmd and its subcommands.
+// Cobra checks required flags and flag groups only after the arguments, so
+// they are checked here as well to report them as usage errors.
+func markArgErrors(cmd *cobra.Command) {
+	validate := cmd.Args
+	cmd.Args = func(c *cobra.Command, args []string) error {
+		err := c.ValidateFlagGroups()
+		if err == nil {
+			err = c.ValidateRequiredFlags()
+		}
+		if err == nil && validate != nil {
+			err = validate(c, args)
+		}
+		if err != nil {
+			return &exitError{code: ExitUsage, err: err}
+		}
+		return nil
+	}
+	for _, sub := range cmd.Commands() {
+		markArgErrors(sub)
+	}
+}
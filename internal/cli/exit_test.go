@@ -0,0 +1,41 @@
+package cli
+
+import (
+	"errors"
+	"testing"
+)
+
+func TestExitCode(t *testing.T) {
+	noisy := writeTemp(t, "noisy.sh", "#!/bin/bash\n#?/name noisy\n#?/bogus one\n")
+	bad := writeTemp(t, "bad.json", `{"meta":{},"bogus":1}`)
+
+	tests := []struct {
+		name string
+		args []string
+		want int
+	}{
+		{"ok", []string{testdataPath(t, "comprehensive.sh")}, ExitOK},
+		{"missing file", []string{"/nonexistent/script.sh"}, ExitError},
+		{"no args", nil, ExitUsage},
+		{"unknown flag", []string{"--bogus", noisy}, ExitUsage},
+		{"exclusive flags", []string{"--to", "man", "--get", "name", noisy}, ExitUsage},
+		{"unknown format", []string{"--to", "xml", noisy}, ExitUsage},
+		{"subcommand args", []string{"man"}, ExitUsage},
+		{"warnings", []string{noisy}, ExitOK},
+		{"fail on warnings", []string{"--fail-on-warnings", noisy}, ExitWarnings},
+		{"man fail on warnings", []string{"man", "--fail-on-warnings", noisy}, ExitWarnings},
+		{"validate findings", []string{"validate", bad}, ExitFindings},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, _, err := runCLI(tt.args...)
+			if got := ExitCode(err); got != tt.want {
+				t.Errorf("ExitCode = %d, want %d (err: %v)", got, tt.want, err)
+			}
+		})
+	}
+
+	if got := ExitCode(errors.New("plain")); got != ExitError {
+		t.Errorf("ExitCode(plain error) = %d, want %d", got, ExitError)
+	}
+}
@@ -0,0 +1,114 @@
+package cli
+
+import (
+	"regexp"
+	"strings"
+
+	"github.com/nickawilliams/shedoc"
+)
+
+// blockFilter reports whether a block matches one --filter expression.
+type blockFilter func(b *shedoc.Block) bool
+
+var (
+	reFilterHas   = regexp.MustCompile(`^(!?)has\((\w+)\)$`)
+	reFilterField = regexp.MustCompile(`^(\w+)\s*(!?=)\s*(.*)$`)
+)
+
+// parseFilter compiles a --filter expression:
+//
+//	has(tag)       the block uses @tag (or has a description)
+//	!has(tag)      the block does not
+//	field=value    visibility, name, function, or since equals value
+//	field!=value   it does not
+func parseFilter(expr string) (blockFilter, error) {
+	expr = strings.TrimSpace(expr)
+
+	if m := reFilterHas.FindStringSubmatch(expr); m != nil {
+		has, ok := blockHas[m[2]]
+		if !ok {
+			return nil, usageErrorf("filter %q: unknown tag %q", expr, m[2])
+		}
+		if m[1] == "!" {
+			return func(b *shedoc.Block) bool { return !has(b) }, nil
+		}
+		return has, nil
+	}
+
+	if m := reFilterField.FindStringSubmatch(expr); m != nil {
+		field, ok := blockFields[m[1]]
+		if !ok {
+			return nil, usageErrorf("filter %q: unknown field %q", expr, m[1])
+		}
+		value := m[3]
+		if m[2] == "!=" {
+			return func(b *shedoc.Block) bool { return field(b) != value }, nil
+		}
+		return func(b *shedoc.Block) bool { return field(b) == value }, nil
+	}
+
+	return nil, usageErrorf("filter %q: expected has(tag) or field=value", expr)
+}
+
+// blockHas maps the tags accepted by has() to a test for their presence.
+var blockHas = map[string]blockFilter{
+	"description": func(b *shedoc.Block) bool { return b.Description != "" },
+	"flag":        func(b *shedoc.Block) bool { return len(b.Flags) > 0 },
+	"option":      func(b *shedoc.Block) bool { return len(b.Options) > 0 },
+	"operand":     func(b *shedoc.Block) bool { return len(b.Operands) > 0 },
+	"env":         func(b *shedoc.Block) bool { return len(b.Env) > 0 },
+	"reads":       func(b *shedoc.Block) bool { return len(b.Reads) > 0 },
+	"requires":    func(b *shedoc.Block) bool { return len(b.Requires) > 0 },
+	"stdin":       func(b *shedoc.Block) bool { return b.Stdin != nil },
+	"exit":        func(b *shedoc.Block) bool { return len(b.Exit) > 0 },
+	"return":      func(b *shedoc.Block) bool { return len(b.Return) > 0 },
+	"stdout":      func(b *shedoc.Block) bool { return b.Stdout != nil },
+	"stderr":      func(b *shedoc.Block) bool { return b.Stderr != nil },
+	"sets":        func(b *shedoc.Block) bool { return len(b.Sets) > 0 },
+	"writes":      func(b *shedoc.Block) bool { return len(b.Writes) > 0 },
+	"alias":       func(b *shedoc.Block) bool { return len(b.Aliases) > 0 },
+	"hidden":      func(b *shedoc.Block) bool { return b.Hidden },
+	"since":       func(b *shedoc.Block) bool { return b.Since != "" },
+	"deprecated":  func(b *shedoc.Block) bool { return b.Deprecated != nil },
+	"see":         func(b *shedoc.Block) bool { return len(b.See) > 0 },
+}
+
+// blockFields maps the fields accepted by field=value to their value.
+var blockFields = map[string]func(b *shedoc.Block) string{
+	"visibility": func(b *shedoc.Block) string { return string(b.Visibility) },
+	"name":       func(b *shedoc.Block) string { return b.Name },
+	"function":   func(b *shedoc.Block) string { return b.FunctionName },
+	"since":      func(b *shedoc.Block) string { return b.Since },
+}
+
+// filterDocuments keeps the blocks that match every filter and drops the
+// documents left with none.
+func filterDocuments(docs []*shedoc.Document, exprs []string) ([]*shedoc.Document, error) {
+	var filters []blockFilter
+	for _, expr := range exprs {
+		f, err := parseFilter(expr)
+		if err != nil {
+			return nil, err
+		}
+		filters = append(filters, f)
+	}
+
+	var kept []*shedoc.Document
+	for _, doc := range docs {
+		var blocks []shedoc.Block
+	blocks:
+		for i := range doc.Blocks {
+			for _, f := range filters {
+				if !f(&doc.Blocks[i]) {
+					continue blocks
+				}
+			}
+			blocks = append(blocks, doc.Blocks[i])
+		}
+		if len(blocks) > 0 {
+			doc.Blocks = blocks
+			kept = append(kept, doc)
+		}
+	}
+	return kept, nil
+}
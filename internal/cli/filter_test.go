@@ -0,0 +1,81 @@
+package cli
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/nickawilliams/shedoc"
+)
+
+func TestParseFilter(t *testing.T) {
+	deprecated := &shedoc.Block{Visibility: shedoc.VisibilitySubcommand, Name: "migrate", Deprecated: &shedoc.Deprecated{}}
+	public := &shedoc.Block{Visibility: shedoc.VisibilityPublic, FunctionName: "to_upper", Flags: []shedoc.Flag{{Long: "--x"}}, Since: "2.1.0"}
+
+	tests := []struct {
+		expr    string
+		matches []*shedoc.Block
+		wantErr string
+	}{
+		{expr: "has(deprecated)", matches: []*shedoc.Block{deprecated}},
+		{expr: "!has(deprecated)", matches: []*shedoc.Block{public}},
+		{expr: "has(flag)", matches: []*shedoc.Block{public}},
+		{expr: "visibility=public", matches: []*shedoc.Block{public}},
+		{expr: "visibility != public", matches: []*shedoc.Block{deprecated}},
+		{expr: "name=migrate", matches: []*shedoc.Block{deprecated}},
+		{expr: "function=to_upper", matches: []*shedoc.Block{public}},
+		{expr: "has(since)", matches: []*shedoc.Block{public}},
+		{expr: "since=2.1.0", matches: []*shedoc.Block{public}},
+		{expr: "has(bogus)", wantErr: `unknown tag "bogus"`},
+		{expr: "color=red", wantErr: `unknown field "color"`},
+		{expr: "deprecated", wantErr: "expected has(tag) or field=value"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.expr, func(t *testing.T) {
+			f, err := parseFilter(tt.expr)
+			if tt.wantErr != "" {
+				if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+					t.Fatalf("error = %v, want %q", err, tt.wantErr)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			for _, b := range []*shedoc.Block{deprecated, public} {
+				want := false
+				for _, m := range tt.matches {
+					want = want || m == b
+				}
+				if got := f(b); got != want {
+					t.Errorf("filter(%s) = %v, want %v", b.Visibility, got, want)
+				}
+			}
+		})
+	}
+}
+
+func TestCLI_Filter(t *testing.T) {
+	stdout, _, err := runCLI("--filter", "has(deprecated)", "--filter", "visibility=subcommand", "--get", "subcommands",
+		testdataPath(t, "comprehensive.sh"), testdataPath(t, "library.sh"))
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if stdout != "migrate\n" {
+		t.Errorf("got %q, want %q", stdout, "migrate\n")
+	}
+
+	// Documents with no matching blocks are dropped.
+	stdout, _, err = runCLI("--filter", "visibility=private", testdataPath(t, "comprehensive.sh"), testdataPath(t, "library.sh"))
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if lines := strings.Split(strings.TrimSpace(stdout), "\n"); len(lines) != 1 || !strings.Contains(lines[0], `"name":"string-utils"`) {
+		t.Errorf("expected only library.sh, got:\n%s", stdout)
+	}
+
+	_, _, err = runCLI("--filter", "bogus(", testdataPath(t, "comprehensive.sh"))
+	if got := ExitCode(err); got != ExitUsage {
+		t.Errorf("ExitCode = %d, want %d (err: %v)", got, ExitUsage, err)
+	}
+}
@@ -0,0 +1,77 @@
+package cli
+
+import (
+	"slices"
+	"strings"
+
+	"github.com/nickawilliams/shedoc"
+)
+
+var flagFormatOptions []string
+
+// formatOptions are the parsed --format-option settings.
+type formatOptions struct {
+	sort     bool
+	include  []shedoc.Visibility
+	examples bool
+}
+
+// visibilities are the block visibilities accepted by include=.
+var visibilities = []shedoc.Visibility{
+	shedoc.VisibilityCommand,
+	shedoc.VisibilitySubcommand,
+	shedoc.VisibilityPublic,
+	shedoc.VisibilityPrivate,
+	shedoc.VisibilitySection,
+	shedoc.VisibilityVar,
+}
+
+// parseFormatOptions parses --format-option key=value settings:
+//
+//	sort=alpha|source              order of subcommands (default source)
+//	include=command,subcommand,... visibilities of the blocks to render
+//	examples=true|false            first example in each subcommand's
+//	                               completion description (fish and zsh)
+//
+// A later setting of a key replaces an earlier one.
+func parseFormatOptions(args []string) (formatOptions, error) {
+	var opts formatOptions
+	for _, arg := range args {
+		key, value, ok := strings.Cut(arg, "=")
+		if !ok {
+			return opts, usageErrorf("format option %q: expected key=value", arg)
+		}
+		switch key {
+		case "sort":
+			switch value {
+			case "alpha":
+				opts.sort = true
+			case "source":
+				opts.sort = false
+			default:
+				return opts, usageErrorf("format option %q: sort must be alpha or source", arg)
+			}
+		case "include":
+			opts.include = []shedoc.Visibility{}
+			for _, v := range strings.Split(value, ",") {
+				vis := shedoc.Visibility(strings.TrimSuffix(strings.TrimSpace(v), "s"))
+				if !slices.Contains(visibilities, vis) {
+					return opts, usageErrorf("format option %q: unknown visibility %q (available: command, subcommand, public, private, section, var)", arg, v)
+				}
+				opts.include = append(opts.include, vis)
+			}
+		case "examples":
+			switch value {
+			case "true":
+				opts.examples = true
+			case "false":
+				opts.examples = false
+			default:
+				return opts, usageErrorf("format option %q: examples must be true or false", arg)
+			}
+		default:
+			return opts, usageErrorf("unknown format option %q (available: sort, include, examples)", key)
+		}
+	}
+	return opts, nil
+}
@@ -0,0 +1,61 @@
+package cli
+
+import (
+	"bytes"
+
+	"github.com/nickawilliams/shedoc"
+	"github.com/nickawilliams/shedoc/format"
+	"github.com/spf13/cobra"
+)
+
+var flagHelpNoPager bool
+
+// newHelpCmd replaces cobra's help command. "shedoc help <command>" still
+// shows help for shedoc's own commands; any other argument is a script whose
+// help text is rendered, paged when stdout is a terminal, or that of one of
+// its subcommands.
+func newHelpCmd() *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "help [flags] <file [subcommand] | command>",
+		Short: "Show a script's help text, or help for a shedoc command",
+		Long: `Renders a script's help text, like "shedoc --to help", paging it through
+$PAGER when stdout is a terminal; with a subcommand, renders the help of that
+subcommand alone. Given the name of a shedoc command instead, shows help for
+that command.`,
+		RunE:          runHelp,
+		SilenceUsage:  true,
+		SilenceErrors: true,
+	}
+
+	cmd.Flags().BoolVar(&flagHelpNoPager, "no-pager", false, "write directly to stdout")
+
+	return cmd
+}
+
+func runHelp(cmd *cobra.Command, args []string) error {
+	root := cmd.Root()
+	if len(args) == 0 {
+		return root.Help()
+	}
+	if target, _, err := root.Find(args); err == nil && target != root {
+		return target.Help()
+	}
+
+	docs, err := parseFiles(args[:1])
+	if err != nil {
+		return err
+	}
+
+	f := shedoc.GetFormatter("help")
+	if len(args) > 1 {
+		if err := checkSubcommand(docs[0], args[1]); err != nil {
+			return err
+		}
+		f = &format.HelpTextFormatter{Subcommand: args[1]}
+	}
+	var buf bytes.Buffer
+	if err := f.Format(&buf, docs[0]); err != nil {
+		return err
+	}
+	return writePaged(cmd.OutOrStdout(), buf.Bytes(), !flagHelpNoPager)
+}
@@ -0,0 +1,112 @@
+package cli
+
+import (
+	"bytes"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestCLI_HelpScript(t *testing.T) {
+	stdout, _, err := runCLI("help", testdataPath(t, "comprehensive.sh"))
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	want, _, err := runCLI("--to", "help", testdataPath(t, "comprehensive.sh"))
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if stdout != want {
+		t.Errorf("help output differs from --to help:\n%s", stdout)
+	}
+}
+
+func TestCLI_HelpSubcommand(t *testing.T) {
+	stdout, _, err := runCLI("help", "--no-pager", testdataPath(t, "comprehensive.sh"), "push")
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	want, _, err := runCLI("--to", "help", "--subcommand", "push", testdataPath(t, "comprehensive.sh"))
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if stdout != want {
+		t.Errorf("help output differs from --to help --subcommand:\n%s", stdout)
+	}
+	for _, s := range []string{"deploy push - ", "Global Options:\n  -v, --verbose"} {
+		if !strings.Contains(stdout, s) {
+			t.Errorf("subcommand help missing %q:\n%s", s, stdout)
+		}
+	}
+
+	for _, args := range [][]string{
+		{"--to", "help", "--subcommand", "nope", testdataPath(t, "comprehensive.sh")},
+		{"help", "--no-pager", testdataPath(t, "comprehensive.sh"), "nope"},
+	} {
+		_, _, err := runCLI(args...)
+		if got := ExitCode(err); got != ExitUsage {
+			t.Errorf("%v: ExitCode = %d, want %d (err: %v)", args, got, ExitUsage, err)
+		}
+	}
+	_, _, err = runCLI("--to", "man", "--subcommand", "push", testdataPath(t, "comprehensive.sh"))
+	if got := ExitCode(err); got != ExitUsage {
+		t.Errorf("--to man: ExitCode = %d, want %d (err: %v)", got, ExitUsage, err)
+	}
+}
+
+func TestCLI_HelpCommand(t *testing.T) {
+	tests := []struct {
+		name string
+		args []string
+		want string
+	}{
+		{name: "root", args: []string{"help"}, want: "Parse and output shell script documentation"},
+		{name: "subcommand", args: []string{"help", "validate"}, want: "shedoc validate <file.json...>"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			stdout, _, err := runCLI(tt.args...)
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if !strings.Contains(stdout, tt.want) {
+				t.Errorf("expected %q in output, got:\n%s", tt.want, stdout)
+			}
+		})
+	}
+}
+
+func TestCLI_HelpMissingFile(t *testing.T) {
+	_, _, err := runCLI("help", "--no-pager", "does-not-exist.sh")
+	if err == nil {
+		t.Fatal("expected error for missing file")
+	}
+}
+
+func TestWritePagedNotTerminal(t *testing.T) {
+	f, err := os.Create(filepath.Join(t.TempDir(), "out"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer f.Close()
+
+	// Regular files are written directly even with paging enabled.
+	t.Setenv("PAGER", "false")
+	if err := writePaged(f, []byte("hello\n"), true); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	got, err := os.ReadFile(f.Name())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if string(got) != "hello\n" {
+		t.Errorf("got %q", got)
+	}
+
+	var buf bytes.Buffer
+	if isTerminal(&buf) {
+		t.Error("buffer reported as terminal")
+	}
+}
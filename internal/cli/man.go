@@ -0,0 +1,234 @@
+package cli
+
+import (
+	"bytes"
+	"compress/gzip"
+	"fmt"
+	"io"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+
+	"github.com/nickawilliams/shedoc"
+	"github.com/nickawilliams/shedoc/format"
+	"github.com/spf13/cobra"
+)
+
+var (
+	flagManPreview  bool
+	flagManSplit    string
+	flagManCompress bool
+	flagManInstall  string
+	flagManLang     string
+)
+
+func newManCmd() *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "man [flags] <file>",
+		Short: "Render a script's man page",
+		Long: `Prints the man page for a script, like "shedoc --to man".
+
+With --preview, the page is rendered and paged instead, through "man -l -"
+or, where that is unavailable, "groff -man -Tutf8" piped to $PAGER.
+
+With --split, the page is written into a directory together with a page for
+each subcommand, named like git's (deploy.1, deploy-push.1, ...). Each
+subcommand page has the subcommand's full description, options, operands,
+environment, files, exit statuses, and examples.
+
+With --install, the split pages are written where man looks for them under
+a man directory such as /usr/local/share/man: into man1 (for section 1) or,
+for a script whose #?/lang or --man-lang is not English, into de/man1 and
+the like. Characters outside ASCII are always written as troff escapes, so
+pages in any language render without preconv.
+
+With --compress, the page is gzip-compressed, as most systems install man
+pages; split pages are then named deploy.1.gz, deploy-push.1.gz, ...
+
+With --manifest, a SHA256SUMS file listing the pages written and their
+SHA-256 checksums, as sha256sum(1) prints them, is written beside them.`,
+		Args:          cobra.ExactArgs(1),
+		RunE:          runMan,
+		SilenceUsage:  true,
+		SilenceErrors: true,
+	}
+
+	cmd.Flags().BoolVar(&flagManPreview, "preview", false, "render and page the man page")
+	cmd.Flags().StringVar(&flagManSplit, "split", "", "write the page and one page per subcommand into this directory")
+
+	cmd.Flags().StringVar(&flagManInstall, "install", "", "write the split pages into this man directory, under [lang/]man<section>")
+	cmd.Flags().StringVar(&flagManLang, "man-lang", "", "language of the page, overriding #?/lang (with --install)")
+	cmd.Flags().BoolVar(&flagManCompress, "compress", false, "gzip-compress the page, or each page with --split")
+	cmd.Flags().BoolVar(&flagManifest, "manifest", false, "also write a SHA256SUMS file of the pages written (with --split or --install)")
+
+	cmd.MarkFlagsMutuallyExclusive("preview", "split")
+	cmd.MarkFlagsMutuallyExclusive("preview", "compress")
+	cmd.MarkFlagsMutuallyExclusive("install", "split", "preview")
+
+	return cmd
+}
+
+func runMan(cmd *cobra.Command, args []string) error {
+	docs, err := parseFiles(args)
+	if err != nil {
+		return err
+	}
+	warnNameCollisions(docs)
+	reportWarnings(cmd, docs)
+	warned := warningsError(docs)
+
+	if flagManLang != "" && flagManInstall == "" {
+		return usageErrorf("--man-lang requires --install")
+	}
+	if flagManifest && flagManSplit == "" && flagManInstall == "" {
+		return usageErrorf("--manifest requires --split or --install")
+	}
+
+	if flagManSplit != "" {
+		if err := writeSplitManPages(cmd, docs[0], flagManSplit); err != nil {
+			return err
+		}
+		return warned
+	}
+	if flagManInstall != "" {
+		doc := docs[0]
+		if flagManLang != "" {
+			doc.Meta.Lang = flagManLang
+		}
+		dir, err := manInstallDir(flagManInstall, doc)
+		if err != nil {
+			return err
+		}
+		if err := writeSplitManPages(cmd, doc, dir); err != nil {
+			return err
+		}
+		return warned
+	}
+
+	var buf bytes.Buffer
+	if err := manFormatter().Format(&buf, docs[0]); err != nil {
+		return err
+	}
+
+	if flagManCompress {
+		if err := writeGzip(cmd.OutOrStdout(), buf.Bytes()); err != nil {
+			return err
+		}
+		return warned
+	}
+	if !flagManPreview {
+		if _, err := buf.WriteTo(cmd.OutOrStdout()); err != nil {
+			return err
+		}
+		return warned
+	}
+
+	pipeline, err := manPreviewPipeline(exec.LookPath)
+	if err != nil {
+		return err
+	}
+	c := exec.Command("sh", "-c", pipeline)
+	c.Stdin = &buf
+	c.Stdout = os.Stdout
+	c.Stderr = os.Stderr
+	if err := c.Run(); err != nil {
+		return err
+	}
+	return warned
+}
+
+// manFormatter returns the man page formatter, dated by $SOURCE_DATE_EPOCH
+// if it is set.
+func manFormatter() *format.ManPageFormatter {
+	f := &format.ManPageFormatter{}
+	if at, ok := sourceDate(); ok {
+		f.GeneratedAt = at
+	}
+	return f
+}
+
+// writeSplitManPages writes the man page of doc and those of its subcommands
+// into dir, creating it if needed. With --compress, each is gzip-compressed,
+// and with --manifest, a SHA256SUMS file lists them.
+func writeSplitManPages(cmd *cobra.Command, doc *shedoc.Document, dir string) error {
+	pages, err := manFormatter().Split(doc)
+	if err != nil {
+		return err
+	}
+	if err := os.MkdirAll(dir, 0o755); err != nil {
+		return err
+	}
+	if flagManifest {
+		manifest = newArtifactManifest(dir)
+		defer func() { manifest = nil }()
+	}
+	for _, page := range pages {
+		path := filepath.Join(dir, page.File)
+		content := page.Content
+		if flagManCompress {
+			path += ".gz"
+			var buf bytes.Buffer
+			if err := writeGzip(&buf, content); err != nil {
+				return err
+			}
+			content = buf.Bytes()
+		}
+		if err := writeArtifact(cmd, path, content); err != nil {
+			return err
+		}
+	}
+	if manifest != nil {
+		return manifest.write(cmd)
+	}
+	return nil
+}
+
+// manInstallDir returns the directory under the man directory root that man
+// looks in for doc's pages: man<section>, preceded by the language as a
+// locale name (pt_BR for pt-BR) unless it is English or unset. It fails with
+// a usage error if the section or language would make either a path.
+func manInstallDir(root string, doc *shedoc.Document) (string, error) {
+	section := doc.Meta.Section
+	if section == "" {
+		section = "1"
+	}
+	dir := "man" + section
+	if !plainFileName(root, dir) {
+		return "", usageErrorf("#?/section %q is not a plain file name", section)
+	}
+	lang := strings.ReplaceAll(doc.Meta.Lang, "-", "_")
+	switch {
+	case lang == "", lang == "C", lang == "en", strings.HasPrefix(lang, "en_"):
+		return filepath.Join(root, dir), nil
+	case !plainFileName(root, lang):
+		return "", usageErrorf("language %q is not a plain file name", doc.Meta.Lang)
+	default:
+		return filepath.Join(root, lang, dir), nil
+	}
+}
+
+// writeGzip writes content to w gzip-compressed at the best compression, with
+// no name or modification time in the header so that output is reproducible.
+func writeGzip(w io.Writer, content []byte) error {
+	gz, err := gzip.NewWriterLevel(w, gzip.BestCompression)
+	if err != nil {
+		return err
+	}
+	if _, err := gz.Write(content); err != nil {
+		return err
+	}
+	return gz.Close()
+}
+
+// manPreviewPipeline returns the shell pipeline that renders troff from
+// stdin onto the terminal, preferring man(1) and falling back to groff.
+func manPreviewPipeline(lookPath func(string) (string, error)) (string, error) {
+	if _, err := lookPath("man"); err == nil {
+		return "man -l -", nil
+	}
+	if _, err := lookPath("groff"); err == nil {
+		return `groff -man -Tutf8 | ${PAGER:-less}`, nil
+	}
+	return "", fmt.Errorf("man page preview requires man or groff")
+}
@@ -0,0 +1,183 @@
+package cli
+
+import (
+	"compress/gzip"
+	"errors"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/nickawilliams/shedoc"
+)
+
+func TestCLI_Man(t *testing.T) {
+	stdout, _, err := runCLI("man", testdataPath(t, "comprehensive.sh"))
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if !strings.Contains(stdout, "\n.TH DEPLOY 1") {
+		t.Errorf("expected man page output, got:\n%s", stdout)
+	}
+}
+
+func TestCLI_ManSplit(t *testing.T) {
+	dir := filepath.Join(t.TempDir(), "man1")
+	stdout, stderr, err := runCLI("man", "--quiet=false", "--split", dir, testdataPath(t, "comprehensive.sh"))
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if stdout != "" {
+		t.Errorf("expected no stdout, got:\n%s", stdout)
+	}
+	if !strings.Contains(stderr, "wrote "+filepath.Join(dir, "deploy-push.1")) {
+		t.Errorf("stderr = %q, want progress messages", stderr)
+	}
+
+	data, err := os.ReadFile(filepath.Join(dir, "deploy-push.1"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !strings.Contains(string(data), "\n.TH DEPLOY\\-PUSH 1") {
+		t.Errorf("expected the push page, got:\n%s", data)
+	}
+	if _, err := os.Stat(filepath.Join(dir, "deploy.1")); err != nil {
+		t.Errorf("missing top-level page: %v", err)
+	}
+}
+
+func TestCLI_ManCompress(t *testing.T) {
+	gunzip := func(t *testing.T, data string) string {
+		t.Helper()
+		gz, err := gzip.NewReader(strings.NewReader(data))
+		if err != nil {
+			t.Fatalf("output is not gzip: %v", err)
+		}
+		out, err := io.ReadAll(gz)
+		if err != nil {
+			t.Fatal(err)
+		}
+		return string(out)
+	}
+
+	for _, args := range [][]string{
+		{"man", "--compress"},
+		{"--to", "man", "--compress"},
+	} {
+		stdout, _, err := runCLI(append(args, testdataPath(t, "comprehensive.sh"))...)
+		if err != nil {
+			t.Fatalf("%v: unexpected error: %v", args, err)
+		}
+		if page := gunzip(t, stdout); !strings.Contains(page, "\n.TH DEPLOY 1") {
+			t.Errorf("%v: expected man page output, got:\n%s", args, page)
+		}
+	}
+
+	dir := t.TempDir()
+	if _, _, err := runCLI("man", "--compress", "--split", dir, testdataPath(t, "comprehensive.sh")); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	data, err := os.ReadFile(filepath.Join(dir, "deploy-push.1.gz"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if page := gunzip(t, string(data)); !strings.Contains(page, "\n.TH DEPLOY\\-PUSH 1") {
+		t.Errorf("expected the push page, got:\n%s", page)
+	}
+
+	_, _, err = runCLI("--to", "help", "--compress", testdataPath(t, "comprehensive.sh"))
+	if got := ExitCode(err); got != ExitUsage {
+		t.Errorf("--to help --compress ExitCode = %d, want %d (err: %v)", got, ExitUsage, err)
+	}
+}
+
+func TestCLI_ManInstall(t *testing.T) {
+	root := t.TempDir()
+	if _, _, err := runCLI("man", "--install", root, "--man-lang", "de", "--compress", testdataPath(t, "comprehensive.sh")); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	for _, name := range []string{"deploy.1.gz", "deploy-push.1.gz"} {
+		if _, err := os.Stat(filepath.Join(root, "de", "man1", name)); err != nil {
+			t.Errorf("missing installed page: %v", err)
+		}
+	}
+
+	_, _, err := runCLI("man", "--man-lang", "de", testdataPath(t, "comprehensive.sh"))
+	if got := ExitCode(err); got != ExitUsage {
+		t.Errorf("--man-lang without --install ExitCode = %d, want %d (err: %v)", got, ExitUsage, err)
+	}
+}
+
+func TestManInstallDir(t *testing.T) {
+	tests := []struct {
+		lang, section string
+		want          string
+	}{
+		{"", "", "man1"},
+		{"en", "1", "man1"},
+		{"en-GB", "1", "man1"},
+		{"de", "8", filepath.Join("de", "man8")},
+		{"pt-BR", "", filepath.Join("pt_BR", "man1")},
+	}
+	for _, tt := range tests {
+		doc := &shedoc.Document{Meta: shedoc.Meta{Lang: tt.lang, Section: tt.section}}
+		got, err := manInstallDir("man", doc)
+		if err != nil || got != filepath.Join("man", tt.want) {
+			t.Errorf("manInstallDir(%q, %q) = %q, %v, want %q", tt.lang, tt.section, got, err, filepath.Join("man", tt.want))
+		}
+	}
+
+	for _, meta := range []shedoc.Meta{
+		{Section: "1/../../esc"},
+		{Lang: ".."},
+		{Lang: "../esc"},
+	} {
+		doc := &shedoc.Document{Meta: meta}
+		if got, err := manInstallDir("man", doc); ExitCode(err) != ExitUsage {
+			t.Errorf("manInstallDir(%+v) = %q, %v, want usage error", meta, got, err)
+		}
+	}
+}
+
+func TestManPreviewPipeline(t *testing.T) {
+	available := func(names ...string) func(string) (string, error) {
+		return func(name string) (string, error) {
+			for _, n := range names {
+				if n == name {
+					return "/usr/bin/" + name, nil
+				}
+			}
+			return "", errors.New("not found")
+		}
+	}
+
+	tests := []struct {
+		name    string
+		tools   []string
+		want    string
+		wantErr bool
+	}{
+		{name: "man", tools: []string{"man", "groff"}, want: "man -l -"},
+		{name: "groff fallback", tools: []string{"groff"}, want: "groff -man -Tutf8 | ${PAGER:-less}"},
+		{name: "neither", wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := manPreviewPipeline(available(tt.tools...))
+			if tt.wantErr {
+				if err == nil {
+					t.Errorf("expected error, got %q", got)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if got != tt.want {
+				t.Errorf("pipeline = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,70 @@
+package cli
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"fmt"
+	"maps"
+	"os"
+	"path/filepath"
+	"slices"
+
+	"github.com/spf13/cobra"
+)
+
+var flagManifest bool
+
+// manifestName is the file --manifest writes into the output directory.
+const manifestName = "SHA256SUMS"
+
+// manifest collects the files written by this run when --manifest is given,
+// and is nil otherwise.
+var manifest *artifactManifest
+
+// artifactManifest lists files written into dir, with their SHA-256 sums.
+type artifactManifest struct {
+	dir  string
+	sums map[string][sha256.Size]byte
+}
+
+func newArtifactManifest(dir string) *artifactManifest {
+	return &artifactManifest{dir: dir, sums: map[string][sha256.Size]byte{}}
+}
+
+// writeArtifact writes a generated file, notes it, and adds it to the
+// manifest, if there is one.
+func writeArtifact(cmd *cobra.Command, path string, data []byte) error {
+	if err := os.WriteFile(path, data, 0o644); err != nil {
+		return err
+	}
+	notef(cmd, "wrote %s\n", path)
+	if manifest != nil {
+		manifest.sums[path] = sha256.Sum256(data)
+	}
+	return nil
+}
+
+// write writes the manifest into its directory in the format of
+// sha256sum(1), one file per line by path relative to the directory, so that
+// "sha256sum -c SHA256SUMS" run there verifies them.
+func (m *artifactManifest) write(cmd *cobra.Command) error {
+	sums := map[string][sha256.Size]byte{}
+	for path, sum := range m.sums {
+		rel, err := filepath.Rel(m.dir, path)
+		if err != nil {
+			return err
+		}
+		sums[filepath.ToSlash(rel)] = sum
+	}
+
+	var buf bytes.Buffer
+	for _, rel := range slices.Sorted(maps.Keys(sums)) {
+		fmt.Fprintf(&buf, "%x  %s\n", sums[rel], rel)
+	}
+	path := filepath.Join(m.dir, manifestName)
+	if err := os.WriteFile(path, buf.Bytes(), 0o644); err != nil {
+		return err
+	}
+	notef(cmd, "wrote %s\n", path)
+	return nil
+}
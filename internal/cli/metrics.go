@@ -0,0 +1,93 @@
+package cli
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"time"
+
+	"github.com/nickawilliams/shedoc"
+	"github.com/spf13/cobra"
+)
+
+// metricsToStderr is the value of a bare --metrics.
+const metricsToStderr = "-"
+
+// runMetrics holds the counts and timings of one run, for --metrics.
+// Durations are in milliseconds.
+type runMetrics struct {
+	Files    int     `json:"files"`
+	Lines    int     `json:"lines"`
+	Bytes    int64   `json:"bytes"`
+	Blocks   int     `json:"blocks"`
+	Warnings int     `json:"warnings"`
+	ParseMS  float64 `json:"parseMs"`
+	FormatMS float64 `json:"formatMs"`
+	TotalMS  float64 `json:"totalMs"`
+}
+
+// metrics collects the current run's metrics while --metrics is given, and
+// is nil otherwise.
+var metrics *runMetrics
+
+// addDocument counts a parsed document and the input it was parsed from.
+func (m *runMetrics) addDocument(doc *shedoc.Document, in *countingReader) {
+	m.Files++
+	m.Lines += in.lines()
+	m.Bytes += in.n
+	m.Blocks += len(doc.Blocks)
+	m.Warnings += len(doc.Warnings)
+}
+
+// writeMetrics prints m on stderr for a bare --metrics, or writes it as JSON
+// to the file named by --metrics=FILE.
+func writeMetrics(cmd *cobra.Command, m *runMetrics) error {
+	if flagMetrics != metricsToStderr {
+		data, err := json.MarshalIndent(m, "", "  ")
+		if err != nil {
+			return err
+		}
+		if err := os.WriteFile(flagMetrics, append(data, '\n'), 0o644); err != nil {
+			return fmt.Errorf("failed to write metrics: %w", err)
+		}
+		return nil
+	}
+
+	fmt.Fprintf(cmd.ErrOrStderr(), "metrics: files=%d lines=%d bytes=%d blocks=%d warnings=%d parse=%.3fms format=%.3fms total=%.3fms\n",
+		m.Files, m.Lines, m.Bytes, m.Blocks, m.Warnings, m.ParseMS, m.FormatMS, m.TotalMS)
+	return nil
+}
+
+// milliseconds returns d in milliseconds, to the microsecond.
+func milliseconds(d time.Duration) float64 {
+	return float64(d.Microseconds()) / 1000
+}
+
+// countingReader counts the bytes and newlines read through it.
+type countingReader struct {
+	r        io.Reader
+	n        int64
+	newlines int
+	last     byte
+}
+
+func (c *countingReader) Read(p []byte) (int, error) {
+	n, err := c.r.Read(p)
+	if n > 0 {
+		c.n += int64(n)
+		c.newlines += bytes.Count(p[:n], []byte("\n"))
+		c.last = p[n-1]
+	}
+	return n, err
+}
+
+// lines returns the number of lines read, counting a final line without a
+// newline.
+func (c *countingReader) lines() int {
+	if c.n > 0 && c.last != '\n' {
+		return c.newlines + 1
+	}
+	return c.newlines
+}
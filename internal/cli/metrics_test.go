@@ -0,0 +1,66 @@
+package cli
+
+import (
+	"encoding/json"
+	"io"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strings"
+	"testing"
+)
+
+func TestCLI_Metrics(t *testing.T) {
+	stdout, stderr, err := runCLI("--metrics", "-t", "help", testdataPath(t, "comprehensive.sh"))
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if !strings.Contains(stdout, "Usage:") {
+		t.Errorf("--metrics changed the output:\n%s", stdout)
+	}
+	re := regexp.MustCompile(`^metrics: files=1 lines=\d+ bytes=\d+ blocks=5 warnings=0 parse=[\d.]+ms format=[\d.]+ms total=[\d.]+ms\n$`)
+	if !re.MatchString(stderr) {
+		t.Errorf("unexpected metrics line: %q", stderr)
+	}
+
+	path := filepath.Join(t.TempDir(), "metrics.json")
+	_, stderr, err = runCLI("--metrics="+path, testdataPath(t, "comprehensive.sh"), testdataPath(t, "standalone.sh"))
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if stderr != "" {
+		t.Errorf("--metrics=FILE wrote to stderr: %q", stderr)
+	}
+	data, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var m runMetrics
+	if err := json.Unmarshal(data, &m); err != nil {
+		t.Fatalf("metrics are not JSON: %v\n%s", err, data)
+	}
+	if m.Files != 2 || m.Lines == 0 || m.Bytes == 0 || m.Blocks == 0 || m.TotalMS < m.ParseMS {
+		t.Errorf("unexpected metrics: %+v", m)
+	}
+}
+
+func TestCountingReader(t *testing.T) {
+	tests := []struct {
+		input string
+		lines int
+	}{
+		{"", 0},
+		{"one\n", 1},
+		{"one\ntwo", 2},
+		{"one\n\nthree\n", 3},
+	}
+	for _, tt := range tests {
+		in := &countingReader{r: strings.NewReader(tt.input)}
+		if _, err := io.ReadAll(in); err != nil {
+			t.Fatal(err)
+		}
+		if in.lines() != tt.lines || in.n != int64(len(tt.input)) {
+			t.Errorf("%q: lines() = %d, n = %d; want %d, %d", tt.input, in.lines(), in.n, tt.lines, len(tt.input))
+		}
+	}
+}
@@ -0,0 +1,100 @@
+package cli
+
+import (
+	"bytes"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/nickawilliams/shedoc"
+	"github.com/nickawilliams/shedoc/format"
+	"github.com/spf13/cobra"
+)
+
+var (
+	flagOutputDir   string
+	flagSearchIndex string
+)
+
+// outputFileNames give, for each format --output-dir supports, the file
+// name a command's output is written to: for completions, the name its
+// shell loads the completion from.
+var outputFileNames = map[string]func(name string) string{
+	"completion:bash":   func(name string) string { return name }, // bash-completion
+	"completion:zsh":    func(name string) string { return "_" + name },
+	"completion:fish":   func(name string) string { return name + ".fish" },
+	"completion:elvish": func(name string) string { return name + ".elv" },
+	"completion:fig":    func(name string) string { return name + ".ts" },
+	"html":              func(name string) string { return name + ".html" },
+}
+
+// searchIndexName is the file the search index of html pages written by
+// --output-dir is written to, beside them.
+const searchIndexName = "search-index.json"
+
+// writeOutputDir writes each document formatted with f into flagOutputDir,
+// creating it if needed, in a file named for the document's #?/name as
+// outputFileNames gives. HTML pages are accompanied by their search index and
+// the assets of any --theme, and with --manifest all of them by a SHA256SUMS
+// file.
+func writeOutputDir(cmd *cobra.Command, f shedoc.Formatter, docs []*shedoc.Document) error {
+	fileName := outputFileNames[flagTo]
+	if err := os.MkdirAll(flagOutputDir, 0o755); err != nil {
+		return err
+	}
+	if flagManifest {
+		manifest = newArtifactManifest(flagOutputDir)
+		defer func() { manifest = nil }()
+	}
+	for _, doc := range docs {
+		if doc.Meta.Name == "" {
+			return fmt.Errorf("%s: --output-dir requires #?/name", docSource(doc))
+		}
+		if !plainFileName(flagOutputDir, doc.Meta.Name) {
+			return usageErrorf("%s: #?/name %q is not a plain file name", docSource(doc), doc.Meta.Name)
+		}
+		var buf bytes.Buffer
+		if err := f.Format(&buf, doc); err != nil {
+			return err
+		}
+		path := filepath.Join(flagOutputDir, fileName(doc.Meta.Name))
+		if err := writeArtifact(cmd, path, buf.Bytes()); err != nil {
+			return err
+		}
+	}
+	if flagTo == "html" {
+		if flagTheme != "" {
+			if err := copyThemeAssets(cmd, flagTheme, flagOutputDir); err != nil {
+				return err
+			}
+		}
+		if err := writeSearchIndex(cmd, filepath.Join(flagOutputDir, searchIndexName), docs); err != nil {
+			return err
+		}
+	}
+	if manifest != nil {
+		return manifest.write(cmd)
+	}
+	return nil
+}
+
+// writeSearchIndex writes the search index of docs to the file at path.
+func writeSearchIndex(cmd *cobra.Command, path string, docs []*shedoc.Document) error {
+	var buf bytes.Buffer
+	if err := format.WriteSearchIndex(&buf, docs); err != nil {
+		return err
+	}
+	return writeArtifact(cmd, path, buf.Bytes())
+}
+
+// plainFileName reports whether name, joined to dir, names a file in dir
+// itself: it has no path separator and is not "." or "..". Names taken from a
+// script are checked with it so that the script cannot direct output outside
+// dir.
+func plainFileName(dir, name string) bool {
+	if name == "." || name == ".." || strings.ContainsAny(name, `/\`) {
+		return false
+	}
+	return filepath.Dir(filepath.Join(dir, name)) == filepath.Clean(dir)
+}
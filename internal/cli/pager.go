@@ -0,0 +1,64 @@
+package cli
+
+import (
+	"bytes"
+	"io"
+	"os"
+	"os/exec"
+	"strconv"
+)
+
+// isTerminal reports whether w is a character device such as a terminal.
+func isTerminal(w io.Writer) bool {
+	f, ok := w.(*os.File)
+	if !ok {
+		return false
+	}
+	info, err := f.Stat()
+	return err == nil && info.Mode()&os.ModeCharDevice != 0
+}
+
+// terminalWidth returns the width to wrap output written to w at: $COLUMNS,
+// or the terminal's own width, or 80. It returns 0, for no wrapping, when w
+// is not a terminal.
+func terminalWidth(w io.Writer) int {
+	if !isTerminal(w) {
+		return 0
+	}
+	if n, err := strconv.Atoi(os.Getenv("COLUMNS")); err == nil && n > 0 {
+		return n
+	}
+	if n := windowWidth(w.(*os.File)); n > 0 {
+		return n
+	}
+	return 80
+}
+
+// writePaged writes data to w, through $PAGER (default less) when w is a
+// terminal and paging is enabled. If the pager cannot be started, the data
+// is written directly.
+func writePaged(w io.Writer, data []byte, page bool) error {
+	if !page || !isTerminal(w) {
+		_, err := w.Write(data)
+		return err
+	}
+
+	pager := os.Getenv("PAGER")
+	if pager == "" {
+		pager = "less"
+	}
+
+	cmd := exec.Command("sh", "-c", pager)
+	cmd.Stdin = bytes.NewReader(data)
+	cmd.Stdout = w
+	cmd.Stderr = os.Stderr
+	// Let less exit when the output fits on one screen.
+	if _, ok := os.LookupEnv("LESS"); !ok {
+		cmd.Env = append(os.Environ(), "LESS=FRX")
+	}
+	if err := cmd.Start(); err != nil {
+		_, err := w.Write(data)
+		return err
+	}
+	return cmd.Wait()
+}
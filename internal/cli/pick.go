@@ -0,0 +1,201 @@
+package cli
+
+import (
+	"bufio"
+	"bytes"
+	"cmp"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"slices"
+	"strings"
+	"unicode"
+
+	"github.com/nickawilliams/shedoc/internal/completionmodel"
+	"github.com/spf13/cobra"
+)
+
+var (
+	flagPickQuery string
+	flagPickNoFzf bool
+)
+
+func newPickCmd() *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "pick [flags] <file>",
+		Short: "Choose a subcommand or flag of a script interactively",
+		Long: `Lists a script's subcommands and flags, with their descriptions, in fzf and
+prints the command line for the one chosen, such as "deploy push --force".
+
+Without fzf (or with --no-fzf), entries are matched against --query, or a
+query read from stdin, with a built-in fuzzy filter, and the best match is
+printed.`,
+		Args:          cobra.ExactArgs(1),
+		RunE:          runPick,
+		SilenceUsage:  true,
+		SilenceErrors: true,
+	}
+
+	cmd.Flags().StringVar(&flagPickQuery, "query", "", "initial fzf query, or the query to match without fzf")
+	cmd.Flags().BoolVar(&flagPickNoFzf, "no-fzf", false, "use the built-in fuzzy filter even if fzf is installed")
+
+	return cmd
+}
+
+func runPick(cmd *cobra.Command, args []string) error {
+	docs, err := parseFiles(args)
+	if err != nil {
+		return err
+	}
+	doc := docs[0]
+
+	name := doc.Meta.Name
+	if name == "" {
+		name = strings.TrimSuffix(filepath.Base(args[0]), filepath.Ext(args[0]))
+	}
+	entries := pickEntries(completionmodel.Build(doc, name))
+	if len(entries) == 0 {
+		return fmt.Errorf("%s documents no subcommands or flags", args[0])
+	}
+
+	var choice string
+	if fzf, err := exec.LookPath("fzf"); err == nil && !flagPickNoFzf {
+		choice, err = pickWithFzf(fzf, entries, name, flagPickQuery)
+		if err != nil {
+			return err
+		}
+	} else {
+		query := flagPickQuery
+		if query == "" {
+			notef(cmd, "%s> ", name)
+			line, err := bufio.NewReader(cmd.InOrStdin()).ReadString('\n')
+			if err != nil && !errors.Is(err, io.EOF) {
+				return err
+			}
+			query = strings.TrimSpace(line)
+		}
+		matches := fuzzyFilter(entries, query)
+		if len(matches) == 0 {
+			return fmt.Errorf("nothing matches %q", query)
+		}
+		choice = matches[0].command
+	}
+
+	fmt.Fprintln(cmd.OutOrStdout(), choice)
+	return nil
+}
+
+// pickEntry is a command line offered by pick, with its description.
+type pickEntry struct {
+	command     string
+	description string
+}
+
+// pickEntries lists the global flags, then each subcommand followed by its
+// own flags, as command lines. Options carry their value placeholder.
+func pickEntries(m *completionmodel.Model) []pickEntry {
+	name := m.Names[0]
+	flagEntries := func(prefix string, flags []completionmodel.Flag) []pickEntry {
+		var entries []pickEntry
+		for _, f := range flags {
+			word := cmp.Or(f.Long, f.Short)
+			if f.Value != nil {
+				word += " " + f.Value.String()
+			}
+			entries = append(entries, pickEntry{prefix + " " + word, firstLine(f.Description)})
+		}
+		return entries
+	}
+
+	entries := flagEntries(name, m.Flags)
+	for _, sub := range m.Subcommands {
+		prefix := name + " " + sub.Name
+		entries = append(entries, pickEntry{prefix, sub.Description})
+		entries = append(entries, flagEntries(prefix, sub.Flags)...)
+	}
+	return entries
+}
+
+// pickWithFzf runs fzf over the entries and returns the chosen command line.
+// fzf draws on the terminal itself, so only its selection is captured.
+func pickWithFzf(fzf string, entries []pickEntry, name, query string) (string, error) {
+	var input bytes.Buffer
+	for _, e := range entries {
+		fmt.Fprintf(&input, "%s\t%s\n", e.command, strings.ReplaceAll(e.description, "\t", " "))
+	}
+
+	c := exec.Command(fzf, "--delimiter=\t", "--tabstop=4", "--prompt="+name+"> ", "--query="+query)
+	c.Stdin = &input
+	c.Stderr = os.Stderr
+	out, err := c.Output()
+	var exitErr *exec.ExitError
+	if errors.As(err, &exitErr) {
+		// fzf exits 1 when nothing matches and 130 when cancelled.
+		return "", fmt.Errorf("nothing selected")
+	}
+	if err != nil {
+		return "", err
+	}
+	command, _, _ := strings.Cut(strings.TrimRight(string(out), "\n"), "\t")
+	return command, nil
+}
+
+// fuzzyFilter returns the entries whose command or description contains the
+// characters of query in order, best match first and, among equals, shortest
+// first.
+func fuzzyFilter(entries []pickEntry, query string) []pickEntry {
+	type scored struct {
+		entry pickEntry
+		score int
+	}
+	var matches []scored
+	for _, e := range entries {
+		score, ok := fuzzyScore(query, e.command)
+		if s, ok2 := fuzzyScore(query, e.description); ok2 && (!ok || s > score) {
+			score, ok = s, true
+		}
+		if ok {
+			matches = append(matches, scored{e, score})
+		}
+	}
+	slices.SortStableFunc(matches, func(a, b scored) int {
+		return cmp.Or(b.score-a.score, len(a.entry.command)-len(b.entry.command))
+	})
+
+	var result []pickEntry
+	for _, m := range matches {
+		result = append(result, m.entry)
+	}
+	return result
+}
+
+// fuzzyScore reports whether the characters of query appear in s in order,
+// ignoring case, and scores the match: consecutive characters and characters
+// at the start of a word score higher, and gaps lower.
+func fuzzyScore(query, s string) (int, bool) {
+	q := []rune(strings.ToLower(query))
+	r := []rune(strings.ToLower(s))
+	score, qi, last := 0, 0, -1
+	for i := 0; i < len(r) && qi < len(q); i++ {
+		if r[i] != q[qi] {
+			continue
+		}
+		switch {
+		case last == i-1:
+			score += 3
+		case i == 0 || !unicode.IsLetter(r[i-1]) && !unicode.IsDigit(r[i-1]):
+			score += 2
+		default:
+			score++
+		}
+		if last >= 0 {
+			score -= min(i-last-1, 3)
+		}
+		last = i
+		qi++
+	}
+	return score, qi == len(q)
+}
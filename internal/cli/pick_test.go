@@ -0,0 +1,111 @@
+package cli
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/nickawilliams/shedoc/internal/completionmodel"
+)
+
+func TestPickEntries(t *testing.T) {
+	doc := mustParseString(t, `#@/command
+ # @flag   -v | --verbose    Verbose output
+ # @option -c <path>         Config file
+ ##
+#@/subcommand push
+ # Deploys.
+ # @flag -f | --force  Skip confirmation
+ ##
+`)
+
+	var got []string
+	for _, e := range pickEntries(completionmodel.Build(doc, "deploy")) {
+		got = append(got, e.command+" | "+e.description)
+	}
+	want := []string{
+		"deploy --verbose | Verbose output",
+		"deploy -c <path> | Config file",
+		"deploy push | Deploys.",
+		"deploy push --force | Skip confirmation",
+	}
+	if strings.Join(got, "\n") != strings.Join(want, "\n") {
+		t.Errorf("entries:\n%s\nwant:\n%s", strings.Join(got, "\n"), strings.Join(want, "\n"))
+	}
+}
+
+func TestFuzzyScore(t *testing.T) {
+	tests := []struct {
+		query, s string
+		ok       bool
+	}{
+		{"psh", "deploy push", true},
+		{"PUSH", "deploy push", true},
+		{"hsup", "deploy push", false},
+		{"", "anything", true},
+	}
+	for _, tt := range tests {
+		if _, ok := fuzzyScore(tt.query, tt.s); ok != tt.ok {
+			t.Errorf("fuzzyScore(%q, %q) ok = %v, want %v", tt.query, tt.s, ok, tt.ok)
+		}
+	}
+
+	// Consecutive and word-start matches rank above scattered ones.
+	tight, _ := fuzzyScore("push", "deploy push")
+	loose, _ := fuzzyScore("push", "deploy p-u-s-h")
+	if tight <= loose {
+		t.Errorf("score(push) = %d, want above scattered %d", tight, loose)
+	}
+}
+
+func TestFuzzyFilter(t *testing.T) {
+	entries := []pickEntry{
+		{"deploy status", "Shows deployment status"},
+		{"deploy push --dry-run", "Show what would be done"},
+		{"deploy push", "Deploys the application"},
+	}
+	got := fuzzyFilter(entries, "push")
+	if len(got) != 2 || got[0].command != "deploy push" {
+		t.Errorf("fuzzyFilter = %v, want deploy push first of 2", got)
+	}
+	if got := fuzzyFilter(entries, "zzz"); len(got) != 0 {
+		t.Errorf("fuzzyFilter(zzz) = %v, want none", got)
+	}
+}
+
+func TestCLI_PickBuiltinFilter(t *testing.T) {
+	stdout, _, err := runCLI("pick", "--no-fzf", "--query", "dry", testdataPath(t, "comprehensive.sh"))
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if got := strings.TrimSpace(stdout); got != "deploy push --dry-run" {
+		t.Errorf("pick = %q, want %q", got, "deploy push --dry-run")
+	}
+
+	_, _, err = runCLI("pick", "--no-fzf", "--query", "zzzz", testdataPath(t, "comprehensive.sh"))
+	if err == nil || !strings.Contains(err.Error(), "nothing matches") {
+		t.Errorf("expected 'nothing matches' error, got %v", err)
+	}
+}
+
+func TestCLI_PickFzf(t *testing.T) {
+	// A stand-in fzf that selects the entry matching its --query.
+	bin := t.TempDir()
+	fzf := `#!/bin/sh
+for arg; do case $arg in --query=*) query=${arg#--query=} ;; esac; done
+grep -F -- "$query" | head -n 1
+`
+	if err := os.WriteFile(filepath.Join(bin, "fzf"), []byte(fzf), 0o755); err != nil {
+		t.Fatal(err)
+	}
+	t.Setenv("PATH", bin+string(os.PathListSeparator)+os.Getenv("PATH"))
+
+	stdout, _, err := runCLI("pick", "--no-fzf=false", "--query", "--tag", testdataPath(t, "comprehensive.sh"))
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if got := strings.TrimSpace(stdout); !strings.HasPrefix(got, "deploy push --tag") || strings.Contains(got, "\t") {
+		t.Errorf("pick = %q, want the deploy push --tag command without its description", got)
+	}
+}
@@ -0,0 +1,86 @@
+package cli
+
+import (
+	"encoding/json"
+	"io"
+
+	"github.com/nickawilliams/shedoc/internal/report"
+	"github.com/spf13/cobra"
+)
+
+var flagReportTo string
+
+func newReportCmd() *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "report <kind> <path...>",
+		Short: "Aggregate documentation across a collection of scripts",
+		Long: `Parses every script under the given files and directories and prints a
+deduplicated report. Directories are searched recursively for files with a
+shell extension, such as .sh or .bash, or starting with a shell shebang.
+
+Kinds:
+  env        environment variables read (@env) and set (@sets), with defaults
+  files      paths read (@reads) and written (@writes)
+  audit      writes to system paths, sets of security-sensitive variables,
+             defaults documented for credential-like variables, and
+             required network tools such as curl and ssh
+  coverage   share of documented items with a description, per script`,
+		Args:          cobra.MinimumNArgs(2),
+		RunE:          runReport,
+		SilenceUsage:  true,
+		SilenceErrors: true,
+	}
+
+	cmd.Flags().StringVarP(&flagReportTo, "to", "t", "text", "output format (text, markdown, json)")
+
+	return cmd
+}
+
+func runReport(cmd *cobra.Command, args []string) error {
+	kind, paths := args[0], args[1:]
+
+	scripts, err := collectScripts(cmd, paths)
+	if err != nil {
+		return err
+	}
+	docs, err := parseFiles(scripts)
+	if err != nil {
+		return err
+	}
+
+	var data any
+	var table *report.Table
+	switch kind {
+	case "env":
+		vars := report.Env(docs)
+		data, table = vars, report.EnvTable(vars)
+	case "files":
+		files := report.Files(docs)
+		data, table = files, report.FilesTable(files)
+	case "audit":
+		findings := report.Audit(docs)
+		data, table = findings, report.AuditTable(findings)
+	case "coverage":
+		scripts := report.Coverage(docs)
+		data, table = scripts, report.CoverageTable(scripts)
+	default:
+		return usageErrorf("unknown report: %q (available: env, files, audit, coverage)", kind)
+	}
+
+	return writeReport(cmd.OutOrStdout(), data, table)
+}
+
+func writeReport(w io.Writer, data any, table *report.Table) error {
+	switch flagReportTo {
+	case "text":
+		return table.WriteText(w)
+	case "markdown":
+		return table.WriteMarkdown(w)
+	case "json":
+		enc := json.NewEncoder(w)
+		enc.SetEscapeHTML(false)
+		return enc.Encode(data)
+	default:
+		return usageErrorf("unknown report format: %q (available: text, markdown, json)", flagReportTo)
+	}
+}
@@ -1,14 +1,21 @@
 package cli
 
 import (
+	"bytes"
+	"encoding/json"
 	"fmt"
 	"io"
 	"os"
+	"path/filepath"
+	"slices"
+	"strconv"
 	"strings"
+	"time"
 
 	"github.com/nickawilliams/shedoc"
-	_ "github.com/nickawilliams/shedoc/internal/generate" // register formatters
+	"github.com/nickawilliams/shedoc/format"
 	"github.com/spf13/cobra"
+	"github.com/spf13/pflag"
 )
 
 var (
@@ -17,6 +24,30 @@ var (
 	flagOutput   string
 	flagWarnings bool
 	flagQuiet    bool
+
+	flagNoWarnings     bool
+	flagMaxWarnings    int
+	flagFailOnWarnings bool
+
+	flagCommandNames []string
+	flagBlock        string
+	flagTSV          bool
+	flagSort         bool
+	flagLicenseFile  string
+	flagTranslations string
+	flagFilters      []string
+	flagSourceURL    string
+	flagSourceRef    string
+	flagMetrics      string
+	flagArray        bool
+	flagTemplate     string
+	flagStdinName    string
+	flagCompress     bool
+	flagHelpStyle    string
+	flagHeadings     string
+	flagWidth        int
+	flagColor        string
+	flagSubcommand   string
 )
 
 // NewRootCmd creates the root shedoc command.
@@ -31,20 +62,106 @@ func NewRootCmd(version string) *cobra.Command {
 		SilenceErrors: true,
 	}
 
-	cmd.Flags().StringVarP(&flagTo, "to", "t", "json", "output format (json, help, man, completion:bash, completion:zsh, completion:fish)")
-	cmd.Flags().StringVarP(&flagGet, "get", "g", "", "extract a single #?/ tag value")
+	cmd.Flags().StringVarP(&flagTo, "to", "t", "json", "output format (json, help, usage, man, completion:bash, completion:zsh, completion:fish, completion:elvish, completion:fig, completion-tests, bats, usage-errors, docopt, comments, translations, table, org, html, asciidoc, whatis, list, template)")
+	cmd.Flags().StringVarP(&flagGet, "get", "g", "", "extract a single #?/ tag value, or list exit-codes, env, flags, subcommands, or requires")
 	cmd.Flags().StringVarP(&flagOutput, "output", "o", "", "write output to file instead of stdout")
+	cmd.Flags().StringVar(&flagOutputDir, "output-dir", "", "write each file's output into this directory: completion scripts named as their shell expects, or NAME.html pages with a search-index.json (completion and html formats only)")
+	cmd.Flags().BoolVar(&flagManifest, "manifest", false, "also write a SHA256SUMS file of the files written into --output-dir, with their SHA-256 checksums")
+	cmd.Flags().StringVar(&flagTheme, "theme", "", "render pages with this directory's page.html template and style.css, and copy its other files into --output-dir (html only)")
+	cmd.Flags().StringVar(&flagSearchIndex, "search-index", "", "also write a JSON search index of the documents to this file, for search over pages rendered from them")
 	cmd.Flags().BoolVarP(&flagWarnings, "warnings", "w", false, "include warnings in output")
-	cmd.Flags().BoolVarP(&flagQuiet, "quiet", "q", false, "suppress warnings on stderr")
+	cmd.Flags().BoolVar(&flagArray, "array", false, "with --to json, write a JSON array of the documents instead of one document per line")
+	cmd.PersistentFlags().BoolVarP(&flagQuiet, "quiet", "q", false, "suppress warnings, prompts, and progress messages on stderr")
+	cmd.PersistentFlags().BoolVar(&flagNoWarnings, "no-warnings", false, "suppress warnings on stderr")
+	cmd.PersistentFlags().BoolVar(&flagFailOnWarnings, "fail-on-warnings", false, "exit with status 3 if any file has parse warnings")
+	cmd.PersistentFlags().BoolVar(&flagRequireShebang, "require-shebang", false, "in directories, skip files with a shell extension that do not start with a shell shebang")
+	cmd.PersistentFlags().BoolVar(&flagFollowSymlinks, "follow-symlinks", false, "in directories, follow symbolic links to files and directories")
+	cmd.PersistentFlags().IntVar(&flagMaxWarnings, "max-warnings", 0, "print at most this many warnings on stderr (0 for no limit)")
+	cmd.PersistentFlags().StringArrayVar(&flagTagAliases, "tag-alias", nil, "parse @from tags as @to, such as param=operand (repeatable)")
+	cmd.PersistentFlags().BoolVar(&flagWarnTagAliases, "warn-tag-aliases", false, "warn on each tag given by a --tag-alias")
+	cmd.PersistentFlags().BoolVar(&flagWarnTagOrder, "warn-tag-order", false, "warn on each tag out of the order the comments format writes tags in")
+	cmd.Flags().BoolVar(&flagCompress, "compress", false, "gzip-compress the output (man only)")
+	cmd.Flags().BoolVar(&flagTSV, "tsv", false, "with --get list paths, print tab-separated descriptions")
+	cmd.Flags().StringVar(&flagTemplate, "template", "", "execute this Go text/template file against each document (template only)")
+	cmd.Flags().StringVar(&flagBlock, "block", "", "output only the block with this name or index (JSON only)")
+	cmd.Flags().StringVar(&flagStdinName, "stdin-name", "", "file name to give the script read from - in warnings, paths, and, without its extension, as its name if it has no #?/name")
+	cmd.Flags().StringVar(&flagMetrics, "metrics", "", "print file counts and parse and format timings on stderr, or with =FILE write them as JSON")
+	cmd.Flags().Lookup("metrics").NoOptDefVal = metricsToStderr
+	addRenderFlags(cmd.Flags())
 
 	cmd.MarkFlagsMutuallyExclusive("to", "get")
+	cmd.MarkFlagsMutuallyExclusive("block", "get")
+	cmd.MarkFlagsMutuallyExclusive("array", "get")
+	cmd.MarkFlagsMutuallyExclusive("array", "block")
+	cmd.MarkFlagsMutuallyExclusive("output", "output-dir")
 
 	cmd.AddCommand(newCompleteCmd())
+	cmd.AddCommand(newValidateCmd())
+	cmd.AddCommand(newSchemaCmd())
+	cmd.AddCommand(newReportCmd())
+	cmd.AddCommand(newBadgeCmd())
+	cmd.AddCommand(newManCmd())
+	cmd.AddCommand(newRoundtripCmd())
+	cmd.AddCommand(newCheckArtifactsCmd())
+	cmd.AddCommand(newCheckContractCmd())
+	cmd.AddCommand(newPickCmd())
+	cmd.AddCommand(newBenchCmd())
+	cmd.SetHelpCommand(newHelpCmd())
+	markUsageErrors(cmd)
 
 	return cmd
 }
 
+// addRenderFlags adds the flags that change how documents are rendered,
+// which check-artifacts shares with the root command.
+func addRenderFlags(flags *pflag.FlagSet) {
+	flags.StringVar(&flagLicenseFile, "license-file", "", "append this file's text as a LICENSE section (man, html, asciidoc)")
+	flags.StringVar(&flagTranslations, "translations", "", "replace descriptions with those in this catalog (see --to translations)")
+	flags.BoolVar(&flagSort, "sort-subcommands", false, "list subcommands alphabetically in help, man, and completion output")
+	flags.MarkDeprecated("sort-subcommands", "use --format-option sort=alpha")
+	flags.StringArrayVar(&flagFilters, "filter", nil, "keep only blocks matching has(tag), !has(tag), or field=value (repeatable; all must match)")
+	flags.StringVar(&flagHelpStyle, "help-style", "summary", "summary lists subcommands only; full also lists each one's flags and options (help only)")
+	flags.IntVar(&flagWidth, "width", 0, "wrap help descriptions to this many columns, or 0 not to (default: the terminal's width)")
+	flags.StringVar(&flagColor, "color", "auto", "color help output: auto (on a terminal, unless NO_COLOR is set), always, or never (help only)")
+	flags.StringVar(&flagSubcommand, "subcommand", "", "show help for this subcommand alone, with the command's options as global options (help only)")
+	flags.StringVar(&flagHeadings, "headings", "", "section headings in this language, such as de, es, or fr (help and man only)")
+	flags.StringArrayVar(&flagFormatOptions, "format-option", nil, "sort=alpha|source, include=command,subcommand,public,..., or examples=true for presentation formats (repeatable)")
+	flags.StringVar(&flagSourceURL, "source-url", "", "link each block to its source with this URL template; {path}, {line}, and {ref} are replaced (html and asciidoc only)")
+	flags.StringVar(&flagSourceRef, "source-ref", "", "branch, tag, or commit for {ref} in --source-url (default HEAD)")
+	flags.StringArrayVar(&flagCommandNames, "command-name", nil, "additional name the script is invoked by (repeatable)")
+}
+
 func runRoot(cmd *cobra.Command, args []string) error {
+	metrics = nil
+	if flagMetrics != "" {
+		metrics = &runMetrics{}
+	}
+	start := time.Now()
+
+	if flagStdinName != "" && !slices.Contains(args, "-") {
+		return usageErrorf("--stdin-name requires - among the files")
+	}
+	if flagCompress && flagTo != "man" {
+		return usageErrorf("--compress supports only the man format; got %q", flagTo)
+	}
+	if flagOutputDir != "" && outputFileNames[flagTo] == nil {
+		return usageErrorf("--output-dir supports only the completion and html formats; got %q", flagTo)
+	}
+	if flagTheme != "" && (flagTo != "html" || flagOutputDir == "") {
+		return usageErrorf("--theme supports only the html format with --output-dir")
+	}
+	if flagManifest && flagOutputDir == "" {
+		return usageErrorf("--manifest requires --output-dir")
+	}
+	if flagTSV {
+		if flagGet == "" {
+			return usageErrorf("--tsv requires --get")
+		}
+		if _, ok := getListField(&shedoc.Document{}, flagGet); !ok {
+			return usageErrorf("--tsv supports only the list paths of --get; got %q", flagGet)
+		}
+	}
+
 	// Determine output writer.
 	var w io.Writer = cmd.OutOrStdout()
 	if flagOutput != "" {
@@ -56,25 +173,46 @@ func runRoot(cmd *cobra.Command, args []string) error {
 		w = f
 	}
 
-	// Parse input files.
-	docs, err := parseFiles(args)
+	// Parse input files, expanding directories to the scripts they contain.
+	scripts, err := collectScripts(cmd, args)
 	if err != nil {
 		return err
 	}
+	docs, err := parseFiles(scripts)
+	if err != nil {
+		return err
+	}
+	parsed := time.Now()
 
-	// Emit warnings to stderr if not suppressed.
-	if !flagQuiet {
-		for _, doc := range docs {
-			for _, warn := range doc.Warnings {
-				source := doc.Path
-				if source == "" {
-					source = "<stdin>"
-				}
-				fmt.Fprintf(cmd.ErrOrStderr(), "%s:%d: warning: %s\n", source, warn.Line, warn.Message)
-			}
+	warnNameCollisions(docs)
+	reportWarnings(cmd, docs)
+	warned := warningsError(docs)
+
+	if flagCompress {
+		var buf bytes.Buffer
+		if err := writeDocuments(cmd, &buf, docs); err != nil {
+			return err
+		}
+		if err := writeGzip(w, buf.Bytes()); err != nil {
+			return err
+		}
+	} else if err := writeDocuments(cmd, w, docs); err != nil {
+		return err
+	}
+
+	if metrics != nil {
+		metrics.ParseMS = milliseconds(parsed.Sub(start))
+		metrics.FormatMS = milliseconds(time.Since(parsed))
+		metrics.TotalMS = milliseconds(time.Since(start))
+		if err := writeMetrics(cmd, metrics); err != nil {
+			return err
 		}
 	}
+	return warned
+}
 
+// writeDocuments writes docs to w as the flags of the root command ask.
+func writeDocuments(cmd *cobra.Command, w io.Writer, docs []*shedoc.Document) error {
 	// Strip warnings from output unless explicitly requested.
 	if !flagWarnings {
 		for i := range docs {
@@ -82,28 +220,264 @@ func runRoot(cmd *cobra.Command, args []string) error {
 		}
 	}
 
+	// Register extra invocation names as command aliases; they only name
+	// the commands a completion is registered for.
+	if len(flagCommandNames) > 0 {
+		if !strings.HasPrefix(flagTo, "completion:") {
+			return usageErrorf("--command-name supports only the completion formats; got %q", flagTo)
+		}
+		for _, doc := range docs {
+			if err := addCommandAliases(doc, flagCommandNames); err != nil {
+				return err
+			}
+		}
+	}
+
+	// Apply a translation catalog before any output is produced.
+	var catalog shedoc.Catalog
+	if flagTranslations != "" {
+		var err error
+		catalog, err = readCatalog(flagTranslations)
+		if err != nil {
+			return err
+		}
+		for _, doc := range docs {
+			shedoc.Translate(doc, catalog)
+		}
+	}
+
+	// Keep only matching blocks, and the documents that still have any.
+	if len(flagFilters) > 0 {
+		var err error
+		docs, err = filterDocuments(docs, flagFilters)
+		if err != nil {
+			return err
+		}
+		if len(docs) == 0 && !flagArray {
+			return nil
+		}
+	}
+
 	// Handle --get: extract a single meta tag value.
 	if flagGet != "" {
 		return runGet(w, docs)
 	}
 
-	// Non-JSON formats accept a single file only.
-	if flagTo != "json" && len(docs) > 1 {
-		return fmt.Errorf("format %q supports a single file; got %d", flagTo, len(docs))
+	// Handle --block: emit a single block's JSON.
+	if flagBlock != "" {
+		return runBlock(w, docs)
+	}
+
+	// Other than JSON, whatis, list, and template, formats accept a single file only, unless
+	// each is written to its own file.
+	if !multiFileFormats[flagTo] && flagOutputDir == "" && len(docs) > 1 {
+		return usageErrorf("format %q supports a single file; got %d", flagTo, len(docs))
+	}
+
+	// --license-file overrides #?/license-file; it is relative to the
+	// working directory rather than the script.
+	if flagLicenseFile != "" {
+		path, err := filepath.Abs(flagLicenseFile)
+		if err != nil {
+			return err
+		}
+		for _, doc := range docs {
+			doc.Meta.LicenseFile = path
+		}
+	}
+
+	// JSON always keeps source order and every block; presentation formats
+	// may sort and scope them.
+	opts, err := parseFormatOptions(flagFormatOptions)
+	if err != nil {
+		return err
+	}
+	if len(flagFormatOptions) > 0 && flagTo == "json" {
+		return usageErrorf("--format-option supports only presentation formats; got %q", flagTo)
+	}
+	// --sort-subcommands is the old spelling of --format-option sort=alpha.
+	if flagSort {
+		if flagTo == "json" {
+			return usageErrorf("--sort-subcommands supports only presentation formats; got %q", flagTo)
+		}
+		opts.sort = true
+	}
+	if opts.include != nil {
+		for _, doc := range docs {
+			shedoc.KeepVisibilities(doc, opts.include...)
+		}
+	}
+	if opts.sort {
+		for _, doc := range docs {
+			shedoc.SortSubcommands(doc)
+		}
+	}
+
+	// --array: one JSON array rather than NDJSON.
+	if flagArray {
+		if flagTo != "json" {
+			return usageErrorf("--array supports only the json format; got %q", flagTo)
+		}
+		if docs == nil {
+			docs = []*shedoc.Document{}
+		}
+		enc := json.NewEncoder(w)
+		enc.SetEscapeHTML(false)
+		return enc.Encode(docs)
 	}
 
 	// Look up formatter.
 	formatter := shedoc.GetFormatter(flagTo)
 	if formatter == nil {
-		return fmt.Errorf("unknown format: %q\navailable formats: %s", flagTo, strings.Join(shedoc.RegisteredFormats(), ", "))
+		return usageErrorf("unknown format: %q\navailable formats: %s", flagTo, strings.Join(shedoc.RegisteredFormats(), ", "))
+	}
+
+	// --source-url configures the formats that can link to source, with
+	// --source-ref for its {ref}.
+	if flagSourceRef != "" && flagSourceURL == "" {
+		return usageErrorf("--source-ref requires --source-url")
+	}
+	sourceURL := flagSourceURL
+	if flagSourceRef != "" {
+		sourceURL = strings.ReplaceAll(sourceURL, "{ref}", flagSourceRef)
+	}
+	if sourceURL != "" {
+		switch flagTo {
+		case "html":
+			formatter = &format.HTMLFormatter{SourceURL: sourceURL}
+		case "asciidoc":
+			formatter = &format.AsciiDocFormatter{SourceURL: sourceURL}
+		default:
+			return usageErrorf("--source-url supports only the html and asciidoc formats; got %q", flagTo)
+		}
+	}
+
+	// examples= adds an example to subcommand descriptions in completions.
+	if opts.examples {
+		switch flagTo {
+		case "completion:fish":
+			formatter = &format.FishCompletionFormatter{Examples: true}
+		case "completion:zsh":
+			formatter = &format.ZshCompletionFormatter{Examples: true}
+		default:
+			return usageErrorf("format option examples supports only the completion:fish and completion:zsh formats; got %q", flagTo)
+		}
+	}
+
+	// --theme replaces the page and stylesheet of html output.
+	if flagTheme != "" {
+		html, err := loadTheme(flagTheme, sourceURL)
+		if err != nil {
+			return err
+		}
+		formatter = html
+	}
+
+	// --headings, then heading.<key> entries of the translation catalog,
+	// replace the section headings of help and man output.
+	var headings format.Headings
+	if flagHeadings != "" {
+		if flagTo != "help" && flagTo != "man" {
+			return usageErrorf("--headings supports only the help and man formats; got %q", flagTo)
+		}
+		headings = format.HeadingsFor(flagHeadings)
+		if headings == nil {
+			return usageErrorf("no headings for language %q (available: %s)", flagHeadings, strings.Join(format.HeadingLanguages(), ", "))
+		}
+	}
+	for key, text := range catalog {
+		if key, ok := strings.CutPrefix(key, "heading."); ok {
+			if headings == nil {
+				headings = format.Headings{}
+			}
+			headings[key] = text
+		}
+	}
+	if headings != nil && flagTo == "man" {
+		formatter = &format.ManPageFormatter{Headings: headings}
 	}
 
-	// Output.
+	// --help-style full lists subcommand flags in help, --width wraps its
+	// descriptions, by default to the terminal's width, --color styles it,
+	// and --subcommand narrows it to one subcommand.
+	for _, name := range []string{"help-style", "width", "color", "subcommand"} {
+		if cmd.Flags().Changed(name) && flagTo != "help" {
+			return usageErrorf("--%s supports only the help format; got %q", name, flagTo)
+		}
+	}
+	if flagTo == "help" {
+		if flagSubcommand != "" {
+			for _, doc := range docs {
+				if err := checkSubcommand(doc, flagSubcommand); err != nil {
+					return err
+				}
+			}
+		}
+		help := &format.HelpTextFormatter{Headings: headings, Width: flagWidth, Subcommand: flagSubcommand}
+		switch flagHelpStyle {
+		case "summary":
+		case "full":
+			help.Full = true
+		default:
+			return usageErrorf("unknown help style: %q (available: summary, full)", flagHelpStyle)
+		}
+		if flagWidth < 0 {
+			return usageErrorf("--width must not be negative; got %d", flagWidth)
+		}
+		if !cmd.Flags().Changed("width") {
+			help.Width = terminalWidth(w)
+		}
+		switch flagColor {
+		case "auto":
+			help.Color = isTerminal(w) && os.Getenv("NO_COLOR") == ""
+		case "always":
+			help.Color = true
+		case "never":
+		default:
+			return usageErrorf("unknown color mode: %q (available: auto, always, never)", flagColor)
+		}
+		formatter = help
+	}
+
+	// --template supplies the template for the template format.
+	if flagTemplate != "" || flagTo == "template" {
+		if flagTo != "template" {
+			return usageErrorf("--template supports only the template format; got %q", flagTo)
+		}
+		if flagTemplate == "" {
+			return usageErrorf("format \"template\" requires --template FILE")
+		}
+		text, err := os.ReadFile(flagTemplate)
+		if err != nil {
+			return err
+		}
+		formatter, err = format.ParseTemplate(filepath.Base(flagTemplate), string(text))
+		if err != nil {
+			return usageErrorf("%v", err)
+		}
+	}
+
+	// $SOURCE_DATE_EPOCH dates man pages and completion scripts for
+	// reproducible builds; without it they note no generation time.
+	if at, ok := sourceDate(); ok {
+		formatter = dated(formatter, at)
+	}
+
+	// Output, and with --search-index the index of what was output.
+	if flagSearchIndex != "" {
+		if err := writeSearchIndex(cmd, flagSearchIndex, docs); err != nil {
+			return err
+		}
+	}
+	if flagOutputDir != "" {
+		return writeOutputDir(cmd, formatter, docs)
+	}
 	if len(docs) == 1 {
 		return formatter.Format(w, docs[0])
 	}
 
-	// Multiple files: NDJSON (one JSON object per line).
+	// Multiple files: NDJSON (one JSON object per line), a whatis index, a
+	// block list, or one template output after another.
 	for _, doc := range docs {
 		if err := formatter.Format(w, doc); err != nil {
 			return err
@@ -112,11 +486,76 @@ func runRoot(cmd *cobra.Command, args []string) error {
 	return nil
 }
 
+// checkSubcommand returns a usage error if doc has no subcommand named name.
+func checkSubcommand(doc *shedoc.Document, name string) error {
+	if doc.Subcommand(name) == nil {
+		return usageErrorf("no subcommand %q in %s", name, docSource(doc))
+	}
+	return nil
+}
+
+// sourceDate returns the time $SOURCE_DATE_EPOCH gives in seconds since the
+// Unix epoch, if it is set.
+func sourceDate() (time.Time, bool) {
+	epoch, err := strconv.ParseInt(os.Getenv("SOURCE_DATE_EPOCH"), 10, 64)
+	if err != nil {
+		return time.Time{}, false
+	}
+	return time.Unix(epoch, 0).UTC(), true
+}
+
+// dated returns a copy of f that notes it was generated at t, if f is a
+// formatter that notes when its output was generated, or else f itself.
+func dated(f shedoc.Formatter, t time.Time) shedoc.Formatter {
+	switch f := f.(type) {
+	case *format.ManPageFormatter:
+		c := *f
+		c.GeneratedAt = t
+		return &c
+	case *format.BashCompletionFormatter:
+		c := *f
+		c.GeneratedAt = t
+		return &c
+	case *format.ZshCompletionFormatter:
+		c := *f
+		c.GeneratedAt = t
+		return &c
+	case *format.FishCompletionFormatter:
+		c := *f
+		c.GeneratedAt = t
+		return &c
+	case *format.ElvishCompletionFormatter:
+		c := *f
+		c.GeneratedAt = t
+		return &c
+	case *format.FigCompletionFormatter:
+		c := *f
+		c.GeneratedAt = t
+		return &c
+	}
+	return f
+}
+
+// multiFileFormats are the formats whose output for several files is the
+// concatenation of their output for each.
+var multiFileFormats = map[string]bool{"json": true, "whatis": true, "list": true, "template": true}
+
 func runGet(w io.Writer, docs []*shedoc.Document) error {
 	for _, doc := range docs {
+		if entries, ok := getListField(doc, flagGet); ok {
+			for _, e := range entries {
+				if flagTSV {
+					fmt.Fprintf(w, "%s\t%s\n", e.key, strings.ReplaceAll(firstLine(e.description), "\t", " "))
+				} else {
+					fmt.Fprintln(w, e.key)
+				}
+			}
+			continue
+		}
+
 		val, ok := getMetaField(&doc.Meta, flagGet)
 		if !ok {
-			return fmt.Errorf("unknown tag: %q", flagGet)
+			return usageErrorf("unknown tag: %q", flagGet)
 		}
 		if val != "" {
 			fmt.Fprintln(w, val)
@@ -125,6 +564,110 @@ func runGet(w io.Writer, docs []*shedoc.Document) error {
 	return nil
 }
 
+type getEntry struct {
+	key         string
+	description string
+}
+
+// getListField collects the entries for list paths across all blocks of doc.
+// Entries are deduplicated by key; the first description wins.
+func getListField(doc *shedoc.Document, path string) ([]getEntry, bool) {
+	var entries []getEntry
+	seen := map[string]bool{}
+	add := func(key, desc string) {
+		if !seen[key] {
+			seen[key] = true
+			entries = append(entries, getEntry{key: key, description: desc})
+		}
+	}
+
+	switch path {
+	case "exit-codes":
+		for _, b := range doc.Blocks {
+			for _, e := range b.Exit {
+				add(e.Code, e.Description)
+			}
+		}
+	case "env":
+		for _, b := range doc.Blocks {
+			for _, e := range b.Env {
+				add(e.Name, e.Description)
+			}
+		}
+	case "flags":
+		for _, b := range doc.Blocks {
+			for _, f := range b.Flags {
+				add(flagKey(f.Short, f.Long), f.Description)
+			}
+			for _, o := range b.Options {
+				add(flagKey(o.Short, o.Long), o.Description)
+			}
+		}
+	case "subcommands":
+		for _, b := range doc.Blocks {
+			if b.Visibility == shedoc.VisibilitySubcommand {
+				add(b.Name, b.Description)
+			}
+		}
+	case "requires":
+		for _, r := range doc.Requirements() {
+			add(r.Command, r.Description)
+		}
+	default:
+		return nil, false
+	}
+	return entries, true
+}
+
+// flagKey joins the short and long forms of a flag, whichever are present.
+func flagKey(short, long string) string {
+	if short != "" && long != "" {
+		return short + ", " + long
+	}
+	return short + long
+}
+
+func firstLine(s string) string {
+	if idx := strings.IndexByte(s, '\n'); idx >= 0 {
+		return s[:idx]
+	}
+	return s
+}
+
+func runBlock(w io.Writer, docs []*shedoc.Document) error {
+	if flagTo != "json" {
+		return usageErrorf("--block supports only the json format; got %q", flagTo)
+	}
+
+	enc := json.NewEncoder(w)
+	enc.SetEscapeHTML(false)
+	for _, doc := range docs {
+		block := findBlock(doc, flagBlock)
+		if block == nil {
+			return fmt.Errorf("block %q not found in %s", flagBlock, docSource(doc))
+		}
+		if err := enc.Encode(block); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// findBlock returns the block whose name or function name is ref, or the
+// block at ref interpreted as a zero-based index.
+func findBlock(doc *shedoc.Document, ref string) *shedoc.Block {
+	for i := range doc.Blocks {
+		b := &doc.Blocks[i]
+		if b.Name == ref || b.FunctionName == ref {
+			return b
+		}
+	}
+	if idx, err := strconv.Atoi(ref); err == nil && idx >= 0 && idx < len(doc.Blocks) {
+		return &doc.Blocks[idx]
+	}
+	return nil
+}
+
 func getMetaField(m *shedoc.Meta, tag string) (string, bool) {
 	switch tag {
 	case "name":
@@ -132,7 +675,7 @@ func getMetaField(m *shedoc.Meta, tag string) (string, bool) {
 	case "version":
 		return m.Version, true
 	case "synopsis":
-		return m.Synopsis, true
+		return strings.Join(m.Synopsis, "\n"), true
 	case "description":
 		return m.Description, true
 	case "examples":
@@ -143,24 +686,75 @@ func getMetaField(m *shedoc.Meta, tag string) (string, bool) {
 		return m.Author, true
 	case "license":
 		return m.License, true
+	case "license-file":
+		return m.LicenseFile, true
+	case "lang":
+		return m.Lang, true
+	case "see-also":
+		return strings.Join(m.SeeAlso, "\n"), true
 	default:
 		return "", false
 	}
 }
 
+// addCommandAliases appends names to the aliases of the document's command
+// block. It fails if the document has no command block to add them to.
+func addCommandAliases(doc *shedoc.Document, names []string) error {
+	if len(names) == 0 {
+		return nil
+	}
+	for i := range doc.Blocks {
+		if doc.Blocks[i].Visibility == shedoc.VisibilityCommand {
+			doc.Blocks[i].Aliases = append(doc.Blocks[i].Aliases, names...)
+			return nil
+		}
+	}
+	return fmt.Errorf("%s: --command-name requires a #@/command block", docSource(doc))
+}
+
+func readCatalog(path string) (shedoc.Catalog, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+
+	catalog, err := shedoc.ReadCatalog(f)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", path, err)
+	}
+	return catalog, nil
+}
+
 func parseFiles(args []string) ([]*shedoc.Document, error) {
+	opts, err := parseOptions()
+	if err != nil {
+		return nil, err
+	}
+
 	var docs []*shedoc.Document
 	for _, arg := range args {
 		if arg == "-" {
-			doc, err := shedoc.ParseReader(os.Stdin)
+			in := &countingReader{r: os.Stdin}
+			doc, err := shedoc.ParseReader(in, opts...)
 			if err != nil {
 				return nil, fmt.Errorf("failed to parse stdin: %w", err)
 			}
+			if flagStdinName != "" {
+				doc.Path = flagStdinName
+				if doc.Meta.Name == "" {
+					base := filepath.Base(flagStdinName)
+					doc.Meta.Name = strings.TrimSuffix(base, filepath.Ext(base))
+				}
+			}
+			if metrics != nil {
+				metrics.addDocument(doc, in)
+			}
 			docs = append(docs, doc)
 			continue
 		}
 
-		doc, err := shedoc.Parse(arg)
+		doc, err := parseFile(arg, opts...)
 		if err != nil {
 			return nil, fmt.Errorf("failed to parse %s: %w", arg, err)
 		}
@@ -168,3 +762,24 @@ func parseFiles(args []string) ([]*shedoc.Document, error) {
 	}
 	return docs, nil
 }
+
+// parseFile parses the script at path like shedoc.Parse, counting it toward
+// --metrics.
+func parseFile(path string, opts ...shedoc.ParseOption) (*shedoc.Document, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+
+	in := &countingReader{r: f}
+	doc, err := shedoc.ParseReader(in, opts...)
+	if err != nil {
+		return nil, err
+	}
+	doc.Path = path
+	if metrics != nil {
+		metrics.addDocument(doc, in)
+	}
+	return doc, nil
+}
@@ -0,0 +1,184 @@
+package cli
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"reflect"
+	"sort"
+
+	"github.com/nickawilliams/shedoc"
+	"github.com/spf13/cobra"
+)
+
+func newRoundtripCmd() *cobra.Command {
+	return &cobra.Command{
+		Use:   "roundtrip <file...>",
+		Short: "Check that documentation survives being rewritten as comments",
+		Long: `Parses each script, writes its documentation back out as shedoc comments
+(as "shedoc --to comments" does), parses the result again, and reports every
+field that differs between the two documents. Line numbers, function names,
+and warnings from the original parse are not compared.`,
+		Args:          cobra.MinimumNArgs(1),
+		RunE:          runRoundtrip,
+		SilenceUsage:  true,
+		SilenceErrors: true,
+	}
+}
+
+func runRoundtrip(cmd *cobra.Command, args []string) error {
+	docs, err := parseFiles(args)
+	if err != nil {
+		return err
+	}
+
+	w := cmd.OutOrStdout()
+	var failed int
+	for i, doc := range docs {
+		problems, err := roundtrip(doc)
+		if err != nil {
+			return fmt.Errorf("%s: %w", args[i], err)
+		}
+		for _, p := range problems {
+			fmt.Fprintf(w, "%s: %s\n", args[i], p)
+		}
+		if len(problems) > 0 {
+			failed++
+		}
+	}
+
+	if failed > 0 {
+		return findingsErrorf("%d of %d files changed in round trip", failed, len(args))
+	}
+	return nil
+}
+
+// roundtrip rewrites doc as comments, re-parses it, and describes each
+// difference between the two, along with any warnings the rewritten comments
+// produce.
+func roundtrip(doc *shedoc.Document) ([]string, error) {
+	var buf bytes.Buffer
+	if err := shedoc.GetFormatter("comments").Format(&buf, doc); err != nil {
+		return nil, err
+	}
+	reparsed, err := shedoc.ParseReader(&buf)
+	if err != nil {
+		return nil, fmt.Errorf("re-parsing comments: %w", err)
+	}
+
+	var problems []string
+	for _, w := range reparsed.Warnings {
+		problems = append(problems, fmt.Sprintf("rewritten line %d: %s", w.Line, w.Message))
+	}
+
+	before, err := roundtripFields(doc)
+	if err != nil {
+		return nil, err
+	}
+	after, err := roundtripFields(reparsed)
+	if err != nil {
+		return nil, err
+	}
+	return append(problems, diffValues("", before, after)...), nil
+}
+
+// roundtripFields returns the JSON form of doc, without the fields that the
+// comments cannot reproduce.
+func roundtripFields(doc *shedoc.Document) (any, error) {
+	data, err := json.Marshal(doc)
+	if err != nil {
+		return nil, err
+	}
+	var v map[string]any
+	if err := json.Unmarshal(data, &v); err != nil {
+		return nil, err
+	}
+	delete(v, "path")
+	delete(v, "warnings")
+	if blocks, ok := v["blocks"].([]any); ok {
+		for _, b := range blocks {
+			if b, ok := b.(map[string]any); ok {
+				delete(b, "functionName")
+				delete(b, "rawTags")
+			}
+		}
+	}
+	stripLines(v)
+	return v, nil
+}
+
+// stripLines removes every "line" key from a decoded JSON value.
+func stripLines(v any) {
+	switch v := v.(type) {
+	case map[string]any:
+		delete(v, "line")
+		for _, child := range v {
+			stripLines(child)
+		}
+	case []any:
+		for _, child := range v {
+			stripLines(child)
+		}
+	}
+}
+
+// diffValues describes each difference between two decoded JSON values, by
+// JSON path.
+func diffValues(path string, before, after any) []string {
+	if reflect.DeepEqual(before, after) {
+		return nil
+	}
+
+	switch b := before.(type) {
+	case map[string]any:
+		a, ok := after.(map[string]any)
+		if !ok {
+			break
+		}
+		keys := make(map[string]bool)
+		for k := range b {
+			keys[k] = true
+		}
+		for k := range a {
+			keys[k] = true
+		}
+		sorted := make([]string, 0, len(keys))
+		for k := range keys {
+			sorted = append(sorted, k)
+		}
+		sort.Strings(sorted)
+
+		var diffs []string
+		for _, k := range sorted {
+			child := k
+			if path != "" {
+				child = path + "." + k
+			}
+			diffs = append(diffs, diffValues(child, b[k], a[k])...)
+		}
+		return diffs
+	case []any:
+		a, ok := after.([]any)
+		if !ok || len(a) != len(b) {
+			break
+		}
+		var diffs []string
+		for i := range b {
+			diffs = append(diffs, diffValues(fmt.Sprintf("%s[%d]", path, i), b[i], a[i])...)
+		}
+		return diffs
+	}
+
+	return []string{fmt.Sprintf("%s: %s became %s", path, diffJSON(before), diffJSON(after))}
+}
+
+func diffJSON(v any) string {
+	if v == nil {
+		return "(missing)"
+	}
+	data, err := json.Marshal(v)
+	if err != nil {
+		return fmt.Sprint(v)
+	}
+	return string(data)
+}
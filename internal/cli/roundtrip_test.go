@@ -0,0 +1,65 @@
+package cli
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/nickawilliams/shedoc"
+)
+
+func TestCLI_Roundtrip(t *testing.T) {
+	args := []string{"roundtrip"}
+	for _, name := range []string{"comprehensive.sh", "edge_cases.sh", "library.sh", "minimal.sh", "standalone.sh"} {
+		args = append(args, testdataPath(t, name))
+	}
+	stdout, _, err := runCLI(args...)
+	if err != nil {
+		t.Fatalf("unexpected error: %v\n%s", err, stdout)
+	}
+	if stdout != "" {
+		t.Errorf("expected no output, got:\n%s", stdout)
+	}
+}
+
+func TestRoundtripReportsLoss(t *testing.T) {
+	doc := &shedoc.Document{
+		Blocks: []shedoc.Block{{
+			Visibility:  shedoc.VisibilityPublic,
+			Description: "First.\n\n@looks like a tag",
+		}},
+	}
+
+	problems, err := roundtrip(doc)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	want := []string{
+		"rewritten line 4: unknown tag @looks",
+		`blocks[0].description: "First.\n\n@looks like a tag" became "First."`,
+	}
+	if strings.Join(problems, "\n") != strings.Join(want, "\n") {
+		t.Errorf("got:\n%s\nwant:\n%s", strings.Join(problems, "\n"), strings.Join(want, "\n"))
+	}
+}
+
+func TestDiffValues(t *testing.T) {
+	before := map[string]any{
+		"meta":   map[string]any{"name": "a"},
+		"blocks": []any{map[string]any{"hidden": true}},
+	}
+	after := map[string]any{
+		"meta":   map[string]any{"name": "b", "version": "1"},
+		"blocks": []any{},
+	}
+
+	got := diffValues("", before, after)
+	want := []string{
+		`blocks: [{"hidden":true}] became []`,
+		`meta.name: "a" became "b"`,
+		`meta.version: (missing) became "1"`,
+	}
+	if strings.Join(got, "\n") != strings.Join(want, "\n") {
+		t.Errorf("got:\n%s\nwant:\n%s", strings.Join(got, "\n"), strings.Join(want, "\n"))
+	}
+}
@@ -0,0 +1,24 @@
+package cli
+
+import (
+	"github.com/nickawilliams/shedoc"
+	"github.com/spf13/cobra"
+)
+
+func newSchemaCmd() *cobra.Command {
+	return &cobra.Command{
+		Use:   "schema",
+		Short: "Print the JSON Schema of shedoc's JSON output",
+		Long: `Prints a JSON Schema (draft 2020-12) describing the documents written by
+"shedoc --to json", for validating them or generating typed bindings.`,
+		Args:          cobra.NoArgs,
+		RunE:          runSchema,
+		SilenceUsage:  true,
+		SilenceErrors: true,
+	}
+}
+
+func runSchema(cmd *cobra.Command, args []string) error {
+	_, err := cmd.OutOrStdout().Write(shedoc.JSONSchema())
+	return err
+}
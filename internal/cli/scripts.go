@@ -0,0 +1,227 @@
+package cli
+
+import (
+	"bytes"
+	"io"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/spf13/cobra"
+)
+
+var (
+	flagRequireShebang bool
+	flagFollowSymlinks bool
+)
+
+// sniffSize is how much of a file is read to tell whether it is a script.
+const sniffSize = 8000
+
+// collectScripts expands directories in paths to the shell scripts they
+// contain, noting each file it skips and why. Files named explicitly, and
+// "-" for stdin, are always included; a file reached again from a directory,
+// through a symbolic link or otherwise, is not.
+func collectScripts(cmd *cobra.Command, paths []string) ([]string, error) {
+	c := &scriptCollector{cmd: cmd, files: map[string]string{}, dirs: map[string]string{}}
+	for _, path := range paths {
+		if path == "-" {
+			c.scripts = append(c.scripts, path)
+			continue
+		}
+		info, err := os.Stat(path)
+		if err != nil {
+			return nil, err
+		}
+		if !info.IsDir() {
+			c.files[realPath(path)] = path
+			c.scripts = append(c.scripts, path)
+			continue
+		}
+		if err := c.walk(path); err != nil {
+			return nil, err
+		}
+	}
+	return c.scripts, nil
+}
+
+// scriptCollector accumulates the scripts found by collectScripts. files and
+// dirs map the real path of each file and directory seen to the path it was
+// first seen by, so that no file is parsed twice and symbolic link cycles
+// end.
+type scriptCollector struct {
+	cmd     *cobra.Command
+	scripts []string
+	files   map[string]string
+	dirs    map[string]string
+}
+
+// walk adds the scripts under dir, skipping hidden directories. Symbolic
+// links are followed only with --follow-symlinks.
+func (c *scriptCollector) walk(dir string) error {
+	real := realPath(dir)
+	if first, ok := c.dirs[real]; ok {
+		notef(c.cmd, "skipping %s: same directory as %s\n", dir, first)
+		return nil
+	}
+	c.dirs[real] = dir
+
+	entries, err := os.ReadDir(dir)
+	if err != nil {
+		return err
+	}
+	for _, e := range entries {
+		p := filepath.Join(dir, e.Name())
+		typ := e.Type()
+		if typ&fs.ModeSymlink != 0 {
+			if !flagFollowSymlinks {
+				notef(c.cmd, "skipping %s: symbolic link (see --follow-symlinks)\n", p)
+				continue
+			}
+			info, err := os.Stat(p)
+			if err != nil {
+				notef(c.cmd, "skipping %s: %v\n", p, err)
+				continue
+			}
+			typ = info.Mode().Type()
+		}
+
+		switch {
+		case typ.IsDir():
+			if strings.HasPrefix(e.Name(), ".") {
+				continue
+			}
+			if err := c.walk(p); err != nil {
+				return err
+			}
+		case typ.IsRegular():
+			c.add(p)
+		}
+	}
+	return nil
+}
+
+// add adds the file at p if it is a script not already seen.
+func (c *scriptCollector) add(p string) {
+	if reason := notScript(p); reason != "" {
+		notef(c.cmd, "skipping %s: %s\n", p, reason)
+		return
+	}
+	real := realPath(p)
+	if first, ok := c.files[real]; ok {
+		notef(c.cmd, "skipping %s: same file as %s\n", p, first)
+		return
+	}
+	c.files[real] = p
+	c.scripts = append(c.scripts, p)
+}
+
+// realPath returns path with symbolic links resolved, or path itself if they
+// cannot be.
+func realPath(path string) string {
+	if real, err := filepath.EvalSymlinks(path); err == nil {
+		return real
+	}
+	return path
+}
+
+// notScript returns why the file at path is not a shell script, or "" if it
+// is one. A script is a text file with a shell extension such as .sh or
+// .bash, or with no extension, that starts with a shell shebang; with
+// --require-shebang the extension alone is not enough. Names like .bashrc
+// that start with their only dot have no extension.
+func notScript(path string) string {
+	ext := scriptExt(path)
+	if ext != "" && !shellExts[ext] {
+		return "not a shell script"
+	}
+
+	f, err := os.Open(path)
+	if err != nil {
+		return err.Error()
+	}
+	defer f.Close()
+
+	head := make([]byte, sniffSize)
+	n, err := io.ReadFull(f, head)
+	if err != nil && err != io.EOF && err != io.ErrUnexpectedEOF {
+		return err.Error()
+	}
+	head = head[:n]
+
+	if bytes.IndexByte(head, 0) >= 0 {
+		return "binary file"
+	}
+	if ext != "" && !flagRequireShebang {
+		return ""
+	}
+	if !hasShellShebang(head) {
+		return "no shell shebang"
+	}
+	return ""
+}
+
+// scriptExt returns the extension of the file at path, or "" for a name
+// whose only dot is its first character.
+func scriptExt(path string) string {
+	name := filepath.Base(path)
+	if strings.LastIndexByte(name, '.') <= 0 {
+		return ""
+	}
+	return filepath.Ext(name)
+}
+
+// shellExts are the extensions of files taken to be shell scripts.
+var shellExts = map[string]bool{
+	".sh": true, ".bash": true, ".dash": true, ".ksh": true,
+	".mksh": true, ".zsh": true, ".ash": true,
+}
+
+// shells are the interpreters a shell shebang may name.
+var shells = map[string]bool{
+	"sh": true, "bash": true, "dash": true, "ksh": true,
+	"mksh": true, "zsh": true, "ash": true,
+}
+
+// hasShellShebang reports whether the file starting with head names a shell
+// as its interpreter, directly, through env, or as a busybox applet.
+func hasShellShebang(head []byte) bool {
+	line, _, _ := bytes.Cut(head, []byte("\n"))
+	if !bytes.HasPrefix(line, []byte("#!")) {
+		return false
+	}
+	fields := strings.Fields(string(line[2:]))
+	if len(fields) == 0 {
+		return false
+	}
+	interp, args := filepath.Base(fields[0]), fields[1:]
+	if interp == "env" {
+		args = envCommand(args)
+		if len(args) == 0 {
+			return false
+		}
+		interp, args = filepath.Base(args[0]), args[1:]
+	}
+	if interp == "busybox" && len(args) > 0 {
+		interp = args[0]
+	}
+	return shells[interp]
+}
+
+// envCommand returns the command line env runs given args, skipping its
+// options, their operands, and NAME=value assignments.
+func envCommand(args []string) []string {
+	for len(args) > 0 {
+		arg := args[0]
+		switch {
+		case arg == "-u" || arg == "--unset" || arg == "-C" || arg == "--chdir":
+			args = args[min(2, len(args)):]
+		case strings.HasPrefix(arg, "-") || strings.Contains(arg, "="):
+			args = args[1:]
+		default:
+			return args
+		}
+	}
+	return nil
+}
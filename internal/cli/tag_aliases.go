@@ -0,0 +1,46 @@
+package cli
+
+import (
+	"strings"
+
+	"github.com/nickawilliams/shedoc"
+)
+
+var (
+	flagTagAliases     []string
+	flagWarnTagAliases bool
+	flagWarnTagOrder   bool
+)
+
+// parseOptions returns the options scripts are parsed with: the tag aliases
+// given by --tag-alias, with --warn-tag-aliases a warning for each use, and
+// with --warn-tag-order a warning for each tag out of canonical order.
+func parseOptions() ([]shedoc.ParseOption, error) {
+	var opts []shedoc.ParseOption
+	if flagWarnTagOrder {
+		opts = append(opts, shedoc.WithTagOrderWarnings())
+	}
+	if len(flagTagAliases) == 0 {
+		if flagWarnTagAliases {
+			return nil, usageErrorf("--warn-tag-aliases requires --tag-alias")
+		}
+		return opts, nil
+	}
+
+	aliases := map[string]string{}
+	for _, a := range flagTagAliases {
+		from, to, ok := strings.Cut(a, "=")
+		from = strings.TrimPrefix(strings.TrimSpace(from), "@")
+		to = strings.TrimPrefix(strings.TrimSpace(to), "@")
+		if !ok || from == "" || to == "" || strings.ContainsAny(from+to, " \t") {
+			return nil, usageErrorf("invalid --tag-alias %q: want from=to, such as param=operand", a)
+		}
+		aliases[from] = to
+	}
+
+	opts = append(opts, shedoc.WithTagAliases(aliases))
+	if flagWarnTagAliases {
+		opts = append(opts, shedoc.WithTagAliasWarnings())
+	}
+	return opts, nil
+}
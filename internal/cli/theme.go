@@ -0,0 +1,78 @@
+package cli
+
+import (
+	"io/fs"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/nickawilliams/shedoc/format"
+	"github.com/spf13/cobra"
+)
+
+var flagTheme string
+
+// Files of a --theme directory that are read rather than copied.
+const (
+	themePage       = "page.html"
+	themeStylesheet = "style.css"
+)
+
+// loadTheme returns an HTMLFormatter that renders pages with the theme in
+// dir: its page.html template in place of the built-in page, and its
+// style.css in place of the built-in stylesheet. Either may be missing.
+func loadTheme(dir, sourceURL string) (*format.HTMLFormatter, error) {
+	f := &format.HTMLFormatter{SourceURL: sourceURL}
+
+	text, err := os.ReadFile(filepath.Join(dir, themePage))
+	switch {
+	case err == nil:
+		if f.Page, err = format.ParseHTMLPage(themePage, string(text)); err != nil {
+			return nil, usageErrorf("%v", err)
+		}
+	case !os.IsNotExist(err):
+		return nil, err
+	}
+
+	style, err := os.ReadFile(filepath.Join(dir, themeStylesheet))
+	switch {
+	case err == nil:
+		f.Stylesheet = string(style)
+	case !os.IsNotExist(err):
+		return nil, err
+	}
+	return f, nil
+}
+
+// copyThemeAssets copies the files of the theme in dir, other than those
+// loadTheme reads and hidden ones, into outDir at the same relative paths.
+func copyThemeAssets(cmd *cobra.Command, dir, outDir string) error {
+	return filepath.WalkDir(dir, func(path string, d fs.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+		rel, err := filepath.Rel(dir, path)
+		if err != nil {
+			return err
+		}
+		if rel != "." && strings.HasPrefix(d.Name(), ".") {
+			if d.IsDir() {
+				return filepath.SkipDir
+			}
+			return nil
+		}
+		if d.IsDir() || rel == themePage || rel == themeStylesheet {
+			return nil
+		}
+
+		data, err := os.ReadFile(path)
+		if err != nil {
+			return err
+		}
+		dest := filepath.Join(outDir, rel)
+		if err := os.MkdirAll(filepath.Dir(dest), 0o755); err != nil {
+			return err
+		}
+		return writeArtifact(cmd, dest, data)
+	})
+}
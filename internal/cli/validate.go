@@ -0,0 +1,293 @@
+package cli
+
+import (
+	"bufio"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"maps"
+	"math"
+	"os"
+	"slices"
+	"strings"
+	"sync"
+
+	"github.com/nickawilliams/shedoc"
+	"github.com/spf13/cobra"
+)
+
+func newValidateCmd() *cobra.Command {
+	return &cobra.Command{
+		Use:   "validate <file.json...>",
+		Short: "Check exported Document JSON against the current schema",
+		Long: `Reads JSON previously produced by "shedoc --to json" (a single document,
+NDJSON, or a --array list) and checks it against the JSON Schema that "shedoc
+schema" prints, reporting fields this version of shedoc does not understand,
+values of the wrong type, and missing or invalid required values. It also
+reports flags and options without a name, and operand and option
+documentation that no invocation can satisfy.`,
+		Args:          cobra.MinimumNArgs(1),
+		RunE:          runValidate,
+		SilenceUsage:  true,
+		SilenceErrors: true,
+	}
+}
+
+func runValidate(cmd *cobra.Command, args []string) error {
+	w := cmd.OutOrStdout()
+
+	var failed int
+	for _, path := range args {
+		problems, err := validateFile(path)
+		if err != nil {
+			return err
+		}
+		for _, p := range problems {
+			fmt.Fprintf(w, "%s: %s\n", path, p)
+		}
+		if len(problems) > 0 {
+			failed++
+		}
+	}
+
+	if failed > 0 {
+		return findingsErrorf("%d of %d files failed validation", failed, len(args))
+	}
+	return nil
+}
+
+// validateFile decodes every document in path and returns a description of
+// each incompatibility found. A non-nil error means the file could not be read.
+func validateFile(path string) ([]string, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+
+	return validateStream(f), nil
+}
+
+// validateStream validates a single document, NDJSON, or, as written by
+// --array, a JSON array of documents.
+func validateStream(r io.Reader) []string {
+	br := bufio.NewReader(r)
+	dec := json.NewDecoder(br)
+
+	array := firstNonSpace(br) == '['
+	if array {
+		if _, err := dec.Token(); err != nil {
+			return []string{err.Error()}
+		}
+	}
+
+	var problems []string
+	for i := 0; ; i++ {
+		if array && !dec.More() {
+			if _, err := dec.Token(); err != nil {
+				return append(problems, err.Error())
+			}
+			array = false
+		}
+		var raw json.RawMessage
+		err := dec.Decode(&raw)
+		if errors.Is(err, io.EOF) {
+			if i == 0 {
+				problems = append(problems, "no documents found")
+			}
+			return problems
+		}
+		if err != nil {
+			return append(problems, fmt.Sprintf("document %d: %v", i, err))
+		}
+		for _, p := range validateDocument(raw) {
+			problems = append(problems, fmt.Sprintf("document %d: %s", i, p))
+		}
+	}
+}
+
+// firstNonSpace returns the first byte of r that is not JSON whitespace,
+// without consuming it, or 0 at the end of input.
+func firstNonSpace(r *bufio.Reader) byte {
+	for {
+		b, err := r.Peek(1)
+		if err != nil {
+			return 0
+		}
+		switch b[0] {
+		case ' ', '\t', '\n', '\r':
+			r.ReadByte()
+		default:
+			return b[0]
+		}
+	}
+}
+
+// validateDocument checks the JSON of one document against
+// shedoc.JSONSchema, and, if it decodes, checks it with checkDocument.
+func validateDocument(raw json.RawMessage) []string {
+	var v any
+	if err := json.Unmarshal(raw, &v); err != nil {
+		return []string{err.Error()}
+	}
+	problems := checkSchema(documentSchema(), v, "")
+
+	var doc shedoc.Document
+	if err := json.Unmarshal(raw, &doc); err != nil {
+		return problems
+	}
+	return append(problems, checkDocument(&doc)...)
+}
+
+// checkDocument checks what the schema cannot express: the names that
+// subcommand blocks, flags, and options require, operand orders that no
+// invocation can satisfy, and required options documented with a default or
+// as hidden.
+func checkDocument(doc *shedoc.Document) []string {
+	var problems []string
+	report := func(format string, args ...any) {
+		problems = append(problems, fmt.Sprintf(format, args...))
+	}
+
+	for i, b := range doc.Blocks {
+		at := fmt.Sprintf("blocks[%d]", i)
+
+		if b.Visibility == shedoc.VisibilitySubcommand && b.Name == "" {
+			report("%s.name: required for subcommand blocks", at)
+		}
+
+		for j, f := range b.Flags {
+			if f.Short == "" && f.Long == "" {
+				report("%s.flags[%d]: short or long is required", at, j)
+			}
+		}
+		for j, o := range b.Options {
+			if o.Short == "" && o.Long == "" {
+				report("%s.options[%d]: short or long is required", at, j)
+			}
+		}
+		for _, w := range b.OperandOrderWarnings() {
+			report("%s.operands: %s", at, w.Message)
+		}
+		for _, w := range b.RequiredOptionWarnings() {
+			report("%s.options: %s", at, w.Message)
+		}
+	}
+
+	return problems
+}
+
+// jsonSchema is a JSON Schema, as far as shedoc.JSONSchema uses it.
+type jsonSchema struct {
+	Ref        string                 `json:"$ref"`
+	Type       string                 `json:"type"`
+	Enum       []string               `json:"enum"`
+	MinLength  int                    `json:"minLength"`
+	Items      *jsonSchema            `json:"items"`
+	Properties map[string]*jsonSchema `json:"properties"`
+	Required   []string               `json:"required"`
+	Defs       map[string]*jsonSchema `json:"$defs"`
+}
+
+// documentSchema returns shedoc.JSONSchema, decoded.
+var documentSchema = sync.OnceValue(func() *jsonSchema {
+	var s jsonSchema
+	if err := json.Unmarshal(shedoc.JSONSchema(), &s); err != nil {
+		panic(err)
+	}
+	return &s
+})
+
+// checkSchema returns the ways v, found at path at of a document, does not
+// conform to s.
+func checkSchema(s *jsonSchema, v any, at string) []string {
+	if ref, ok := strings.CutPrefix(s.Ref, "#/$defs/"); ok {
+		s = documentSchema().Defs[ref]
+	}
+	field := func(name string) string {
+		if at == "" {
+			return name
+		}
+		return at + "." + name
+	}
+
+	var problems []string
+	switch v := v.(type) {
+	case map[string]any:
+		if s.Type != "object" {
+			break
+		}
+		for _, name := range s.Required {
+			if _, ok := v[name]; !ok {
+				problems = append(problems, schemaProblem(field(name), "missing"))
+			}
+		}
+		for _, name := range slices.Sorted(maps.Keys(v)) {
+			prop, ok := s.Properties[name]
+			if !ok {
+				problems = append(problems, schemaProblem(at, fmt.Sprintf("unknown field %q", name)))
+				continue
+			}
+			problems = append(problems, checkSchema(prop, v[name], field(name))...)
+		}
+		return problems
+	case []any:
+		if s.Type != "array" {
+			break
+		}
+		for i, item := range v {
+			problems = append(problems, checkSchema(s.Items, item, fmt.Sprintf("%s[%d]", at, i))...)
+		}
+		return problems
+	case string:
+		if s.Type != "string" {
+			break
+		}
+		switch {
+		case len(v) < s.MinLength:
+			problems = append(problems, schemaProblem(at, "missing"))
+		case s.Enum != nil && !slices.Contains(s.Enum, v):
+			problems = append(problems, schemaProblem(at, fmt.Sprintf("unknown value %q", v)))
+		}
+		return problems
+	case float64:
+		if s.Type == "integer" && v == math.Trunc(v) {
+			return nil
+		}
+	case bool:
+		if s.Type == "boolean" {
+			return nil
+		}
+	}
+	return []string{schemaProblem(at, fmt.Sprintf("got %s, want %s", jsonType(v), s.Type))}
+}
+
+// schemaProblem describes a problem with the value at path at, or with the
+// document itself if at is empty.
+func schemaProblem(at, problem string) string {
+	if at == "" {
+		return problem
+	}
+	return at + ": " + problem
+}
+
+// jsonType returns the JSON type of v as decoded by encoding/json.
+func jsonType(v any) string {
+	switch v := v.(type) {
+	case map[string]any:
+		return "object"
+	case []any:
+		return "array"
+	case string:
+		return "string"
+	case float64:
+		if v == math.Trunc(v) {
+			return "integer"
+		}
+		return "number"
+	case bool:
+		return "boolean"
+	}
+	return "null"
+}
@@ -0,0 +1,124 @@
+package cli
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+// writeTemp writes content to a file in a fresh temp dir and returns its path.
+func writeTemp(t *testing.T, name, content string) string {
+	t.Helper()
+	path := filepath.Join(t.TempDir(), name)
+	if err := os.WriteFile(path, []byte(content), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	return path
+}
+
+func TestCLI_ValidateRoundTrip(t *testing.T) {
+	exported, _, err := runCLI("--warnings", testdataPath(t, "comprehensive.sh"), testdataPath(t, "edge_cases.sh"))
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	path := writeTemp(t, "docs.json", exported)
+	stdout, _, err := runCLI("validate", path)
+	if err != nil {
+		t.Fatalf("validate failed: %v\n%s", err, stdout)
+	}
+	if stdout != "" {
+		t.Errorf("expected no output, got:\n%s", stdout)
+	}
+}
+
+func TestCLI_ValidateUnknownField(t *testing.T) {
+	path := writeTemp(t, "doc.json", `{"meta":{"name":"x","homepage":"y"}}`)
+	stdout, _, err := runCLI("validate", path)
+	if err == nil {
+		t.Fatal("expected error for unknown field")
+	}
+	if !strings.Contains(stdout, `unknown field "homepage"`) {
+		t.Errorf("expected unknown field report, got:\n%s", stdout)
+	}
+}
+
+func TestValidateStream(t *testing.T) {
+	tests := []struct {
+		name  string
+		input string
+		want  []string
+	}{
+		{
+			name:  "valid",
+			input: `{"meta":{},"blocks":[{"visibility":"public","line":1}]}`,
+		},
+		{
+			name:  "empty",
+			input: "",
+			want:  []string{"no documents found"},
+		},
+		{
+			name:  "bad visibility",
+			input: `{"meta":{},"blocks":[{"visibility":"internal","line":1}]}`,
+			want:  []string{`document 0: blocks[0].visibility: unknown value "internal"`},
+		},
+		{
+			name:  "missing required values",
+			input: `{"meta":{},"blocks":[{"visibility":"subcommand","line":1,"flags":[{"line":2}],"exit":[{"code":"","line":3}]}]}`,
+			want: []string{
+				"document 0: blocks[0].exit[0].code: missing",
+				"document 0: blocks[0].name: required for subcommand blocks",
+				"document 0: blocks[0].flags[0]: short or long is required",
+			},
+		},
+		{
+			name:  "wrong types",
+			input: `{"meta":{"name":1},"blocks":[{"visibility":"public","line":"1"}]}`,
+			want: []string{
+				"document 0: blocks[0].line: got string, want integer",
+				"document 0: meta.name: got integer, want string",
+			},
+		},
+		{
+			name:  "not an object",
+			input: `"deploy"`,
+			want:  []string{"document 0: got string, want object"},
+		},
+		{
+			name:  "impossible operand order",
+			input: `{"meta":{},"blocks":[{"visibility":"command","line":1,"operands":[{"value":{"name":"a","required":false},"line":2},{"value":{"name":"b","required":true},"line":3}]}]}`,
+			want:  []string{"document 0: blocks[0].operands: required operand <b> follows optional operand [a]"},
+		},
+		{
+			name:  "required option with default",
+			input: `{"meta":{},"blocks":[{"visibility":"command","line":1,"options":[{"long":"--mode","value":{"name":"m","required":false,"default":"fast"},"required":true,"line":2}]}]}`,
+			want:  []string{"document 0: blocks[0].options: required option --mode has a default that never applies"},
+		},
+		{
+			name:  "second document",
+			input: "{\"meta\":{}}\n{\"meta\":{},\"blocks\":[{\"line\":1}]}\n",
+			want:  []string{"document 1: blocks[0].visibility: missing"},
+		},
+		{
+			name:  "array",
+			input: " [{\"meta\":{}},\n{\"meta\":{},\"blocks\":[{\"line\":1}]}]\n",
+			want:  []string{"document 1: blocks[0].visibility: missing"},
+		},
+		{
+			name:  "empty array",
+			input: "[]",
+			want:  []string{"no documents found"},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := validateStream(strings.NewReader(tt.input))
+			if strings.Join(got, "\n") != strings.Join(tt.want, "\n") {
+				t.Errorf("validateStream() =\n%s\nwant\n%s", strings.Join(got, "\n"), strings.Join(tt.want, "\n"))
+			}
+		})
+	}
+}
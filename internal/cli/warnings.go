@@ -0,0 +1,143 @@
+package cli
+
+import (
+	"fmt"
+	"io"
+	"strings"
+
+	"github.com/nickawilliams/shedoc"
+	"github.com/spf13/cobra"
+)
+
+// reportWarnings prints the parse warnings of docs on stderr, unless --quiet
+// or --no-warnings is given.
+func reportWarnings(cmd *cobra.Command, docs []*shedoc.Document) {
+	if flagQuiet || flagNoWarnings {
+		return
+	}
+	writeWarnings(cmd.ErrOrStderr(), docs, flagMaxWarnings)
+}
+
+// warningsError returns an error that exits with ExitWarnings if
+// --fail-on-warnings is given and any of docs has warnings, or nil.
+func warningsError(docs []*shedoc.Document) error {
+	if !flagFailOnWarnings {
+		return nil
+	}
+	var n int
+	for _, doc := range docs {
+		n += len(doc.Warnings)
+	}
+	if n == 0 {
+		return nil
+	}
+	return &exitError{code: ExitWarnings, err: fmt.Errorf("%d warnings (--fail-on-warnings)", n)}
+}
+
+// warnNameCollisions adds a warning, with no line, to each of docs whose
+// #?/name another of docs also declares: their man pages and completions
+// would be installed under the same name. A file given twice, by the same
+// path or through a symbolic link, is counted once.
+func warnNameCollisions(docs []*shedoc.Document) {
+	byName := map[string][]*shedoc.Document{}
+	seen := map[string]bool{}
+	for _, doc := range docs {
+		if doc.Meta.Name == "" {
+			continue
+		}
+		if doc.Path != "" {
+			real := realPath(doc.Path)
+			if seen[real] {
+				continue
+			}
+			seen[real] = true
+		}
+		byName[doc.Meta.Name] = append(byName[doc.Meta.Name], doc)
+	}
+	for _, doc := range docs {
+		same := byName[doc.Meta.Name]
+		if len(same) < 2 {
+			continue
+		}
+		var others []string
+		for _, other := range same {
+			if other != doc && (other.Path == "" || doc.Path == "" || realPath(other.Path) != realPath(doc.Path)) {
+				others = append(others, docSource(other))
+			}
+		}
+		doc.Warnings = append(doc.Warnings, shedoc.Warning{
+			Message: fmt.Sprintf("#?/name %q is also declared by %s", doc.Meta.Name, strings.Join(others, ", ")),
+		})
+	}
+}
+
+// docSource names the file a document was parsed from.
+func docSource(doc *shedoc.Document) string {
+	if doc.Path == "" {
+		return "<stdin>"
+	}
+	return doc.Path
+}
+
+// notef prints a non-essential message, such as a prompt or a progress
+// note, on stderr, unless --quiet is given. Results and errors are never
+// written this way.
+func notef(cmd *cobra.Command, format string, args ...any) {
+	if flagQuiet {
+		return
+	}
+	fmt.Fprintf(cmd.ErrOrStderr(), format, args...)
+}
+
+// writeWarnings prints the warnings of each document, one per line. Identical
+// messages within a document are collapsed into their first occurrence with
+// a count. When limit is positive, at most limit lines are printed, followed by a
+// note of how many were left out.
+func writeWarnings(w io.Writer, docs []*shedoc.Document, limit int) {
+	var printed, omitted int
+	for _, doc := range docs {
+		source := docSource(doc)
+		for _, warn := range collapseWarnings(doc.Warnings) {
+			if limit > 0 && printed == limit {
+				omitted++
+				continue
+			}
+			printed++
+			if warn.Line > 0 {
+				fmt.Fprintf(w, "%s:%d: warning: %s", source, warn.Line, warn.Message)
+			} else {
+				fmt.Fprintf(w, "%s: warning: %s", source, warn.Message)
+			}
+			if warn.count > 1 {
+				fmt.Fprintf(w, " (%d occurrences)", warn.count)
+			}
+			fmt.Fprintln(w)
+		}
+	}
+	if omitted > 0 {
+		fmt.Fprintf(w, "shedoc: %d more warnings not shown (--max-warnings %d)\n", omitted, limit)
+	}
+}
+
+// collapsedWarning is the first occurrence of a warning message and the
+// number of times it occurs.
+type collapsedWarning struct {
+	shedoc.Warning
+	count int
+}
+
+// collapseWarnings groups warnings by message, keeping the order of first
+// occurrence.
+func collapseWarnings(warnings []shedoc.Warning) []collapsedWarning {
+	var out []collapsedWarning
+	index := map[string]int{}
+	for _, warn := range warnings {
+		if i, ok := index[warn.Message]; ok {
+			out[i].count++
+			continue
+		}
+		index[warn.Message] = len(out)
+		out = append(out, collapsedWarning{Warning: warn, count: 1})
+	}
+	return out
+}
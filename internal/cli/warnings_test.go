@@ -0,0 +1,174 @@
+package cli
+
+import (
+	"bytes"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/nickawilliams/shedoc"
+)
+
+func TestWriteWarnings(t *testing.T) {
+	docs := []*shedoc.Document{
+		{Path: "a.sh", Warnings: []shedoc.Warning{
+			{Line: 3, Message: "unknown tag @foo"},
+			{Line: 5, Message: "unknown tag @bar"},
+			{Line: 9, Message: "unknown tag @foo"},
+			{Line: 12, Message: "unknown tag @foo"},
+		}},
+		{Warnings: []shedoc.Warning{{Line: 1, Message: "unknown tag @foo"}}},
+		{Path: "b.sh", Warnings: []shedoc.Warning{{Message: `#?/name "a" is also declared by a.sh`}}},
+	}
+
+	tests := []struct {
+		limit int
+		want  string
+	}{
+		{0, "a.sh:3: warning: unknown tag @foo (3 occurrences)\n" +
+			"a.sh:5: warning: unknown tag @bar\n" +
+			"<stdin>:1: warning: unknown tag @foo\n" +
+			"b.sh: warning: #?/name \"a\" is also declared by a.sh\n"},
+		{2, "a.sh:3: warning: unknown tag @foo (3 occurrences)\n" +
+			"a.sh:5: warning: unknown tag @bar\n" +
+			"shedoc: 2 more warnings not shown (--max-warnings 2)\n"},
+	}
+	for _, tt := range tests {
+		var buf bytes.Buffer
+		writeWarnings(&buf, docs, tt.limit)
+		if got := buf.String(); got != tt.want {
+			t.Errorf("limit %d:\ngot:\n%s\nwant:\n%s", tt.limit, got, tt.want)
+		}
+	}
+}
+
+func TestCLI_MaxWarnings(t *testing.T) {
+	path := writeTemp(t, "noisy.sh", `#!/bin/bash
+#?/name noisy
+#?/bogus one
+#?/bogus two
+#?/other three
+##
+`)
+
+	_, stderr, err := runCLI("--max-warnings", "1", path)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	lines := strings.Split(strings.TrimSpace(stderr), "\n")
+	if len(lines) != 2 || !strings.Contains(lines[0], "(2 occurrences)") || !strings.Contains(lines[1], "1 more warnings not shown") {
+		t.Errorf("stderr = %q", stderr)
+	}
+}
+
+func TestCLI_NoWarningsAndQuiet(t *testing.T) {
+	path := writeTemp(t, "noisy.sh", `#!/bin/bash
+#?/name noisy
+#?/bogus one
+#@/command
+ # @flag -v | --verbose  Verbose output
+ ##
+`)
+
+	tests := []struct {
+		args       []string
+		wantWarn   bool
+		wantPrompt bool
+	}{
+		{nil, true, true},
+		{[]string{"--no-warnings"}, false, true},
+		{[]string{"--quiet"}, false, false},
+	}
+	for _, tt := range tests {
+		_, stderr, err := runCLI(append(tt.args, path)...)
+		if err != nil {
+			t.Fatalf("%v: unexpected error: %v", tt.args, err)
+		}
+		if got := strings.Contains(stderr, "warning:"); got != tt.wantWarn {
+			t.Errorf("%v: root stderr = %q, want warnings %v", tt.args, stderr, tt.wantWarn)
+		}
+
+		_, stderr, err = runCLI(append([]string{"man"}, append(tt.args, path)...)...)
+		if err != nil {
+			t.Fatalf("%v: man: unexpected error: %v", tt.args, err)
+		}
+		if got := strings.Contains(stderr, "warning:"); got != tt.wantWarn {
+			t.Errorf("%v: man stderr = %q, want warnings %v", tt.args, stderr, tt.wantWarn)
+		}
+
+		var out, errOut bytes.Buffer
+		cmd := NewRootCmd("test-version")
+		cmd.SetOut(&out)
+		cmd.SetErr(&errOut)
+		cmd.SetIn(strings.NewReader("verb\n"))
+		cmd.SetArgs(append([]string{"pick", "--no-fzf", "--query="}, append(tt.args, path)...))
+		if err := cmd.Execute(); err != nil {
+			t.Fatalf("%v: pick: unexpected error: %v", tt.args, err)
+		}
+		if got := strings.Contains(errOut.String(), "noisy> "); got != tt.wantPrompt {
+			t.Errorf("%v: pick stderr = %q, want prompt %v", tt.args, errOut.String(), tt.wantPrompt)
+		}
+		if got := strings.TrimSpace(out.String()); got != "noisy --verbose" {
+			t.Errorf("%v: pick = %q, want %q", tt.args, got, "noisy --verbose")
+		}
+	}
+}
+
+func TestCLI_NameCollisions(t *testing.T) {
+	dir := t.TempDir()
+	for name, content := range map[string]string{
+		"deploy.sh": "#!/bin/bash\n#?/name deploy\n",
+		"old.sh":    "#!/bin/bash\n#?/name deploy\n",
+		"other.sh":  "#!/bin/bash\n#?/name other\n",
+	} {
+		if err := os.WriteFile(filepath.Join(dir, name), []byte(content), 0o644); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	stdout, stderr, err := runCLI("--warnings", dir)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	deploy, old := filepath.Join(dir, "deploy.sh"), filepath.Join(dir, "old.sh")
+	for _, want := range []string{
+		deploy + `: warning: #?/name "deploy" is also declared by ` + old + "\n",
+		old + `: warning: #?/name "deploy" is also declared by ` + deploy + "\n",
+	} {
+		if !strings.Contains(stderr, want) {
+			t.Errorf("stderr missing %q\n%s", want, stderr)
+		}
+	}
+	if strings.Contains(stderr, "other.sh") {
+		t.Errorf("warned of a name declared once:\n%s", stderr)
+	}
+	if !strings.Contains(stdout, `"message":"#?/name \"deploy\" is also declared by `) {
+		t.Errorf("JSON output missing the collision:\n%s", stdout)
+	}
+
+	_, _, err = runCLI("--fail-on-warnings", dir)
+	if got := ExitCode(err); got != ExitWarnings {
+		t.Errorf("--fail-on-warnings: ExitCode = %d, want %d (err: %v)", got, ExitWarnings, err)
+	}
+}
+
+func TestCLI_NameCollisions_SameFile(t *testing.T) {
+	dir := t.TempDir()
+	deploy := filepath.Join(dir, "deploy.sh")
+	if err := os.WriteFile(deploy, []byte("#!/bin/bash\n#?/name deploy\n"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	link := filepath.Join(dir, "link.sh")
+	if err := os.Symlink(deploy, link); err != nil {
+		t.Skipf("symlinks unsupported: %v", err)
+	}
+
+	_, stderr, err := runCLI("--fail-on-warnings", deploy, deploy, link)
+	if err != nil {
+		t.Fatalf("unexpected error: %v\n%s", err, stderr)
+	}
+	if strings.Contains(stderr, "also declared") {
+		t.Errorf("warned of a file colliding with itself:\n%s", stderr)
+	}
+}
@@ -0,0 +1,10 @@
+//go:build !linux && !darwin
+
+package cli
+
+import "os"
+
+// windowWidth returns 0: the terminal's width is not known on this platform.
+func windowWidth(f *os.File) int {
+	return 0
+}
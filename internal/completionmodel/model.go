@@ -0,0 +1,223 @@
+// Package completionmodel builds the shell-agnostic completion view of a
+// Document shared by the static completion generators and the dynamic
+// completion handler, so new tags only need to be wired in one place.
+package completionmodel
+
+import (
+	"slices"
+	"strings"
+
+	"github.com/nickawilliams/shedoc"
+)
+
+// Model is the completion view of a Document. Hidden blocks, flags, and
+// options are excluded.
+type Model struct {
+	// Names holds the command name followed by its @alias names.
+	Names       []string
+	Flags       []Flag
+	Operands    []Operand
+	Subcommands []Subcommand
+	// MinArgs and MaxArgs bound the number of operands; MaxArgs is
+	// shedoc.ArgsUnbounded when the last operand is variadic.
+	MinArgs int
+	MaxArgs int
+}
+
+// Flag is a flag or option offered as a completion candidate.
+type Flag struct {
+	Short       string
+	Long        string
+	Description string
+	// Value is set for options that take a value and nil for boolean flags.
+	Value *shedoc.Value
+	// Complete is a shell command whose output lines complete the value.
+	Complete string
+	// Required is set for options that must be given (@option!).
+	Required bool
+}
+
+// Operand is a positional argument, in documented order.
+type Operand struct {
+	Name        string
+	Description string
+	// Position is the operand's 1-based ordinal among its block's operands.
+	Position int
+	Optional bool
+	Variadic bool
+	// Default is the documented default value, offered as a candidate.
+	Default string
+	// Path is set when the operand's name says it is a file or directory,
+	// so that it completes as a path.
+	Path bool
+	// Complete is a shell command whose output lines complete the operand.
+	Complete string
+}
+
+// Subcommand is a subcommand offered as a completion candidate.
+type Subcommand struct {
+	Name        string
+	Aliases     []string
+	Description string
+	Flags       []Flag
+	Operands    []Operand
+	MinArgs     int
+	MaxArgs     int
+}
+
+// Build collects the command name, global flags, and subcommands of doc.
+// name is the primary command name; it may be empty for callers that only
+// need candidates.
+func Build(doc *shedoc.Document, name string) *Model {
+	m := &Model{}
+	if name != "" {
+		m.Names = []string{name}
+	}
+
+	var command shedoc.Block
+	for i := range doc.Blocks {
+		b := &doc.Blocks[i]
+		if b.Hidden {
+			continue
+		}
+		switch b.Visibility {
+		case shedoc.VisibilityCommand:
+			for _, alias := range b.Aliases {
+				if !slices.Contains(m.Names, alias) {
+					m.Names = append(m.Names, alias)
+				}
+			}
+			m.Flags = append(m.Flags, blockFlags(b)...)
+			m.Operands = append(m.Operands, blockOperands(b)...)
+			command.Operands = append(command.Operands, b.Operands...)
+		case shedoc.VisibilitySubcommand:
+			desc := firstLine(b.Description)
+			if b.Deprecated != nil {
+				desc = "[deprecated] " + b.Deprecated.Message
+			}
+			minArgs, maxArgs := b.OperandCounts()
+			m.Subcommands = append(m.Subcommands, Subcommand{
+				Name:        b.Name,
+				Aliases:     b.Aliases,
+				Description: desc,
+				Flags:       blockFlags(b),
+				Operands:    blockOperands(b),
+				MinArgs:     minArgs,
+				MaxArgs:     maxArgs,
+			})
+		}
+	}
+	m.MinArgs, m.MaxArgs = command.OperandCounts()
+
+	return m
+}
+
+// Subcommand returns the subcommand invoked as word, matching its name or any
+// of its aliases, or nil if there is none.
+func (m *Model) Subcommand(word string) *Subcommand {
+	for i := range m.Subcommands {
+		if slices.Contains(m.Subcommands[i].Words(), word) {
+			return &m.Subcommands[i]
+		}
+	}
+	return nil
+}
+
+// Words returns the subcommand's name followed by its aliases.
+func (s Subcommand) Words() []string {
+	return append([]string{s.Name}, s.Aliases...)
+}
+
+// Words returns the flag's short and long forms, whichever are present.
+func (f Flag) Words() []string {
+	var words []string
+	if f.Short != "" {
+		words = append(words, f.Short)
+	}
+	if f.Long != "" {
+		words = append(words, f.Long)
+	}
+	return words
+}
+
+// FlagWords returns the short and long forms of every flag.
+func FlagWords(flags []Flag) []string {
+	var words []string
+	for _, f := range flags {
+		words = append(words, f.Words()...)
+	}
+	return words
+}
+
+// blockFlags returns the visible flags and options of a block.
+func blockFlags(b *shedoc.Block) []Flag {
+	var flags []Flag
+	for _, f := range b.Flags {
+		if f.Hidden {
+			continue
+		}
+		flags = append(flags, Flag{Short: f.Short, Long: f.Long, Description: f.Description})
+	}
+	for _, o := range b.Options {
+		if o.Hidden {
+			continue
+		}
+		v := o.Value
+		flags = append(flags, Flag{Short: o.Short, Long: o.Long, Description: o.Description, Value: &v, Complete: o.Complete, Required: o.Required})
+	}
+	return flags
+}
+
+// blockOperands returns the operands of a block in positional order.
+func blockOperands(b *shedoc.Block) []Operand {
+	var operands []Operand
+	for i, op := range b.Operands {
+		operands = append(operands, Operand{
+			Name:        op.Value.Name,
+			Description: op.Description,
+			Position:    i + 1,
+			Optional:    !op.Value.Required,
+			Variadic:    op.Value.Variadic,
+			Default:     op.Value.Default,
+			Path:        isPathName(op.Value.Name),
+			Complete:    op.Complete,
+		})
+	}
+	return operands
+}
+
+// pathWords are the value names, or final words of hyphenated or underscored
+// names, that denote a file or directory.
+var pathWords = []string{"file", "files", "filename", "path", "paths", "dir", "dirs", "directory"}
+
+// isPathName reports whether a value named name is a file or directory, as
+// in <file>, <config-path>, or [output_dir].
+func isPathName(name string) bool {
+	name = strings.ToLower(name)
+	if i := strings.LastIndexAny(name, "-_"); i >= 0 {
+		name = name[i+1:]
+	}
+	return slices.Contains(pathWords, name)
+}
+
+// OperandAt returns the operand at positional index i, or nil if there is
+// none. A trailing variadic operand absorbs every index past its own.
+func OperandAt(operands []Operand, i int) *Operand {
+	if i < 0 || len(operands) == 0 {
+		return nil
+	}
+	if i < len(operands) {
+		return &operands[i]
+	}
+	if last := &operands[len(operands)-1]; last.Variadic {
+		return last
+	}
+	return nil
+}
+
+func firstLine(s string) string {
+	if idx := strings.IndexByte(s, '\n'); idx >= 0 {
+		return s[:idx]
+	}
+	return s
+}
@@ -0,0 +1,183 @@
+package completionmodel
+
+import (
+	"slices"
+	"testing"
+
+	"github.com/nickawilliams/shedoc"
+)
+
+var testDoc = &shedoc.Document{
+	Meta: shedoc.Meta{
+		Name: "deploy",
+	},
+	Blocks: []shedoc.Block{
+		{
+			Visibility: shedoc.VisibilityCommand,
+			Aliases:    []string{"dep", "deploy"},
+			Flags: []shedoc.Flag{
+				{Short: "-v", Long: "--verbose", Description: "Enable verbose output"},
+				{Long: "--debug", Description: "Dump internal state", Hidden: true},
+			},
+			Options: []shedoc.Option{
+				{Short: "-c", Long: "--config", Value: shedoc.Value{Name: "path", Required: true}},
+			},
+		},
+		{
+			Visibility:  shedoc.VisibilitySubcommand,
+			Name:        "push",
+			Aliases:     []string{"p"},
+			Description: "Deploys the application.\nMore detail.",
+			Options: []shedoc.Option{
+				{Long: "--trace", Value: shedoc.Value{Name: "file", Required: true}, Hidden: true},
+				{Long: "--tag", Value: shedoc.Value{Name: "version"}},
+			},
+		},
+		{
+			Visibility: shedoc.VisibilitySubcommand,
+			Name:       "migrate",
+			Deprecated: &shedoc.Deprecated{Message: "Use push."},
+		},
+		{
+			Visibility: shedoc.VisibilitySubcommand,
+			Name:       "selftest",
+			Hidden:     true,
+		},
+		{
+			Visibility: shedoc.VisibilityPublic,
+			Flags: []shedoc.Flag{
+				{Long: "--library"},
+			},
+		},
+	},
+}
+
+func TestBuild_Names(t *testing.T) {
+	m := Build(testDoc, "deploy")
+	if want := []string{"deploy", "dep"}; !slices.Equal(m.Names, want) {
+		t.Errorf("Names = %v, want %v", m.Names, want)
+	}
+
+	m = Build(testDoc, "")
+	if want := []string{"dep", "deploy"}; !slices.Equal(m.Names, want) {
+		t.Errorf("Names without primary = %v, want %v", m.Names, want)
+	}
+}
+
+func TestBuild_Flags(t *testing.T) {
+	m := Build(testDoc, "deploy")
+	if got, want := FlagWords(m.Flags), []string{"-v", "--verbose", "-c", "--config"}; !slices.Equal(got, want) {
+		t.Errorf("FlagWords = %v, want %v", got, want)
+	}
+	if m.Flags[0].Value != nil {
+		t.Error("boolean flag should have nil Value")
+	}
+	if m.Flags[1].Value == nil || m.Flags[1].Value.Name != "path" {
+		t.Errorf("option Value = %+v, want path", m.Flags[1].Value)
+	}
+}
+
+func TestBuild_Subcommands(t *testing.T) {
+	m := Build(testDoc, "deploy")
+	if len(m.Subcommands) != 2 {
+		t.Fatalf("got %d subcommands, want 2", len(m.Subcommands))
+	}
+
+	push := m.Subcommands[0]
+	if got, want := push.Words(), []string{"push", "p"}; !slices.Equal(got, want) {
+		t.Errorf("push Words = %v, want %v", got, want)
+	}
+	if push.Description != "Deploys the application." {
+		t.Errorf("push Description = %q, want first line only", push.Description)
+	}
+	if got, want := FlagWords(push.Flags), []string{"--tag"}; !slices.Equal(got, want) {
+		t.Errorf("push FlagWords = %v, want %v", got, want)
+	}
+
+	if got := m.Subcommands[1].Description; got != "[deprecated] Use push." {
+		t.Errorf("migrate Description = %q, want deprecation notice", got)
+	}
+}
+
+func TestModel_Subcommand(t *testing.T) {
+	m := Build(testDoc, "deploy")
+	for _, word := range []string{"push", "p"} {
+		if sub := m.Subcommand(word); sub == nil || sub.Name != "push" {
+			t.Errorf("Subcommand(%q) = %v, want push", word, sub)
+		}
+	}
+	for _, word := range []string{"selftest", "unknown"} {
+		if sub := m.Subcommand(word); sub != nil {
+			t.Errorf("Subcommand(%q) = %v, want nil", word, sub)
+		}
+	}
+}
+
+func TestBuild_ArgCounts(t *testing.T) {
+	doc := &shedoc.Document{
+		Blocks: []shedoc.Block{
+			{
+				Visibility: shedoc.VisibilityCommand,
+				Operands:   []shedoc.Operand{{Value: shedoc.Value{Name: "command", Required: true}}},
+			},
+			{
+				Visibility: shedoc.VisibilitySubcommand,
+				Name:       "push",
+				Operands: []shedoc.Operand{
+					{Value: shedoc.Value{Name: "env", Required: true}},
+					{Value: shedoc.Value{Name: "services", Variadic: true}},
+				},
+			},
+		},
+	}
+
+	m := Build(doc, "")
+	if m.MinArgs != 1 || m.MaxArgs != 1 {
+		t.Errorf("command args = %d..%d, want 1..1", m.MinArgs, m.MaxArgs)
+	}
+	push := m.Subcommand("push")
+	if push.MinArgs != 1 || push.MaxArgs != shedoc.ArgsUnbounded {
+		t.Errorf("push args = %d..%d, want 1..unbounded", push.MinArgs, push.MaxArgs)
+	}
+}
+
+func TestBuild_OperandDefaultsAndPaths(t *testing.T) {
+	doc := &shedoc.Document{
+		Blocks: []shedoc.Block{{
+			Visibility: shedoc.VisibilityCommand,
+			Operands: []shedoc.Operand{
+				{Value: shedoc.Value{Name: "env", Default: "staging"}},
+				{Value: shedoc.Value{Name: "config-file", Required: true}, Description: "Config"},
+				{Value: shedoc.Value{Name: "profile", Required: true}},
+			},
+		}},
+	}
+
+	m := Build(doc, "")
+	want := []Operand{
+		{Name: "env", Position: 1, Optional: true, Default: "staging"},
+		{Name: "config-file", Description: "Config", Position: 2, Path: true},
+		{Name: "profile", Position: 3},
+	}
+	if !slices.Equal(m.Operands, want) {
+		t.Errorf("Operands = %+v, want %+v", m.Operands, want)
+	}
+}
+
+func TestIsPathName(t *testing.T) {
+	tests := map[string]bool{
+		"file":       true,
+		"FILES":      true,
+		"output_dir": true,
+		"src-path":   true,
+		"directory":  true,
+		"profile":    false,
+		"name":       false,
+		"dirname":    false,
+	}
+	for name, want := range tests {
+		if got := isPathName(name); got != want {
+			t.Errorf("isPathName(%q) = %v, want %v", name, got, want)
+		}
+	}
+}
@@ -0,0 +1,40 @@
+// Package generate is the former home of the formatters, which now live in
+// the public package github.com/nickawilliams/shedoc/format. Its names are
+// aliases for theirs, and importing it still registers every format.
+//
+// Deprecated: Use package format.
+package generate
+
+import (
+	"github.com/nickawilliams/shedoc"
+	"github.com/nickawilliams/shedoc/format"
+)
+
+type (
+	JSONFormatter             = format.JSONFormatter
+	HelpTextFormatter         = format.HelpTextFormatter
+	UsageFormatter            = format.UsageFormatter
+	ManPageFormatter          = format.ManPageFormatter
+	BashCompletionFormatter   = format.BashCompletionFormatter
+	ZshCompletionFormatter    = format.ZshCompletionFormatter
+	FishCompletionFormatter   = format.FishCompletionFormatter
+	ElvishCompletionFormatter = format.ElvishCompletionFormatter
+	FigCompletionFormatter    = format.FigCompletionFormatter
+	CompletionTestsFormatter  = format.CompletionTestsFormatter
+	BatsFormatter             = format.BatsFormatter
+	UsageErrorsFormatter      = format.UsageErrorsFormatter
+	DocoptFormatter           = format.DocoptFormatter
+	CommentsFormatter         = format.CommentsFormatter
+	TranslationsFormatter     = format.TranslationsFormatter
+	TableFormatter            = format.TableFormatter
+	OrgFormatter              = format.OrgFormatter
+	HTMLFormatter             = format.HTMLFormatter
+	AsciiDocFormatter         = format.AsciiDocFormatter
+	WhatisFormatter           = format.WhatisFormatter
+	ManPage                   = format.ManPage
+)
+
+// SplitManPages calls format.SplitManPages.
+func SplitManPages(doc *shedoc.Document) ([]ManPage, error) {
+	return format.SplitManPages(doc)
+}
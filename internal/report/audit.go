@@ -0,0 +1,165 @@
+package report
+
+import (
+	"cmp"
+	"fmt"
+	"path"
+	"slices"
+	"strings"
+
+	"github.com/nickawilliams/shedoc"
+)
+
+// Audit finding kinds.
+const (
+	FindingSystemWrite   = "system-write"
+	FindingSensitiveSet  = "sensitive-set"
+	FindingSecretDefault = "secret-default"
+	FindingNetworkTool   = "network-tool"
+)
+
+// systemDirs are the directories whose contents a write flags for review.
+var systemDirs = []string{
+	"/bin", "/boot", "/dev", "/etc", "/lib", "/lib64", "/opt", "/proc",
+	"/root", "/sbin", "/sys", "/usr", "/var",
+}
+
+// sensitiveVars change how the shell or dynamic linker resolves and runs
+// programs.
+var sensitiveVars = []string{
+	"BASH_ENV", "CDPATH", "ENV", "IFS", "LD_LIBRARY_PATH", "LD_PRELOAD",
+	"PATH", "PROMPT_COMMAND", "PS4", "SHELLOPTS",
+}
+
+// networkTools are commands that reach other hosts, whether to fetch,
+// upload, or run remote commands.
+var networkTools = []string{
+	"curl", "ftp", "nc", "ncat", "netcat", "rsync", "scp", "sftp", "socat",
+	"ssh", "telnet", "wget",
+}
+
+// secretMarkers flag variables that likely hold credentials.
+var secretMarkers = []string{"CREDENTIAL", "KEY", "PASSWORD", "SECRET", "TOKEN"}
+
+// Finding is one documented behavior that warrants security review.
+type Finding struct {
+	Script      string `json:"script"`
+	Line        int    `json:"line"`
+	Kind        string `json:"kind"`
+	Subject     string `json:"subject"`
+	Description string `json:"description,omitempty"`
+}
+
+// Audit collects findings across docs: @writes to system paths, @sets of
+// security-sensitive or credential-like variables, credential-like @env
+// variables with a documented default, which is likely a secret in the
+// script, and #?/requires or @requires of network tools such as curl and
+// ssh. Findings are sorted by script and line.
+func Audit(docs []*shedoc.Document) []Finding {
+	var findings []Finding
+	for _, doc := range docs {
+		script := scriptName(doc)
+		requires := func(reqs []shedoc.Requires) {
+			for _, r := range reqs {
+				if isNetworkTool(r.Command) {
+					findings = append(findings, Finding{
+						Script:      script,
+						Line:        r.Line,
+						Kind:        FindingNetworkTool,
+						Subject:     r.Command,
+						Description: r.Description,
+					})
+				}
+			}
+		}
+		requires(doc.Meta.Requires)
+		for _, b := range doc.Blocks {
+			requires(b.Requires)
+			for _, w := range b.Writes {
+				if isSystemPath(w.Path) {
+					findings = append(findings, Finding{
+						Script:      script,
+						Line:        w.Line,
+						Kind:        FindingSystemWrite,
+						Subject:     w.Path,
+						Description: w.Description,
+					})
+				}
+			}
+			for _, s := range b.Sets {
+				if isSensitiveVar(s.Name) {
+					findings = append(findings, Finding{
+						Script:      script,
+						Line:        s.Line,
+						Kind:        FindingSensitiveSet,
+						Subject:     s.Name,
+						Description: s.Description,
+					})
+				}
+			}
+			for _, e := range b.Env {
+				if e.Default != "" && isSecretVar(e.Name) {
+					findings = append(findings, Finding{
+						Script:      script,
+						Line:        e.Line,
+						Kind:        FindingSecretDefault,
+						Subject:     e.Name,
+						Description: e.Description,
+					})
+				}
+			}
+		}
+	}
+
+	slices.SortStableFunc(findings, func(a, b Finding) int {
+		return cmp.Or(strings.Compare(a.Script, b.Script), cmp.Compare(a.Line, b.Line))
+	})
+	return findings
+}
+
+// AuditTable renders findings as a table.
+func AuditTable(findings []Finding) *Table {
+	t := &Table{Header: []string{"Location", "Finding", "Subject", "Description"}}
+	for _, f := range findings {
+		t.Rows = append(t.Rows, []string{
+			fmt.Sprintf("%s:%d", f.Script, f.Line),
+			f.Kind,
+			f.Subject,
+			f.Description,
+		})
+	}
+	return t
+}
+
+func isSystemPath(p string) bool {
+	if !strings.HasPrefix(p, "/") {
+		return false
+	}
+	p = path.Clean(p)
+	for _, dir := range systemDirs {
+		if p == dir || strings.HasPrefix(p, dir+"/") {
+			return true
+		}
+	}
+	return false
+}
+
+// isNetworkTool reports whether command, by name or path, is one of
+// networkTools.
+func isNetworkTool(command string) bool {
+	return slices.Contains(networkTools, path.Base(command))
+}
+
+func isSensitiveVar(name string) bool {
+	return slices.Contains(sensitiveVars, name) || isSecretVar(name)
+}
+
+func isSecretVar(name string) bool {
+	upper := strings.ToUpper(name)
+	for _, marker := range secretMarkers {
+		if strings.Contains(upper, marker) {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,64 @@
+package report
+
+import (
+	"reflect"
+	"testing"
+
+	"github.com/nickawilliams/shedoc"
+)
+
+func TestAudit(t *testing.T) {
+	docs := []*shedoc.Document{
+		{
+			Path: "setup.sh",
+			Blocks: []shedoc.Block{
+				{
+					Writes: []shedoc.Writes{
+						{Path: "/etc/hosts", Description: "Adds host entries", Line: 9},
+						{Path: "./build/out.txt", Line: 10},
+						{Path: "/etcetera/file", Line: 11},
+					},
+					Sets: []shedoc.Sets{
+						{Name: "PATH", Description: "Prepends ./bin", Line: 4},
+						{Name: "BUILD_DIR", Line: 5},
+						{Name: "github_token", Line: 6},
+					},
+				},
+			},
+		},
+		{
+			Path: "deploy.sh",
+			Meta: shedoc.Meta{Requires: []shedoc.Requires{
+				{Command: "curl", Description: "Fetches releases", Line: 2},
+				{Command: "jq", Line: 2},
+			}},
+			Blocks: []shedoc.Block{{
+				Requires: []shedoc.Requires{{Command: "/usr/bin/ssh", Line: 4}, {Command: "sshd-keygen", Line: 4}},
+				Writes:   []shedoc.Writes{{Path: "/var/log/../log/deploy.log", Line: 3}},
+				Env: []shedoc.Env{
+					{Name: "API_TOKEN", Default: "dev-token", Line: 5},
+					{Name: "DB_PASSWORD", Line: 6},
+					{Name: "PATH", Default: "/usr/bin", Line: 7},
+				},
+			}},
+		},
+	}
+
+	got := Audit(docs)
+	want := []Finding{
+		{Script: "deploy.sh", Line: 2, Kind: FindingNetworkTool, Subject: "curl", Description: "Fetches releases"},
+		{Script: "deploy.sh", Line: 3, Kind: FindingSystemWrite, Subject: "/var/log/../log/deploy.log"},
+		{Script: "deploy.sh", Line: 4, Kind: FindingNetworkTool, Subject: "/usr/bin/ssh"},
+		{Script: "deploy.sh", Line: 5, Kind: FindingSecretDefault, Subject: "API_TOKEN"},
+		{Script: "setup.sh", Line: 4, Kind: FindingSensitiveSet, Subject: "PATH", Description: "Prepends ./bin"},
+		{Script: "setup.sh", Line: 6, Kind: FindingSensitiveSet, Subject: "github_token"},
+		{Script: "setup.sh", Line: 9, Kind: FindingSystemWrite, Subject: "/etc/hosts", Description: "Adds host entries"},
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("Audit() =\n%+v\nwant\n%+v", got, want)
+	}
+
+	if loc := AuditTable(got).Rows[0][0]; loc != "deploy.sh:2" {
+		t.Errorf("first location = %q, want %q", loc, "deploy.sh:2")
+	}
+}
@@ -0,0 +1,84 @@
+package report
+
+import (
+	"fmt"
+	"html"
+	"io"
+)
+
+// Badge is a shields.io endpoint response: https://shields.io/badges/endpoint-badge.
+type Badge struct {
+	SchemaVersion int    `json:"schemaVersion"`
+	Label         string `json:"label"`
+	Message       string `json:"message"`
+	Color         string `json:"color"`
+}
+
+// badgeColors maps the shields.io color names used by CoverageBadge to the
+// hex values drawn by WriteSVG.
+var badgeColors = map[string]string{
+	"brightgreen": "#4c1",
+	"green":       "#97ca00",
+	"yellow":      "#dfb317",
+	"orange":      "#fe7d37",
+	"red":         "#e05d44",
+}
+
+// CoverageBadge returns a badge showing c's percentage under label, colored
+// from red to bright green as coverage rises.
+func CoverageBadge(label string, c ScriptCoverage) Badge {
+	pct := c.Percent()
+	var color string
+	switch {
+	case pct >= 90:
+		color = "brightgreen"
+	case pct >= 75:
+		color = "green"
+	case pct >= 60:
+		color = "yellow"
+	case pct >= 40:
+		color = "orange"
+	default:
+		color = "red"
+	}
+	return Badge{SchemaVersion: 1, Label: label, Message: fmt.Sprintf("%d%%", pct), Color: color}
+}
+
+// WriteSVG draws b as a flat two-part badge in the shields.io style. Text
+// widths are estimated, so the badge needs no font metrics.
+func (b Badge) WriteSVG(w io.Writer) error {
+	labelWidth := badgeTextWidth(b.Label)
+	messageWidth := badgeTextWidth(b.Message)
+	width := labelWidth + messageWidth
+	color, ok := badgeColors[b.Color]
+	if !ok {
+		color = b.Color
+	}
+	label, message := html.EscapeString(b.Label), html.EscapeString(b.Message)
+
+	_, err := fmt.Fprintf(w, `<svg xmlns="http://www.w3.org/2000/svg" width="%d" height="20" role="img" aria-label="%s: %s">
+<title>%s: %s</title>
+<linearGradient id="s" x2="0" y2="100%%"><stop offset="0" stop-color="#bbb" stop-opacity=".1"/><stop offset="1" stop-opacity=".1"/></linearGradient>
+<clipPath id="r"><rect width="%d" height="20" rx="3" fill="#fff"/></clipPath>
+<g clip-path="url(#r)"><rect width="%d" height="20" fill="#555"/><rect x="%d" width="%d" height="20" fill="%s"/><rect width="%d" height="20" fill="url(#s)"/></g>
+<g fill="#fff" text-anchor="middle" font-family="Verdana,Geneva,DejaVu Sans,sans-serif" font-size="11">
+<text x="%d" y="15" fill="#010101" fill-opacity=".3">%s</text><text x="%d" y="14">%s</text>
+<text x="%d" y="15" fill="#010101" fill-opacity=".3">%s</text><text x="%d" y="14">%s</text>
+</g>
+</svg>
+`,
+		width, label, message,
+		label, message,
+		width,
+		labelWidth, labelWidth, messageWidth, html.EscapeString(color), width,
+		labelWidth/2, label, labelWidth/2, label,
+		labelWidth+messageWidth/2, message, labelWidth+messageWidth/2, message,
+	)
+	return err
+}
+
+// badgeTextWidth estimates the width in pixels of a badge part holding s:
+// about seven pixels a character at 11px Verdana, plus padding.
+func badgeTextWidth(s string) int {
+	return 7*len([]rune(s)) + 10
+}
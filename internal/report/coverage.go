@@ -0,0 +1,111 @@
+package report
+
+import (
+	"fmt"
+
+	"github.com/nickawilliams/shedoc"
+)
+
+// ScriptCoverage counts the documented items of one script, and how many of
+// them have a description. Items are the script's #?/requires, and blocks
+// and each of their flags, options, operands, environment variables,
+// requirements, files, streams, sets, and exit statuses.
+type ScriptCoverage struct {
+	Script    string `json:"script"`
+	Described int    `json:"described"`
+	Total     int    `json:"total"`
+}
+
+// Percent returns the share of items that are described, rounded down. A
+// script with nothing to describe is fully covered.
+func (c ScriptCoverage) Percent() int {
+	if c.Total == 0 {
+		return 100
+	}
+	return c.Described * 100 / c.Total
+}
+
+// Coverage counts described items in each of docs, in the order given.
+func Coverage(docs []*shedoc.Document) []ScriptCoverage {
+	var scripts []ScriptCoverage
+	for _, doc := range docs {
+		c := ScriptCoverage{Script: scriptName(doc)}
+		count := func(desc string) {
+			c.Total++
+			if desc != "" {
+				c.Described++
+			}
+		}
+		for _, r := range doc.Meta.Requires {
+			count(r.Description)
+		}
+		for _, b := range doc.Blocks {
+			count(b.Description)
+			for _, f := range b.Flags {
+				count(f.Description)
+			}
+			for _, o := range b.Options {
+				count(o.Description)
+			}
+			for _, o := range b.Operands {
+				count(o.Description)
+			}
+			for _, e := range b.Env {
+				count(e.Description)
+			}
+			for _, r := range b.Requires {
+				count(r.Description)
+			}
+			for _, r := range b.Reads {
+				count(r.Description)
+			}
+			for _, w := range b.Writes {
+				count(w.Description)
+			}
+			for _, s := range b.Sets {
+				count(s.Description)
+			}
+			for _, e := range b.Exit {
+				count(e.Description)
+			}
+			for _, r := range b.Return {
+				count(r.Description)
+			}
+			if b.Stdin != nil {
+				count(b.Stdin.Description)
+			}
+			if b.Stdout != nil {
+				count(b.Stdout.Description)
+			}
+			if b.Stderr != nil {
+				count(b.Stderr.Description)
+			}
+		}
+		scripts = append(scripts, c)
+	}
+	return scripts
+}
+
+// TotalCoverage sums the counts of scripts.
+func TotalCoverage(scripts []ScriptCoverage) ScriptCoverage {
+	total := ScriptCoverage{Script: "total"}
+	for _, c := range scripts {
+		total.Described += c.Described
+		total.Total += c.Total
+	}
+	return total
+}
+
+// CoverageTable renders scripts as a table, ending with their total.
+func CoverageTable(scripts []ScriptCoverage) *Table {
+	t := &Table{Header: []string{"Script", "Described", "Total", "Coverage"}}
+	for _, c := range append(scripts, TotalCoverage(scripts)) {
+		t.Rows = append(t.Rows, []string{
+			c.Script,
+			fmt.Sprint(c.Described),
+			fmt.Sprint(c.Total),
+			fmt.Sprintf("%d%%", c.Percent()),
+		})
+	}
+	return t
+}
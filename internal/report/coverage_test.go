@@ -0,0 +1,89 @@
+package report
+
+import (
+	"bytes"
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/nickawilliams/shedoc"
+)
+
+func TestCoverage(t *testing.T) {
+	docs := []*shedoc.Document{
+		{
+			Path: "deploy.sh",
+			Meta: shedoc.Meta{Requires: []shedoc.Requires{{Command: "jq", Description: "JSON processing"}}},
+			Blocks: []shedoc.Block{
+				{
+					Description: "Deploys the app",
+					Flags:       []shedoc.Flag{{Long: "--force", Description: "Skip confirmation"}, {Long: "--dry-run"}},
+					Operands:    []shedoc.Operand{{Value: shedoc.Value{Name: "env"}}},
+					Requires:    []shedoc.Requires{{Command: "git"}},
+					Stdout:      &shedoc.Stdout{Description: "Progress"},
+				},
+				{Exit: []shedoc.Exit{{Code: "1", Description: "Failure"}}},
+			},
+		},
+		{Path: "empty.sh"},
+	}
+
+	got := Coverage(docs)
+	want := []ScriptCoverage{
+		{Script: "deploy.sh", Described: 5, Total: 9},
+		{Script: "empty.sh"},
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Fatalf("Coverage() =\n%+v\nwant\n%+v", got, want)
+	}
+	if pct := got[0].Percent(); pct != 55 {
+		t.Errorf("deploy.sh Percent() = %d, want 55", pct)
+	}
+	if pct := got[1].Percent(); pct != 100 {
+		t.Errorf("empty.sh Percent() = %d, want 100", pct)
+	}
+
+	rows := CoverageTable(got).Rows
+	if last := rows[len(rows)-1]; !reflect.DeepEqual(last, []string{"total", "5", "9", "55%"}) {
+		t.Errorf("total row = %q", last)
+	}
+}
+
+func TestCoverageBadge(t *testing.T) {
+	tests := []struct {
+		described, total int
+		message, color   string
+	}{
+		{9, 10, "90%", "brightgreen"},
+		{8, 10, "80%", "green"},
+		{6, 10, "60%", "yellow"},
+		{4, 10, "40%", "orange"},
+		{1, 10, "10%", "red"},
+		{0, 0, "100%", "brightgreen"},
+	}
+	for _, tt := range tests {
+		got := CoverageBadge("docs", ScriptCoverage{Described: tt.described, Total: tt.total})
+		want := Badge{SchemaVersion: 1, Label: "docs", Message: tt.message, Color: tt.color}
+		if got != want {
+			t.Errorf("CoverageBadge(%d/%d) = %+v, want %+v", tt.described, tt.total, got, want)
+		}
+	}
+}
+
+func TestBadgeWriteSVG(t *testing.T) {
+	var buf bytes.Buffer
+	b := Badge{SchemaVersion: 1, Label: "a&b", Message: "87%", Color: "green"}
+	if err := b.WriteSVG(&buf); err != nil {
+		t.Fatal(err)
+	}
+	got := buf.String()
+	for _, want := range []string{
+		`<svg xmlns="http://www.w3.org/2000/svg" width="62" height="20"`,
+		`<title>a&amp;b: 87%</title>`,
+		`fill="#97ca00"`,
+	} {
+		if !strings.Contains(got, want) {
+			t.Errorf("SVG missing %q:\n%s", want, got)
+		}
+	}
+}
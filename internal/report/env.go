@@ -0,0 +1,75 @@
+package report
+
+import (
+	"slices"
+	"strings"
+
+	"github.com/nickawilliams/shedoc"
+)
+
+// EnvVar is one environment variable, the scripts that read or set it, and
+// the defaults they document for it.
+type EnvVar struct {
+	Name         string   `json:"name"`
+	ReadBy       []string `json:"readBy,omitempty"`
+	SetBy        []string `json:"setBy,omitempty"`
+	Defaults     []string `json:"defaults,omitempty"`
+	Descriptions []string `json:"descriptions,omitempty"`
+}
+
+// Env aggregates @env and @sets across docs, one entry per variable, sorted
+// by name. Scripts and descriptions keep the order they were first seen.
+func Env(docs []*shedoc.Document) []EnvVar {
+	index := map[string]*EnvVar{}
+	var vars []*EnvVar
+	lookup := func(name string) *EnvVar {
+		v, ok := index[name]
+		if !ok {
+			v = &EnvVar{Name: name}
+			index[name] = v
+			vars = append(vars, v)
+		}
+		return v
+	}
+
+	for _, doc := range docs {
+		script := scriptName(doc)
+		for _, b := range doc.Blocks {
+			for _, e := range b.Env {
+				v := lookup(e.Name)
+				v.ReadBy = appendUnique(v.ReadBy, script)
+				v.Defaults = appendUnique(v.Defaults, e.Default)
+				v.Descriptions = appendUnique(v.Descriptions, e.Description)
+			}
+			for _, s := range b.Sets {
+				v := lookup(s.Name)
+				v.SetBy = appendUnique(v.SetBy, script)
+				v.Descriptions = appendUnique(v.Descriptions, s.Description)
+			}
+		}
+	}
+
+	result := make([]EnvVar, len(vars))
+	for i, v := range vars {
+		result[i] = *v
+	}
+	slices.SortFunc(result, func(a, b EnvVar) int {
+		return strings.Compare(a.Name, b.Name)
+	})
+	return result
+}
+
+// EnvTable renders vars as a table.
+func EnvTable(vars []EnvVar) *Table {
+	t := &Table{Header: []string{"Variable", "Read by", "Set by", "Default", "Description"}}
+	for _, v := range vars {
+		t.Rows = append(t.Rows, []string{
+			v.Name,
+			strings.Join(v.ReadBy, ", "),
+			strings.Join(v.SetBy, ", "),
+			strings.Join(v.Defaults, ", "),
+			strings.Join(v.Descriptions, "; "),
+		})
+	}
+	return t
+}
@@ -0,0 +1,51 @@
+package report
+
+import (
+	"reflect"
+	"testing"
+
+	"github.com/nickawilliams/shedoc"
+)
+
+func TestEnv(t *testing.T) {
+	docs := []*shedoc.Document{
+		{
+			Path: "deploy.sh",
+			Blocks: []shedoc.Block{
+				{Env: []shedoc.Env{{Name: "TOKEN", Description: "API token"}, {Name: "REGION", Default: "us-east-1"}}},
+				{
+					Env:  []shedoc.Env{{Name: "TOKEN", Description: "API token"}},
+					Sets: []shedoc.Sets{{Name: "LAST_DEPLOY", Description: "Timestamp"}},
+				},
+			},
+		},
+		{
+			Path: "login.sh",
+			Blocks: []shedoc.Block{
+				{
+					Env:  []shedoc.Env{{Name: "HOME"}},
+					Sets: []shedoc.Sets{{Name: "TOKEN", Description: "Fresh token"}},
+				},
+			},
+		},
+	}
+
+	got := Env(docs)
+	want := []EnvVar{
+		{Name: "HOME", ReadBy: []string{"login.sh"}},
+		{Name: "LAST_DEPLOY", SetBy: []string{"deploy.sh"}, Descriptions: []string{"Timestamp"}},
+		{Name: "REGION", ReadBy: []string{"deploy.sh"}, Defaults: []string{"us-east-1"}},
+		{Name: "TOKEN", ReadBy: []string{"deploy.sh"}, SetBy: []string{"login.sh"}, Descriptions: []string{"API token", "Fresh token"}},
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("Env() =\n%+v\nwant\n%+v", got, want)
+	}
+
+	table := EnvTable(got)
+	if row := table.Rows[3]; row[4] != "API token; Fresh token" {
+		t.Errorf("TOKEN description cell = %q", row[4])
+	}
+	if row := table.Rows[2]; row[3] != "us-east-1" {
+		t.Errorf("REGION default cell = %q", row[3])
+	}
+}
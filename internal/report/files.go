@@ -0,0 +1,71 @@
+package report
+
+import (
+	"slices"
+	"strings"
+
+	"github.com/nickawilliams/shedoc"
+)
+
+// File is one path and the scripts that read or write it.
+type File struct {
+	Path         string   `json:"path"`
+	Readers      []string `json:"readers,omitempty"`
+	Writers      []string `json:"writers,omitempty"`
+	Descriptions []string `json:"descriptions,omitempty"`
+}
+
+// Files aggregates @reads and @writes across docs, one entry per path, sorted
+// by path. Scripts and descriptions keep the order they were first seen.
+func Files(docs []*shedoc.Document) []File {
+	index := map[string]*File{}
+	var files []*File
+	lookup := func(path string) *File {
+		f, ok := index[path]
+		if !ok {
+			f = &File{Path: path}
+			index[path] = f
+			files = append(files, f)
+		}
+		return f
+	}
+
+	for _, doc := range docs {
+		script := scriptName(doc)
+		for _, b := range doc.Blocks {
+			for _, r := range b.Reads {
+				f := lookup(r.Path)
+				f.Readers = appendUnique(f.Readers, script)
+				f.Descriptions = appendUnique(f.Descriptions, r.Description)
+			}
+			for _, w := range b.Writes {
+				f := lookup(w.Path)
+				f.Writers = appendUnique(f.Writers, script)
+				f.Descriptions = appendUnique(f.Descriptions, w.Description)
+			}
+		}
+	}
+
+	result := make([]File, len(files))
+	for i, f := range files {
+		result[i] = *f
+	}
+	slices.SortFunc(result, func(a, b File) int {
+		return strings.Compare(a.Path, b.Path)
+	})
+	return result
+}
+
+// FilesTable renders files as a table.
+func FilesTable(files []File) *Table {
+	t := &Table{Header: []string{"Path", "Readers", "Writers", "Description"}}
+	for _, f := range files {
+		t.Rows = append(t.Rows, []string{
+			f.Path,
+			strings.Join(f.Readers, ", "),
+			strings.Join(f.Writers, ", "),
+			strings.Join(f.Descriptions, "; "),
+		})
+	}
+	return t
+}
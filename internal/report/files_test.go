@@ -0,0 +1,48 @@
+package report
+
+import (
+	"reflect"
+	"testing"
+
+	"github.com/nickawilliams/shedoc"
+)
+
+func TestFiles(t *testing.T) {
+	docs := []*shedoc.Document{
+		{
+			Path: "deploy.sh",
+			Blocks: []shedoc.Block{
+				{
+					Reads:  []shedoc.Reads{{Path: "~/.deployrc", Description: "User configuration"}},
+					Writes: []shedoc.Writes{{Path: "/var/log/deploy.log", Description: "Deployment log"}},
+				},
+				{Writes: []shedoc.Writes{{Path: "/var/log/deploy.log", Description: "Rollback log entry"}}},
+			},
+		},
+		{
+			Path: "audit.sh",
+			Blocks: []shedoc.Block{
+				{Reads: []shedoc.Reads{{Path: "/var/log/deploy.log"}}},
+			},
+		},
+	}
+
+	got := Files(docs)
+	want := []File{
+		{
+			Path:         "/var/log/deploy.log",
+			Readers:      []string{"audit.sh"},
+			Writers:      []string{"deploy.sh"},
+			Descriptions: []string{"Deployment log", "Rollback log entry"},
+		},
+		{Path: "~/.deployrc", Readers: []string{"deploy.sh"}, Descriptions: []string{"User configuration"}},
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("Files() =\n%+v\nwant\n%+v", got, want)
+	}
+
+	table := FilesTable(got)
+	if row := table.Rows[0]; row[1] != "audit.sh" || row[2] != "deploy.sh" {
+		t.Errorf("first row = %v", row)
+	}
+}
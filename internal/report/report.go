@@ -0,0 +1,78 @@
+// Package report aggregates documentation across a collection of scripts
+// into tables suitable for auditing.
+package report
+
+import (
+	"fmt"
+	"io"
+	"slices"
+	"strings"
+	"text/tabwriter"
+
+	"github.com/nickawilliams/shedoc"
+)
+
+// Table is a rendered report: a header row and data rows of equal width.
+type Table struct {
+	Header []string
+	Rows   [][]string
+}
+
+// WriteText writes t as aligned columns under an upper-case header, showing
+// empty cells as "-".
+func (t *Table) WriteText(w io.Writer) error {
+	tw := tabwriter.NewWriter(w, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(tw, strings.ToUpper(strings.Join(t.Header, "\t")))
+	for _, row := range t.Rows {
+		cells := make([]string, len(row))
+		for i, c := range row {
+			if c == "" {
+				c = "-"
+			}
+			cells[i] = c
+		}
+		fmt.Fprintln(tw, strings.Join(cells, "\t"))
+	}
+	return tw.Flush()
+}
+
+// WriteMarkdown writes t as a GitHub-flavored Markdown table.
+func (t *Table) WriteMarkdown(w io.Writer) error {
+	writeRow := func(cells []string) {
+		escaped := make([]string, len(cells))
+		for i, c := range cells {
+			escaped[i] = strings.ReplaceAll(c, "|", "\\|")
+		}
+		fmt.Fprintf(w, "| %s |\n", strings.Join(escaped, " | "))
+	}
+
+	writeRow(t.Header)
+	sep := make([]string, len(t.Header))
+	for i := range sep {
+		sep[i] = "---"
+	}
+	fmt.Fprintf(w, "| %s |\n", strings.Join(sep, " | "))
+	for _, row := range t.Rows {
+		writeRow(row)
+	}
+	return nil
+}
+
+// scriptName labels a document in a report.
+func scriptName(doc *shedoc.Document) string {
+	if doc.Path != "" {
+		return doc.Path
+	}
+	if doc.Meta.Name != "" {
+		return doc.Meta.Name
+	}
+	return "<stdin>"
+}
+
+// appendUnique appends s to list unless it is empty or already present.
+func appendUnique(list []string, s string) []string {
+	if s == "" || slices.Contains(list, s) {
+		return list
+	}
+	return append(list, s)
+}
@@ -0,0 +1,36 @@
+package report
+
+import (
+	"bytes"
+	"testing"
+)
+
+func TestTableWriteText(t *testing.T) {
+	table := &Table{
+		Header: []string{"Name", "Value"},
+		Rows:   [][]string{{"A", "1"}, {"LONGER", ""}},
+	}
+	var buf bytes.Buffer
+	if err := table.WriteText(&buf); err != nil {
+		t.Fatal(err)
+	}
+	want := "NAME    VALUE\nA       1\nLONGER  -\n"
+	if got := buf.String(); got != want {
+		t.Errorf("WriteText() =\n%q\nwant\n%q", got, want)
+	}
+}
+
+func TestTableWriteMarkdown(t *testing.T) {
+	table := &Table{
+		Header: []string{"Name", "Value"},
+		Rows:   [][]string{{"a|b", ""}},
+	}
+	var buf bytes.Buffer
+	if err := table.WriteMarkdown(&buf); err != nil {
+		t.Fatal(err)
+	}
+	want := "| Name | Value |\n| --- | --- |\n| a\\|b |  |\n"
+	if got := buf.String(); got != want {
+		t.Errorf("WriteMarkdown() =\n%q\nwant\n%q", got, want)
+	}
+}
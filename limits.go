@@ -0,0 +1,67 @@
+package shedoc
+
+import (
+	"fmt"
+	"io"
+)
+
+// Limits bounds the resources the parser will spend on a single input. A zero
+// field disables that limit.
+type Limits struct {
+	MaxFileSize   int64 // bytes read from the input
+	MaxLineLength int   // bytes in a single line, excluding the newline
+	MaxBlocks     int   // sheblocks per document
+	MaxWarnings   int   // warnings recorded per document; the rest are dropped
+}
+
+// DefaultLimits are the limits used by Parse and ParseReader. They are far
+// above anything a hand-written script needs.
+var DefaultLimits = Limits{
+	MaxFileSize:   16 << 20,
+	MaxLineLength: 1 << 20,
+	MaxBlocks:     10000,
+	MaxWarnings:   1000,
+}
+
+// Limit names reported by LimitError.
+const (
+	LimitFileSize   = "file size"
+	LimitLineLength = "line length"
+	LimitBlocks     = "blocks"
+)
+
+// LimitError reports that an input exceeded one of the configured Limits.
+type LimitError struct {
+	Limit string // one of the Limit* constants
+	Max   int64
+	Line  int // line at which the limit was exceeded, or 0 if unknown
+}
+
+func (e *LimitError) Error() string {
+	if e.Line > 0 {
+		return fmt.Sprintf("line %d: %s limit of %d exceeded", e.Line, e.Limit, e.Max)
+	}
+	return fmt.Sprintf("%s limit of %d exceeded", e.Limit, e.Max)
+}
+
+// sizeLimitReader fails with a LimitError once more than max bytes are read.
+type sizeLimitReader struct {
+	r   io.Reader
+	n   int64
+	max int64
+}
+
+func (s *sizeLimitReader) Read(b []byte) (int, error) {
+	if s.n > s.max {
+		return 0, &LimitError{Limit: LimitFileSize, Max: s.max}
+	}
+	if remaining := s.max + 1 - s.n; int64(len(b)) > remaining {
+		b = b[:remaining]
+	}
+	n, err := s.r.Read(b)
+	s.n += int64(n)
+	if s.n > s.max {
+		return 0, &LimitError{Limit: LimitFileSize, Max: s.max}
+	}
+	return n, err
+}
@@ -0,0 +1,121 @@
+package shedoc
+
+import (
+	"errors"
+	"strings"
+	"testing"
+)
+
+func TestParseReaderLimits(t *testing.T) {
+	blocks := strings.Repeat("#@/public\n # Does a thing.\n ##\nf() { :; }\n", 3)
+	warnings := strings.Repeat("#?/bogus value\n", 3)
+
+	tests := []struct {
+		name    string
+		input   string
+		limits  Limits
+		wantErr *LimitError
+	}{
+		{
+			name:   "within limits",
+			input:  blocks + warnings,
+			limits: Limits{MaxFileSize: 1024, MaxLineLength: 32, MaxBlocks: 3, MaxWarnings: 3},
+		},
+		{
+			name:    "file size",
+			input:   blocks,
+			limits:  Limits{MaxFileSize: 10},
+			wantErr: &LimitError{Limit: LimitFileSize, Max: 10},
+		},
+		{
+			name:    "line length",
+			input:   "#!/bin/bash\n#?/name " + strings.Repeat("x", 40) + "\n",
+			limits:  Limits{MaxLineLength: 32},
+			wantErr: &LimitError{Limit: LimitLineLength, Max: 32, Line: 2},
+		},
+		{
+			name:    "line length beyond scanner buffer",
+			input:   "#!/bin/bash\n" + strings.Repeat("x", 100) + "\n",
+			limits:  Limits{MaxLineLength: 32},
+			wantErr: &LimitError{Limit: LimitLineLength, Max: 32, Line: 2},
+		},
+		{
+			name:    "blocks",
+			input:   blocks,
+			limits:  Limits{MaxBlocks: 2},
+			wantErr: &LimitError{Limit: LimitBlocks, Max: 2, Line: 9},
+		},
+		{
+			name:   "zero disables limits",
+			input:  strings.Repeat("x", 200000) + "\n" + blocks,
+			limits: Limits{},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			doc, err := ParseReader(strings.NewReader(tt.input), WithLimits(tt.limits))
+			if tt.wantErr == nil {
+				if err != nil {
+					t.Fatalf("unexpected error: %v", err)
+				}
+				if len(doc.Blocks) != 3 {
+					t.Errorf("len(Blocks) = %d, want 3", len(doc.Blocks))
+				}
+				return
+			}
+
+			var le *LimitError
+			if !errors.As(err, &le) {
+				t.Fatalf("error = %v, want *LimitError", err)
+			}
+			if *le != *tt.wantErr {
+				t.Errorf("error = %+v, want %+v", *le, *tt.wantErr)
+			}
+			if doc != nil {
+				t.Error("expected nil document on limit error")
+			}
+		})
+	}
+}
+
+func TestParseReaderDefaultLimits(t *testing.T) {
+	input := "#?/name " + strings.Repeat("x", 100000) + "\n"
+	doc, err := ParseReader(strings.NewReader(input))
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if len(doc.Meta.Name) != 100000 {
+		t.Errorf("len(Meta.Name) = %d, want 100000", len(doc.Meta.Name))
+	}
+}
+
+func TestParseReaderWarningLimit(t *testing.T) {
+	input := "#@/public\n # Does a thing.\n ##\nf() { :; }\n" + strings.Repeat("#?/bogus value\n", 1200)
+	doc, err := ParseReader(strings.NewReader(input))
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if len(doc.Blocks) != 1 {
+		t.Errorf("len(Blocks) = %d, want 1", len(doc.Blocks))
+	}
+	limit := DefaultLimits.MaxWarnings
+	if len(doc.Warnings) != limit+1 {
+		t.Fatalf("len(Warnings) = %d, want %d", len(doc.Warnings), limit+1)
+	}
+	last := doc.Warnings[limit]
+	if want := "warnings limit of 1000 reached; further warnings omitted"; last.Message != want || last.Line != limit+5 {
+		t.Errorf("last warning = %+v, want %q at line %d", last, want, limit+5)
+	}
+}
+
+func TestLimitErrorMessage(t *testing.T) {
+	err := &LimitError{Limit: LimitBlocks, Max: 2, Line: 9}
+	if got, want := err.Error(), "line 9: blocks limit of 2 exceeded"; got != want {
+		t.Errorf("Error() = %q, want %q", got, want)
+	}
+	err = &LimitError{Limit: LimitFileSize, Max: 10}
+	if got, want := err.Error(), "file size limit of 10 exceeded"; got != want {
+		t.Errorf("Error() = %q, want %q", got, want)
+	}
+}
@@ -1,6 +1,7 @@
 package shedoc
 
 // Document is the top-level parse result for a single shell script file.
+// Blocks, and the tags within each block, are in source order.
 type Document struct {
 	Path     string    `json:"path,omitempty"`
 	Shebang  string    `json:"shebang,omitempty"`
@@ -11,14 +12,20 @@ type Document struct {
 
 // Meta holds file-level metadata from #?/ shedoc tags.
 type Meta struct {
-	Name        string `json:"name,omitempty"`
-	Version     string `json:"version,omitempty"`
-	Synopsis    string `json:"synopsis,omitempty"`
-	Description string `json:"description,omitempty"`
-	Examples    string `json:"examples,omitempty"`
-	Section     string `json:"section,omitempty"`
-	Author      string `json:"author,omitempty"`
-	License     string `json:"license,omitempty"`
+	Name        string   `json:"name,omitempty"`
+	Version     string   `json:"version,omitempty"`
+	Synopsis    []string `json:"synopsis,omitempty"`
+	Description string   `json:"description,omitempty"`
+	Examples    string   `json:"examples,omitempty"`
+	Section     string   `json:"section,omitempty"`
+	Author      string   `json:"author,omitempty"`
+	License     string   `json:"license,omitempty"`
+	LicenseFile string   `json:"licenseFile,omitempty"`
+	Lang        string   `json:"lang,omitempty"`
+	SeeAlso     []string `json:"seeAlso,omitempty"`
+	// Requires lists the commands and libraries the whole script needs,
+	// from #?/requires.
+	Requires []Requires `json:"requires,omitempty"`
 }
 
 // Visibility represents the access level of a documented block.
@@ -29,33 +36,54 @@ const (
 	VisibilitySubcommand Visibility = "subcommand"
 	VisibilityPublic     Visibility = "public"
 	VisibilityPrivate    Visibility = "private"
+	// VisibilitySection marks a block that documents a part of the script
+	// rather than a function: #@/section <title>. Name holds the title.
+	VisibilitySection Visibility = "section"
+	// VisibilityVar marks a block that documents a global variable rather
+	// than a function: #@/var NAME. Name holds the variable's name.
+	VisibilityVar Visibility = "var"
 )
 
 // Block represents a single sheblock (#@/) documentation entry.
 type Block struct {
-	Visibility   Visibility  `json:"visibility"`
-	Name         string      `json:"name,omitempty"`
-	Description  string      `json:"description,omitempty"`
-	FunctionName string      `json:"functionName,omitempty"`
-	Line         int         `json:"line"`
+	Visibility   Visibility `json:"visibility"`
+	Name         string     `json:"name,omitempty"`
+	Description  string     `json:"description,omitempty"`
+	FunctionName string     `json:"functionName,omitempty"`
+	Line         int        `json:"line"`
+
+	// Default and Readonly describe the variable of a var block, or the
+	// constant a public or private block documents, as the declaration
+	// following the block gives them.
+	Default  string `json:"default,omitempty"`
+	Readonly bool   `json:"readonly,omitempty"`
 
 	// Inputs
-	Flags    []Flag    `json:"flags,omitempty"`
-	Options  []Option  `json:"options,omitempty"`
-	Operands []Operand `json:"operands,omitempty"`
-	Env      []Env     `json:"env,omitempty"`
-	Reads    []Reads   `json:"reads,omitempty"`
-	Stdin    *Stdin    `json:"stdin,omitempty"`
+	Flags    []Flag     `json:"flags,omitempty"`
+	Options  []Option   `json:"options,omitempty"`
+	Operands []Operand  `json:"operands,omitempty"`
+	Env      []Env      `json:"env,omitempty"`
+	Reads    []Reads    `json:"reads,omitempty"`
+	Requires []Requires `json:"requires,omitempty"`
+	Stdin    *Stdin     `json:"stdin,omitempty"`
 
 	// Outputs
 	Exit   []Exit   `json:"exit,omitempty"`
+	Return []Return `json:"return,omitempty"`
 	Stdout *Stdout  `json:"stdout,omitempty"`
 	Stderr *Stderr  `json:"stderr,omitempty"`
 	Sets   []Sets   `json:"sets,omitempty"`
 	Writes []Writes `json:"writes,omitempty"`
 
 	// Metadata
+	Aliases    []string    `json:"aliases,omitempty"`
+	Hidden     bool        `json:"hidden,omitempty"`
+	Since      string      `json:"since,omitempty"`
 	Deprecated *Deprecated `json:"deprecated,omitempty"`
+	See        []string    `json:"see,omitempty"`
+
+	// RawTags is populated only when parsing WithRawTags.
+	RawTags []RawTag `json:"rawTags,omitempty"`
 }
 
 // Flag represents a boolean flag: @flag -s | --long description
@@ -63,7 +91,10 @@ type Flag struct {
 	Short       string `json:"short,omitempty"`
 	Long        string `json:"long,omitempty"`
 	Description string `json:"description,omitempty"`
-	Line        int    `json:"line"`
+	Hidden      bool   `json:"hidden,omitempty"`
+	// Group is the heading of the @group the flag is listed under.
+	Group string `json:"group,omitempty"`
+	Line  int    `json:"line"`
 }
 
 // Option represents an option with a value: @option -f | --format <value> description
@@ -72,13 +103,21 @@ type Option struct {
 	Long        string `json:"long,omitempty"`
 	Value       Value  `json:"value"`
 	Description string `json:"description,omitempty"`
-	Line        int    `json:"line"`
+	// Required marks an option that must be given (@option!). Value.Required
+	// is separate: it means the option cannot be given without a value.
+	Required bool   `json:"required,omitempty"`
+	Hidden   bool   `json:"hidden,omitempty"`
+	Complete string `json:"complete,omitempty"`
+	// Group is the heading of the @group the option is listed under.
+	Group string `json:"group,omitempty"`
+	Line  int    `json:"line"`
 }
 
 // Operand represents a positional argument: @operand <name> description
 type Operand struct {
 	Value       Value  `json:"value"`
 	Description string `json:"description,omitempty"`
+	Complete    string `json:"complete,omitempty"`
 	Line        int    `json:"line"`
 }
 
@@ -88,11 +127,27 @@ type Value struct {
 	Required bool   `json:"required"`
 	Default  string `json:"default,omitempty"`
 	Variadic bool   `json:"variadic,omitempty"`
+	// Unit is the unit the value is given in, such as "seconds", from
+	// notation like <timeout:seconds>.
+	Unit string `json:"unit,omitempty"`
+	// DefaultEnv is set when Default is a reference to an environment
+	// variable: $NAME, ${NAME}, or ${NAME:-fallback}.
+	DefaultEnv EnvRef `json:"defaultEnv,omitzero"`
+}
+
+// EnvRef is a reference to an environment variable, with the fallback used
+// when it is unset.
+type EnvRef struct {
+	Name     string `json:"name"`
+	Fallback string `json:"fallback,omitempty"`
 }
 
 // Env represents an environment variable read: @env VAR_NAME description
 type Env struct {
-	Name        string `json:"name"`
+	Name string `json:"name"`
+	// Default is the value the script uses when the variable is unset:
+	// @env VAR_NAME [=default] description
+	Default     string `json:"default,omitempty"`
 	Description string `json:"description,omitempty"`
 	Line        int    `json:"line"`
 }
@@ -104,6 +159,14 @@ type Reads struct {
 	Line        int    `json:"line"`
 }
 
+// Requires represents a command or sourced library that must be present:
+// @requires <command> description
+type Requires struct {
+	Command     string `json:"command"`
+	Description string `json:"description,omitempty"`
+	Line        int    `json:"line"`
+}
+
 // Stdin represents standard input: @stdin description
 type Stdin struct {
 	Description string `json:"description,omitempty"`
@@ -117,6 +180,13 @@ type Exit struct {
 	Line        int    `json:"line"`
 }
 
+// Return represents a function's return status: @return <code> description
+type Return struct {
+	Code        string `json:"code"`
+	Description string `json:"description,omitempty"`
+	Line        int    `json:"line"`
+}
+
 // Stdout represents standard output: @stdout description
 type Stdout struct {
 	Description string `json:"description,omitempty"`
@@ -149,8 +219,17 @@ type Deprecated struct {
 	Line    int    `json:"line"`
 }
 
-// Warning represents a non-fatal parse issue.
+// RawTag is the original text of a tag and its continuation lines, as written
+// after the " # " comment prefix.
+type RawTag struct {
+	Text string `json:"text"`
+	Line int    `json:"line"`
+}
+
+// Warning represents a non-fatal parse issue. Unparsed holds the original
+// text of a tag that could not be parsed, so that it is not silently lost.
 type Warning struct {
-	Line    int    `json:"line"`
-	Message string `json:"message"`
+	Line     int    `json:"line"`
+	Message  string `json:"message"`
+	Unparsed string `json:"unparsed,omitempty"`
 }
@@ -0,0 +1,51 @@
+package shedoc
+
+import "fmt"
+
+// ArgsUnbounded is the maximum operand count of a block whose last operand
+// is variadic.
+const ArgsUnbounded = -1
+
+// OperandCounts returns the minimum and maximum number of positional
+// arguments the block accepts, from its operands in order. max is
+// ArgsUnbounded if any operand is variadic.
+func (b *Block) OperandCounts() (min, max int) {
+	for _, op := range b.Operands {
+		if op.Value.Required {
+			min++
+		}
+		if op.Value.Variadic {
+			max = ArgsUnbounded
+		} else if max != ArgsUnbounded {
+			max++
+		}
+	}
+	return min, max
+}
+
+// OperandOrderWarnings reports operand orders that no invocation can
+// satisfy: a required operand after an optional one, and a variadic operand
+// that is not last. Each warning is at the line of the offending operand.
+func (b *Block) OperandOrderWarnings() []Warning {
+	var warnings []Warning
+	var optional *Operand
+	for i := range b.Operands {
+		op := &b.Operands[i]
+		if op.Value.Required && optional != nil {
+			warnings = append(warnings, Warning{
+				Line:    op.Line,
+				Message: fmt.Sprintf("required operand %s follows optional operand %s", op.Value, optional.Value),
+			})
+		}
+		if !op.Value.Required && optional == nil {
+			optional = op
+		}
+		if op.Value.Variadic && i < len(b.Operands)-1 {
+			warnings = append(warnings, Warning{
+				Line:    op.Line,
+				Message: fmt.Sprintf("variadic operand %s is not last", op.Value),
+			})
+		}
+	}
+	return warnings
+}
@@ -0,0 +1,67 @@
+package shedoc
+
+import (
+	"testing"
+)
+
+func TestOperandCounts(t *testing.T) {
+	tests := []struct {
+		name     string
+		operands []Operand
+		min, max int
+	}{
+		{name: "none"},
+		{
+			name:     "required and optional",
+			operands: []Operand{{Value: Value{Name: "a", Required: true}}, {Value: Value{Name: "b"}}},
+			min:      1,
+			max:      2,
+		},
+		{
+			name:     "required variadic",
+			operands: []Operand{{Value: Value{Name: "a", Required: true}}, {Value: Value{Name: "b", Required: true, Variadic: true}}},
+			min:      2,
+			max:      ArgsUnbounded,
+		},
+		{
+			name:     "optional variadic",
+			operands: []Operand{{Value: Value{Name: "a", Variadic: true}}},
+			min:      0,
+			max:      ArgsUnbounded,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			b := &Block{Operands: tt.operands}
+			min, max := b.OperandCounts()
+			if min != tt.min || max != tt.max {
+				t.Errorf("OperandCounts() = %d, %d, want %d, %d", min, max, tt.min, tt.max)
+			}
+		})
+	}
+}
+
+func TestParseOperandOrderWarnings(t *testing.T) {
+	input := `#@/command
+ # @operand [config]
+ # @operand <files...>
+ # @operand <target>
+ ##
+`
+	doc := mustParse(t, input)
+
+	want := []Warning{
+		{Line: 3, Message: "required operand <files...> follows optional operand [config]"},
+		{Line: 3, Message: "variadic operand <files...> is not last"},
+		{Line: 4, Message: "required operand <target> follows optional operand [config]"},
+	}
+	if len(doc.Warnings) != len(want) {
+		t.Fatalf("got warnings %+v, want %+v", doc.Warnings, want)
+	}
+	for i := range want {
+		if doc.Warnings[i] != want[i] {
+			t.Errorf("warning %d = %+v, want %+v", i, doc.Warnings[i], want[i])
+		}
+	}
+}
@@ -0,0 +1,64 @@
+package shedoc
+
+// ParseOption configures Parse and ParseReader.
+type ParseOption func(*parseConfig)
+
+type parseConfig struct {
+	limits      Limits
+	rawTags     bool
+	tagAliases  map[string]string
+	warnAliases bool
+	warnOrder   bool
+}
+
+func newParseConfig(opts []ParseOption) parseConfig {
+	cfg := parseConfig{limits: DefaultLimits}
+	for _, opt := range opts {
+		opt(&cfg)
+	}
+	return cfg
+}
+
+// WithLimits replaces DefaultLimits with the given limits.
+func WithLimits(limits Limits) ParseOption {
+	return func(c *parseConfig) {
+		c.limits = limits
+	}
+}
+
+// WithRawTags records the original text of every tag in Block.RawTags,
+// whether or not the tag parsed successfully.
+func WithRawTags() ParseOption {
+	return func(c *parseConfig) {
+		c.rawTags = true
+	}
+}
+
+// WithTagAliases parses each tag named by a key of aliases as the tag it maps
+// to, so that comments written for another docblock style, such as @param
+// for @operand, need not be rewritten first. Names are given without the @.
+func WithTagAliases(aliases map[string]string) ParseOption {
+	return func(c *parseConfig) {
+		c.tagAliases = aliases
+	}
+}
+
+// WithTagAliasWarnings adds a warning for each tag given by an alias from
+// WithTagAliases, naming the tag to use instead.
+func WithTagAliasWarnings() ParseOption {
+	return func(c *parseConfig) {
+		c.warnAliases = true
+	}
+}
+
+// WithTagOrderWarnings adds a warning for each tag that comes after a tag it
+// should precede in the canonical order, the order the comments format
+// writes tags in: @flag, @option, @operand, @env, @reads, @requires, and
+// @stdin; @exit, @return, @stdout, @stderr, @sets, and @writes; then @alias,
+// @hidden, @complete, @since, @deprecated, and @see. @group may appear
+// anywhere.
+func WithTagOrderWarnings() ParseOption {
+	return func(c *parseConfig) {
+		c.warnOrder = true
+	}
+}
@@ -0,0 +1,91 @@
+package shedoc
+
+import (
+	"slices"
+	"strings"
+)
+
+// CommandBlock returns the document's first command block, or nil if there
+// is none.
+func (d *Document) CommandBlock() *Block {
+	for i := range d.Blocks {
+		if d.Blocks[i].Visibility == VisibilityCommand {
+			return &d.Blocks[i]
+		}
+	}
+	return nil
+}
+
+// Subcommands returns the document's subcommand blocks in their current
+// order, which is source order unless SortSubcommands has been applied.
+func (d *Document) Subcommands() []Block {
+	var subs []Block
+	for _, b := range d.Blocks {
+		if b.Visibility == VisibilitySubcommand {
+			subs = append(subs, b)
+		}
+	}
+	return subs
+}
+
+// Subcommand returns the subcommand block called name, or by an alias name,
+// or nil if there is none.
+func (d *Document) Subcommand(name string) *Block {
+	for i := range d.Blocks {
+		b := &d.Blocks[i]
+		if b.Visibility == VisibilitySubcommand && (b.Name == name || slices.Contains(b.Aliases, name)) {
+			return b
+		}
+	}
+	return nil
+}
+
+// Requirements returns the requirements of #?/requires followed by those of
+// each block's @requires tags, without repeating a command; the first
+// description given for a command wins.
+func (d *Document) Requirements() []Requires {
+	var reqs []Requires
+	seen := map[string]bool{}
+	add := func(rs []Requires) {
+		for _, r := range rs {
+			if !seen[r.Command] {
+				seen[r.Command] = true
+				reqs = append(reqs, r)
+			}
+		}
+	}
+	add(d.Meta.Requires)
+	for i := range d.Blocks {
+		add(d.Blocks[i].Requires)
+	}
+	return reqs
+}
+
+// SortSubcommands reorders the document's subcommand blocks alphabetically by
+// name for presentation. The subcommands keep the slots they occupied among
+// the other blocks, which stay in source order.
+func SortSubcommands(d *Document) {
+	var slots []int
+	for i, b := range d.Blocks {
+		if b.Visibility == VisibilitySubcommand {
+			slots = append(slots, i)
+		}
+	}
+
+	subs := d.Subcommands()
+	slices.SortStableFunc(subs, func(a, b Block) int {
+		return strings.Compare(a.Name, b.Name)
+	})
+	for i, slot := range slots {
+		d.Blocks[slot] = subs[i]
+	}
+}
+
+// KeepVisibilities removes the document's blocks whose visibility is not one
+// of vis, scoping presentation to, say, the command and its subcommands. The
+// remaining blocks stay in order.
+func KeepVisibilities(d *Document, vis ...Visibility) {
+	d.Blocks = slices.DeleteFunc(d.Blocks, func(b Block) bool {
+		return !slices.Contains(vis, b.Visibility)
+	})
+}
@@ -0,0 +1,104 @@
+package shedoc
+
+import (
+	"slices"
+	"testing"
+)
+
+func TestDocumentCommandBlockAndSubcommands(t *testing.T) {
+	doc, err := Parse("testdata/comprehensive.sh")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	cmd := doc.CommandBlock()
+	if cmd == nil || cmd.FunctionName != "main" {
+		t.Fatalf("CommandBlock() = %+v, want main", cmd)
+	}
+
+	var names []string
+	for _, sub := range doc.Subcommands() {
+		names = append(names, sub.Name)
+	}
+	want := []string{"push", "status", "rollback", "migrate"}
+	if !slices.Equal(names, want) {
+		t.Errorf("Subcommands() = %v, want source order %v", names, want)
+	}
+
+	if (&Document{}).CommandBlock() != nil {
+		t.Error("CommandBlock() on empty document should be nil")
+	}
+}
+
+func TestDocumentSubcommand(t *testing.T) {
+	doc := &Document{Blocks: []Block{
+		{Visibility: VisibilityPrivate, Name: "push"},
+		{Visibility: VisibilitySubcommand, Name: "push", Line: 3},
+		{Visibility: VisibilitySubcommand, Name: "status", Aliases: []string{"st"}},
+	}}
+
+	if b := doc.Subcommand("push"); b == nil || b.Line != 3 {
+		t.Errorf("Subcommand(push) = %+v, want the subcommand block", b)
+	}
+	if b := doc.Subcommand("st"); b == nil || b.Name != "status" {
+		t.Errorf("Subcommand(st) = %+v, want status", b)
+	}
+	if b := doc.Subcommand("helper"); b != nil {
+		t.Errorf("Subcommand(helper) = %+v, want nil", b)
+	}
+}
+
+func TestDocumentRequirements(t *testing.T) {
+	doc := &Document{
+		Meta: Meta{Requires: []Requires{{Command: "jq", Description: "JSON processing"}}},
+		Blocks: []Block{
+			{Requires: []Requires{{Command: "docker"}, {Command: "jq", Description: "Other"}}},
+			{Requires: []Requires{{Command: "git"}, {Command: "docker"}}},
+		},
+	}
+	got := doc.Requirements()
+	want := []Requires{{Command: "jq", Description: "JSON processing"}, {Command: "docker"}, {Command: "git"}}
+	if !slices.Equal(got, want) {
+		t.Errorf("Requirements() = %+v, want %+v", got, want)
+	}
+}
+
+func TestSortSubcommands(t *testing.T) {
+	doc := &Document{Blocks: []Block{
+		{Visibility: VisibilityCommand},
+		{Visibility: VisibilitySubcommand, Name: "status"},
+		{Visibility: VisibilityPrivate, Name: "helper"},
+		{Visibility: VisibilitySubcommand, Name: "push"},
+		{Visibility: VisibilitySubcommand, Name: "migrate"},
+	}}
+	SortSubcommands(doc)
+
+	var got []string
+	for _, b := range doc.Blocks {
+		got = append(got, string(b.Visibility)+":"+b.Name)
+	}
+	want := []string{"command:", "subcommand:migrate", "private:helper", "subcommand:push", "subcommand:status"}
+	if !slices.Equal(got, want) {
+		t.Errorf("blocks after SortSubcommands = %v, want %v", got, want)
+	}
+}
+
+func TestKeepVisibilities(t *testing.T) {
+	doc := &Document{Blocks: []Block{
+		{Visibility: VisibilityCommand},
+		{Visibility: VisibilitySubcommand, Name: "status"},
+		{Visibility: VisibilityPrivate, Name: "helper"},
+		{Visibility: VisibilityPublic, Name: "util"},
+		{Visibility: VisibilitySubcommand, Name: "push"},
+	}}
+	KeepVisibilities(doc, VisibilityCommand, VisibilitySubcommand)
+
+	var got []string
+	for _, b := range doc.Blocks {
+		got = append(got, string(b.Visibility)+":"+b.Name)
+	}
+	want := []string{"command:", "subcommand:status", "subcommand:push"}
+	if !slices.Equal(got, want) {
+		t.Errorf("blocks after KeepVisibilities = %v, want %v", got, want)
+	}
+}
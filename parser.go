@@ -2,21 +2,25 @@ package shedoc
 
 import (
 	"bufio"
+	"errors"
+	"fmt"
 	"io"
+	"math"
 	"os"
 	"regexp"
 	"strings"
+	"sync"
 )
 
 // Parse parses shedoc documentation from a shell script file at the given path.
-func Parse(path string) (*Document, error) {
+func Parse(path string, opts ...ParseOption) (*Document, error) {
 	f, err := os.Open(path)
 	if err != nil {
 		return nil, err
 	}
 	defer f.Close()
 
-	doc, err := ParseReader(f)
+	doc, err := ParseReader(f, opts...)
 	if err != nil {
 		return nil, err
 	}
@@ -24,16 +28,105 @@ func Parse(path string) (*Document, error) {
 	return doc, nil
 }
 
-// ParseReader parses shedoc documentation from a reader.
-func ParseReader(r io.Reader) (*Document, error) {
-	p := &parser{
-		scanner: bufio.NewScanner(r),
-		doc:     &Document{},
+// ParseReader parses shedoc documentation from a reader. Exceeding a limit
+// returns a *LimitError.
+func ParseReader(r io.Reader, opts ...ParseOption) (*Document, error) {
+	p := newParser(r, opts)
+	defer p.release()
+	if err := p.parse(); err != nil {
+		return nil, err
 	}
-	p.parse()
 	return p.doc, nil
 }
 
+// ParseStream parses the shell script file at path like Parse, writing the
+// document to w with f as it goes rather than collecting its blocks; see
+// ParseReaderStream.
+func ParseStream(path string, w io.Writer, f StreamFormatter, opts ...ParseOption) (*Document, error) {
+	file, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer file.Close()
+
+	p := newParser(file, opts)
+	defer p.release()
+	p.doc.Path = path
+	p.stream = &streamTarget{w: w, f: f}
+	if err := p.parse(); err != nil {
+		return nil, err
+	}
+	return p.doc, nil
+}
+
+// ParseReaderStream parses shedoc documentation from a reader like
+// ParseReader, writing the document to w with f as it goes. Each block is
+// passed to f once it is complete, when the next block opens or at the end
+// of input, and is not kept. f.BeginDocument sees the metadata that precedes
+// the first block; f.EndDocument sees all of it, and the warnings.
+//
+// The document returned holds the metadata and warnings but no blocks. An
+// error from f stops parsing and is returned.
+func ParseReaderStream(r io.Reader, w io.Writer, f StreamFormatter, opts ...ParseOption) (*Document, error) {
+	p := newParser(r, opts)
+	defer p.release()
+	p.stream = &streamTarget{w: w, f: f}
+	if err := p.parse(); err != nil {
+		return nil, err
+	}
+	return p.doc, nil
+}
+
+func newParser(r io.Reader, opts []ParseOption) *parser {
+	cfg := newParseConfig(opts)
+	limits := cfg.limits
+	if limits.MaxFileSize > 0 {
+		r = &sizeLimitReader{r: r, max: limits.MaxFileSize}
+	}
+
+	scanner := bufio.NewScanner(r)
+	maxToken := math.MaxInt
+	if limits.MaxLineLength > 0 {
+		// Leave room for a "\r\n" terminator; longer lines fail in the
+		// scanner or in parse, and both report the same LimitError.
+		maxToken = limits.MaxLineLength + 2
+	}
+	buf := scanBuffers.Get().(*[]byte)
+	scanner.Buffer((*buf)[:0:min(maxToken, cap(*buf))], maxToken)
+
+	return &parser{
+		scanner:     scanner,
+		scanBuf:     buf,
+		limits:      limits,
+		rawTags:     cfg.rawTags,
+		aliases:     cfg.tagAliases,
+		warnAliases: cfg.warnAliases,
+		warnOrder:   cfg.warnOrder,
+		doc:         &Document{},
+		envNames:    map[string]bool{},
+	}
+}
+
+// scanBuffers holds line buffers for reuse across parses, which would
+// otherwise allocate one for each file read in a directory-wide run. Lines
+// are copied out of the buffer as they are scanned, so nothing parsed refers
+// to it.
+var scanBuffers = sync.Pool{
+	New: func() any {
+		buf := make([]byte, bufio.MaxScanTokenSize)
+		return &buf
+	},
+}
+
+// release returns the parser's line buffer for reuse. The scanner must not
+// be used afterwards.
+func (p *parser) release() {
+	if p.scanBuf != nil {
+		scanBuffers.Put(p.scanBuf)
+		p.scanBuf = nil
+	}
+}
+
 type parseState int
 
 const (
@@ -45,36 +138,77 @@ const (
 // Compiled patterns for line classification.
 var (
 	reShebang       = regexp.MustCompile(`^#!(.+)$`)
-	reShedocInline  = regexp.MustCompile(`^#\?/(\w+)\s+(.+)$`)
-	reShedocOpen    = regexp.MustCompile(`^#\?/(\w+)\s*$`)
+	reShedocInline  = regexp.MustCompile(`^#\?/(\w[\w-]*)\s+(.+)$`)
+	reShedocOpen    = regexp.MustCompile(`^#\?/(\w[\w-]*)\s*$`)
 	reSheblockOpen  = regexp.MustCompile(`^#@/(\w*)\s*(.*)$`)
 	reContinuation  = regexp.MustCompile(`^ # ?(.*)$`)
 	reBlockClose    = regexp.MustCompile(`^ ##\s*$`)
 	reFuncParen     = regexp.MustCompile(`^\s*(\w[\w-]*)\s*\(\)\s*\{?`)
 	reFuncKeyword   = regexp.MustCompile(`^\s*function\s+(\w[\w-]*)`)
+	reVarDecl       = regexp.MustCompile(`^\s*(?:(readonly|declare|typeset|export)((?:\s+[-+]\w+)*)\s+)?([A-Za-z_]\w*)(=.*)?$`)
+	reVarExpansion  = regexp.MustCompile(`^\$\{(\w+):?[-=](.*)\}$`)
 )
 
 type parser struct {
 	scanner       *bufio.Scanner
+	scanBuf       *[]byte // pooled initial buffer of scanner
+	limits        Limits
+	rawTags       bool  // record RawTags on each block
+	aliases       map[string]string // from WithTagAliases
+	warnAliases   bool              // warn on each aliased tag
+	warnOrder     bool              // warn on tags out of canonical order
+	err           error // first limit exceeded; stops parsing
+	truncated     bool  // MaxWarnings reached; later warnings are dropped
 	doc           *Document
 	line          int
 	state         parseState
 	shedocTag     string   // current #?/ tag being accumulated
+	shedocLine    int      // line of the current #?/ block opener
 	shedocLines   []string // accumulated lines for multi-line shedoc
 
-	// sheblock accumulation
+	// sheblock accumulation. The slices are truncated rather than dropped
+	// between blocks, so that their storage is reused.
 	block         *Block
 	blockDesc     []string // description lines before first @tag
 	inTags        bool     // true once we've seen the first @tag
 	currentTag    string   // name of current @tag being accumulated
 	currentResult any      // parsed result of current @tag
 	tagContLines  []string // continuation lines for current @tag
+	hiddenNames   []string // flag/option names marked by @hidden
+	lastTag       string   // highest-ranked tag so far, for warnOrder
+	completes     []*completeSpec
+	group         string // heading of the current @group
+	declPending   bool   // the next line may declare the last block's variable
+
+	// cross-block checks, which outlive streamed blocks
+	blocks      int             // blocks finalized so far
+	envNames    map[string]bool // variables documented with @env
+	defaultRefs []defaultRef    // defaults that refer to a variable
+
+	stream *streamTarget // set by the Stream functions
 }
 
-func (p *parser) parse() {
-	for p.scanner.Scan() {
+// defaultRef is an option or operand default that refers to an environment
+// variable, checked against @env once the whole file has been read.
+type defaultRef struct {
+	value Value
+	line  int
+}
+
+// streamTarget is where a streaming parse writes blocks.
+type streamTarget struct {
+	w     io.Writer
+	f     StreamFormatter
+	begun bool
+}
+
+func (p *parser) parse() error {
+	for p.err == nil && p.scanner.Scan() {
 		p.line++
 		line := p.scanner.Text()
+		if p.limits.MaxLineLength > 0 && len(line) > p.limits.MaxLineLength {
+			return p.lineLengthError()
+		}
 
 		switch p.state {
 		case stateTop:
@@ -86,6 +220,17 @@ func (p *parser) parse() {
 		}
 	}
 
+	if p.err != nil {
+		return p.err
+	}
+	if err := p.scanner.Err(); err != nil {
+		if errors.Is(err, bufio.ErrTooLong) {
+			p.line++
+			return p.lineLengthError()
+		}
+		return err
+	}
+
 	// If we're mid-block at EOF, finalize what we have.
 	switch p.state {
 	case stateShedoc:
@@ -94,6 +239,89 @@ func (p *parser) parse() {
 		p.finalizeCurrentTag()
 		p.finalizeBlock()
 	}
+	p.checkDefaultEnv()
+	if p.stream != nil && p.err == nil {
+		p.flushBlocks()
+		if p.err == nil {
+			p.fail(p.stream.f.EndDocument(p.stream.w, p.doc))
+		}
+	}
+	return p.err
+}
+
+// flushBlocks passes the blocks parsed so far to the stream formatter,
+// beginning the document first, and drops them.
+func (p *parser) flushBlocks() {
+	s := p.stream
+	if s == nil || p.err != nil {
+		return
+	}
+	blocks := p.doc.Blocks
+	p.doc.Blocks = nil
+	if !s.begun {
+		s.begun = true
+		if err := s.f.BeginDocument(s.w, p.doc); err != nil {
+			p.fail(err)
+			return
+		}
+	}
+	for i := range blocks {
+		if err := s.f.Block(s.w, &blocks[i]); err != nil {
+			p.fail(err)
+			return
+		}
+	}
+}
+
+func (p *parser) lineLengthError() error {
+	return &LimitError{Limit: LimitLineLength, Max: int64(p.limits.MaxLineLength), Line: p.line}
+}
+
+// warn records a warning at the given line.
+func (p *parser) warn(line int, message string) {
+	p.addWarning(Warning{Line: line, Message: message})
+}
+
+// addWarning records w. Once MaxWarnings have been recorded, it records a
+// single warning that the rest were omitted and drops any that follow, so
+// that a noisy file still yields its document.
+func (p *parser) addWarning(w Warning) {
+	if p.limits.MaxWarnings > 0 && len(p.doc.Warnings) >= p.limits.MaxWarnings {
+		if !p.truncated {
+			p.truncated = true
+			p.doc.Warnings = append(p.doc.Warnings, Warning{
+				Line:    w.Line,
+				Message: fmt.Sprintf("warnings limit of %d reached; further warnings omitted", p.limits.MaxWarnings),
+			})
+		}
+		return
+	}
+	p.doc.Warnings = append(p.doc.Warnings, w)
+}
+
+// checkTagOrder warns if the tag named name should come before a tag earlier
+// in the block.
+func (p *parser) checkTagOrder(name string) {
+	rank, ok := tagOrder[name]
+	if !ok {
+		return
+	}
+	if rank < tagOrder[p.lastTag] {
+		p.addWarning(Warning{
+			Line:    p.line,
+			Message: "@" + name + " should come before @" + p.lastTag,
+		})
+		return
+	}
+	p.lastTag = name
+}
+
+// fail records the first error encountered; parsing stops after the current
+// line. A nil err is ignored.
+func (p *parser) fail(err error) {
+	if p.err == nil && err != nil {
+		p.err = err
+	}
 }
 
 func (p *parser) handleTop(line string) {
@@ -105,7 +333,7 @@ func (p *parser) handleTop(line string) {
 
 	// Shedoc single-line: #?/tag value
 	if m := reShedocInline.FindStringSubmatch(line); m != nil {
-		p.setShedocMeta(m[1], strings.TrimSpace(m[2]))
+		p.setShedocMeta(m[1], strings.TrimSpace(m[2]), p.line)
 		return
 	}
 
@@ -113,12 +341,15 @@ func (p *parser) handleTop(line string) {
 	if m := reShedocOpen.FindStringSubmatch(line); m != nil {
 		p.state = stateShedoc
 		p.shedocTag = m[1]
-		p.shedocLines = nil
+		p.shedocLine = p.line
+		p.shedocLines = p.shedocLines[:0]
 		return
 	}
 
 	// Sheblock open: #@/visibility [name]
 	if m := reSheblockOpen.FindStringSubmatch(line); m != nil {
+		// The previous block can no longer gain a function name.
+		p.flushBlocks()
 		visibility, name := parseSheblockHeader(m[1], strings.TrimSpace(m[2]))
 		p.state = stateSheblock
 		p.block = &Block{
@@ -126,25 +357,58 @@ func (p *parser) handleTop(line string) {
 			Name:       name,
 			Line:       p.line,
 		}
-		p.blockDesc = nil
+		p.blockDesc = p.blockDesc[:0]
 		p.inTags = false
 		p.currentTag = ""
 		p.currentResult = nil
-		p.tagContLines = nil
+		p.tagContLines = p.tagContLines[:0]
+		p.hiddenNames = p.hiddenNames[:0]
+		p.lastTag = ""
+		p.completes = p.completes[:0]
+		p.group = ""
+		p.declPending = false
 		return
 	}
 
+	// The line after a var block may declare its variable, and the line
+	// after a function block a constant, which the block then documents.
+	if p.declPending && strings.TrimSpace(line) != "" {
+		p.declPending = false
+		if d, ok := matchVarDecl(line); ok && len(p.doc.Blocks) > 0 {
+			p.applyVarDecl(&p.doc.Blocks[len(p.doc.Blocks)-1], d)
+		}
+	}
+
 	// Function declaration — attach to most recent block if applicable.
+	// Sections document a part of the script, never a function.
 	if funcName := matchFuncDecl(line); funcName != "" {
 		if len(p.doc.Blocks) > 0 {
 			last := &p.doc.Blocks[len(p.doc.Blocks)-1]
-			if last.FunctionName == "" {
+			if last.FunctionName == "" && last.Visibility != VisibilitySection && last.Visibility != VisibilityVar && !last.Readonly {
 				last.FunctionName = funcName
 			}
 		}
 	}
 }
 
+// applyVarDecl records the declaration d, on the line after b, in b: the
+// variable of a var block, or a constant declared with readonly or
+// declare -r after a public or private block.
+func (p *parser) applyVarDecl(b *Block, d varDecl) {
+	if b.Visibility != VisibilityVar && (!d.readonly || b.Name != "") {
+		return
+	}
+	if b.Name == "" {
+		b.Name = d.name
+	}
+	if b.Name != d.name {
+		p.warn(p.line, "#@/var "+b.Name+" is followed by a declaration of "+d.name)
+		return
+	}
+	b.Default = d.value
+	b.Readonly = d.readonly
+}
+
 func (p *parser) handleShedoc(line string) {
 	if reBlockClose.MatchString(line) {
 		p.finalizeShedoc()
@@ -189,18 +453,35 @@ func (p *parser) handleSheblock(line string) {
 	if tagName, tagText, ok := splitTag(content); ok {
 		p.finalizeCurrentTag()
 		p.inTags = true
+		if p.rawTags {
+			p.block.RawTags = append(p.block.RawTags, RawTag{Text: content, Line: p.line})
+		}
+
+		if to, ok := p.aliases[tagName]; ok {
+			if p.warnAliases {
+				p.addWarning(Warning{
+					Line:    p.line,
+					Message: "@" + tagName + " is an alias; use @" + to,
+				})
+			}
+			tagName = to
+		}
+		if p.warnOrder {
+			p.checkTagOrder(tagName)
+		}
 
 		name, result, err := parseTag(tagName, tagText, p.line)
 		if err != nil {
-			p.doc.Warnings = append(p.doc.Warnings, Warning{
-				Line:    p.line,
-				Message: err.Error(),
+			p.addWarning(Warning{
+				Line:     p.line,
+				Message:  err.Error(),
+				Unparsed: strings.TrimSpace(content),
 			})
 			return
 		}
 		p.currentTag = name
 		p.currentResult = result
-		p.tagContLines = nil
+		p.tagContLines = p.tagContLines[:0]
 		return
 	}
 
@@ -216,6 +497,10 @@ func (p *parser) handleSheblock(line string) {
 	if p.currentTag != "" {
 		// Tag continuation
 		p.tagContLines = append(p.tagContLines, strings.TrimSpace(content))
+		if p.rawTags {
+			raw := &p.block.RawTags[len(p.block.RawTags)-1]
+			raw.Text += "\n" + content
+		}
 	} else if !p.inTags {
 		// Block description
 		p.blockDesc = append(p.blockDesc, content)
@@ -225,17 +510,17 @@ func (p *parser) handleSheblock(line string) {
 func (p *parser) finalizeShedoc() {
 	if p.shedocTag != "" {
 		value := strings.Join(p.shedocLines, "\n")
-		p.setShedocMeta(p.shedocTag, value)
+		p.setShedocMeta(p.shedocTag, value, p.shedocLine)
 	}
 	p.shedocTag = ""
-	p.shedocLines = nil
+	p.shedocLines = p.shedocLines[:0]
 }
 
 func (p *parser) finalizeCurrentTag() {
 	if p.currentTag == "" || p.currentResult == nil {
 		p.currentTag = ""
 		p.currentResult = nil
-		p.tagContLines = nil
+		p.tagContLines = p.tagContLines[:0]
 		return
 	}
 
@@ -248,7 +533,7 @@ func (p *parser) finalizeCurrentTag() {
 	p.applyTagToBlock(p.currentTag, p.currentResult)
 	p.currentTag = ""
 	p.currentResult = nil
-	p.tagContLines = nil
+	p.tagContLines = p.tagContLines[:0]
 }
 
 func (p *parser) finalizeBlock() {
@@ -258,33 +543,139 @@ func (p *parser) finalizeBlock() {
 	if len(p.blockDesc) > 0 {
 		p.block.Description = strings.Join(p.blockDesc, "\n")
 	}
+	p.applyHidden()
+	p.applyComplete()
+	for _, w := range p.block.OperandOrderWarnings() {
+		p.addWarning(w)
+	}
+	for _, w := range p.block.RequiredOptionWarnings() {
+		p.addWarning(w)
+	}
+	if p.limits.MaxBlocks > 0 && p.blocks >= p.limits.MaxBlocks {
+		p.fail(&LimitError{Limit: LimitBlocks, Max: int64(p.limits.MaxBlocks), Line: p.block.Line})
+		p.block = nil
+		return
+	}
+	for _, e := range p.block.Env {
+		p.envNames[e.Name] = true
+	}
+	for _, o := range p.block.Options {
+		if o.Value.DefaultEnv.Name != "" {
+			p.defaultRefs = append(p.defaultRefs, defaultRef{o.Value, o.Line})
+		}
+	}
+	for _, o := range p.block.Operands {
+		if o.Value.DefaultEnv.Name != "" {
+			p.defaultRefs = append(p.defaultRefs, defaultRef{o.Value, o.Line})
+		}
+	}
+	p.blocks++
+	switch p.block.Visibility {
+	case VisibilityVar, VisibilityPublic, VisibilityPrivate:
+		p.declPending = true
+	default:
+		p.declPending = false
+	}
 	p.doc.Blocks = append(p.doc.Blocks, *p.block)
 	p.block = nil
 }
 
-func (p *parser) setShedocMeta(tag, value string) {
+func (p *parser) setShedocMeta(tag, value string, line int) {
+	m := &p.doc.Meta
 	switch tag {
 	case "name":
-		p.doc.Meta.Name = value
+		p.setMetaScalar(&m.Name, tag, value, line)
 	case "version":
-		p.doc.Meta.Version = value
+		p.setMetaScalar(&m.Version, tag, value, line)
 	case "synopsis":
-		p.doc.Meta.Synopsis = value
+		p.setMetaLines(&m.Synopsis, tag, value, line)
 	case "description":
-		p.doc.Meta.Description = value
+		p.setMetaText(&m.Description, tag, value, line)
 	case "examples":
-		p.doc.Meta.Examples = value
+		p.setMetaText(&m.Examples, tag, value, line)
 	case "section":
-		p.doc.Meta.Section = value
+		p.setMetaScalar(&m.Section, tag, value, line)
 	case "author":
-		p.doc.Meta.Author = value
+		p.setMetaText(&m.Author, tag, value, line)
 	case "license":
-		p.doc.Meta.License = value
+		p.setMetaScalar(&m.License, tag, value, line)
+	case "license-file":
+		p.setMetaScalar(&m.LicenseFile, tag, value, line)
+	case "lang":
+		p.setMetaScalar(&m.Lang, tag, value, line)
+	case "see-also":
+		p.setMetaList(&m.SeeAlso, tag, value, line)
+	case "requires":
+		p.setMetaRequires(&m.Requires, value, line)
 	default:
-		p.doc.Warnings = append(p.doc.Warnings, Warning{
-			Line:    p.line,
-			Message: "unknown shedoc tag: #?/" + tag,
-		})
+		p.warn(line, "unknown shedoc tag: #?/"+tag)
+	}
+}
+
+// setMetaScalar sets a single-valued meta field. A repeated tag with a
+// different value keeps the first and warns.
+func (p *parser) setMetaScalar(field *string, tag, value string, line int) {
+	switch {
+	case *field == "":
+		*field = value
+	case *field != value:
+		p.warn(line, "duplicate #?/"+tag+" ignored; keeping "+*field)
+	}
+}
+
+// setMetaText sets a multi-line meta field. A repeated tag appends its value
+// on a new line and warns.
+func (p *parser) setMetaText(field *string, tag, value string, line int) {
+	if *field == "" {
+		*field = value
+		return
+	}
+	if value == "" {
+		return
+	}
+	*field += "\n" + value
+	p.warn(line, "duplicate #?/"+tag+" appended to earlier value")
+}
+
+// setMetaLines sets a meta field holding one entry per line. A repeated tag
+// appends its lines and warns.
+func (p *parser) setMetaLines(field *[]string, tag, value string, line int) {
+	var lines []string
+	for _, l := range strings.Split(value, "\n") {
+		if l = strings.TrimSpace(l); l != "" {
+			lines = append(lines, l)
+		}
+	}
+	if len(*field) > 0 && len(lines) > 0 {
+		p.warn(line, "duplicate #?/"+tag+" appended to earlier value")
+	}
+	*field = append(*field, lines...)
+}
+
+// setMetaList sets a meta field holding a list whose entries are separated
+// by commas or newlines. A repeated tag appends its entries and warns.
+func (p *parser) setMetaList(field *[]string, tag, value string, line int) {
+	entries := splitList(value)
+	if len(*field) > 0 && len(entries) > 0 {
+		p.warn(line, "duplicate #?/"+tag+" appended to earlier value")
+	}
+	*field = append(*field, entries...)
+}
+
+// setMetaRequires appends the requirements of #?/requires, one command and
+// its description per line. Unlike other meta tags it may be repeated, one
+// requirement to a tag.
+func (p *parser) setMetaRequires(field *[]Requires, value string, line int) {
+	for _, l := range strings.Split(value, "\n") {
+		if strings.TrimSpace(l) == "" {
+			continue
+		}
+		r, err := parseRequires(l, line)
+		if err != nil {
+			p.warn(line, err.Error())
+			continue
+		}
+		*field = append(*field, *r)
 	}
 }
 
@@ -293,10 +684,12 @@ func (p *parser) applyTagToBlock(name string, result any) {
 	switch name {
 	case "flag":
 		if v, ok := result.(*Flag); ok {
+			v.Group = p.group
 			b.Flags = append(b.Flags, *v)
 		}
 	case "option":
 		if v, ok := result.(*Option); ok {
+			v.Group = p.group
 			b.Options = append(b.Options, *v)
 		}
 	case "operand":
@@ -311,6 +704,10 @@ func (p *parser) applyTagToBlock(name string, result any) {
 		if v, ok := result.(*Reads); ok {
 			b.Reads = append(b.Reads, *v)
 		}
+	case "requires":
+		if v, ok := result.(*Requires); ok {
+			b.Requires = append(b.Requires, *v)
+		}
 	case "stdin":
 		if v, ok := result.(*Stdin); ok {
 			b.Stdin = v
@@ -319,6 +716,13 @@ func (p *parser) applyTagToBlock(name string, result any) {
 		if v, ok := result.(*Exit); ok {
 			b.Exit = append(b.Exit, *v)
 		}
+	case "return":
+		if v, ok := result.(*Return); ok {
+			if b.Visibility == VisibilityCommand || b.Visibility == VisibilitySubcommand {
+				p.warn(v.Line, "@return on a "+string(b.Visibility)+" block; use @exit")
+			}
+			b.Return = append(b.Return, *v)
+		}
 	case "stdout":
 		if v, ok := result.(*Stdout); ok {
 			b.Stdout = v
@@ -335,10 +739,100 @@ func (p *parser) applyTagToBlock(name string, result any) {
 		if v, ok := result.(*Writes); ok {
 			b.Writes = append(b.Writes, *v)
 		}
+	case "alias":
+		if v, ok := result.([]string); ok {
+			b.Aliases = append(b.Aliases, v...)
+		}
+	case "hidden":
+		if v, ok := result.([]string); ok {
+			if len(v) == 0 {
+				b.Hidden = true
+			}
+			p.hiddenNames = append(p.hiddenNames, v...)
+		}
+	case "complete":
+		if v, ok := result.(*completeSpec); ok {
+			p.completes = append(p.completes, v)
+		}
 	case "deprecated":
 		if v, ok := result.(*Deprecated); ok {
 			b.Deprecated = v
 		}
+	case "see":
+		if v, ok := result.([]string); ok {
+			b.See = append(b.See, v...)
+		}
+	case "group":
+		if v, ok := result.(string); ok {
+			p.group = v
+		}
+	case "since":
+		if v, ok := result.(string); ok {
+			b.Since = v
+		}
+	}
+}
+
+// applyHidden marks the block's flags and options named by @hidden tags.
+// Names are resolved once the block is complete so that @hidden may appear
+// before or after the tag it refers to.
+func (p *parser) applyHidden() {
+	for _, name := range p.hiddenNames {
+		found := false
+		for i := range p.block.Flags {
+			f := &p.block.Flags[i]
+			if f.Short == name || f.Long == name {
+				f.Hidden = true
+				found = true
+			}
+		}
+		for i := range p.block.Options {
+			o := &p.block.Options[i]
+			if o.Short == name || o.Long == name {
+				o.Hidden = true
+				found = true
+			}
+		}
+		if !found {
+			p.warn(p.block.Line, "@hidden refers to undocumented flag or option: "+name)
+		}
+	}
+	p.hiddenNames = p.hiddenNames[:0]
+}
+
+// applyComplete attaches @complete commands to the options and operands they
+// name.
+func (p *parser) applyComplete() {
+	for _, c := range p.completes {
+		found := false
+		for i := range p.block.Options {
+			o := &p.block.Options[i]
+			if o.Short == c.Target || o.Long == c.Target {
+				o.Complete = c.Command
+				found = true
+			}
+		}
+		for i := range p.block.Operands {
+			op := &p.block.Operands[i]
+			if op.Value.Name == c.Target {
+				op.Complete = c.Command
+				found = true
+			}
+		}
+		if !found {
+			p.warn(c.Line, "@complete refers to undocumented option or operand: "+c.Target)
+		}
+	}
+	p.completes = p.completes[:0]
+}
+
+// checkDefaultEnv warns about option and operand defaults that refer to an
+// environment variable no block documents with @env.
+func (p *parser) checkDefaultEnv() {
+	for _, ref := range p.defaultRefs {
+		if name := ref.value.DefaultEnv.Name; !p.envNames[name] {
+			p.warn(ref.line, "default "+ref.value.String()+" refers to undocumented environment variable "+name)
+		}
 	}
 }
 
@@ -354,6 +848,10 @@ func parseSheblockHeader(vis, extra string) (Visibility, string) {
 		return VisibilityPublic, ""
 	case "private":
 		return VisibilityPrivate, ""
+	case "section":
+		return VisibilitySection, extra
+	case "var":
+		return VisibilityVar, extra
 	case "":
 		return VisibilityPublic, ""
 	default:
@@ -362,6 +860,45 @@ func parseSheblockHeader(vis, extra string) (Visibility, string) {
 	}
 }
 
+// varDecl is a variable declaration: NAME=value, or one made with readonly,
+// declare, typeset, or export, with or without a value.
+type varDecl struct {
+	name, value string
+	readonly    bool
+}
+
+// matchVarDecl returns the declaration if line is a variable declaration.
+// The value is the literal value, unquoted and without a trailing comment,
+// or for NAME=${NAME:-value} the value the variable defaults to.
+func matchVarDecl(line string) (varDecl, bool) {
+	m := reVarDecl.FindStringSubmatch(line)
+	if m == nil || m[1] == "" && m[4] == "" {
+		return varDecl{}, false
+	}
+	d := varDecl{name: m[3], readonly: m[1] == "readonly"}
+	if m[1] == "declare" || m[1] == "typeset" {
+		for _, opt := range strings.Fields(m[2]) {
+			if strings.HasPrefix(opt, "-") && strings.Contains(opt, "r") {
+				d.readonly = true
+			}
+		}
+	}
+
+	value := strings.TrimSpace(strings.TrimPrefix(m[4], "="))
+	if len(value) >= 2 && (value[0] == '"' || value[0] == '\'') {
+		if end := strings.IndexByte(value[1:], value[0]); end >= 0 {
+			value = value[1 : end+1]
+		}
+	} else if i := strings.Index(value, " #"); i >= 0 {
+		value = strings.TrimSpace(value[:i])
+	}
+	if e := reVarExpansion.FindStringSubmatch(value); e != nil && e[1] == d.name {
+		value = e[2]
+	}
+	d.value = value
+	return d, true
+}
+
 // matchFuncDecl returns the function name if line is a function declaration.
 func matchFuncDecl(line string) string {
 	if m := reFuncKeyword.FindStringSubmatch(line); m != nil {
@@ -401,6 +938,8 @@ func appendTagDescription(result any, text string) {
 		v.Description = joinDesc(v.Description, text)
 	case *Reads:
 		v.Description = joinDesc(v.Description, text)
+	case *Requires:
+		v.Description = joinDesc(v.Description, text)
 	case *Stdin:
 		v.Description = joinDesc(v.Description, text)
 	case *Exit:
@@ -1,6 +1,10 @@
 package shedoc
 
 import (
+	"errors"
+	"fmt"
+	"io"
+	"slices"
 	"strings"
 	"testing"
 )
@@ -45,12 +49,29 @@ func TestParseShedocInline(t *testing.T) {
 	}
 }
 
+func TestParseShedocLicenseFile(t *testing.T) {
+	doc := mustParse(t, "#!/bin/bash\n#?/license      MIT\n#?/license-file LICENSE.md\n")
+	if doc.Meta.LicenseFile != "LICENSE.md" {
+		t.Errorf("Meta.LicenseFile = %q, want %q", doc.Meta.LicenseFile, "LICENSE.md")
+	}
+	if len(doc.Warnings) != 0 {
+		t.Errorf("unexpected warnings: %v", doc.Warnings)
+	}
+}
+
+func TestParseShedocLang(t *testing.T) {
+	doc := mustParse(t, "#!/bin/bash\n#?/lang en\n")
+	if doc.Meta.Lang != "en" {
+		t.Errorf("Meta.Lang = %q, want %q", doc.Meta.Lang, "en")
+	}
+}
+
 func TestParseShedocInlineSynopsis(t *testing.T) {
 	input := `#!/bin/bash
 #?/synopsis deploy [-v] [-c config] <command> [args...]
 `
 	doc := mustParse(t, input)
-	if doc.Meta.Synopsis != "deploy [-v] [-c config] <command> [args...]" {
+	if len(doc.Meta.Synopsis) != 1 || doc.Meta.Synopsis[0] != "deploy [-v] [-c config] <command> [args...]" {
 		t.Errorf("Meta.Synopsis = %q, want %q", doc.Meta.Synopsis, "deploy [-v] [-c config] <command> [args...]")
 	}
 }
@@ -209,6 +230,118 @@ _validate_input() {
 	}
 }
 
+func TestParseSheblockSection(t *testing.T) {
+	input := `#!/bin/bash
+#@/section Database migrations
+ # Functions that apply and roll back migrations.
+ ##
+migrate_up() {
+    :
+}
+`
+	doc := mustParse(t, input)
+	if len(doc.Blocks) != 1 {
+		t.Fatalf("got %d blocks, want 1", len(doc.Blocks))
+	}
+	b := doc.Blocks[0]
+	if b.Visibility != VisibilitySection {
+		t.Errorf("Visibility = %q, want %q", b.Visibility, VisibilitySection)
+	}
+	if b.Name != "Database migrations" {
+		t.Errorf("Name = %q, want %q", b.Name, "Database migrations")
+	}
+	if b.FunctionName != "" {
+		t.Errorf("FunctionName = %q, want none for a section", b.FunctionName)
+	}
+}
+
+func TestParseSheblockVar(t *testing.T) {
+	input := `#!/bin/bash
+#@/var DEPLOY_TIMEOUT
+ # Seconds to wait for a rollout.
+ ##
+DEPLOY_TIMEOUT=${DEPLOY_TIMEOUT:-300}
+
+#@/var
+ # Directory configuration is read from.
+ ##
+declare -r CONFIG_DIR="/etc/deploy"  # set at install
+
+#@/var LOG_LEVEL
+ ##
+readonly VERBOSE=1
+
+#@/var STATE
+ ##
+setup() {
+    :
+}
+`
+	doc := mustParse(t, input)
+	want := []struct {
+		name, def string
+		readonly  bool
+	}{
+		{"DEPLOY_TIMEOUT", "300", false},
+		{"CONFIG_DIR", "/etc/deploy", true},
+		{"LOG_LEVEL", "", false},
+		{"STATE", "", false},
+	}
+	if len(doc.Blocks) != len(want) {
+		t.Fatalf("got %d blocks, want %d", len(doc.Blocks), len(want))
+	}
+	for i, w := range want {
+		b := doc.Blocks[i]
+		if b.Visibility != VisibilityVar || b.Name != w.name || b.Default != w.def || b.Readonly != w.readonly {
+			t.Errorf("Blocks[%d] = %q %q default %q readonly %v, want var %q default %q readonly %v",
+				i, b.Visibility, b.Name, b.Default, b.Readonly, w.name, w.def, w.readonly)
+		}
+		if b.FunctionName != "" {
+			t.Errorf("Blocks[%d].FunctionName = %q, want none for a var", i, b.FunctionName)
+		}
+	}
+	if len(doc.Warnings) != 1 || doc.Warnings[0].Line != 14 {
+		t.Errorf("Warnings = %+v, want one for the declaration of VERBOSE on line 14", doc.Warnings)
+	}
+}
+
+func TestParseSheblockConstant(t *testing.T) {
+	input := `#!/bin/bash
+#@/public
+ # Version of the on-disk format.
+ ##
+readonly FORMAT_VERSION=3
+format_version() {
+    :
+}
+
+#@/private
+ ##
+declare -r -a LEVELS=(debug info)
+
+#@/public
+ ##
+SETTING=1
+configure() {
+    :
+}
+`
+	doc := mustParse(t, input)
+	if len(doc.Blocks) != 3 {
+		t.Fatalf("got %d blocks, want 3", len(doc.Blocks))
+	}
+	if b := doc.Blocks[0]; b.Name != "FORMAT_VERSION" || b.Default != "3" || !b.Readonly || b.FunctionName != "" {
+		t.Errorf("Blocks[0] = %q default %q readonly %v function %q, want constant FORMAT_VERSION=3 and no function",
+			b.Name, b.Default, b.Readonly, b.FunctionName)
+	}
+	if b := doc.Blocks[1]; b.Name != "LEVELS" || !b.Readonly {
+		t.Errorf("Blocks[1] = %q readonly %v, want constant LEVELS", b.Name, b.Readonly)
+	}
+	if b := doc.Blocks[2]; b.Name != "" || b.Readonly || b.FunctionName != "configure" {
+		t.Errorf("Blocks[2] = %q readonly %v function %q, want function configure", b.Name, b.Readonly, b.FunctionName)
+	}
+}
+
 func TestParseSheblockBare(t *testing.T) {
 	input := `#!/bin/bash
 #@/
@@ -384,6 +517,158 @@ func TestParseWarningOnUnknownShedocTag(t *testing.T) {
 	}
 }
 
+func TestParseMalformedTagPreservesText(t *testing.T) {
+	input := `#!/bin/bash
+#@/public
+ # Does things.
+ # @option --format <> Output format
+ # @flag verbose Enable verbose output
+ # @flag -q | --quiet Suppress output
+ ##
+f() { :; }
+`
+	doc := mustParse(t, input)
+	if len(doc.Blocks) != 1 || len(doc.Blocks[0].Flags) != 1 {
+		t.Fatalf("expected one block with one flag, got %+v", doc.Blocks)
+	}
+	want := []Warning{
+		{Line: 4, Message: `@option value: invalid value notation: "<>"`, Unparsed: "@option --format <> Output format"},
+		{Line: 5, Message: "@flag requires at least one flag name", Unparsed: "@flag verbose Enable verbose output"},
+	}
+	if len(doc.Warnings) != len(want) {
+		t.Fatalf("got %d warnings, want %d: %+v", len(doc.Warnings), len(want), doc.Warnings)
+	}
+	for i, w := range want {
+		if doc.Warnings[i] != w {
+			t.Errorf("Warnings[%d] = %+v, want %+v", i, doc.Warnings[i], w)
+		}
+	}
+}
+
+func TestParseRawTags(t *testing.T) {
+	input := `#!/bin/bash
+#@/public
+ # Does things.
+ #
+ # @flag -v | --verbose  Enable verbose
+ #   output for debugging
+ # @option --format <> Broken
+ ##
+f() { :; }
+`
+	doc := mustParse(t, input)
+	if doc.Blocks[0].RawTags != nil {
+		t.Errorf("RawTags recorded without WithRawTags: %+v", doc.Blocks[0].RawTags)
+	}
+
+	doc, err := ParseReader(strings.NewReader(input), WithRawTags())
+	if err != nil {
+		t.Fatalf("ParseReader() error: %v", err)
+	}
+	want := []RawTag{
+		{Text: "@flag -v | --verbose  Enable verbose\n  output for debugging", Line: 5},
+		{Text: "@option --format <> Broken", Line: 7},
+	}
+	got := doc.Blocks[0].RawTags
+	if len(got) != len(want) {
+		t.Fatalf("RawTags = %+v, want %+v", got, want)
+	}
+	for i := range want {
+		if got[i] != want[i] {
+			t.Errorf("RawTags[%d] = %+v, want %+v", i, got[i], want[i])
+		}
+	}
+	if d := doc.Blocks[0].Flags[0].Description; d != "Enable verbose output for debugging" {
+		t.Errorf("Flags[0].Description = %q", d)
+	}
+}
+
+func FuzzParseReader(f *testing.F) {
+	for _, seed := range []string{
+		"#@/public\n # @flag -v | --verbose Verbose\n ##\n",
+		"#@/command\n # @option -f | --format [type=json] Format\n # @operand <file...>\n ##\n",
+		"#@/public\n # @complete <file> $(ls)\n # @hidden -v\n ##\n",
+		"#?/description\n # text\n ##\n",
+		"#@/public\n # @option | -- <[x]>\n ##\n",
+		"#@/public\n # @complete x $(\n # @complete x $()\n # @operand <\n ##\n",
+	} {
+		f.Add(seed)
+	}
+	f.Fuzz(func(t *testing.T, input string) {
+		doc, err := ParseReader(strings.NewReader(input))
+		if err != nil {
+			return
+		}
+		for _, b := range doc.Blocks {
+			for _, fl := range b.Flags {
+				if fl.Short == "" && fl.Long == "" {
+					t.Errorf("flag without a name: %+v", fl)
+				}
+			}
+			for _, o := range b.Options {
+				if o.Value.Name == "" {
+					t.Errorf("option without a value name: %+v", o)
+				}
+			}
+		}
+	})
+}
+
+func TestParseShedocBlockSynopsis(t *testing.T) {
+	input := `#!/bin/bash
+#?/synopsis
+ # deploy [-v] push <environment>
+ #
+ # deploy [-v] status <environment>
+ ##
+`
+	doc := mustParse(t, input)
+	want := []string{"deploy [-v] push <environment>", "deploy [-v] status <environment>"}
+	if len(doc.Meta.Synopsis) != len(want) || doc.Meta.Synopsis[0] != want[0] || doc.Meta.Synopsis[1] != want[1] {
+		t.Errorf("Meta.Synopsis = %q, want %q", doc.Meta.Synopsis, want)
+	}
+}
+
+func TestParseRepeatedShedocTags(t *testing.T) {
+	input := `#!/bin/bash
+#?/name first
+#?/name first
+#?/name second
+#?/description Short summary.
+#?/description
+ # Longer explanation
+ # over two lines.
+ ##
+#?/author Jane Developer
+#?/author John Contributor
+`
+	doc := mustParse(t, input)
+	if doc.Meta.Name != "first" {
+		t.Errorf("Meta.Name = %q, want %q", doc.Meta.Name, "first")
+	}
+	wantDesc := "Short summary.\nLonger explanation\nover two lines."
+	if doc.Meta.Description != wantDesc {
+		t.Errorf("Meta.Description = %q, want %q", doc.Meta.Description, wantDesc)
+	}
+	if doc.Meta.Author != "Jane Developer\nJohn Contributor" {
+		t.Errorf("Meta.Author = %q", doc.Meta.Author)
+	}
+
+	want := []Warning{
+		{Line: 4, Message: "duplicate #?/name ignored; keeping first"},
+		{Line: 6, Message: "duplicate #?/description appended to earlier value"},
+		{Line: 11, Message: "duplicate #?/author appended to earlier value"},
+	}
+	if len(doc.Warnings) != len(want) {
+		t.Fatalf("Warnings = %+v, want %+v", doc.Warnings, want)
+	}
+	for i := range want {
+		if doc.Warnings[i] != want[i] {
+			t.Errorf("Warnings[%d] = %+v, want %+v", i, doc.Warnings[i], want[i])
+		}
+	}
+}
+
 func TestParseSetsAndWrites(t *testing.T) {
 	input := `#!/bin/bash
 #@/subcommand rollback
@@ -643,6 +928,310 @@ func TestParseTagContinuationNoInitialDescription(t *testing.T) {
 	}
 }
 
+func TestParseAlias(t *testing.T) {
+	input := `#@/command
+ # @alias dep
+ # @alias deployer dply
+ ##
+`
+	doc := mustParse(t, input)
+	want := []string{"dep", "deployer", "dply"}
+	got := doc.Blocks[0].Aliases
+	if len(got) != len(want) {
+		t.Fatalf("Aliases = %v, want %v", got, want)
+	}
+	for i := range want {
+		if got[i] != want[i] {
+			t.Errorf("Aliases[%d] = %q, want %q", i, got[i], want[i])
+		}
+	}
+}
+
+func TestParseGroup(t *testing.T) {
+	input := `#@/command
+ # @flag    -v | --verbose          Verbose
+ # @group   Connection
+ # @option  --host <host>           Host
+ # @flag    --tls                   Use TLS
+ # @group
+ # @flag    -q | --quiet            Quiet
+ ##
+
+#@/subcommand push
+ # @flag    -f | --force            Force
+ ##
+`
+	doc := mustParse(t, input)
+	cmd := doc.Blocks[0]
+	for _, tc := range []struct{ name, got, want string }{
+		{"--verbose", cmd.Flags[0].Group, ""},
+		{"--tls", cmd.Flags[1].Group, "Connection"},
+		{"--quiet", cmd.Flags[2].Group, ""},
+		{"--host", cmd.Options[0].Group, "Connection"},
+		{"push --force", doc.Blocks[1].Flags[0].Group, ""},
+	} {
+		if tc.got != tc.want {
+			t.Errorf("%s: Group = %q, want %q", tc.name, tc.got, tc.want)
+		}
+	}
+}
+
+func TestParseTagAliases(t *testing.T) {
+	input := `#@/command
+ # @param   <file>                  File to read
+ # @returns 0                       Success
+ ##
+`
+	aliases := WithTagAliases(map[string]string{"param": "operand", "returns": "exit"})
+
+	doc, err := ParseReader(strings.NewReader(input), aliases)
+	if err != nil {
+		t.Fatal(err)
+	}
+	b := doc.Blocks[0]
+	if len(b.Operands) != 1 || b.Operands[0].Value.Name != "file" {
+		t.Errorf("Operands = %+v", b.Operands)
+	}
+	if len(b.Exit) != 1 || b.Exit[0].Code != "0" {
+		t.Errorf("Exit = %+v", b.Exit)
+	}
+	if len(doc.Warnings) != 0 {
+		t.Errorf("unexpected warnings: %+v", doc.Warnings)
+	}
+
+	doc, err = ParseReader(strings.NewReader(input), aliases, WithTagAliasWarnings())
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := []Warning{
+		{Line: 2, Message: "@param is an alias; use @operand"},
+		{Line: 3, Message: "@returns is an alias; use @exit"},
+	}
+	if !slices.Equal(doc.Warnings, want) {
+		t.Errorf("Warnings = %+v, want %+v", doc.Warnings, want)
+	}
+}
+
+func TestParseTagOrderWarnings(t *testing.T) {
+	input := `#@/command
+ # @group Output
+ # @flag -v Verbose
+ # @stdout The report
+ # @exit 0 Success
+ # @option -o <file> Output file
+ # @see other(1)
+ ##
+
+#@/subcommand push
+ # @exit 0 Success
+ ##
+`
+	doc := mustParse(t, input)
+	if len(doc.Warnings) != 0 {
+		t.Errorf("warned without WithTagOrderWarnings: %+v", doc.Warnings)
+	}
+
+	doc, err := ParseReader(strings.NewReader(input), WithTagOrderWarnings())
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := []Warning{
+		{Line: 5, Message: "@exit should come before @stdout"},
+		{Line: 6, Message: "@option should come before @stdout"},
+	}
+	if !slices.Equal(doc.Warnings, want) {
+		t.Errorf("Warnings = %+v, want %+v", doc.Warnings, want)
+	}
+}
+
+func TestParseRequires(t *testing.T) {
+	input := `#?/requires jq JSON processing
+#?/requires
+ # curl    HTTP client
+ # git
+ ##
+
+#@/command
+ # @requires docker   Container runtime,
+ #                    version 24 or newer
+ # @requires
+ ##
+`
+	doc := mustParse(t, input)
+	wantMeta := []Requires{
+		{Command: "jq", Description: "JSON processing", Line: 1},
+		{Command: "curl", Description: "HTTP client", Line: 2},
+		{Command: "git", Line: 2},
+	}
+	if !slices.Equal(doc.Meta.Requires, wantMeta) {
+		t.Errorf("Meta.Requires = %+v, want %+v", doc.Meta.Requires, wantMeta)
+	}
+	wantBlock := []Requires{{Command: "docker", Description: "Container runtime, version 24 or newer", Line: 8}}
+	if !slices.Equal(doc.Blocks[0].Requires, wantBlock) {
+		t.Errorf("Requires = %+v, want %+v", doc.Blocks[0].Requires, wantBlock)
+	}
+	if len(doc.Warnings) != 1 || doc.Warnings[0].Line != 10 {
+		t.Errorf("Warnings = %+v, want one for the empty @requires on line 10", doc.Warnings)
+	}
+}
+
+func TestParseSince(t *testing.T) {
+	input := `#@/subcommand push
+ # @since   2.1.0
+ ##
+
+#@/subcommand pull
+ # @since
+ ##
+`
+	doc := mustParse(t, input)
+	if got := doc.Blocks[0].Since; got != "2.1.0" {
+		t.Errorf("Since = %q, want %q", got, "2.1.0")
+	}
+	if len(doc.Warnings) != 1 || doc.Warnings[0].Line != 6 {
+		t.Errorf("Warnings = %+v, want one for the empty @since on line 6", doc.Warnings)
+	}
+}
+
+func TestParseEnvDefault(t *testing.T) {
+	input := `#@/command
+ # @env  DEPLOY_REGION [=us-east-1]  Region used when none is given
+ # @env  DEPLOY_TOKEN                Token
+ # @env  DEPLOY_HOST [=localhost
+ ##
+`
+	doc := mustParse(t, input)
+	want := []Env{
+		{Name: "DEPLOY_REGION", Default: "us-east-1", Description: "Region used when none is given", Line: 2},
+		{Name: "DEPLOY_TOKEN", Description: "Token", Line: 3},
+	}
+	if !slices.Equal(doc.Blocks[0].Env, want) {
+		t.Errorf("Env = %+v, want %+v", doc.Blocks[0].Env, want)
+	}
+	if len(doc.Warnings) != 1 || doc.Warnings[0].Line != 4 {
+		t.Errorf("Warnings = %+v, want one for the unclosed default on line 4", doc.Warnings)
+	}
+}
+
+func TestParseReturn(t *testing.T) {
+	input := `#@/private
+ # @return  0   The image exists
+ # @return  1
+ # @exit    2   Docker is not running
+ ##
+
+#@/command
+ # @return  0   Success
+ ##
+`
+	doc := mustParse(t, input)
+	want := []Return{
+		{Code: "0", Description: "The image exists", Line: 2},
+		{Code: "1", Line: 3},
+	}
+	if !slices.Equal(doc.Blocks[0].Return, want) {
+		t.Errorf("Return = %+v, want %+v", doc.Blocks[0].Return, want)
+	}
+	if len(doc.Blocks[0].Exit) != 1 {
+		t.Errorf("Exit = %+v, want the @exit kept apart", doc.Blocks[0].Exit)
+	}
+	if len(doc.Warnings) != 1 || doc.Warnings[0].Line != 8 {
+		t.Errorf("Warnings = %+v, want one for @return on the command on line 8", doc.Warnings)
+	}
+}
+
+func TestParseSee(t *testing.T) {
+	input := `#!/bin/bash
+#?/see-also git(1), ssh(1)
+
+#@/command
+ # @see deploy.conf(5)
+ # @see https://example.com/deploy, rsync(1)
+ ##
+`
+	doc := mustParse(t, input)
+	if want := []string{"git(1)", "ssh(1)"}; !slices.Equal(doc.Meta.SeeAlso, want) {
+		t.Errorf("Meta.SeeAlso = %v, want %v", doc.Meta.SeeAlso, want)
+	}
+	if want := []string{"deploy.conf(5)", "https://example.com/deploy", "rsync(1)"}; !slices.Equal(doc.Blocks[0].See, want) {
+		t.Errorf("See = %v, want %v", doc.Blocks[0].See, want)
+	}
+	if len(doc.Warnings) != 0 {
+		t.Errorf("unexpected warnings: %v", doc.Warnings)
+	}
+}
+
+func TestParseHidden(t *testing.T) {
+	input := `#@/command
+ # @hidden --debug
+ # @flag -v | --verbose Verbose output
+ # @flag --debug Dump internal state
+ # @option --trace <file> Trace output
+ # @hidden --trace
+ ##
+
+#@/subcommand selftest
+ # @hidden
+ ##
+`
+	doc := mustParse(t, input)
+	cmd := doc.Blocks[0]
+	if cmd.Hidden {
+		t.Error("command block should not be hidden")
+	}
+	if cmd.Flags[0].Hidden {
+		t.Error("--verbose should not be hidden")
+	}
+	if !cmd.Flags[1].Hidden {
+		t.Error("--debug should be hidden")
+	}
+	if !cmd.Options[0].Hidden {
+		t.Error("--trace should be hidden")
+	}
+	if !doc.Blocks[1].Hidden {
+		t.Error("selftest subcommand should be hidden")
+	}
+	if len(doc.Warnings) != 0 {
+		t.Errorf("unexpected warnings: %v", doc.Warnings)
+	}
+}
+
+func TestParseHiddenUnknownName(t *testing.T) {
+	input := `#@/command
+ # @hidden --nope
+ ##
+`
+	doc := mustParse(t, input)
+	if len(doc.Warnings) != 1 {
+		t.Fatalf("expected 1 warning, got %d", len(doc.Warnings))
+	}
+	if !strings.Contains(doc.Warnings[0].Message, "--nope") {
+		t.Errorf("warning = %q, want mention of --nope", doc.Warnings[0].Message)
+	}
+}
+
+func TestParseCompleteTargets(t *testing.T) {
+	input := `#@/command
+ # @option    --env <name>     Environment
+ # @operand   <target>         Deploy target
+ # @complete  --env $(ls envs)
+ # @complete  <target> $(cat targets.txt)
+ # @complete  --nope $(true)
+ ##
+`
+	doc := mustParse(t, input)
+	b := doc.Blocks[0]
+	if b.Options[0].Complete != "ls envs" {
+		t.Errorf("Option.Complete = %q, want %q", b.Options[0].Complete, "ls envs")
+	}
+	if b.Operands[0].Complete != "cat targets.txt" {
+		t.Errorf("Operand.Complete = %q, want %q", b.Operands[0].Complete, "cat targets.txt")
+	}
+	if len(doc.Warnings) != 1 || doc.Warnings[0].Line != 6 {
+		t.Errorf("expected 1 warning on line 6 for --nope, got %v", doc.Warnings)
+	}
+}
+
 func mustParse(t *testing.T, input string) *Document {
 	t.Helper()
 	doc, err := ParseReader(strings.NewReader(input))
@@ -651,3 +1240,132 @@ func mustParse(t *testing.T, input string) *Document {
 	}
 	return doc
 }
+
+func TestParseDefaultEnvUndocumented(t *testing.T) {
+	input := `#@/command
+ # @env     AWS_PROFILE
+ # @option  --profile [name=$AWS_PROFILE]
+ # @option  --region [region=${AWS_REGION:-us-east-1}]
+ ##
+`
+	doc := mustParse(t, input)
+	if len(doc.Warnings) != 1 {
+		t.Fatalf("expected 1 warning, got %+v", doc.Warnings)
+	}
+	want := Warning{Line: 4, Message: "default [region=${AWS_REGION:-us-east-1}] refers to undocumented environment variable AWS_REGION"}
+	if doc.Warnings[0] != want {
+		t.Errorf("warning = %+v, want %+v", doc.Warnings[0], want)
+	}
+}
+
+// recordingFormatter records the stream callbacks it receives.
+type recordingFormatter struct {
+	calls   []string
+	failOn  string
+	failErr error
+}
+
+func (f *recordingFormatter) record(call string) error {
+	f.calls = append(f.calls, call)
+	if call == f.failOn {
+		return f.failErr
+	}
+	return nil
+}
+
+func (f *recordingFormatter) Format(w io.Writer, doc *Document) error {
+	return FormatStream(w, doc, f)
+}
+
+func (f *recordingFormatter) BeginDocument(w io.Writer, doc *Document) error {
+	return f.record(fmt.Sprintf("begin %s blocks=%d", doc.Meta.Name, len(doc.Blocks)))
+}
+
+func (f *recordingFormatter) Block(w io.Writer, b *Block) error {
+	return f.record(fmt.Sprintf("block %s %s", b.Name, b.FunctionName))
+}
+
+func (f *recordingFormatter) EndDocument(w io.Writer, doc *Document) error {
+	return f.record(fmt.Sprintf("end %s %s warnings=%d", doc.Meta.Name, doc.Meta.Version, len(doc.Warnings)))
+}
+
+func TestParseReaderStream(t *testing.T) {
+	input := `#?/name tool
+#@/subcommand push
+ # @option --tag [tag=${TAG}] Tag
+ ##
+push() {
+#@/subcommand status
+ ##
+#?/version 1.0
+status() {
+`
+	f := &recordingFormatter{}
+	doc, err := ParseReaderStream(strings.NewReader(input), io.Discard, f)
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := []string{
+		"begin tool blocks=0",
+		"block push push",
+		"block status status",
+		"end tool 1.0 warnings=1",
+	}
+	if strings.Join(f.calls, "\n") != strings.Join(want, "\n") {
+		t.Errorf("calls =\n%s\nwant\n%s", strings.Join(f.calls, "\n"), strings.Join(want, "\n"))
+	}
+	if len(doc.Blocks) != 0 {
+		t.Errorf("streamed document kept %d blocks", len(doc.Blocks))
+	}
+	if len(doc.Warnings) != 1 || !strings.Contains(doc.Warnings[0].Message, "undocumented environment variable TAG") {
+		t.Errorf("Warnings = %+v", doc.Warnings)
+	}
+
+	// No blocks: the document is still begun and ended.
+	f = &recordingFormatter{}
+	if _, err := ParseReaderStream(strings.NewReader("#?/name bare\n"), io.Discard, f); err != nil {
+		t.Fatal(err)
+	}
+	if strings.Join(f.calls, "; ") != "begin bare blocks=0; end bare  warnings=0" {
+		t.Errorf("calls = %q", f.calls)
+	}
+}
+
+func TestParseReaderStream_Errors(t *testing.T) {
+	input := "#@/public\n ##\na() {\n#@/public\n ##\nb() {\n#@/public\n ##\n"
+
+	errStop := errors.New("stop")
+	f := &recordingFormatter{failOn: "block  a", failErr: errStop}
+	if _, err := ParseReaderStream(strings.NewReader(input), io.Discard, f); !errors.Is(err, errStop) {
+		t.Errorf("error = %v, want %v", err, errStop)
+	}
+	if len(f.calls) != 2 {
+		t.Errorf("parsing continued after an error: %q", f.calls)
+	}
+
+	f = &recordingFormatter{}
+	_, err := ParseReaderStream(strings.NewReader(input), io.Discard, f, WithLimits(Limits{MaxBlocks: 2}))
+	var le *LimitError
+	if !errors.As(err, &le) || le.Limit != LimitBlocks {
+		t.Errorf("error = %v, want block limit", err)
+	}
+}
+
+func TestParseReaderReusesBuffers(t *testing.T) {
+	// Documents must not refer to the line buffers reused by later parses.
+	first := mustParse(t, "#?/name first\n#@/public\n # Alpha beta.\n ##\nalpha() {\n")
+	for range 3 {
+		mustParse(t, "#?/name other\n#@/public\n # Gamma delta.\n ##\ngamma() {\n")
+	}
+	if first.Meta.Name != "first" || first.Blocks[0].Description != "Alpha beta." || first.Blocks[0].FunctionName != "alpha" {
+		t.Errorf("first document changed: %+v", first)
+	}
+
+	// A pooled buffer larger than MaxLineLength does not raise the limit.
+	mustParse(t, strings.Repeat("x", 1000)+"\n")
+	_, err := ParseReader(strings.NewReader(strings.Repeat("x", 100)+"\n"), WithLimits(Limits{MaxLineLength: 32}))
+	var le *LimitError
+	if !errors.As(err, &le) || le.Limit != LimitLineLength {
+		t.Errorf("error = %v, want line length limit", err)
+	}
+}
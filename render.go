@@ -0,0 +1,110 @@
+package shedoc
+
+import (
+	"fmt"
+	"io"
+	"slices"
+	"strings"
+)
+
+// RenderOption configures Render.
+type RenderOption func(*renderConfig)
+
+type renderConfig struct {
+	sort       bool
+	catalog    Catalog
+	visibility []Visibility
+}
+
+// WithSortedSubcommands lists subcommands alphabetically; see
+// SortSubcommands.
+func WithSortedSubcommands() RenderOption {
+	return func(c *renderConfig) {
+		c.sort = true
+	}
+}
+
+// WithCatalog replaces descriptions with their translations in c; see
+// Translate.
+func WithCatalog(c Catalog) RenderOption {
+	return func(cfg *renderConfig) {
+		cfg.catalog = c
+	}
+}
+
+// WithVisibilities renders only the blocks with one of the given
+// visibilities; see KeepVisibilities.
+func WithVisibilities(vis ...Visibility) RenderOption {
+	return func(c *renderConfig) {
+		c.visibility = vis
+	}
+}
+
+// Render writes doc to w in the named format, such as "man" or "html". The
+// built-in formats are registered by importing
+// github.com/nickawilliams/shedoc/format. Render does not modify doc.
+func Render(doc *Document, format string, w io.Writer, opts ...RenderOption) error {
+	f := GetFormatter(format)
+	if f == nil {
+		formats := RegisteredFormats()
+		slices.Sort(formats)
+		return fmt.Errorf("unknown format: %q (available: %s)", format, strings.Join(formats, ", "))
+	}
+
+	var cfg renderConfig
+	for _, opt := range opts {
+		opt(&cfg)
+	}
+	if cfg.sort || cfg.catalog != nil || cfg.visibility != nil {
+		doc = doc.Clone()
+		if cfg.catalog != nil {
+			Translate(doc, cfg.catalog)
+		}
+		if cfg.visibility != nil {
+			KeepVisibilities(doc, cfg.visibility...)
+		}
+		if cfg.sort {
+			SortSubcommands(doc)
+		}
+	}
+	return f.Format(w, doc)
+}
+
+// Clone returns a deep copy of d.
+func (d *Document) Clone() *Document {
+	c := *d
+	c.Meta.Synopsis = slices.Clone(d.Meta.Synopsis)
+	c.Meta.SeeAlso = slices.Clone(d.Meta.SeeAlso)
+	c.Meta.Requires = slices.Clone(d.Meta.Requires)
+	c.Warnings = slices.Clone(d.Warnings)
+	c.Blocks = slices.Clone(d.Blocks)
+	for i := range c.Blocks {
+		b := &c.Blocks[i]
+		b.Flags = slices.Clone(b.Flags)
+		b.Options = slices.Clone(b.Options)
+		b.Operands = slices.Clone(b.Operands)
+		b.Env = slices.Clone(b.Env)
+		b.Reads = slices.Clone(b.Reads)
+		b.Requires = slices.Clone(b.Requires)
+		b.Exit = slices.Clone(b.Exit)
+		b.Return = slices.Clone(b.Return)
+		b.Sets = slices.Clone(b.Sets)
+		b.Writes = slices.Clone(b.Writes)
+		b.Aliases = slices.Clone(b.Aliases)
+		b.See = slices.Clone(b.See)
+		b.RawTags = slices.Clone(b.RawTags)
+		b.Stdin = clonePtr(b.Stdin)
+		b.Stdout = clonePtr(b.Stdout)
+		b.Stderr = clonePtr(b.Stderr)
+		b.Deprecated = clonePtr(b.Deprecated)
+	}
+	return &c
+}
+
+func clonePtr[T any](p *T) *T {
+	if p == nil {
+		return nil
+	}
+	v := *p
+	return &v
+}
@@ -0,0 +1,97 @@
+package shedoc
+
+import (
+	"bytes"
+	"fmt"
+	"io"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+// subcommandsFormatter writes each subcommand's name and description.
+type subcommandsFormatter struct{}
+
+func (f *subcommandsFormatter) Format(w io.Writer, doc *Document) error {
+	for _, sub := range doc.Subcommands() {
+		fmt.Fprintf(w, "%s: %s\n", sub.Name, sub.Description)
+	}
+	return nil
+}
+
+func TestRender(t *testing.T) {
+	saved := formatters
+	formatters = map[string]Formatter{"subs": &subcommandsFormatter{}}
+	defer func() { formatters = saved }()
+
+	doc := mustParse(t, `#?/name tool
+ ##
+#@/subcommand status
+ # Shows status.
+ ##
+#@/subcommand push
+ # Pushes changes.
+ ##
+`)
+	orig := doc.Clone()
+
+	tests := []struct {
+		name string
+		opts []RenderOption
+		want string
+	}{
+		{"source order", nil, "status: Shows status.\npush: Pushes changes.\n"},
+		{"sorted", []RenderOption{WithSortedSubcommands()}, "push: Pushes changes.\nstatus: Shows status.\n"},
+		{
+			"translated",
+			[]RenderOption{WithCatalog(Catalog{"subcommand.push.description": "Envoie."})},
+			"status: Shows status.\npush: Envoie.\n",
+		},
+		{"visibilities", []RenderOption{WithVisibilities(VisibilityCommand)}, ""},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var buf bytes.Buffer
+			if err := Render(doc, "subs", &buf, tt.opts...); err != nil {
+				t.Fatal(err)
+			}
+			if got := buf.String(); got != tt.want {
+				t.Errorf("got %q, want %q", got, tt.want)
+			}
+			if !reflect.DeepEqual(doc, orig) {
+				t.Error("Render modified the document")
+			}
+		})
+	}
+
+	err := Render(doc, "nope", io.Discard)
+	if err == nil || !strings.Contains(err.Error(), `unknown format: "nope" (available: subs)`) {
+		t.Errorf("Render(unknown) error = %v", err)
+	}
+}
+
+func TestDocumentClone(t *testing.T) {
+	doc := &Document{
+		Meta: Meta{Name: "tool", Synopsis: []string{"tool <x>"}, SeeAlso: []string{"git(1)"}},
+		Blocks: []Block{{
+			Visibility: VisibilityCommand,
+			Flags:      []Flag{{Long: "--force", Description: "Force"}},
+			Stdout:     &Stdout{Description: "Output"},
+			See:        []string{"ssh(1)"},
+		}},
+	}
+	c := doc.Clone()
+	if !reflect.DeepEqual(c, doc) {
+		t.Fatalf("Clone() = %+v, want %+v", c, doc)
+	}
+
+	c.Meta.Synopsis[0] = "changed"
+	c.Blocks[0].Flags[0].Description = "changed"
+	c.Blocks[0].Stdout.Description = "changed"
+	c.Meta.SeeAlso[0] = "changed"
+	c.Blocks[0].See[0] = "changed"
+	if doc.Meta.Synopsis[0] != "tool <x>" || doc.Blocks[0].Flags[0].Description != "Force" || doc.Blocks[0].Stdout.Description != "Output" ||
+		doc.Meta.SeeAlso[0] != "git(1)" || doc.Blocks[0].See[0] != "ssh(1)" {
+		t.Errorf("changing the clone changed the original: %+v", doc)
+	}
+}
@@ -0,0 +1,32 @@
+package shedoc
+
+import "fmt"
+
+// RequiredOptionWarnings reports required options (@option!) whose
+// documentation contradicts itself: a default that can never apply, or
+// @hidden, which keeps a mandatory option out of help and completions.
+func (b *Block) RequiredOptionWarnings() []Warning {
+	var warnings []Warning
+	for _, o := range b.Options {
+		if !o.Required {
+			continue
+		}
+		name := o.Long
+		if name == "" {
+			name = o.Short
+		}
+		if o.Value.Default != "" {
+			warnings = append(warnings, Warning{
+				Line:    o.Line,
+				Message: fmt.Sprintf("required option %s has a default that never applies", name),
+			})
+		}
+		if o.Hidden {
+			warnings = append(warnings, Warning{
+				Line:    o.Line,
+				Message: fmt.Sprintf("required option %s is hidden", name),
+			})
+		}
+	}
+	return warnings
+}
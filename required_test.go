@@ -0,0 +1,43 @@
+package shedoc
+
+import (
+	"testing"
+)
+
+func TestParseRequiredOption(t *testing.T) {
+	input := `#@/command
+ # @option! -t | --token <value>  API token
+ # @option  --region [name]       Region
+ # @option! --mode [m=fast]       Mode
+ # @option! --secret <s>          Secret
+ # @hidden --secret
+ ##
+`
+	doc := mustParse(t, input)
+
+	opts := doc.Blocks[0].Options
+	if len(opts) != 4 {
+		t.Fatalf("got %d options, want 4", len(opts))
+	}
+	for i, want := range []bool{true, false, true, true} {
+		if opts[i].Required != want {
+			t.Errorf("options[%d].Required = %v, want %v", i, opts[i].Required, want)
+		}
+	}
+	if opts[0].Long != "--token" || opts[0].Description != "API token" {
+		t.Errorf("options[0] = %+v", opts[0])
+	}
+
+	want := []Warning{
+		{Line: 4, Message: "required option --mode has a default that never applies"},
+		{Line: 5, Message: "required option --secret is hidden"},
+	}
+	if len(doc.Warnings) != len(want) {
+		t.Fatalf("got warnings %+v, want %+v", doc.Warnings, want)
+	}
+	for i := range want {
+		if doc.Warnings[i] != want[i] {
+			t.Errorf("warning %d = %+v, want %+v", i, doc.Warnings[i], want[i])
+		}
+	}
+}
@@ -0,0 +1,93 @@
+package shedoc
+
+import (
+	"encoding/json"
+	"reflect"
+	"strings"
+)
+
+// JSONSchema returns a JSON Schema (draft 2020-12) describing Document as
+// encoded by encoding/json, with a definition for each type it contains.
+// Fields without omitempty or omitzero are required, and must not be empty
+// if they are strings; unknown fields are rejected. "shedoc validate" checks
+// documents against it.
+func JSONSchema() []byte {
+	defs := map[string]any{}
+	schema := schemaFor(reflect.TypeFor[Document](), defs)
+	schema["$schema"] = "https://json-schema.org/draft/2020-12/schema"
+	schema["title"] = "shedoc Document"
+	schema["$defs"] = defs
+
+	data, err := json.MarshalIndent(schema, "", "  ")
+	if err != nil {
+		panic(err) // the schema holds only strings, maps, and slices
+	}
+	return append(data, '\n')
+}
+
+// visibilities are the values of Visibility.
+var visibilities = []Visibility{
+	VisibilityCommand, VisibilitySubcommand, VisibilityPublic, VisibilityPrivate,
+	VisibilitySection, VisibilityVar,
+}
+
+// schemaFor returns the schema for t, adding a definition to defs for each
+// struct type it refers to. Document itself is returned inline.
+func schemaFor(t reflect.Type, defs map[string]any) map[string]any {
+	switch {
+	case t == reflect.TypeFor[Visibility]():
+		return map[string]any{"type": "string", "enum": visibilities}
+	case t.Kind() == reflect.Pointer:
+		return schemaFor(t.Elem(), defs)
+	case t.Kind() == reflect.Slice:
+		return map[string]any{"type": "array", "items": schemaFor(t.Elem(), defs)}
+	case t.Kind() == reflect.String:
+		return map[string]any{"type": "string"}
+	case t.Kind() == reflect.Int:
+		return map[string]any{"type": "integer"}
+	case t.Kind() == reflect.Bool:
+		return map[string]any{"type": "boolean"}
+	case t.Kind() != reflect.Struct:
+		panic("shedoc: no schema for " + t.String())
+	}
+
+	if t != reflect.TypeFor[Document]() {
+		ref := map[string]any{"$ref": "#/$defs/" + t.Name()}
+		if _, ok := defs[t.Name()]; ok {
+			return ref
+		}
+		defs[t.Name()] = nil // reserved, in case t refers to itself
+		defs[t.Name()] = structSchema(t, defs)
+		return ref
+	}
+	return structSchema(t, defs)
+}
+
+func structSchema(t reflect.Type, defs map[string]any) map[string]any {
+	props := map[string]any{}
+	required := []string{}
+	for i := range t.NumField() {
+		field := t.Field(i)
+		name, opts, _ := strings.Cut(field.Tag.Get("json"), ",")
+		if !field.IsExported() || name == "-" {
+			continue
+		}
+		if name == "" {
+			name = field.Name
+		}
+		prop := schemaFor(field.Type, defs)
+		if !strings.Contains(opts, "omitempty") && !strings.Contains(opts, "omitzero") {
+			required = append(required, name)
+			if prop["type"] == "string" && prop["enum"] == nil {
+				prop["minLength"] = 1
+			}
+		}
+		props[name] = prop
+	}
+	return map[string]any{
+		"type":                 "object",
+		"properties":           props,
+		"required":             required,
+		"additionalProperties": false,
+	}
+}
@@ -0,0 +1,116 @@
+package shedoc
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"slices"
+	"testing"
+)
+
+type testSchema struct {
+	Ref                  string                 `json:"$ref"`
+	Type                 string                 `json:"type"`
+	Enum                 []string               `json:"enum"`
+	MinLength            int                    `json:"minLength"`
+	Items                *testSchema            `json:"items"`
+	Properties           map[string]*testSchema `json:"properties"`
+	Required             []string               `json:"required"`
+	AdditionalProperties *bool                  `json:"additionalProperties"`
+	Defs                 map[string]*testSchema `json:"$defs"`
+}
+
+func TestJSONSchema(t *testing.T) {
+	var schema testSchema
+	if err := json.Unmarshal(JSONSchema(), &schema); err != nil {
+		t.Fatalf("schema is not JSON: %v", err)
+	}
+
+	if !slices.Equal(schema.Required, []string{"meta"}) {
+		t.Errorf("Document required = %v, want [meta]", schema.Required)
+	}
+	block := schema.Defs["Block"]
+	if block == nil {
+		t.Fatal("schema has no Block definition")
+	}
+	if got := block.Properties["visibility"].Enum; !slices.Equal(got, []string{"command", "subcommand", "public", "private", "section", "var"}) {
+		t.Errorf("visibility enum = %v", got)
+	}
+	if got := block.Properties["stdin"].Ref; got != "#/$defs/Stdin" {
+		t.Errorf("stdin $ref = %q", got)
+	}
+	if value := schema.Defs["Value"]; !slices.Equal(value.Required, []string{"name", "required"}) {
+		t.Errorf("Value required = %v, want [name required]", value.Required)
+	}
+	if got := schema.Defs["Value"].Properties["name"].MinLength; got != 1 {
+		t.Errorf("Value name minLength = %d, want 1", got)
+	}
+
+	// Every document in testdata conforms to the schema.
+	paths, err := filepath.Glob(filepath.Join("testdata", "*.json"))
+	if err != nil || len(paths) == 0 {
+		t.Fatalf("no testdata: %v", err)
+	}
+	for _, path := range paths {
+		data, err := os.ReadFile(path)
+		if err != nil {
+			t.Fatal(err)
+		}
+		var doc any
+		if err := json.Unmarshal(data, &doc); err != nil {
+			t.Fatalf("%s: %v", path, err)
+		}
+		for _, problem := range checkSchema(&schema, schema.Defs, doc, "") {
+			t.Errorf("%s: %s", path, problem)
+		}
+	}
+}
+
+// checkSchema checks v against the subset of JSON Schema that JSONSchema
+// uses.
+func checkSchema(s *testSchema, defs map[string]*testSchema, v any, at string) []string {
+	if s.Ref != "" {
+		s = defs[filepath.Base(s.Ref)]
+	}
+	var problems []string
+	switch v := v.(type) {
+	case map[string]any:
+		if s.Type != "object" {
+			return []string{fmt.Sprintf("%s: got object, want %s", at, s.Type)}
+		}
+		for _, name := range s.Required {
+			if _, ok := v[name]; !ok {
+				problems = append(problems, fmt.Sprintf("%s.%s: missing", at, name))
+			}
+		}
+		for name, field := range v {
+			prop, ok := s.Properties[name]
+			if !ok {
+				problems = append(problems, fmt.Sprintf("%s.%s: not in schema", at, name))
+				continue
+			}
+			problems = append(problems, checkSchema(prop, defs, field, at+"."+name)...)
+		}
+	case []any:
+		if s.Type != "array" {
+			return []string{fmt.Sprintf("%s: got array, want %s", at, s.Type)}
+		}
+		for i, item := range v {
+			problems = append(problems, checkSchema(s.Items, defs, item, fmt.Sprintf("%s[%d]", at, i))...)
+		}
+	case string:
+		if s.Type != "string" || len(v) < s.MinLength || s.Enum != nil && !slices.Contains(s.Enum, v) {
+			problems = append(problems, fmt.Sprintf("%s: unexpected string %q", at, v))
+		}
+	case float64:
+		if s.Type != "integer" || v != float64(int(v)) {
+			problems = append(problems, fmt.Sprintf("%s: unexpected number %v", at, v))
+		}
+	case bool:
+		if s.Type != "boolean" {
+			problems = append(problems, fmt.Sprintf("%s: unexpected boolean", at))
+		}
+	}
+	return problems
+}
@@ -0,0 +1,81 @@
+package shedoc
+
+import (
+	"fmt"
+	"path/filepath"
+	"strings"
+)
+
+// Summary returns a compact one-paragraph description of the document: its
+// name and version, the first sentence of its description, and counts of what it
+// documents.
+func (d *Document) Summary() string {
+	var sb strings.Builder
+
+	name := d.Meta.Name
+	if name == "" && d.Path != "" {
+		name = filepath.Base(d.Path)
+	}
+	if name == "" {
+		name = "(unnamed)"
+	}
+	sb.WriteString(name)
+	if d.Meta.Version != "" {
+		sb.WriteString(" " + d.Meta.Version)
+	}
+
+	if desc := firstSentence(d.Meta.Description); desc != "" {
+		sb.WriteString(": " + desc)
+	}
+
+	var subcommands, functions, flags, options, operands int
+	for _, b := range d.Blocks {
+		switch b.Visibility {
+		case VisibilitySubcommand:
+			subcommands++
+		case VisibilityPublic, VisibilityPrivate:
+			if !b.Readonly {
+				functions++
+			}
+		}
+		flags += len(b.Flags)
+		options += len(b.Options)
+		operands += len(b.Operands)
+	}
+
+	counts := []string{
+		plural(subcommands, "subcommand"),
+		plural(functions, "function"),
+		plural(flags, "flag"),
+		plural(options, "option"),
+		plural(operands, "operand"),
+	}
+	if len(d.Warnings) > 0 {
+		counts = append(counts, plural(len(d.Warnings), "warning"))
+	}
+	fmt.Fprintf(&sb, " (%s)", strings.Join(counts, ", "))
+
+	return sb.String()
+}
+
+// String implements fmt.Stringer using Summary.
+func (d *Document) String() string {
+	return d.Summary()
+}
+
+func plural(n int, noun string) string {
+	if n == 1 {
+		return "1 " + noun
+	}
+	return fmt.Sprintf("%d %ss", n, noun)
+}
+
+// firstSentence returns the text up to and including the first ". " of a
+// possibly wrapped description, with line breaks folded into spaces.
+func firstSentence(s string) string {
+	s = strings.Join(strings.Fields(s), " ")
+	if idx := strings.Index(s, ". "); idx >= 0 {
+		return s[:idx+1]
+	}
+	return s
+}
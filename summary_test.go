@@ -0,0 +1,48 @@
+package shedoc
+
+import (
+	"fmt"
+	"testing"
+)
+
+func TestDocumentSummary(t *testing.T) {
+	tests := []struct {
+		name string
+		doc  *Document
+		want string
+	}{
+		{
+			name: "empty",
+			doc:  &Document{},
+			want: "(unnamed) (0 subcommands, 0 functions, 0 flags, 0 options, 0 operands)",
+		},
+		{
+			name: "path fallback with warnings",
+			doc: &Document{
+				Path:     "scripts/build.sh",
+				Blocks:   []Block{{Visibility: VisibilityPublic, Flags: []Flag{{Long: "--all"}}}},
+				Warnings: []Warning{{Line: 1, Message: "x"}},
+			},
+			want: "build.sh (0 subcommands, 1 function, 1 flag, 0 options, 0 operands, 1 warning)",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := tt.doc.Summary(); got != tt.want {
+				t.Errorf("Summary() = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestDocumentSummaryComprehensive(t *testing.T) {
+	doc, err := Parse("testdata/comprehensive.sh")
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := "deploy 2.1.0: A deployment tool for managing application releases. (4 subcommands, 0 functions, 4 flags, 3 options, 6 operands)"
+	if got := fmt.Sprint(doc); got != want {
+		t.Errorf("String() = %q, want %q", got, want)
+	}
+}
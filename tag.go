@@ -5,6 +5,31 @@ import (
 	"strings"
 )
 
+// tagOrder ranks tags in canonical order; see WithTagOrderWarnings. Tags of
+// equal rank may be mixed, and unranked tags may appear anywhere.
+var tagOrder = map[string]int{
+	"flag":       1,
+	"option":     2,
+	"option!":    2,
+	"operand":    3,
+	"env":        4,
+	"reads":      5,
+	"requires":   6,
+	"stdin":      7,
+	"exit":       8,
+	"return":     9,
+	"stdout":     10,
+	"stderr":     11,
+	"sets":       12,
+	"writes":     13,
+	"alias":      14,
+	"hidden":     15,
+	"complete":   16,
+	"since":      17,
+	"deprecated": 18,
+	"see":        19,
+}
+
 // parseTag dispatches to the appropriate tag parser based on the tag name.
 // text is everything after "@tagname " on the line.
 func parseTag(name, text string, line int) (tagName string, result any, err error) {
@@ -15,6 +40,12 @@ func parseTag(name, text string, line int) (tagName string, result any, err erro
 	case "option":
 		r, e := parseOption(text, line)
 		return name, r, e
+	case "option!":
+		r, e := parseOption(text, line)
+		if r != nil {
+			r.Required = true
+		}
+		return "option", r, e
 	case "operand":
 		r, e := parseOperand(text, line)
 		return name, r, e
@@ -24,11 +55,17 @@ func parseTag(name, text string, line int) (tagName string, result any, err erro
 	case "reads":
 		r, e := parseReads(text, line)
 		return name, r, e
+	case "requires":
+		r, e := parseRequires(text, line)
+		return name, r, e
 	case "stdin":
 		return name, &Stdin{Description: text, Line: line}, nil
 	case "exit":
 		r, e := parseExit(text, line)
 		return name, r, e
+	case "return":
+		r, e := parseReturn(text, line)
+		return name, r, e
 	case "stdout":
 		return name, &Stdout{Description: text, Line: line}, nil
 	case "stderr":
@@ -39,8 +76,24 @@ func parseTag(name, text string, line int) (tagName string, result any, err erro
 	case "writes":
 		r, e := parseWrites(text, line)
 		return name, r, e
+	case "alias":
+		r, e := parseAlias(text)
+		return name, r, e
+	case "hidden":
+		return name, strings.Fields(text), nil
+	case "complete":
+		r, e := parseComplete(text, line)
+		return name, r, e
+	case "since":
+		r, e := parseSince(text)
+		return name, r, e
 	case "deprecated":
 		return name, &Deprecated{Message: text, Line: line}, nil
+	case "see":
+		r, e := parseSee(text)
+		return name, r, e
+	case "group":
+		return name, strings.TrimSpace(text), nil
 	default:
 		return name, nil, fmt.Errorf("unknown tag @%s", name)
 	}
@@ -56,6 +109,9 @@ func parseFlag(text string, line int) (*Flag, error) {
 	}
 
 	rest := consumeFlags(text, &f.Short, &f.Long)
+	if f.Short == "" && f.Long == "" {
+		return nil, fmt.Errorf("@flag requires at least one flag name")
+	}
 	f.Description = strings.TrimSpace(rest)
 	return f, nil
 }
@@ -69,6 +125,9 @@ func parseOption(text string, line int) (*Option, error) {
 	}
 
 	rest := consumeFlags(text, &o.Short, &o.Long)
+	if o.Short == "" && o.Long == "" {
+		return nil, fmt.Errorf("@option requires at least one flag name and a value")
+	}
 	rest = strings.TrimSpace(rest)
 
 	// Next token should be a value notation
@@ -106,7 +165,7 @@ func parseOperand(text string, line int) (*Operand, error) {
 	}, nil
 }
 
-// parseEnv parses: VAR_NAME description
+// parseEnv parses: VAR_NAME [=default] description
 func parseEnv(text string, line int) (*Env, error) {
 	text = strings.TrimSpace(text)
 	if text == "" {
@@ -114,9 +173,19 @@ func parseEnv(text string, line int) (*Env, error) {
 	}
 
 	name, desc := splitFirstToken(text)
+	desc = strings.TrimSpace(desc)
+	var def string
+	if rest, ok := strings.CutPrefix(desc, "[="); ok {
+		end := strings.IndexByte(rest, ']')
+		if end < 0 {
+			return nil, fmt.Errorf("@env %s: default is missing ]", name)
+		}
+		def, desc = rest[:end], strings.TrimSpace(rest[end+1:])
+	}
 	return &Env{
 		Name:        name,
-		Description: strings.TrimSpace(desc),
+		Default:     def,
+		Description: desc,
 		Line:        line,
 	}, nil
 }
@@ -136,6 +205,21 @@ func parseReads(text string, line int) (*Reads, error) {
 	}, nil
 }
 
+// parseRequires parses: <command> description
+func parseRequires(text string, line int) (*Requires, error) {
+	text = strings.TrimSpace(text)
+	if text == "" {
+		return nil, fmt.Errorf("@requires requires a command")
+	}
+
+	command, desc := splitFirstToken(text)
+	return &Requires{
+		Command:     command,
+		Description: strings.TrimSpace(desc),
+		Line:        line,
+	}, nil
+}
+
 // parseExit parses: <code> description
 func parseExit(text string, line int) (*Exit, error) {
 	text = strings.TrimSpace(text)
@@ -151,6 +235,21 @@ func parseExit(text string, line int) (*Exit, error) {
 	}, nil
 }
 
+// parseReturn parses: <code> description
+func parseReturn(text string, line int) (*Return, error) {
+	text = strings.TrimSpace(text)
+	if text == "" {
+		return nil, fmt.Errorf("@return requires a return code")
+	}
+
+	code, desc := splitFirstToken(text)
+	return &Return{
+		Code:        code,
+		Description: strings.TrimSpace(desc),
+		Line:        line,
+	}, nil
+}
+
 // parseSets parses: VAR_NAME description
 func parseSets(text string, line int) (*Sets, error) {
 	text = strings.TrimSpace(text)
@@ -181,24 +280,94 @@ func parseWrites(text string, line int) (*Writes, error) {
 	}, nil
 }
 
+// parseAlias parses: name [name...]
+func parseAlias(text string) ([]string, error) {
+	names := strings.Fields(text)
+	if len(names) == 0 {
+		return nil, fmt.Errorf("@alias requires at least one name")
+	}
+	return names, nil
+}
+
+// parseSince parses: <version>
+func parseSince(text string) (string, error) {
+	fields := strings.Fields(text)
+	if len(fields) != 1 {
+		return "", fmt.Errorf("@since requires a single version")
+	}
+	return fields[0], nil
+}
+
+// parseSee parses: <reference...>
+// References, such as git(1) or a URL, are separated by commas or spaces.
+func parseSee(text string) ([]string, error) {
+	refs := splitList(strings.ReplaceAll(text, " ", ","))
+	if len(refs) == 0 {
+		return nil, fmt.Errorf("@see requires at least one reference")
+	}
+	return refs, nil
+}
+
+// splitList splits a list separated by commas or newlines into its trimmed,
+// non-empty entries.
+func splitList(text string) []string {
+	var entries []string
+	for _, e := range strings.FieldsFunc(text, func(r rune) bool { return r == ',' || r == '\n' }) {
+		if e = strings.TrimSpace(e); e != "" {
+			entries = append(entries, e)
+		}
+	}
+	return entries
+}
+
+// completeSpec is a parsed @complete tag. It is resolved against the block's
+// options and operands once the block is complete.
+type completeSpec struct {
+	Target  string
+	Command string
+	Line    int
+}
+
+// parseComplete parses: <target> $(command)
+// The target is an option name (-f, --format) or an operand name, with or
+// without value notation.
+func parseComplete(text string, line int) (*completeSpec, error) {
+	target, rest := splitFirstToken(text)
+	rest = strings.TrimSpace(rest)
+	if target == "" || len(rest) < len("$()") || !strings.HasPrefix(rest, "$(") || !strings.HasSuffix(rest, ")") {
+		return nil, fmt.Errorf("@complete requires a target and a $(command)")
+	}
+
+	command := strings.TrimSpace(rest[2 : len(rest)-1])
+	if command == "" {
+		return nil, fmt.Errorf("@complete requires a non-empty $(command)")
+	}
+
+	if !strings.HasPrefix(target, "-") {
+		if v, err := ParseValue(target); err == nil {
+			target = v.Name
+		}
+	}
+	return &completeSpec{Target: target, Command: command, Line: line}, nil
+}
+
 // consumeFlags parses flag names from the beginning of text, setting short
-// and/or long as found. Returns the remaining text after flags.
+// and/or long as found. Returns the remaining text after flags. A bare "-" or
+// "--" is not a flag name and is left in the returned text.
 // Handles: -s, --long, -s | --long
 func consumeFlags(text string, short, long *string) string {
 	text = strings.TrimSpace(text)
 
 	for text != "" {
-		if strings.HasPrefix(text, "--") {
-			name, rest := splitFirstToken(text)
+		name, rest := splitFirstToken(text)
+		if strings.HasPrefix(name, "--") && len(name) > 2 {
 			*long = name
-			text = strings.TrimSpace(rest)
-		} else if strings.HasPrefix(text, "-") {
-			name, rest := splitFirstToken(text)
+		} else if strings.HasPrefix(name, "-") && len(name) > 1 && name[1] != '-' {
 			*short = name
-			text = strings.TrimSpace(rest)
 		} else {
 			break
 		}
+		text = strings.TrimSpace(rest)
 
 		// Consume pipe separator if present
 		if strings.HasPrefix(text, "|") {
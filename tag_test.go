@@ -51,6 +51,16 @@ func TestParseFlag(t *testing.T) {
 			input:   "",
 			wantErr: true,
 		},
+		{
+			name:    "no flag name",
+			input:   "verbose Enable verbose output",
+			wantErr: true,
+		},
+		{
+			name:    "bare dashes",
+			input:   "- | -- Nothing",
+			wantErr: true,
+		},
 	}
 
 	for _, tt := range tests {
@@ -130,6 +140,11 @@ func TestParseOption(t *testing.T) {
 			input:   "--format",
 			wantErr: true,
 		},
+		{
+			name:    "no flag name",
+			input:   "<type> Output format",
+			wantErr: true,
+		},
 	}
 
 	for _, tt := range tests {
@@ -400,6 +415,69 @@ func TestParseOperandNoDescription(t *testing.T) {
 	}
 }
 
+func TestParseComplete(t *testing.T) {
+	tests := []struct {
+		name    string
+		input   string
+		want    completeSpec
+		wantErr bool
+	}{
+		{
+			name:  "option",
+			input: "--env $(ls envs)",
+			want:  completeSpec{Target: "--env", Command: "ls envs", Line: 1},
+		},
+		{
+			name:  "operand notation",
+			input: "<environment> $( git branch --format='%(refname:short)' )",
+			want:  completeSpec{Target: "environment", Command: "git branch --format='%(refname:short)'", Line: 1},
+		},
+		{
+			name:  "bare operand name",
+			input: "services $(docker compose config --services)",
+			want:  completeSpec{Target: "services", Command: "docker compose config --services", Line: 1},
+		},
+		{
+			name:    "missing command",
+			input:   "--env",
+			wantErr: true,
+		},
+		{
+			name:    "not a substitution",
+			input:   "--env ls envs",
+			wantErr: true,
+		},
+		{
+			name:    "empty substitution",
+			input:   "--env $( )",
+			wantErr: true,
+		},
+		{
+			name:    "unclosed substitution",
+			input:   "--env $(",
+			wantErr: true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := parseComplete(tt.input, 1)
+			if tt.wantErr {
+				if err == nil {
+					t.Errorf("parseComplete(%q) expected error, got %+v", tt.input, got)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("parseComplete(%q) unexpected error: %v", tt.input, err)
+			}
+			if *got != tt.want {
+				t.Errorf("parseComplete(%q) = %+v, want %+v", tt.input, *got, tt.want)
+			}
+		})
+	}
+}
+
 func TestParseTag(t *testing.T) {
 	tests := []struct {
 		name     string
@@ -413,6 +491,8 @@ func TestParseTag(t *testing.T) {
 		{"stderr", "stderr", "Error messages", "stderr", false},
 		{"deprecated", "deprecated", "Use 'deploy push --migrate' instead.", "deprecated", false},
 		{"deprecated empty", "deprecated", "", "deprecated", false},
+		{"alias", "alias", "co ck", "alias", false},
+		{"alias empty", "alias", "", "alias", true},
 		{"unknown", "foobar", "something", "foobar", true},
 	}
 
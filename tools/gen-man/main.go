@@ -58,7 +58,7 @@ func main() {
 	// OPTIONS
 	fmt.Fprintln(f, "")
 	fmt.Fprintln(f, ".SH OPTIONS")
-	root.Flags().VisitAll(func(flag *pflag.Flag) {
+	root.LocalFlags().VisitAll(func(flag *pflag.Flag) {
 		if flag.Hidden {
 			return
 		}
@@ -91,12 +91,11 @@ func main() {
 	// EXIT STATUS
 	fmt.Fprintln(f, "")
 	fmt.Fprintln(f, ".SH EXIT STATUS")
-	fmt.Fprintln(f, ".TP")
-	fmt.Fprintln(f, "0")
-	fmt.Fprintln(f, "Success.")
-	fmt.Fprintln(f, ".TP")
-	fmt.Fprintln(f, "1")
-	fmt.Fprintln(f, "An error occurred.")
+	for _, status := range exitStatuses {
+		fmt.Fprintln(f, ".TP")
+		fmt.Fprintln(f, status.code)
+		fmt.Fprintln(f, escapeManPage(status.description))
+	}
 
 	// SEE ALSO
 	fmt.Fprintln(f, "")
@@ -106,6 +105,18 @@ func main() {
 	fmt.Printf("wrote man page to %s\n", outPath)
 }
 
+// exitStatuses describes the exit statuses defined by the cli package.
+var exitStatuses = []struct {
+	code        int
+	description string
+}{
+	{cli.ExitOK, "Success."},
+	{cli.ExitError, "An error occurred, such as a file that could not be read or parsed."},
+	{cli.ExitUsage, "Invalid arguments or flags."},
+	{cli.ExitWarnings, "Parse warnings were found and --fail-on-warnings was given."},
+	{cli.ExitFindings, "validate, check-artifacts, check-contract, or roundtrip found problems."},
+}
+
 func escapeManPage(s string) string {
 	s = strings.ReplaceAll(s, `\`, `\\`)
 	s = strings.ReplaceAll(s, "-", `\-`)
@@ -2,11 +2,13 @@ package shedoc
 
 import (
 	"fmt"
+	"regexp"
 	"strings"
 )
 
 // ParseValue parses value notation like <name>, [name], [name=default],
-// <name...>, or [name...] into a Value struct.
+// <name...>, or [name...] into a Value struct. A unit may follow the name
+// after a colon: <timeout:seconds> or [timeout:seconds=30].
 func ParseValue(s string) (Value, error) {
 	s = strings.TrimSpace(s)
 	if len(s) < 3 {
@@ -52,10 +54,58 @@ func ParseValue(s string) (Value, error) {
 		}
 	}
 
+	var unit string
+	if idx := strings.Index(inner, ":"); idx >= 0 {
+		unit = inner[idx+1:]
+		inner = inner[:idx]
+		if inner == "" || unit == "" {
+			return Value{}, fmt.Errorf("invalid value notation: %q (empty name or unit around :)", s)
+		}
+	}
+
+	if strings.ContainsAny(inner+unit, "<>[]") {
+		return Value{}, fmt.Errorf("invalid value notation: %q (nested brackets in name)", s)
+	}
+
 	return Value{
-		Name:     inner,
-		Required: required,
-		Default:  def,
-		Variadic: variadic,
+		Name:       inner,
+		Required:   required,
+		Default:    def,
+		Variadic:   variadic,
+		Unit:       unit,
+		DefaultEnv: parseEnvRef(def),
 	}, nil
 }
+
+var reEnvRef = regexp.MustCompile(`^\$(?:(\w+)|\{(\w+)(?::?-([^}]*))?\})$`)
+
+// parseEnvRef returns the environment variable that s refers to in its
+// entirety, or the zero EnvRef if s is anything else.
+func parseEnvRef(s string) EnvRef {
+	m := reEnvRef.FindStringSubmatch(s)
+	if m == nil {
+		return EnvRef{}
+	}
+	if m[1] != "" {
+		return EnvRef{Name: m[1]}
+	}
+	return EnvRef{Name: m[2], Fallback: m[3]}
+}
+
+// String returns v in value notation, in the form ParseValue accepts.
+func (v Value) String() string {
+	name := v.Name
+	if v.Unit != "" {
+		name += ":" + v.Unit
+	}
+	if v.Default != "" {
+		name += "=" + v.Default
+	}
+	if v.Variadic {
+		name += "..."
+	}
+	if v.Required {
+		return "<" + name + ">"
+	}
+	return "[" + name + "]"
+}
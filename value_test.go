@@ -36,6 +36,26 @@ func TestParseValue(t *testing.T) {
 			input: "[name...]",
 			want:  Value{Name: "name", Required: false, Variadic: true},
 		},
+		{
+			name:  "optional variadic with default",
+			input: "[name=a...]",
+			want:  Value{Name: "name", Default: "a", Variadic: true},
+		},
+		{
+			name:  "env default",
+			input: "[region=$AWS_REGION]",
+			want:  Value{Name: "region", Default: "$AWS_REGION", DefaultEnv: EnvRef{Name: "AWS_REGION"}},
+		},
+		{
+			name:  "braced env default with fallback",
+			input: "[region=${AWS_REGION:-us-east-1}]",
+			want:  Value{Name: "region", Default: "${AWS_REGION:-us-east-1}", DefaultEnv: EnvRef{Name: "AWS_REGION", Fallback: "us-east-1"}},
+		},
+		{
+			name:  "default containing a reference",
+			input: "[dir=$HOME/.cache]",
+			want:  Value{Name: "dir", Default: "$HOME/.cache"},
+		},
 		{
 			name:  "hyphenated name",
 			input: "<file-path>",
@@ -46,6 +66,21 @@ func TestParseValue(t *testing.T) {
 			input: "[version=1.0.0]",
 			want:  Value{Name: "version", Required: false, Default: "1.0.0"},
 		},
+		{
+			name:  "unit",
+			input: "<timeout:seconds>",
+			want:  Value{Name: "timeout", Required: true, Unit: "seconds"},
+		},
+		{
+			name:  "unit with default",
+			input: "[timeout:seconds=30]",
+			want:  Value{Name: "timeout", Default: "30", Unit: "seconds"},
+		},
+		{
+			name:  "default containing a colon",
+			input: "[url=http://localhost]",
+			want:  Value{Name: "url", Default: "http://localhost"},
+		},
 		{
 			name:  "whitespace trimmed",
 			input: "  <name>  ",
@@ -76,6 +111,11 @@ func TestParseValue(t *testing.T) {
 			input:   "<>",
 			wantErr: true,
 		},
+		{
+			name:    "nested brackets",
+			input:   "<[name]>",
+			wantErr: true,
+		},
 		{
 			name:    "default on required",
 			input:   "<name=foo>",
@@ -86,6 +126,11 @@ func TestParseValue(t *testing.T) {
 			input:   "<...>",
 			wantErr: true,
 		},
+		{
+			name:    "empty unit",
+			input:   "<timeout:>",
+			wantErr: true,
+		},
 		{
 			name:    "empty name before equals",
 			input:   "[=default]",
@@ -108,6 +153,21 @@ func TestParseValue(t *testing.T) {
 			if got != tt.want {
 				t.Errorf("ParseValue(%q) = %+v, want %+v", tt.input, got, tt.want)
 			}
+			if again, err := ParseValue(got.String()); err != nil || again != got {
+				t.Errorf("ParseValue(%q) = %+v, %v; want %+v", got.String(), again, err, got)
+			}
 		})
 	}
 }
+
+func FuzzParseValue(f *testing.F) {
+	for _, seed := range []string{"<name>", "[name=default]", "<name...>", "[=]", "<...>", "[[x]]"} {
+		f.Add(seed)
+	}
+	f.Fuzz(func(t *testing.T, input string) {
+		v, err := ParseValue(input)
+		if err == nil && v.Name == "" {
+			t.Errorf("ParseValue(%q) = %+v with empty name", input, v)
+		}
+	})
+}